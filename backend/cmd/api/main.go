@@ -3,6 +3,8 @@ package main
 import (
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -13,6 +15,7 @@ import (
 	"github.com/myplate/backend/internal/repositories"
 	"github.com/myplate/backend/internal/services"
 	"github.com/myplate/backend/pkg/database"
+	"github.com/myplate/backend/pkg/storage"
 )
 
 func main() {
@@ -27,6 +30,12 @@ func main() {
 	}
 	defer database.Close()
 	
+	// Хранилище изображений рецептов (локальный каталог или S3)
+	imageStorage, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+	
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository()
 	recipeRepo := repositories.NewRecipeRepository()
@@ -34,15 +43,32 @@ func main() {
 	pantryRepo := repositories.NewPantryRepository()
 	menuRepo := repositories.NewMenuRepository()
 	shoppingRepo := repositories.NewShoppingListRepository()
+	foodLogRepo := repositories.NewFoodLogRepository()
+	bodyRepo := repositories.NewBodyRepository()
+	tagRepo := repositories.NewDietaryTagRepository()
+	feedbackRepo := repositories.NewRecipeFeedbackRepository()
+	submissionRepo := repositories.NewRecipeSubmissionRepository()
+	importJobRepo := repositories.NewImportJobRepository()
+	cookingRepo := repositories.NewCookingSessionRepository()
+	refreshTokenRepo := repositories.NewRefreshTokenRepository()
 	
 	// Initialize services
-	authService := services.NewAuthService(userRepo)
-	recipeService := services.NewRecipeService(recipeRepo)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo)
+	recipeService := services.NewRecipeService(recipeRepo, tagRepo)
 	userService := services.NewUserService(goalsRepo)
 	pantryService := services.NewPantryService(pantryRepo)
-	menuService := services.NewMenuService(recipeRepo, menuRepo, pantryRepo, shoppingRepo, goalsRepo)
+	menuService := services.NewMenuService(recipeRepo, menuRepo, pantryRepo, shoppingRepo, goalsRepo, tagRepo, feedbackRepo)
 	shoppingService := services.NewShoppingListService(shoppingRepo, menuRepo, recipeRepo, pantryRepo)
-	adminRecipeService := services.NewAdminRecipeService(recipeRepo)
+	adminRecipeService := services.NewAdminRecipeService(recipeRepo, tagRepo)
+	foodLogService := services.NewFoodLogService(foodLogRepo, recipeRepo, menuRepo, goalsRepo)
+	reportService := services.NewReportService(menuRepo, foodLogRepo, goalsRepo)
+	bodyService := services.NewBodyService(bodyRepo, goalsRepo)
+	feedbackService := services.NewRecipeFeedbackService(feedbackRepo, recipeRepo)
+	userRecipeService := services.NewUserRecipeService(adminRecipeService, recipeRepo, submissionRepo)
+	importJobService := services.NewImportJobService(adminRecipeService, importJobRepo)
+	recipeImageService := services.NewRecipeImageService(recipeRepo, imageStorage)
+	cookingService := services.NewCookingSessionService(cookingRepo, menuRepo, recipeRepo)
+	menuJobService := services.NewMenuJobService(menuService, 4)
 	
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
@@ -52,9 +78,23 @@ func main() {
 	menuHandler := handlers.NewMenuHandler(menuService)
 	shoppingHandler := handlers.NewShoppingListHandler(shoppingService)
 	adminRecipeHandler := handlers.NewAdminRecipeHandler(adminRecipeService)
+	foodLogHandler := handlers.NewFoodLogHandler(foodLogService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	bodyHandler := handlers.NewBodyHandler(bodyService)
+	feedbackHandler := handlers.NewRecipeFeedbackHandler(feedbackService)
+	userRecipeHandler := handlers.NewUserRecipeHandler(userRecipeService)
+	importJobHandler := handlers.NewImportJobHandler(importJobService)
+	recipeImageHandler := handlers.NewRecipeImageHandler(recipeImageService)
+	cookingHandler := handlers.NewCookingSessionHandler(cookingService)
+	menuJobHandler := handlers.NewMenuJobHandler(menuJobService)
 	
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
+		// Тела больше BodyLimit (4 МБ по умолчанию) не отклоняются сервером, а читаются
+		// потоком: размер проверяет middleware.BodyLimit, и большие тела допускаются
+		// только на маршрутах импорта и загрузки изображений
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -72,13 +112,39 @@ func main() {
 		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
 	}))
 	
+	// Ограничение размера тела запроса. Маршруты импорта и загрузки изображений
+	// задают свое ограничение сами
+	defaultBodyLimit := middleware.BodyLimit(fiber.DefaultBodyLimit)
+	app.Use(func(c *fiber.Ctx) error {
+		path := strings.ToLower(c.Path())
+		if strings.HasPrefix(path, "/admin/recipes/import") ||
+			(strings.HasPrefix(path, "/admin/recipes/") && strings.HasSuffix(strings.TrimSuffix(path, "/"), "/image")) {
+			return c.Next()
+		}
+		return defaultBodyLimit(c)
+	})
+	importBody := middleware.BodyLimit(50 << 20)                         // файлы импорта рецептов
+	imageBody := middleware.BodyLimit(services.MaxRecipeImageSize + 1<<20) // изображение и поля multipart-формы
+	
+	// Дедлайны запросов: по истечении срока запросы к базе отменяются. Маршрутам
+	// с потоковой передачей (SSE, изображения) дедлайн не задается
+	quick := middleware.Deadline(10 * time.Second)
+	heavy := middleware.Deadline(2 * time.Minute) // генерация меню, отчеты, проверки каталога
+	bulk := middleware.Deadline(10 * time.Minute) // импорт и экспорт каталога
+	
 	// Public routes (должны быть ДО создания защищенной группы)
-	app.Get("/auth/test", authHandler.TestAuth) // Тестовая авторизация для разработки
-	app.Post("/auth/telegram", authHandler.AuthenticateTelegram)
-	app.Post("/auth/register", authHandler.Register) // Регистрация
-	app.Post("/auth/login", authHandler.Login)         // Вход
-	app.Get("/recipes", recipeHandler.GetAll)
-	app.Get("/recipes/:id", recipeHandler.GetByID)
+	app.Get("/auth/test", quick, authHandler.TestAuth) // Тестовая авторизация для разработки
+	app.Post("/auth/telegram", quick, authHandler.AuthenticateTelegram)
+	app.Post("/auth/register", quick, authHandler.Register) // Регистрация
+	app.Post("/auth/login", quick, authHandler.Login)         // Вход
+	app.Post("/auth/refresh", quick, authHandler.Refresh) // Новая пара токенов по refresh-токену
+	app.Post("/auth/logout", quick, authHandler.Logout)   // Выход: отзыв токенов входа
+	app.Get("/recipes", quick, recipeHandler.GetAll)
+	app.Get("/recipes/:id", quick, recipeHandler.GetByID)
+	app.Get("/recipes/:id/similar", quick, recipeHandler.GetSimilar) // Похожие рецепты
+	app.Get("/dietary-tags", quick, recipeHandler.GetDietaryTags) // Справочник диет и аллергенов
+	app.Get("/substitutions", quick, recipeHandler.GetSubstitutions) // Замены ингредиентов
+	app.Get("/images/*", recipeImageHandler.Get)               // Изображения рецептов и уменьшенные копии
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok"})
 	})
@@ -87,37 +153,111 @@ func main() {
 	api := app.Group("/", middleware.AuthMiddleware(authService))
 	
 	// Auth routes (требуют авторизации)
-	api.Get("/auth/profile", authHandler.GetProfile)              // Получить профиль
-	api.Put("/auth/profile", authHandler.UpdateProfile)           // Обновить профиль
-	api.Put("/auth/password", authHandler.UpdatePassword)         // Обновить пароль
+	api.Get("/auth/profile", quick, authHandler.GetProfile)              // Получить профиль
+	api.Put("/auth/profile", quick, authHandler.UpdateProfile)           // Обновить профиль
+	api.Put("/auth/password", quick, authHandler.UpdatePassword)         // Обновить пароль
 	
 	// Menu routes
-	api.Post("/menus/generate", menuHandler.Generate)
-	api.Get("/menu/weekly", menuHandler.GenerateWeekly) // Генерация недельного меню
-	api.Post("/menu/weekly/save", menuHandler.SaveWeeklyMenu) // Сохранение недельного меню
-	api.Get("/menus/weekly", menuHandler.GetWeeklyMenus) // Получение всех недельных меню
-	api.Get("/menus/daily", menuHandler.GetDaily)
-	api.Get("/menus", menuHandler.GetAll)
-	api.Get("/menus/:id", menuHandler.GetByID)
-	api.Delete("/menus/:id", menuHandler.Delete) // Удаление меню
+	api.Post("/menus/generate", heavy, menuHandler.Generate)
+	api.Get("/menu/weekly", heavy, menuHandler.GenerateWeekly) // Генерация недельного меню
+	api.Post("/menu/weekly/save", quick, menuHandler.SaveWeeklyMenu) // Сохранение недельного меню
+	api.Get("/menus/weekly", quick, menuHandler.GetWeeklyMenus) // Получение всех недельных меню
+	api.Post("/menus/jobs", quick, menuJobHandler.Create)        // Фоновая генерация недельного меню
+	api.Get("/menus/jobs/:id", quick, menuJobHandler.GetByID)
+	api.Get("/menus/jobs/:id/events", menuJobHandler.Events) // Прогресс генерации (SSE)
+	api.Delete("/menus/jobs/:id", quick, menuJobHandler.Cancel)
+	api.Get("/menus/daily", quick, menuHandler.GetDaily)
+	api.Get("/menus", quick, menuHandler.GetAll)
+	api.Get("/menus/:id", quick, menuHandler.GetByID)
+	api.Get("/menus/:id/calendar.ics", quick, menuHandler.GetCalendar) // Экспорт меню в iCalendar
+	api.Delete("/menus/:id", quick, menuHandler.Delete) // Удаление меню
 	
 	// User goals routes
-	api.Post("/users/goals", userHandler.SetGoals)
-	api.Get("/users/goals", userHandler.GetGoals)
+	api.Post("/users/goals", quick, userHandler.SetGoals)
+	api.Get("/users/goals", quick, userHandler.GetGoals)
+	api.Get("/users/goals/adjustments", quick, bodyHandler.GetAdjustments) // Журнал корректировок калорийности
+	api.Post("/users/goals/adjustments/:id/apply", quick, bodyHandler.ApplyAdjustment)
+	api.Post("/users/goals/adjustments/:id/reject", quick, bodyHandler.RejectAdjustment)
+	
+	// Body measurements routes (вес и обхваты)
+	api.Post("/body/measurements", quick, bodyHandler.CreateMeasurement)
+	api.Get("/body/measurements", quick, bodyHandler.GetMeasurements)
+	api.Delete("/body/measurements/:id", quick, bodyHandler.DeleteMeasurement)
+	
+	// User recipes routes (личные рецепты и заявки в общий каталог)
+	api.Post("/users/recipes", quick, userRecipeHandler.Create)
+	api.Get("/users/recipes", quick, userRecipeHandler.GetAll)
+	api.Delete("/users/recipes/:id", quick, userRecipeHandler.Delete)
+	api.Post("/users/submissions", quick, userRecipeHandler.Submit)
+	api.Get("/users/submissions", quick, userRecipeHandler.GetUserSubmissions)
+	
+	// Recipe feedback routes (оценки, избранное, запрет)
+	api.Get("/users/recipes/feedback", quick, feedbackHandler.GetFeedback)
+	api.Put("/recipes/:id/rating", quick, feedbackHandler.Rate)
+	api.Delete("/recipes/:id/rating", quick, feedbackHandler.ClearRating)
+	api.Put("/recipes/:id/favorite", quick, feedbackHandler.AddFavorite)
+	api.Delete("/recipes/:id/favorite", quick, feedbackHandler.RemoveFavorite)
+	api.Put("/recipes/:id/ban", quick, feedbackHandler.Ban)            // Больше не показывать
+	api.Delete("/recipes/:id/ban", quick, feedbackHandler.Unban)
 	
 	// Pantry routes
-	api.Get("/pantry", pantryHandler.GetAll)
-	api.Post("/pantry", pantryHandler.Create)
-	api.Delete("/pantry/:id", pantryHandler.Delete)
+	api.Get("/pantry", quick, pantryHandler.GetAll)
+	api.Post("/pantry", quick, pantryHandler.Create)
+	api.Delete("/pantry/:id", quick, pantryHandler.Delete)
+	
+	// Cooking sessions (пошаговое приготовление блюд из меню)
+	api.Post("/cooking/sessions", quick, cookingHandler.Start)
+	api.Get("/cooking/sessions", quick, cookingHandler.GetActive)
+	api.Get("/cooking/sessions/:id", quick, cookingHandler.GetByID)
+	api.Get("/cooking/sessions/:id/step", quick, cookingHandler.CurrentStep)
+	api.Post("/cooking/sessions/:id/next", quick, cookingHandler.Next)
+	api.Get("/cooking/sessions/:id/events", cookingHandler.Events) // Таймеры и изменения сессии (SSE)
+	api.Post("/cooking/sessions/:id/finish", quick, cookingHandler.Finish) // Завершение и списание из кладовой
+	api.Delete("/cooking/sessions/:id", quick, cookingHandler.Cancel)
 	
 	// Shopping list routes
-	api.Get("/shopping-list/:menu_id", shoppingHandler.GetByMenuID)
+	api.Get("/shopping-list/:menu_id", quick, shoppingHandler.GetByMenuID)
+	
+	// Food log routes (дневник питания)
+	api.Post("/food-log", quick, foodLogHandler.Create)
+	api.Get("/food-log", quick, foodLogHandler.GetDaily)
+	api.Get("/food-log/weekly", quick, foodLogHandler.GetWeekly)
+	api.Delete("/food-log/:id", quick, foodLogHandler.Delete)
+	
+	// Reports
+	api.Get("/reports/nutrition", heavy, reportHandler.GetNutrition)
 	
 	// Admin routes (требуют роль admin)
 	admin := api.Group("/admin", middleware.AdminMiddleware())
-	admin.Post("/recipes", adminRecipeHandler.Create)
-	admin.Post("/recipes/import", adminRecipeHandler.Import)
-	admin.Get("/recipes/export", adminRecipeHandler.Export)
+	admin.Post("/recipes", quick, adminRecipeHandler.Create)
+	admin.Post("/recipes/:id/image", imageBody, heavy, recipeImageHandler.Upload) // Загрузка изображения рецепта
+	admin.Post("/recipes/import", importBody, bulk, adminRecipeHandler.Import)
+	admin.Post("/recipes/import/schema-org", importBody, bulk, adminRecipeHandler.ImportSchemaOrg) // Импорт schema.org Recipe из HTML/JSON-LD
+	admin.Post("/recipes/import/jobs", importBody, bulk, importJobHandler.Create) // Фоновый импорт больших файлов
+	admin.Get("/jobs", quick, importJobHandler.GetAll)
+	admin.Get("/jobs/:id", quick, importJobHandler.GetByID)
+	admin.Get("/jobs/:id/events", importJobHandler.Events) // Прогресс задания (SSE)
+	admin.Get("/jobs/:id/result", quick, importJobHandler.GetResult)
+	admin.Get("/recipes/export", bulk, adminRecipeHandler.Export)
+	admin.Get("/recipes/export/manifest", bulk, adminRecipeHandler.ExportManifest)
+	admin.Post("/recipes/audit", heavy, adminRecipeHandler.Audit) // Проверка тегов рецептов по ингредиентам
+	admin.Put("/ingredients/nutrients", heavy, adminRecipeHandler.UpsertIngredientNutrients) // Справочник микронутриентов продуктов
+	admin.Put("/ingredients/substitutions", heavy, adminRecipeHandler.ReplaceSubstitutions)  // Таблица замен ингредиентов
+	admin.Post("/goals/adjustments/run", heavy, bodyHandler.RunAdjustments)
+	admin.Get("/submissions", quick, userRecipeHandler.GetSubmissions) // Очередь модерации рецептов
+	admin.Get("/submissions/:id", quick, userRecipeHandler.GetSubmission)
+	admin.Post("/submissions/:id/approve", quick, userRecipeHandler.ApproveSubmission)
+	admin.Post("/submissions/:id/reject", quick, userRecipeHandler.RejectSubmission)
+	
+	// Еженедельная корректировка калорийности по тренду веса: проверяется при старте
+	// и каждый час, пользователю предлагается не чаще раза в неделю
+	bodyService.StartWeeklyAdjustments(time.Hour)
+	
+	// Удаление истекших refresh-токенов и записей об отозванных access-токенах
+	authService.StartTokenCleanup(time.Hour)
+	
+	// Воркеры фоновых заданий импорта (прерванные перезапуском задания выполняются заново)
+	importJobService.Start(2)
 	
 	// Start server
 	port := os.Getenv("PORT")
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/myplate/backend/internal/repositories"
+	"github.com/myplate/backend/internal/services"
+	"github.com/myplate/backend/pkg/database"
+)
+
+// Проверка каталога рецептов: теги диет и аллергенов сверяются с ингредиентами,
+// найденные противоречия сохраняются в рецептах и выводятся в лог.
+// Код выхода 1, если есть рецепты с противоречиями.
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	if err := database.Connect(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer database.Close()
+
+	adminRecipeService := services.NewAdminRecipeService(repositories.NewRecipeRepository(), repositories.NewDietaryTagRepository())
+	audit, err := adminRecipeService.AuditRecipes(context.Background())
+	if err != nil {
+		log.Fatal("Audit failed:", err)
+	}
+
+	for _, recipe := range audit.Recipes {
+		log.Printf("Рецепт %d (%s):", recipe.RecipeID, recipe.Name)
+		for _, issue := range recipe.Issues {
+			log.Printf("  - %s", issue.Message)
+		}
+	}
+	log.Printf("Проверено рецептов: %d, с противоречиями: %d", audit.Checked, audit.Flagged)
+
+	if audit.Flagged > 0 {
+		database.Close()
+		os.Exit(1)
+	}
+}
@@ -1,11 +1,24 @@
 package handlers
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/internal/services"
 )
 
+// exportStreamTimeout ограничивает потоковый экспорт так же, как срок маршрутов
+// импорта и экспорта: поток пишется после возврата из обработчика, и контекст
+// запроса к этому времени уже отменен
+const exportStreamTimeout = 10 * time.Minute
+
 type AdminRecipeHandler struct {
 	adminRecipeService *services.AdminRecipeService
 }
@@ -24,7 +37,7 @@ func (h *AdminRecipeHandler) Create(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
 	}
 	
-	recipe, err := h.adminRecipeService.CreateRecipe(&req)
+	recipe, err := h.adminRecipeService.CreateRecipe(c.UserContext(), &req)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -32,8 +45,47 @@ func (h *AdminRecipeHandler) Create(c *fiber.Ctx) error {
 	return c.Status(201).JSON(recipe)
 }
 
-// Import импортирует рецепты из JSON
+// Import импортирует рецепты из JSON, CSV или XLSX. Формат - из ?format=json|csv|xlsx,
+// иначе по Content-Type. Таблицы передаются в теле запроса или файлами формы
+// multipart/form-data: recipes и необязательный ingredients.
+// ?mode=skip|update|fail - что делать с дубликатами, ?dry_run=true - проверка без записи
 func (h *AdminRecipeHandler) Import(c *fiber.Ctx) error {
+	opts, err := importOptions(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	format := strings.ToLower(c.Query("format"))
+	contentType := strings.ToLower(c.Get(fiber.HeaderContentType))
+	
+	if strings.HasPrefix(contentType, fiber.MIMEMultipartForm) {
+		recipes, recipesFormat, err := formFile(c, "recipes")
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if recipes == nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Файл recipes не передан"})
+		}
+		ingredients, _, err := formFile(c, "ingredients")
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if format == "" {
+			format = recipesFormat
+		}
+		return h.importTable(c, format, recipes, ingredients, opts)
+	}
+	
+	if format == "" {
+		format = tableFormat(contentType)
+	}
+	if format == services.TableFormatCSV || format == services.TableFormatXLSX {
+		return h.importTable(c, format, c.Body(), nil, opts)
+	}
+	if format != "" && format != "json" {
+		return c.Status(400).JSON(fiber.Map{"error": "Формат должен быть json, csv или xlsx"})
+	}
+	
 	var req models.RecipeImportRequest
 	
 	if err := c.BodyParser(&req); err != nil {
@@ -44,25 +96,270 @@ func (h *AdminRecipeHandler) Import(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Список рецептов пуст"})
 	}
 	
-	result, err := h.adminRecipeService.ImportRecipes(req.Recipes)
+	result, err := h.adminRecipeService.ImportRecipes(c.UserContext(), req.Recipes, opts)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	
-	return c.Status(201).JSON(fiber.Map{
-		"imported": result.Imported,
-		"failed":   result.Failed,
-		"errors":   result.Errors,
+	return c.Status(importStatus(result)).JSON(fiber.Map{
+		"imported":     result.Imported,
+		"updated":      result.Updated,
+		"skipped":      result.Skipped,
+		"failed":       result.Failed,
+		"errors":       result.Errors,
+		"unknown_tags": result.UnknownTags,
+		"flagged":      result.Flagged,
+		"warnings":     result.Warnings,
+		"dry_run":      result.DryRun,
 	})
 }
 
-// Export экспортирует все рецепты в JSON
+// importOptions читает параметры импорта mode и dry_run
+func importOptions(c *fiber.Ctx) (services.ImportOptions, error) {
+	return services.NewImportOptions(strings.ToLower(c.Query("mode")), c.QueryBool("dry_run"))
+}
+
+// importStatus - 201, если импорт записан в базу, 200 для пробного импорта
+func importStatus(result *services.ImportResult) int {
+	if result.DryRun {
+		return 200
+	}
+	return 201
+}
+
+// importTable импортирует рецепты из таблицы CSV или XLSX
+func (h *AdminRecipeHandler) importTable(c *fiber.Ctx, format string, recipes, ingredients []byte, opts services.ImportOptions) error {
+	if format != services.TableFormatCSV && format != services.TableFormatXLSX {
+		return c.Status(400).JSON(fiber.Map{"error": "Формат должен быть csv или xlsx"})
+	}
+	
+	table, err := services.ParseRecipeTable(format, recipes, ingredients)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(table.Recipes) == 0 && len(table.Errors) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Список рецептов пуст"})
+	}
+	
+	result, err := h.adminRecipeService.ImportRecipeTable(c.UserContext(), table, opts)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.Status(importStatus(result)).JSON(result)
+}
+
+// tableFormat определяет формат таблицы по Content-Type или расширению файла
+func tableFormat(value string) string {
+	value = strings.ToLower(value)
+	switch {
+	case strings.Contains(value, "text/csv"), strings.Contains(value, "application/csv"), strings.HasSuffix(value, ".csv"):
+		return services.TableFormatCSV
+	case strings.Contains(value, "spreadsheetml.sheet"), strings.HasSuffix(value, ".xlsx"):
+		return services.TableFormatXLSX
+	}
+	return ""
+}
+
+// formFile читает файл формы и определяет его формат (nil, если файла нет)
+func formFile(c *fiber.Ctx, field string) ([]byte, string, error) {
+	header, err := c.FormFile(field)
+	if err != nil {
+		return nil, "", nil
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("Не удалось прочитать файл %s", header.Filename)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("Не удалось прочитать файл %s", header.Filename)
+	}
+	format := tableFormat(header.Filename)
+	if format == "" {
+		format = tableFormat(header.Header.Get(fiber.HeaderContentType))
+	}
+	return data, format, nil
+}
+
+// ImportSchemaOrg импортирует рецепты schema.org Recipe (JSON-LD): файлы HTML/JSON
+// в поле files формы multipart/form-data или HTML/JSON в теле запроса
+// POST /admin/recipes/import/schema-org
+func (h *AdminRecipeHandler) ImportSchemaOrg(c *fiber.Ctx) error {
+	opts, err := importOptions(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	var sources []services.SchemaOrgSource
+	
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Неверная форма"})
+		}
+		for _, header := range form.File["files"] {
+			file, err := header.Open()
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "Не удалось прочитать файл " + header.Filename})
+			}
+			data, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "Не удалось прочитать файл " + header.Filename})
+			}
+			sources = append(sources, services.SchemaOrgSource{Name: header.Filename, Data: data})
+		}
+	} else if len(c.Body()) > 0 {
+		sources = append(sources, services.SchemaOrgSource{Name: "body", Data: append([]byte(nil), c.Body()...)})
+	}
+	
+	if len(sources) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Файлы для импорта не переданы"})
+	}
+	
+	result, err := h.adminRecipeService.ImportSchemaOrg(c.UserContext(), sources, opts)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.Status(importStatus(result)).JSON(result)
+}
+
+// Export экспортирует рецепты общего каталога в JSON, NDJSON, CSV или XLSX
+// (?format=json|ndjson|csv|xlsx, иначе по заголовку Accept). Принимает фильтры
+// GET /recipes и updated_since для инкрементального экспорта (удаления он не
+// передает - их находят сравнением с полным экспортом); JSON и NDJSON
+// передаются потоком и завершаются манифестом с контрольной суммой.
+// GET /admin/recipes/export
 func (h *AdminRecipeHandler) Export(c *fiber.Ctx) error {
-	exportData, err := h.adminRecipeService.ExportRecipes()
+	filter, err := parseRecipeFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = exportFormat(c.Get(fiber.HeaderAccept))
+	}
+	
+	switch format {
+	case services.TableFormatCSV, services.TableFormatXLSX:
+		data, err := h.adminRecipeService.ExportRecipeTable(c.UserContext(), format, filter)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if format == services.TableFormatCSV {
+			c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		} else {
+			c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		}
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="recipes.`+format+`"`)
+		return c.Send(data)
+	case services.ExportFormatJSON:
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	case services.ExportFormatNDJSON:
+		c.Set(fiber.HeaderContentType, "application/x-ndjson; charset=utf-8")
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Формат должен быть json, ndjson, csv или xlsx"})
+	}
+	
+	// Ошибка посреди потока уже не может изменить статус ответа: экспорт
+	// обрывается без манифеста, и клиент видит, что он неполный. Отключение
+	// клиента обнаруживается при сбросе очередной порции рецептов
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), exportStreamTimeout)
+		defer cancel()
+	
+		if _, err := h.adminRecipeService.StreamExport(ctx, filter, format, w); err != nil {
+			log.Printf("Ошибка потокового экспорта рецептов: %v", err)
+			return
+		}
+		if err := w.Flush(); err != nil {
+			log.Printf("Ошибка потокового экспорта рецептов: %v", err)
+		}
+	})
+	
+	return nil
+}
+
+// ExportManifest возвращает только манифест экспорта с теми же параметрами - чтобы
+// сравнить каталоги без скачивания
+// GET /admin/recipes/export/manifest
+func (h *AdminRecipeHandler) ExportManifest(c *fiber.Ctx) error {
+	filter, err := parseRecipeFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	manifest, err := h.adminRecipeService.StreamExport(c.UserContext(), filter, services.ExportFormatNDJSON, io.Discard)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(manifest)
+}
+
+// exportFormat определяет формат экспорта по заголовку Accept (по умолчанию JSON)
+func exportFormat(accept string) string {
+	if format := tableFormat(accept); format != "" {
+		return format
+	}
+	if strings.Contains(strings.ToLower(accept), "ndjson") {
+		return services.ExportFormatNDJSON
+	}
+	return services.ExportFormatJSON
+}
+
+// Audit проверяет теги диет и аллергенов всех рецептов по ингредиентам
+// POST /admin/recipes/audit
+func (h *AdminRecipeHandler) Audit(c *fiber.Ctx) error {
+	audit, err := h.adminRecipeService.AuditRecipes(c.UserContext())
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	
-	return c.JSON(exportData)
+	return c.JSON(audit)
 }
 
+// UpsertIngredientNutrients обновляет справочник продуктов для расчета микронутриентов
+// PUT /admin/ingredients/nutrients
+func (h *AdminRecipeHandler) UpsertIngredientNutrients(c *fiber.Ctx) error {
+	var items []models.IngredientNutrients
+	
+	if err := c.BodyParser(&items); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
+	}
+	
+	if len(items) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Список продуктов пуст"})
+	}
+	
+	if err := h.adminRecipeService.UpsertIngredientNutrients(c.UserContext(), items); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(fiber.Map{"updated": len(items)})
+}
+
+// ReplaceSubstitutions обновляет таблицу замен ингредиентов: все замены
+// перечисленных ингредиентов заменяются переданными
+// PUT /admin/ingredients/substitutions
+func (h *AdminRecipeHandler) ReplaceSubstitutions(c *fiber.Ctx) error {
+	var items []models.IngredientSubstitution
+	
+	if err := c.BodyParser(&items); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
+	}
+	
+	if len(items) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Список замен пуст"})
+	}
+	
+	if err := h.adminRecipeService.ReplaceSubstitutions(c.UserContext(), items); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(fiber.Map{"updated": len(items)})
+}
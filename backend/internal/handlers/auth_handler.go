@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/internal/services"
 )
 
@@ -41,9 +45,26 @@ type UpdateProfileRequest struct {
 	LastName  string `json:"last_name"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type AuthResponse struct {
-	User  interface{} `json:"user"`
-	Token string      `json:"token"`
+	User             interface{} `json:"user"`
+	Token            string      `json:"token"`
+	ExpiresAt        time.Time   `json:"expires_at"`
+	RefreshToken     string      `json:"refresh_token"`
+	RefreshExpiresAt time.Time   `json:"refresh_expires_at"`
+}
+
+func newAuthResponse(user *models.User, tokens *models.TokenPair) AuthResponse {
+	return AuthResponse{
+		User:             user,
+		Token:            tokens.AccessToken,
+		ExpiresAt:        tokens.ExpiresAt,
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+	}
 }
 
 func (h *AuthHandler) AuthenticateTelegram(c *fiber.Ctx) error {
@@ -52,28 +73,22 @@ func (h *AuthHandler) AuthenticateTelegram(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
 	}
 	
-	user, token, err := h.authService.AuthenticateTelegram(req.InitData)
+	user, tokens, err := h.authService.AuthenticateTelegram(c.UserContext(), req.InitData)
 	if err != nil {
 		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
 	}
 	
-	return c.JSON(AuthResponse{
-		User:  user,
-		Token: token,
-	})
+	return c.JSON(newAuthResponse(user, tokens))
 }
 
 // TestAuth - для разработки и тестирования, создает тестового пользователя и возвращает токен
 func (h *AuthHandler) TestAuth(c *fiber.Ctx) error {
-	user, token, err := h.authService.CreateTestUser()
+	user, tokens, err := h.authService.CreateTestUser(c.UserContext())
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	
-	return c.JSON(AuthResponse{
-		User:  user,
-		Token: token,
-	})
+	return c.JSON(newAuthResponse(user, tokens))
 }
 
 // Register регистрирует нового пользователя
@@ -94,15 +109,12 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Пароль должен содержать минимум 6 символов"})
 	}
 
-	user, token, err := h.authService.Register(req.Email, req.Password, req.FirstName, req.LastName)
+	user, tokens, err := h.authService.Register(c.UserContext(), req.Email, req.Password, req.FirstName, req.LastName)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(201).JSON(AuthResponse{
-		User:  user,
-		Token: token,
-	})
+	return c.Status(201).JSON(newAuthResponse(user, tokens))
 }
 
 // Login авторизует пользователя
@@ -120,15 +132,49 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Пароль обязателен"})
 	}
 
-	user, token, err := h.authService.Login(req.Email, req.Password)
+	user, tokens, err := h.authService.Login(c.UserContext(), req.Email, req.Password)
 	if err != nil {
 		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.JSON(AuthResponse{
-		User:  user,
-		Token: token,
-	})
+	return c.JSON(newAuthResponse(user, tokens))
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов
+// POST /auth/refresh
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
+	}
+	if req.RefreshToken == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "refresh_token обязателен"})
+	}
+
+	tokens, err := h.authService.Refresh(c.UserContext(), req.RefreshToken)
+	if err != nil {
+		return refreshTokenError(c, err)
+	}
+
+	return c.JSON(tokens)
+}
+
+// Logout завершает вход: refresh-токен и выданные по нему access-токены отзываются
+// POST /auth/logout
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
+	}
+	if req.RefreshToken == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "refresh_token обязателен"})
+	}
+
+	if err := h.authService.Logout(c.UserContext(), req.RefreshToken); err != nil {
+		return refreshTokenError(c, err)
+	}
+
+	return c.SendStatus(204)
 }
 
 // GetProfile возвращает профиль текущего пользователя
@@ -143,7 +189,7 @@ func (h *AuthHandler) GetProfile(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Ошибка при получении ID пользователя"})
 	}
 
-	user, err := h.authService.GetUserProfile(userID)
+	user, err := h.authService.GetUserProfile(c.UserContext(), userID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "ошибка при получении профиля: " + err.Error()})
 	}
@@ -171,7 +217,7 @@ func (h *AuthHandler) UpdatePassword(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Новый пароль должен содержать минимум 6 символов"})
 	}
 
-	err := h.authService.UpdatePassword(userID, req.OldPassword, req.NewPassword)
+	err := h.authService.UpdatePassword(c.UserContext(), userID, req.OldPassword, req.NewPassword)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -188,7 +234,7 @@ func (h *AuthHandler) UpdateProfile(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
 	}
 
-	user, err := h.authService.UpdateProfile(userID, req.FirstName, req.LastName)
+	user, err := h.authService.UpdateProfile(c.UserContext(), userID, req.FirstName, req.LastName)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -196,4 +242,12 @@ func (h *AuthHandler) UpdateProfile(c *fiber.Ctx) error {
 	return c.JSON(user)
 }
 
-
+// refreshTokenError преобразует ошибку refresh-токена в HTTP-ответ
+func refreshTokenError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidRefreshToken), errors.Is(err, services.ErrRefreshTokenReused):
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/services"
+)
+
+type BodyHandler struct {
+	bodyService *services.BodyService
+}
+
+func NewBodyHandler(bodyService *services.BodyService) *BodyHandler {
+	return &BodyHandler{
+		bodyService: bodyService,
+	}
+}
+
+// CreateMeasurement добавляет измерение веса и талии
+// POST /body/measurements
+func (h *BodyHandler) CreateMeasurement(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	var req models.BodyMeasurementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
+	}
+	
+	measurement, err := h.bodyService.AddMeasurement(c.UserContext(), userID, &req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.Status(201).JSON(measurement)
+}
+
+// GetMeasurements возвращает измерения за период (по умолчанию - последние 30 дней)
+// GET /body/measurements?from=2024-01-01&to=2024-01-31
+func (h *BodyHandler) GetMeasurements(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	to, err := parseDateQuery(c, "to")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный формат даты 'to'"})
+	}
+	
+	from := to.AddDate(0, 0, -29)
+	if value := c.Query("from"); value != "" {
+		from, err = time.Parse(models.DateLayout, value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Неверный формат даты 'from'"})
+		}
+	}
+	
+	measurements, err := h.bodyService.GetMeasurements(c.UserContext(), userID, from, to)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(measurements)
+}
+
+// DeleteMeasurement удаляет измерение
+// DELETE /body/measurements/:id
+func (h *BodyHandler) DeleteMeasurement(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID измерения"})
+	}
+	
+	if err := h.bodyService.DeleteMeasurement(c.UserContext(), userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "Измерение не найдено"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(fiber.Map{"message": "Измерение успешно удалено"})
+}
+
+// GetAdjustments возвращает журнал корректировок калорийности
+// GET /users/goals/adjustments
+func (h *BodyHandler) GetAdjustments(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	adjustments, err := h.bodyService.GetAdjustments(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(adjustments)
+}
+
+// ApplyAdjustment применяет предложенную калорийность
+// POST /users/goals/adjustments/:id/apply
+func (h *BodyHandler) ApplyAdjustment(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID корректировки"})
+	}
+	
+	goals, err := h.bodyService.ApplyAdjustment(c.UserContext(), userID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "Корректировка не найдена"})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(goals)
+}
+
+// RejectAdjustment отклоняет предложенную калорийность
+// POST /users/goals/adjustments/:id/reject
+func (h *BodyHandler) RejectAdjustment(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID корректировки"})
+	}
+	
+	if err := h.bodyService.RejectAdjustment(c.UserContext(), userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "Корректировка не найдена"})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(fiber.Map{"message": "Корректировка отклонена"})
+}
+
+// RunAdjustments запускает еженедельную корректировку вручную (для админа)
+// POST /admin/goals/adjustments/run
+func (h *BodyHandler) RunAdjustments(c *fiber.Ctx) error {
+	created, err := h.bodyService.RunWeeklyAdjustments(c.UserContext(), time.Now())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(fiber.Map{"created": created})
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/services"
+)
+
+type CookingSessionHandler struct {
+	cookingService *services.CookingSessionService
+}
+
+func NewCookingSessionHandler(cookingService *services.CookingSessionService) *CookingSessionHandler {
+	return &CookingSessionHandler{
+		cookingService: cookingService,
+	}
+}
+
+// Start начинает пошаговое приготовление приемов пищи из меню
+// POST /cooking/sessions
+func (h *CookingSessionHandler) Start(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	var req models.CookingSessionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
+	}
+	
+	session, err := h.cookingService.Start(c.UserContext(), userID, &req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	c.Set(fiber.HeaderLocation, fmt.Sprintf("/cooking/sessions/%d", session.ID))
+	return c.Status(201).JSON(session)
+}
+
+// GetActive возвращает незавершенные сессии пользователя
+// GET /cooking/sessions
+func (h *CookingSessionHandler) GetActive(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	sessions, err := h.cookingService.GetActive(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(sessions)
+}
+
+// GetByID возвращает сессию со всеми шагами и таймерами
+// GET /cooking/sessions/:id
+func (h *CookingSessionHandler) GetByID(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID сессии"})
+	}
+	
+	session, err := h.cookingService.Get(c.UserContext(), userID, id)
+	if err != nil {
+		return cookingSessionError(c, err)
+	}
+	
+	return c.JSON(session)
+}
+
+// CurrentStep возвращает текущий шаг и таймеры
+// GET /cooking/sessions/:id/step
+func (h *CookingSessionHandler) CurrentStep(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID сессии"})
+	}
+	
+	step, err := h.cookingService.CurrentStep(c.UserContext(), userID, id)
+	if err != nil {
+		return cookingSessionError(c, err)
+	}
+	
+	return c.JSON(step)
+}
+
+// Next завершает текущий шаг и переходит к следующему
+// POST /cooking/sessions/:id/next
+func (h *CookingSessionHandler) Next(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID сессии"})
+	}
+	
+	step, err := h.cookingService.Next(c.UserContext(), userID, id)
+	if err != nil {
+		return cookingSessionError(c, err)
+	}
+	
+	return c.JSON(step)
+}
+
+// Finish завершает сессию и списывает продукты из кладовой
+// POST /cooking/sessions/:id/finish
+func (h *CookingSessionHandler) Finish(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID сессии"})
+	}
+	
+	session, err := h.cookingService.Finish(c.UserContext(), userID, id)
+	if err != nil {
+		return cookingSessionError(c, err)
+	}
+	
+	return c.JSON(session)
+}
+
+// Cancel отменяет сессию без списания продуктов
+// DELETE /cooking/sessions/:id
+func (h *CookingSessionHandler) Cancel(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID сессии"})
+	}
+	
+	if err := h.cookingService.Cancel(c.UserContext(), userID, id); err != nil {
+		return cookingSessionError(c, err)
+	}
+	
+	return c.JSON(fiber.Map{"message": "Сессия отменена"})
+}
+
+// Events передает события сессии как Server-Sent Events: state - при изменении
+// шагов (в том числе с другого устройства), timer - когда таймер шага истек,
+// finished - после завершения или отмены сессии
+// GET /cooking/sessions/:id/events
+func (h *CookingSessionHandler) Events(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID сессии"})
+	}
+	
+	session, err := h.cookingService.Get(c.UserContext(), userID, id)
+	if err != nil {
+		return cookingSessionError(c, err)
+	}
+	
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+	
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// Поток живет дольше обработчика, поэтому контекст запроса здесь не используется
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+	
+		send := func(event string, payload interface{}) bool {
+			data, _ := json.Marshal(payload)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+			return w.Flush() == nil // false - клиент отключился
+		}
+	
+		// Таймеры, истекшие до подключения, не отправляются повторно
+		notified := map[int]bool{}
+		for _, timer := range session.Timers {
+			notified[timer.Step] = timer.Done
+		}
+		if !send("state", session) {
+			return
+		}
+	
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for tick := 1; ; tick++ {
+			<-ticker.C
+	
+			// Изменения с других устройств - из базы, раз в 2 секунды
+			if tick%2 == 0 {
+				reloaded, err := h.cookingService.Get(ctx, userID, id)
+				if err != nil {
+					return
+				}
+				if !reloaded.UpdatedAt.Equal(session.UpdatedAt) {
+					session = reloaded
+					if !send("state", session) {
+						return
+					}
+				}
+				if !session.Active() {
+					send("finished", session)
+					return
+				}
+				// Комментарий SSE поддерживает соединение и выявляет отключение клиента
+				fmt.Fprint(w, ": ping\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+	
+			for _, timer := range h.cookingService.Timers(session) {
+				if timer.Done && !notified[timer.Step] {
+					notified[timer.Step] = true
+					if !send("timer", timer) {
+						return
+					}
+				}
+			}
+		}
+	})
+	
+	return nil
+}
+
+// cookingSessionError отвечает статусом, соответствующим ошибке сервиса
+func cookingSessionError(c *fiber.Ctx, err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return c.Status(404).JSON(fiber.Map{"error": "Сессия не найдена"})
+	case services.ErrCookingSessionFinished, services.ErrCookingSessionChanged, services.ErrCookingStepsDone:
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/services"
+)
+
+type FoodLogHandler struct {
+	foodLogService *services.FoodLogService
+}
+
+func NewFoodLogHandler(foodLogService *services.FoodLogService) *FoodLogHandler {
+	return &FoodLogHandler{
+		foodLogService: foodLogService,
+	}
+}
+
+// Create добавляет запись в дневник питания
+// POST /food-log
+func (h *FoodLogHandler) Create(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	var req models.FoodLogRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
+	}
+	
+	entry, err := h.foodLogService.AddEntry(c.UserContext(), userID, &req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.Status(201).JSON(entry)
+}
+
+// GetDaily возвращает дневник за день с итогами и сравнением с целями
+// GET /food-log?date=2024-01-01
+func (h *FoodLogHandler) GetDaily(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	date, err := parseDateQuery(c, "date")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный формат даты"})
+	}
+	
+	day, err := h.foodLogService.GetDay(c.UserContext(), userID, date)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(day)
+}
+
+// GetWeekly возвращает дневник за 7 дней
+// GET /food-log/weekly?start_date=2024-01-01
+func (h *FoodLogHandler) GetWeekly(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	startDate, err := parseDateQuery(c, "start_date")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный формат даты"})
+	}
+	
+	week, err := h.foodLogService.GetWeek(c.UserContext(), userID, startDate)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(week)
+}
+
+// Delete удаляет запись дневника
+// DELETE /food-log/:id
+func (h *FoodLogHandler) Delete(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID записи"})
+	}
+	
+	if err := h.foodLogService.DeleteEntry(c.UserContext(), userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "Запись не найдена"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(fiber.Map{"message": "Запись успешно удалена"})
+}
+
+// parseDateQuery разбирает дату YYYY-MM-DD из query (по умолчанию - сегодня)
+func parseDateQuery(c *fiber.Ctx, key string) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return time.Now(), nil
+	}
+	return time.Parse(models.DateLayout, value)
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/services"
+)
+
+type ImportJobHandler struct {
+	importJobService *services.ImportJobService
+}
+
+func NewImportJobHandler(importJobService *services.ImportJobService) *ImportJobHandler {
+	return &ImportJobHandler{
+		importJobService: importJobService,
+	}
+}
+
+// Create ставит импорт рецептов в очередь. Файлы передаются так же, как в
+// POST /admin/recipes/import; ?format=schema-org - HTML/JSON-LD в поле files формы
+// POST /admin/recipes/import/jobs
+func (h *ImportJobHandler) Create(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	format, files, err := importJobFiles(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	job, err := h.importJobService.Submit(c.UserContext(), userID, format, files, strings.ToLower(c.Query("mode")), c.QueryBool("dry_run"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	c.Set(fiber.HeaderLocation, fmt.Sprintf("/admin/jobs/%d", job.ID))
+	return c.Status(202).JSON(job)
+}
+
+// importJobFiles читает файлы задания из формы или тела запроса и определяет формат
+func importJobFiles(c *fiber.Ctx) (string, []models.ImportJobFile, error) {
+	format := strings.ToLower(c.Query("format"))
+	contentType := strings.ToLower(c.Get(fiber.HeaderContentType))
+	
+	if !strings.HasPrefix(contentType, fiber.MIMEMultipartForm) {
+		if len(c.Body()) == 0 {
+			return "", nil, fmt.Errorf("Файлы для импорта не переданы")
+		}
+		if format == "" {
+			format = tableFormat(contentType)
+		}
+		if format == "" && strings.Contains(contentType, "text/html") {
+			format = services.ImportFormatSchemaOrg
+		}
+		if format == "" {
+			format = services.ImportFormatJSON
+		}
+		data := append([]byte(nil), c.Body()...)
+		return format, []models.ImportJobFile{{Name: "recipes", Data: data}}, nil
+	}
+	
+	form, err := c.MultipartForm()
+	if err != nil {
+		return "", nil, fmt.Errorf("Неверная форма")
+	}
+	if format == "" && len(form.File["recipes"]) == 0 && len(form.File["files"]) > 0 {
+		format = services.ImportFormatSchemaOrg
+	}
+	
+	// schema.org: любое количество HTML/JSON-LD файлов
+	if format == services.ImportFormatSchemaOrg {
+		var files []models.ImportJobFile
+		for _, header := range form.File["files"] {
+			file, err := header.Open()
+			if err != nil {
+				return "", nil, fmt.Errorf("Не удалось прочитать файл %s", header.Filename)
+			}
+			data, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return "", nil, fmt.Errorf("Не удалось прочитать файл %s", header.Filename)
+			}
+			files = append(files, models.ImportJobFile{Name: header.Filename, Data: data})
+		}
+		return format, files, nil
+	}
+	
+	recipes, recipesFormat, err := formFile(c, "recipes")
+	if err != nil {
+		return "", nil, err
+	}
+	if recipes == nil {
+		return "", nil, fmt.Errorf("Файл recipes не передан")
+	}
+	ingredients, _, err := formFile(c, "ingredients")
+	if err != nil {
+		return "", nil, err
+	}
+	if format == "" {
+		format = recipesFormat
+	}
+	if format == "" {
+		format = services.ImportFormatJSON
+	}
+	
+	files := []models.ImportJobFile{{Name: "recipes", Data: recipes}}
+	if ingredients != nil {
+		files = append(files, models.ImportJobFile{Name: "ingredients", Data: ingredients})
+	}
+	return format, files, nil
+}
+
+// GetAll возвращает последние задания импорта
+// GET /admin/jobs
+func (h *ImportJobHandler) GetAll(c *fiber.Ctx) error {
+	jobs, err := h.importJobService.GetRecent(c.UserContext())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(jobs)
+}
+
+// GetByID возвращает статус и прогресс задания (результат - после завершения)
+// GET /admin/jobs/:id
+func (h *ImportJobHandler) GetByID(c *fiber.Ctx) error {
+	job, status, err := h.job(c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(job)
+}
+
+// GetResult отдает результат импорта (ImportResult) файлом
+// GET /admin/jobs/:id/result
+func (h *ImportJobHandler) GetResult(c *fiber.Ctx) error {
+	job, status, err := h.job(c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	if job.Status == "failed" {
+		return c.Status(409).JSON(fiber.Map{"error": "Задание завершилось с ошибкой: " + job.Error})
+	}
+	if job.Status != "completed" {
+		return c.Status(409).JSON(fiber.Map{"error": "Задание еще выполняется"})
+	}
+	
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="import-%d.json"`, job.ID))
+	return c.Send(job.Result)
+}
+
+// Events передает прогресс задания как Server-Sent Events: событие progress при
+// каждом обновлении и done с результатом после завершения
+// GET /admin/jobs/:id/events
+func (h *ImportJobHandler) Events(c *fiber.Ctx) error {
+	job, status, err := h.job(c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+	
+	updates, unsubscribe := h.importJobService.Subscribe(job.ID)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		// Поток живет дольше обработчика, поэтому контекст запроса здесь не используется
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+	
+		// Прогресс заданий, выполняющихся на другом сервере, - из базы
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+	
+		current := *job
+		lastSent := -1
+		for {
+			if current.Processed != lastSent || current.Finished() {
+				event := "progress"
+				if current.Finished() {
+					event = "done"
+				}
+				data, _ := json.Marshal(current)
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+				if err := w.Flush(); err != nil {
+					return // клиент отключился
+				}
+				if current.Finished() {
+					return
+				}
+				lastSent = current.Processed
+			}
+	
+			select {
+			case update := <-updates:
+				current = update
+			case <-ticker.C:
+				if reloaded, err := h.importJobService.Get(ctx, job.ID); err == nil {
+					current = *reloaded
+				}
+				// Комментарий SSE поддерживает соединение и выявляет отключение клиента
+				fmt.Fprint(w, ": ping\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+	
+	return nil
+}
+
+// job читает задание по :id; возвращает HTTP-статус ошибки
+func (h *ImportJobHandler) job(c *fiber.Ctx) (*models.ImportJob, int, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return nil, 400, fmt.Errorf("Неверный ID задания")
+	}
+	
+	job, err := h.importJobService.Get(c.UserContext(), id)
+	if err == sql.ErrNoRows {
+		return nil, 404, fmt.Errorf("Задание не найдено")
+	}
+	if err != nil {
+		return nil, 500, err
+	}
+	return job, 200, nil
+}
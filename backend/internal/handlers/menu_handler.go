@@ -2,7 +2,8 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -10,7 +11,6 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/internal/services"
-	"github.com/myplate/backend/pkg/database"
 )
 
 type MenuHandler struct {
@@ -30,7 +30,7 @@ func (h *MenuHandler) Generate(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
 	}
 	
-	menu, err := h.menuService.GenerateMenu(&req)
+	menu, err := h.menuService.GenerateMenu(c.UserContext(), &req)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -53,7 +53,7 @@ func (h *MenuHandler) GetDaily(c *fiber.Ctx) error {
 		date = time.Now()
 	}
 	
-	menu, err := h.menuService.GetDaily(userID, date)
+	menu, err := h.menuService.GetDaily(c.UserContext(), userID, date)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -64,7 +64,7 @@ func (h *MenuHandler) GetDaily(c *fiber.Ctx) error {
 func (h *MenuHandler) GetAll(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(int)
 	
-	menus, err := h.menuService.GetAllByUserID(userID)
+	menus, err := h.menuService.GetAllByUserID(c.UserContext(), userID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -78,7 +78,7 @@ func (h *MenuHandler) GetByID(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID меню"})
 	}
 	
-	menu, err := h.menuService.GetByID(menuID)
+	menu, err := h.menuService.GetByID(c.UserContext(), menuID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -91,21 +91,37 @@ func (h *MenuHandler) GetByID(c *fiber.Ctx) error {
 }
 
 // GenerateWeekly генерирует меню на неделю
-// GET /menu/weekly?adults=2&children=1&diet_type=vegetarian&allergies=nuts,dairy
+// GET /menu/weekly?adults=2&children=1&diet_type=vegetarian&allergies=nuts,dairy&allow_substitutions=true
+// &prefer_seasonal=true&balance_cuisines=true&weekday_max_hard=1
 func (h *MenuHandler) GenerateWeekly(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(int)
 	
-	var req models.WeeklyMenuRequest
-	req.UserID = userID
+	req, err := parseWeeklyMenuRequest(c, userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	weeklyMenu, err := h.menuService.GenerateWeeklyMenu(c.UserContext(), req, nil)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(weeklyMenu)
+}
+
+// parseWeeklyMenuRequest читает параметры недельного меню из query (общие для
+// GET /menu/weekly и POST /menus/jobs)
+func parseWeeklyMenuRequest(c *fiber.Ctx, userID int) (*models.WeeklyMenuRequest, error) {
+	req := &models.WeeklyMenuRequest{UserID: userID}
 	
 	// Парсим обязательные параметры
 	adultsStr := c.Query("adults")
 	if adultsStr == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "Параметр 'adults' обязателен"})
+		return nil, fmt.Errorf("Параметр 'adults' обязателен")
 	}
 	adults, err := strconv.Atoi(adultsStr)
 	if err != nil || adults < 1 {
-		return c.Status(400).JSON(fiber.Map{"error": "Параметр 'adults' должен быть положительным числом"})
+		return nil, fmt.Errorf("Параметр 'adults' должен быть положительным числом")
 	}
 	req.Adults = adults
 	
@@ -115,12 +131,18 @@ func (h *MenuHandler) GenerateWeekly(c *fiber.Ctx) error {
 	} else {
 		children, err := strconv.Atoi(childrenStr)
 		if err != nil || children < 0 {
-			return c.Status(400).JSON(fiber.Map{"error": "Параметр 'children' должен быть неотрицательным числом"})
+			return nil, fmt.Errorf("Параметр 'children' должен быть неотрицательным числом")
 		}
 		req.Children = children
 	}
 	
 	// Опциональные параметры
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		req.StartDate, err = time.Parse(models.DateLayout, startDateStr)
+		if err != nil {
+			return nil, fmt.Errorf("Неверный формат start_date, ожидается YYYY-MM-DD")
+		}
+	}
 	req.DietType = c.Query("diet_type")
 	maxTotalTimeStr := c.Query("max_total_time")
 	if maxTotalTimeStr != "" {
@@ -151,12 +173,25 @@ func (h *MenuHandler) GenerateWeekly(c *fiber.Ctx) error {
 		}
 	}
 	
-	weeklyMenu, err := h.menuService.GenerateWeeklyMenu(&req)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	req.AllowSubstitutions = c.Query("allow_substitutions") == "true"
+	
+	// Оборудование, недоступное в будни (например, weekday_unavailable_equipment=oven)
+	req.WeekdayUnavailableEquipment = queryList(c, "weekday_unavailable_equipment")
+	
+	// Не больше N сложных рецептов в будний день (weekday_max_hard=0 - без сложных)
+	if value := c.Query("weekday_max_hard"); value != "" {
+		maxHard, err := strconv.Atoi(value)
+		if err != nil || maxHard < 0 {
+			return nil, fmt.Errorf("Параметр 'weekday_max_hard' должен быть неотрицательным числом")
+		}
+		req.WeekdayMaxHard = &maxHard
 	}
 	
-	return c.JSON(weeklyMenu)
+	// Разнообразие: сезонные рецепты и чередование кухонь
+	req.PreferSeasonal = c.Query("prefer_seasonal") == "true"
+	req.BalanceCuisines = c.Query("balance_cuisines") == "true"
+	
+	return req, nil
 }
 
 // SaveWeeklyMenu сохраняет недельное меню в базу данных
@@ -169,7 +204,10 @@ func (h *MenuHandler) SaveWeeklyMenu(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса: " + err.Error()})
 	}
 	
-	menu, err := h.menuService.SaveWeeklyMenu(userID, &weeklyMenu)
+	menu, err := h.menuService.SaveWeeklyMenu(c.UserContext(), userID, &weeklyMenu)
+	if errors.Is(err, services.ErrInvalidWeeklyMenu) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -182,56 +220,12 @@ func (h *MenuHandler) SaveWeeklyMenu(c *fiber.Ctx) error {
 func (h *MenuHandler) GetWeeklyMenus(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(int)
 	
-	menus, err := h.menuService.GetWeeklyMenus(userID)
+	menus, err := h.menuService.GetWeeklyMenus(c.UserContext(), userID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	
-	// Преобразуем Menu в формат с meals как JSON
-	type WeeklyMenuResponse struct {
-		ID                 int                    `json:"id"`
-		UserID             int                    `json:"user_id"`
-		Date               string                 `json:"date"`
-		TotalCalories      int                    `json:"total_calories"`
-		TotalTime          int                    `json:"total_time"`
-		MenuType           string                 `json:"menu_type"`
-		Meals              interface{}            `json:"meals"` // JSON данные недели
-		IngredientsUsed    models.Ingredients     `json:"ingredients_used,omitempty"`
-		MissingIngredients models.Ingredients     `json:"missing_ingredients,omitempty"`
-		CreatedAt          string                 `json:"created_at"`
-		UpdatedAt          string                 `json:"updated_at"`
-	}
-	
-	// Получаем сырые данные meals из базы
-	responses := make([]WeeklyMenuResponse, 0, len(menus))
-	for _, menu := range menus {
-		// Получаем meals напрямую из базы как JSON
-		query := `SELECT meals FROM menus WHERE id = $1`
-		var mealsJSON []byte
-		err := database.DB.QueryRow(query, menu.ID).Scan(&mealsJSON)
-		if err != nil {
-			continue
-		}
-		
-		var mealsData interface{}
-		json.Unmarshal(mealsJSON, &mealsData)
-		
-		responses = append(responses, WeeklyMenuResponse{
-			ID:                 menu.ID,
-			UserID:             menu.UserID,
-			Date:               menu.Date.Format(time.RFC3339),
-			TotalCalories:      menu.TotalCalories,
-			TotalTime:          menu.TotalTime,
-			MenuType:           menu.MenuType,
-			Meals:              mealsData,
-			IngredientsUsed:    menu.IngredientsUsed,
-			MissingIngredients: menu.MissingIngredients,
-			CreatedAt:          menu.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:          menu.UpdatedAt.Format(time.RFC3339),
-		})
-	}
-	
-	return c.JSON(responses)
+	return c.JSON(menus)
 }
 
 // Delete удаляет меню по ID
@@ -243,7 +237,7 @@ func (h *MenuHandler) Delete(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID меню"})
 	}
 	
-	err = h.menuService.DeleteMenu(menuID, userID)
+	err = h.menuService.DeleteMenu(c.UserContext(), menuID, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return c.Status(404).JSON(fiber.Map{"error": "Меню не найдено"})
@@ -254,3 +248,36 @@ func (h *MenuHandler) Delete(c *fiber.Ctx) error {
 	return c.Status(200).JSON(fiber.Map{"message": "Меню успешно удалено"})
 }
 
+// GetCalendar экспортирует меню в формате iCalendar
+// GET /menus/:id/calendar.ics?breakfast=08:00&lunch=13:00&dinner=19:00
+func (h *MenuHandler) GetCalendar(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	menuID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID меню"})
+	}
+	
+	menu, err := h.menuService.GetByID(c.UserContext(), menuID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if menu == nil || menu.UserID != userID {
+		return c.Status(404).JSON(fiber.Map{"error": "Меню не найдено"})
+	}
+	
+	mealTimes := map[string]string{
+		"breakfast": c.Query("breakfast"),
+		"lunch":     c.Query("lunch"),
+		"dinner":    c.Query("dinner"),
+		"snack":     c.Query("snack"),
+	}
+	
+	calendar, err := h.menuService.ExportCalendar(c.UserContext(), menu, mealTimes)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="menu-%d.ics"`, menuID))
+	return c.SendString(calendar)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/myplate/backend/internal/services"
+)
+
+type MenuJobHandler struct {
+	menuJobService *services.MenuJobService
+}
+
+func NewMenuJobHandler(menuJobService *services.MenuJobService) *MenuJobHandler {
+	return &MenuJobHandler{
+		menuJobService: menuJobService,
+	}
+}
+
+// Create запускает фоновую генерацию недельного меню. Параметры - как у GET /menu/weekly
+// POST /menus/jobs?adults=2&children=1&diet_type=vegetarian
+func (h *MenuJobHandler) Create(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	req, err := parseWeeklyMenuRequest(c, userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	job, err := h.menuJobService.Submit(req)
+	if err != nil {
+		return menuJobError(c, err)
+	}
+	
+	c.Set(fiber.HeaderLocation, fmt.Sprintf("/menus/jobs/%d", job.ID))
+	return c.Status(202).JSON(job)
+}
+
+// GetByID возвращает статус и прогресс генерации (меню - после завершения)
+// GET /menus/jobs/:id
+func (h *MenuJobHandler) GetByID(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID задания"})
+	}
+	
+	job, err := h.menuJobService.Get(userID, id)
+	if err != nil {
+		return menuJobError(c, err)
+	}
+	
+	return c.JSON(job)
+}
+
+// Cancel отменяет генерацию
+// DELETE /menus/jobs/:id
+func (h *MenuJobHandler) Cancel(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID задания"})
+	}
+	
+	job, err := h.menuJobService.Cancel(userID, id)
+	if err != nil {
+		return menuJobError(c, err)
+	}
+	
+	return c.JSON(job)
+}
+
+// Events передает прогресс генерации как Server-Sent Events: событие progress при
+// каждом готовом дне и переходе к оптимизации, done - с меню после завершения,
+// ошибки или отмены
+// GET /menus/jobs/:id/events
+func (h *MenuJobHandler) Events(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID задания"})
+	}
+	
+	updates, unsubscribe, err := h.menuJobService.Subscribe(userID, id)
+	if err != nil {
+		return menuJobError(c, err)
+	}
+	// Состояние читается после подписки, чтобы не пропустить обновления
+	job, err := h.menuJobService.Get(userID, id)
+	if err != nil {
+		unsubscribe()
+		return menuJobError(c, err)
+	}
+	
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+	
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+	
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+	
+		current := *job
+		for {
+			event := "progress"
+			if current.Finished() {
+				event = "done"
+			}
+			data, _ := json.Marshal(current)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+			if err := w.Flush(); err != nil {
+				return // клиент отключился
+			}
+			if current.Finished() {
+				return
+			}
+	
+			for waiting := true; waiting; {
+				select {
+				case current = <-updates:
+					waiting = false
+				case <-ticker.C:
+					// Завершение могло не дойти до медленного подписчика
+					if reloaded, err := h.menuJobService.Get(userID, id); err == nil && reloaded.Finished() {
+						current = *reloaded
+						waiting = false
+						continue
+					}
+					// Комментарий SSE поддерживает соединение и выявляет отключение клиента
+					fmt.Fprint(w, ": ping\n\n")
+					if err := w.Flush(); err != nil {
+						return
+					}
+				}
+			}
+		}
+	})
+	
+	return nil
+}
+
+// menuJobError отвечает статусом, соответствующим ошибке сервиса
+func menuJobError(c *fiber.Ctx, err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return c.Status(404).JSON(fiber.Map{"error": "Задание не найдено"})
+	case services.ErrMenuJobFinished:
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case services.ErrTooManyMenuJobs:
+		return c.Status(429).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+}
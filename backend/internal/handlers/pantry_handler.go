@@ -20,7 +20,7 @@ func NewPantryHandler(pantryService *services.PantryService) *PantryHandler {
 func (h *PantryHandler) GetAll(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(int)
 	
-	items, err := h.pantryService.GetByUserID(userID)
+	items, err := h.pantryService.GetByUserID(c.UserContext(), userID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -36,7 +36,7 @@ func (h *PantryHandler) Create(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
 	}
 	
-	if err := h.pantryService.Create(userID, &item); err != nil {
+	if err := h.pantryService.Create(c.UserContext(), userID, &item); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	
@@ -51,7 +51,7 @@ func (h *PantryHandler) Delete(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID продукта"})
 	}
 	
-	if err := h.pantryService.Delete(userID, id); err != nil {
+	if err := h.pantryService.Delete(c.UserContext(), userID, id); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/services"
+)
+
+type RecipeFeedbackHandler struct {
+	feedbackService *services.RecipeFeedbackService
+}
+
+func NewRecipeFeedbackHandler(feedbackService *services.RecipeFeedbackService) *RecipeFeedbackHandler {
+	return &RecipeFeedbackHandler{
+		feedbackService: feedbackService,
+	}
+}
+
+// GetFeedback возвращает оценки, избранные и запрещенные рецепты пользователя
+// GET /users/recipes/feedback
+func (h *RecipeFeedbackHandler) GetFeedback(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	feedback, err := h.feedbackService.GetFeedback(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(feedback)
+}
+
+// Rate ставит оценку рецепту
+// PUT /recipes/:id/rating
+func (h *RecipeFeedbackHandler) Rate(c *fiber.Ctx) error {
+	var req models.RecipeRatingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
+	}
+	
+	return h.respond(c, func(ctx context.Context, userID, recipeID int) (*models.RecipeFeedback, error) {
+		return h.feedbackService.Rate(ctx, userID, recipeID, req.Rating)
+	})
+}
+
+// ClearRating удаляет оценку рецепта
+// DELETE /recipes/:id/rating
+func (h *RecipeFeedbackHandler) ClearRating(c *fiber.Ctx) error {
+	return h.respond(c, h.feedbackService.ClearRating)
+}
+
+// AddFavorite добавляет рецепт в избранное
+// PUT /recipes/:id/favorite
+func (h *RecipeFeedbackHandler) AddFavorite(c *fiber.Ctx) error {
+	return h.respond(c, func(ctx context.Context, userID, recipeID int) (*models.RecipeFeedback, error) {
+		return h.feedbackService.SetFavorite(ctx, userID, recipeID, true)
+	})
+}
+
+// RemoveFavorite убирает рецепт из избранного
+// DELETE /recipes/:id/favorite
+func (h *RecipeFeedbackHandler) RemoveFavorite(c *fiber.Ctx) error {
+	return h.respond(c, func(ctx context.Context, userID, recipeID int) (*models.RecipeFeedback, error) {
+		return h.feedbackService.SetFavorite(ctx, userID, recipeID, false)
+	})
+}
+
+// Ban запрещает рецепт: он больше не предлагается при генерации меню
+// PUT /recipes/:id/ban
+func (h *RecipeFeedbackHandler) Ban(c *fiber.Ctx) error {
+	return h.respond(c, func(ctx context.Context, userID, recipeID int) (*models.RecipeFeedback, error) {
+		return h.feedbackService.SetBanned(ctx, userID, recipeID, true)
+	})
+}
+
+// Unban снимает запрет с рецепта
+// DELETE /recipes/:id/ban
+func (h *RecipeFeedbackHandler) Unban(c *fiber.Ctx) error {
+	return h.respond(c, func(ctx context.Context, userID, recipeID int) (*models.RecipeFeedback, error) {
+		return h.feedbackService.SetBanned(ctx, userID, recipeID, false)
+	})
+}
+
+// respond разбирает ID рецепта, выполняет изменение и возвращает отметки рецепта
+func (h *RecipeFeedbackHandler) respond(c *fiber.Ctx, update func(ctx context.Context, userID, recipeID int) (*models.RecipeFeedback, error)) error {
+	userID := c.Locals("user_id").(int)
+	
+	recipeID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID рецепта"})
+	}
+	
+	feedback, err := update(c.UserContext(), userID, recipeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "Рецепт не найден"})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(feedback)
+}
@@ -1,8 +1,15 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
 	"strconv"
+	"strings"
+	"time"
 	"github.com/gofiber/fiber/v2"
+	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/internal/services"
 )
 
@@ -16,21 +23,59 @@ func NewRecipeHandler(recipeService *services.RecipeService) *RecipeHandler {
 	}
 }
 
+// GetAll возвращает рецепты общего каталога по фильтрам
+// GET /recipes?diet_type=vegan,gluten_free&allergies=nuts&meal_type=dinner&max_calories=600&max_time=30
+// &cuisine=italian&season=summer&difficulty=easy,medium
 func (h *RecipeHandler) GetAll(c *fiber.Ctx) error {
-	recipes, err := h.recipeService.GetAll()
+	filter, err := parseRecipeFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	recipes, err := h.recipeService.GetAll(c.UserContext(), filter)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(recipes)
 }
 
+// GetByID возвращает рецепт общего каталога, при заданных порциях - пересчитанный
+// GET /recipes/:id?servings=6 или ?adults=2&children=1
 func (h *RecipeHandler) GetByID(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID рецепта"})
 	}
 	
-	recipe, err := h.recipeService.GetByID(id)
+	// Пересчет на другое количество порций: ?servings=6 или ?adults=2&children=1
+	if c.Query("servings") != "" || c.Query("adults") != "" || c.Query("children") != "" {
+		servings, err := strconv.ParseFloat(c.Query("servings", "0"), 64)
+		if err != nil || math.IsNaN(servings) || math.IsInf(servings, 0) || (c.Query("servings") != "" && servings <= 0) {
+			return c.Status(400).JSON(fiber.Map{"error": "Неверное количество порций"})
+		}
+		adults, err := strconv.Atoi(c.Query("adults", "0"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Неверное количество взрослых"})
+		}
+		children, err := strconv.Atoi(c.Query("children", "0"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Неверное количество детей"})
+		}
+	
+		scaled, err := h.recipeService.GetScaled(c.UserContext(), id, servings, adults, children)
+		if errors.Is(err, services.ErrInvalidServings) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if scaled == nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Рецепт не найден"})
+		}
+		return c.JSON(scaled)
+	}
+	
+	recipe, err := h.recipeService.GetByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -42,4 +87,119 @@ func (h *RecipeHandler) GetByID(c *fiber.Ctx) error {
 	return c.JSON(recipe)
 }
 
+// GetSimilar возвращает похожие рецепты: по ингредиентам, профилю БЖУ и приему пищи
+// GET /recipes/:id/similar?limit=10
+func (h *RecipeHandler) GetSimilar(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID рецепта"})
+	}
+	
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 || limit > 50 {
+		return c.Status(400).JSON(fiber.Map{"error": "limit должен быть от 1 до 50"})
+	}
+	
+	recipes, err := h.recipeService.GetSimilar(c.UserContext(), id, limit)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Рецепт не найден"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(recipes)
+}
 
+// GetDietaryTags возвращает справочник диет и аллергенов
+// GET /dietary-tags
+func (h *RecipeHandler) GetDietaryTags(c *fiber.Ctx) error {
+	tags, err := h.recipeService.GetDietaryTags(c.UserContext())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(tags)
+}
+
+// GetSubstitutions возвращает замены ингредиента
+// GET /substitutions?ingredient=пахта&quantity=200&unit=мл&allergies=dairy
+func (h *RecipeHandler) GetSubstitutions(c *fiber.Ctx) error {
+	ingredient := models.Ingredient{
+		Name:     c.Query("ingredient"),
+		Quantity: 1,
+		Unit:     c.Query("unit"),
+	}
+	if strings.TrimSpace(ingredient.Name) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Параметр 'ingredient' обязателен"})
+	}
+	if quantityStr := c.Query("quantity"); quantityStr != "" {
+		quantity, err := strconv.ParseFloat(quantityStr, 64)
+		if err != nil || quantity <= 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Параметр 'quantity' должен быть положительным числом"})
+		}
+		ingredient.Quantity = quantity
+	}
+	
+	var allergies []string
+	if allergiesStr := c.Query("allergies"); allergiesStr != "" {
+		for _, allergy := range strings.Split(allergiesStr, ",") {
+			allergies = append(allergies, strings.TrimSpace(allergy))
+		}
+	}
+	
+	options, err := h.recipeService.GetSubstitutions(c.UserContext(), ingredient, allergies)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(options)
+}
+
+// parseRecipeFilter читает фильтры рецептов из query: diet_type, allergies, meal_type,
+// cuisine, season и difficulty через запятую, max_calories, max_time и updated_since
+// (RFC3339 или YYYY-MM-DD)
+func parseRecipeFilter(c *fiber.Ctx) (*models.RecipeFilter, error) {
+	filter := &models.RecipeFilter{
+		DietTypes:    queryList(c, "diet_type"),
+		Allergies:    queryList(c, "allergies"),
+		MealTypes:    queryList(c, "meal_type"),
+		Cuisines:     queryList(c, "cuisine"),
+		Seasons:      queryList(c, "season"),
+		Difficulties: queryList(c, "difficulty"),
+	}
+	
+	for name, target := range map[string]**int{"max_calories": &filter.MaxCalories, "max_time": &filter.MaxTime} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil || number <= 0 {
+			return nil, fmt.Errorf("Параметр '%s' должен быть положительным целым числом", name)
+		}
+		*target = &number
+	}
+	
+	if value := c.Query("updated_since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			since, err = time.Parse(models.DateLayout, value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Неверный формат updated_since, ожидается RFC3339 или YYYY-MM-DD")
+		}
+		filter.UpdatedSince = &since
+	}
+	
+	return filter, nil
+}
+
+// queryList разбирает список значений через запятую
+func queryList(c *fiber.Ctx, name string) []string {
+	var values []string
+	for _, value := range strings.Split(c.Query(name), ",") {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	
+	"github.com/gofiber/fiber/v2"
+	"github.com/myplate/backend/internal/services"
+	"github.com/myplate/backend/pkg/storage"
+)
+
+type RecipeImageHandler struct {
+	recipeImageService *services.RecipeImageService
+}
+
+func NewRecipeImageHandler(recipeImageService *services.RecipeImageService) *RecipeImageHandler {
+	return &RecipeImageHandler{
+		recipeImageService: recipeImageService,
+	}
+}
+
+// Upload загружает изображение рецепта (JPEG, PNG или WebP до 10 МБ) - полем image
+// формы или телом запроса
+// POST /admin/recipes/:id/image
+func (h *RecipeImageHandler) Upload(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID рецепта"})
+	}
+	
+	data := c.Body()
+	if strings.HasPrefix(strings.ToLower(c.Get(fiber.HeaderContentType)), fiber.MIMEMultipartForm) {
+		header, err := c.FormFile("image")
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Файл image не передан"})
+		}
+		if header.Size > services.MaxRecipeImageSize {
+			return c.Status(413).JSON(fiber.Map{"error": services.ErrImageTooLarge.Error()})
+		}
+		file, err := header.Open()
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Не удалось прочитать файл"})
+		}
+		defer file.Close()
+		if data, err = io.ReadAll(file); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Не удалось прочитать файл"})
+		}
+	}
+	
+	image, err := h.recipeImageService.Upload(c.UserContext(), id, data)
+	switch {
+	case err == sql.ErrNoRows:
+		return c.Status(404).JSON(fiber.Map{"error": "Рецепт не найден"})
+	case errors.Is(err, services.ErrImageTooLarge):
+		return c.Status(413).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrUnsupportedImage):
+		return c.Status(415).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidImage):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.Status(201).JSON(image)
+}
+
+// Get отдает файл изображения из хранилища. Имена файлов содержат хеш содержимого,
+// поэтому ответ кешируется бессрочно
+// GET /images/*
+func (h *RecipeImageHandler) Get(c *fiber.Ctx) error {
+	object, err := h.recipeImageService.Get(c.UserContext(), c.Params("*"))
+	switch {
+	case err == storage.ErrNotFound:
+		return c.Status(404).JSON(fiber.Map{"error": "Изображение не найдено"})
+	case errors.Is(err, storage.ErrInvalidKey):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	etag := `"` + c.Params("*") + `"`
+	c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	c.Set(fiber.HeaderETag, etag)
+	if !object.ModTime.IsZero() {
+		c.Set(fiber.HeaderLastModified, object.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(304)
+	}
+	
+	if object.ContentType != "" {
+		c.Set(fiber.HeaderContentType, object.ContentType)
+	}
+	return c.Send(object.Data)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/services"
+)
+
+type ReportHandler struct {
+	reportService *services.ReportService
+}
+
+func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// GetNutrition возвращает отчет о питании за период (JSON или CSV)
+// GET /reports/nutrition?from=2024-01-01&to=2024-01-31&format=csv
+func (h *ReportHandler) GetNutrition(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	to, err := parseDateQuery(c, "to")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный формат даты 'to'"})
+	}
+	
+	// По умолчанию - последние 7 дней
+	from := to.AddDate(0, 0, -6)
+	if value := c.Query("from"); value != "" {
+		from, err = time.Parse(models.DateLayout, value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Неверный формат даты 'from'"})
+		}
+	}
+	
+	report, err := h.reportService.NutritionReport(c.UserContext(), userID, from, to)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	if c.Query("format") == "csv" || strings.Contains(c.Get("Accept"), "text/csv") {
+		var buf bytes.Buffer
+		if err := services.WriteNutritionReportCSV(&buf, report); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Ошибка при формировании CSV"})
+		}
+		
+		c.Set("Content-Type", "text/csv; charset=utf-8")
+		c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"nutrition-%s-%s.csv\"", report.From, report.To))
+		return c.Send(buf.Bytes())
+	}
+	
+	return c.JSON(report)
+}
@@ -22,7 +22,7 @@ func (h *ShoppingListHandler) GetByMenuID(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID меню"})
 	}
 	
-	list, err := h.shoppingService.GetByMenuID(menuID)
+	list, err := h.shoppingService.GetByMenuID(c.UserContext(), menuID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
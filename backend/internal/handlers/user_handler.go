@@ -24,7 +24,7 @@ func (h *UserHandler) SetGoals(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
 	}
 	
-	if err := h.userService.SetGoals(userID, &goals); err != nil {
+	if err := h.userService.SetGoals(c.UserContext(), userID, &goals); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	
@@ -34,7 +34,7 @@ func (h *UserHandler) SetGoals(c *fiber.Ctx) error {
 func (h *UserHandler) GetGoals(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(int)
 	
-	goals, err := h.userService.GetGoals(userID)
+	goals, err := h.userService.GetGoals(c.UserContext(), userID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
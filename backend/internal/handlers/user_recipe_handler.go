@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/services"
+)
+
+type UserRecipeHandler struct {
+	userRecipeService *services.UserRecipeService
+}
+
+func NewUserRecipeHandler(userRecipeService *services.UserRecipeService) *UserRecipeHandler {
+	return &UserRecipeHandler{
+		userRecipeService: userRecipeService,
+	}
+}
+
+// Create создает личный рецепт пользователя
+// POST /users/recipes
+func (h *UserRecipeHandler) Create(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	var req models.RecipeImportDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
+	}
+	
+	recipe, err := h.userRecipeService.CreateRecipe(c.UserContext(), userID, &req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.Status(201).JSON(recipe)
+}
+
+// GetAll возвращает личные рецепты пользователя
+// GET /users/recipes
+func (h *UserRecipeHandler) GetAll(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	recipes, err := h.userRecipeService.GetRecipes(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(recipes)
+}
+
+// Delete удаляет личный рецепт
+// DELETE /users/recipes/:id
+func (h *UserRecipeHandler) Delete(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID рецепта"})
+	}
+	
+	if err := h.userRecipeService.DeleteRecipe(c.UserContext(), userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "Рецепт не найден"})
+		}
+		if errors.Is(err, services.ErrRecipeInUse) {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(fiber.Map{"message": "Рецепт успешно удален"})
+}
+
+// Submit предлагает рецепт в общий каталог
+// POST /users/submissions
+func (h *UserRecipeHandler) Submit(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	var req models.RecipeImportDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
+	}
+	
+	submission, err := h.userRecipeService.Submit(c.UserContext(), userID, &req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.Status(201).JSON(submission)
+}
+
+// GetUserSubmissions возвращает заявки пользователя и их статус
+// GET /users/submissions
+func (h *UserRecipeHandler) GetUserSubmissions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	
+	submissions, err := h.userRecipeService.GetUserSubmissions(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(submissions)
+}
+
+// GetSubmissions возвращает очередь модерации
+// GET /admin/submissions?status=pending
+func (h *UserRecipeHandler) GetSubmissions(c *fiber.Ctx) error {
+	submissions, err := h.userRecipeService.GetSubmissions(c.UserContext(), c.Query("status"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(submissions)
+}
+
+// GetSubmission возвращает заявку
+// GET /admin/submissions/:id
+func (h *UserRecipeHandler) GetSubmission(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID заявки"})
+	}
+	
+	submission, err := h.userRecipeService.GetSubmission(c.UserContext(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "Заявка не найдена"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(submission)
+}
+
+// ApproveSubmission одобряет заявку и добавляет рецепт в каталог
+// POST /admin/submissions/:id/approve
+func (h *UserRecipeHandler) ApproveSubmission(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID заявки"})
+	}
+	
+	submission, err := h.userRecipeService.ApproveSubmission(c.UserContext(), id, adminID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "Заявка не найдена"})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(submission)
+}
+
+// RejectSubmission отклоняет заявку
+// POST /admin/submissions/:id/reject
+func (h *UserRecipeHandler) RejectSubmission(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(int)
+	
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID заявки"})
+	}
+	
+	var req models.SubmissionReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверное тело запроса"})
+	}
+	
+	submission, err := h.userRecipeService.RejectSubmission(c.UserContext(), id, adminID, req.Reason)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "Заявка не найдена"})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	
+	return c.JSON(submission)
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/myplate/backend/internal/services"
 )
@@ -17,10 +19,17 @@ func AuthMiddleware(authService *services.AuthService) fiber.Handler {
 			token = token[7:]
 		}
 		
-		userID, role, err := authService.ValidateJWT(token)
-		if err != nil {
+		userID, role, err := authService.ValidateJWT(c.UserContext(), token)
+		if errors.Is(err, services.ErrTokenRevoked) {
+			return c.Status(401).JSON(fiber.Map{"error": "Токен отозван"})
+		}
+		if errors.Is(err, services.ErrInvalidToken) {
 			return c.Status(401).JSON(fiber.Map{"error": "Неверный токен"})
 		}
+		if err != nil {
+			// Токен не удалось проверить (недоступна база) - это не повод для повторного входа
+			return c.Status(503).JSON(fiber.Map{"error": "Не удалось проверить токен, повторите запрос"})
+		}
 		
 		c.Locals("user_id", userID)
 		c.Locals("user_role", role)
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit отклоняет с 413 запросы, тело которых больше limit байт.
+//
+// Рассчитан на приложение с StreamRequestBody: сервер не отклоняет большие тела
+// сам, а передает их потоком, поэтому размер проверяется здесь до чтения тела -
+// по Content-Length, а для chunked-запросов без него тело дочитывается не
+// больше чем до limit.
+func BodyLimit(limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := c.Request()
+		if req.Header.ContentLength() > limit {
+			return bodyTooLarge(c, limit)
+		}
+		if req.Header.ContentLength() < 0 {
+			if stream := req.BodyStream(); stream != nil {
+				body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+				if err != nil {
+					return c.Status(400).JSON(fiber.Map{"error": "Не удалось прочитать тело запроса"})
+				}
+				if len(body) > limit {
+					return bodyTooLarge(c, limit)
+				}
+				req.SetBody(body)
+			}
+		}
+		return c.Next()
+	}
+}
+
+func bodyTooLarge(c *fiber.Ctx, limit int) error {
+	c.Set(fiber.HeaderConnection, "close")
+	return c.Status(413).JSON(fiber.Map{"error": fmt.Sprintf("Тело запроса больше %d МБ", limit>>20)})
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deadline ограничивает время обработки запроса: контекст c.UserContext() отменяется
+// через d, и незавершенные запросы к базе прерываются. Ошибка сервера после
+// истечения срока возвращается клиенту как 504.
+//
+// Отключение клиента контекст не отменяет: fasthttp не сообщает о закрытии
+// соединения во время работы обработчика, поэтому запросы к базе прерывает только
+// дедлайн. Потоковые ответы прекращают передачу сами по ошибке записи.
+//
+// Не подходит для маршрутов, которые передают ответ потоком после возврата из
+// обработчика (SSE, файлы): к началу передачи контекст уже отменен.
+func Deadline(d time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), d)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if ctx.Err() == context.DeadlineExceeded && (err != nil || c.Response().StatusCode() >= 500) {
+			return c.Status(504).JSON(fiber.Map{"error": "Превышено время обработки запроса"})
+		}
+		return err
+	}
+}
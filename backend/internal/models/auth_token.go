@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// TokenPair - короткоживущий access-токен и refresh-токен для получения новой пары
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// RefreshToken - сохраненный refresh-токен; сам токен не хранится, только его хеш.
+// Токены, полученные ротацией из одного входа, имеют общий FamilyID
+type RefreshToken struct {
+	ID              int        `json:"id"`
+	UserID          int        `json:"user_id"`
+	FamilyID        string     `json:"family_id"`
+	TokenHash       string     `json:"-"`
+	AccessJTI       string     `json:"access_jti"`
+	AccessExpiresAt time.Time  `json:"access_expires_at"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	UsedAt          *time.Time `json:"used_at,omitempty"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
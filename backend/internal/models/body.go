@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// BodyMeasurement - измерение тела пользователя за день
+type BodyMeasurement struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Date      time.Time `json:"date"`
+	Weight    float64   `json:"weight"`          // кг
+	Waist     *float64  `json:"waist,omitempty"` // см
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BodyMeasurementRequest - запрос на добавление измерения
+// (повторное измерение за ту же дату заменяет предыдущее)
+type BodyMeasurementRequest struct {
+	Date   string   `json:"date"` // YYYY-MM-DD, по умолчанию - сегодня
+	Weight float64  `json:"weight"`
+	Waist  *float64 `json:"waist,omitempty"`
+	Note   string   `json:"note,omitempty"`
+}
+
+// GoalAdjustment - предложенная корректировка калорийности с обоснованием
+type GoalAdjustment struct {
+	ID                 int        `json:"id"`
+	UserID             int        `json:"user_id"`
+	PeriodStart        time.Time  `json:"period_start"`
+	PeriodEnd          time.Time  `json:"period_end"`
+	MeasurementsCount  int        `json:"measurements_count"`
+	TargetWeeklyChange float64    `json:"target_weekly_change"` // кг в неделю
+	ActualWeeklyChange float64    `json:"actual_weekly_change"` // кг в неделю по тренду
+	PreviousCalories   int        `json:"previous_calories"`
+	ProposedCalories   int        `json:"proposed_calories"`
+	Reason             string     `json:"reason"`
+	Status             string     `json:"status"` // proposed, applied, rejected
+	CreatedAt          time.Time  `json:"created_at"`
+	ResolvedAt         *time.Time `json:"resolved_at,omitempty"`
+}
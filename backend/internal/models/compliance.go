@@ -0,0 +1,59 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// IngredientCategory - ключевое слово названия ингредиента и категория продукта
+type IngredientCategory struct {
+	Keyword    string   `json:"keyword"`
+	Category   string   `json:"category"`
+	Exceptions []string `json:"exceptions,omitempty"` // начала слов, при которых ключевое слово не срабатывает
+}
+
+// ComplianceIssue - противоречие между тегами рецепта и его ингредиентами
+type ComplianceIssue struct {
+	Tag        string `json:"tag"`  // код диеты или аллергена
+	Kind       string `json:"kind"` // diet - ингредиент запрещен диетой, allergen - аллерген не указан
+	Ingredient string `json:"ingredient"`
+	Category   string `json:"category"`
+	Message    string `json:"message"`
+}
+
+type ComplianceIssues []ComplianceIssue
+
+func (c *ComplianceIssues) Scan(value interface{}) error {
+	if value == nil {
+		*c = ComplianceIssues{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return json.Unmarshal([]byte(value.(string)), c)
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+func (c ComplianceIssues) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(c)
+}
+
+// RecipeCompliance - результат проверки одного рецепта
+type RecipeCompliance struct {
+	RecipeID int              `json:"recipe_id"`
+	Name     string           `json:"name"`
+	Issues   ComplianceIssues `json:"issues"`
+}
+
+// ComplianceAudit - результат проверки каталога рецептов
+type ComplianceAudit struct {
+	Checked   int                `json:"checked"`
+	Flagged   int                `json:"flagged"`
+	Recipes   []RecipeCompliance `json:"recipes"` // только рецепты с противоречиями
+	CheckedAt time.Time          `json:"checked_at"`
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// CookingSession - пошаговое приготовление одного или нескольких блюд из меню.
+// Шаги блюд чередуются: пока одно блюдо в духовке, готовится другое.
+type CookingSession struct {
+	ID          int            `json:"id"`
+	UserID      int            `json:"user_id"`
+	Status      string         `json:"status"`   // active, finished, cancelled
+	Servings    float64        `json:"servings"` // порции для списания из кладовой (взрослые + дети * 0.7)
+	Meals       CookingMeals   `json:"meals"`
+	Steps       CookingSteps   `json:"steps"`
+	CurrentStep int            `json:"current_step"` // индекс в steps; len(steps) - все шаги выполнены
+	PantryUsed  Ingredients    `json:"pantry_used"`  // списано из кладовой при завершении
+	Timers      []CookingTimer `json:"timers"`
+	StartedAt   time.Time      `json:"started_at"`
+	FinishedAt  *time.Time     `json:"finished_at,omitempty"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// Active - сессия еще не завершена и не отменена
+func (s *CookingSession) Active() bool {
+	return s.Status == "active"
+}
+
+// CookingMeal - прием пищи из меню, который готовится в сессии
+type CookingMeal struct {
+	MenuMealID int    `json:"menu_meal_id"`
+	RecipeID   int    `json:"recipe_id"`
+	RecipeName string `json:"recipe_name"`
+	MealType   string `json:"meal_type"`
+	Date       string `json:"date"`
+}
+
+type CookingMeals []CookingMeal
+
+func (m *CookingMeals) Scan(value interface{}) error {
+	if value == nil {
+		*m = CookingMeals{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return json.Unmarshal([]byte(value.(string)), m)
+	}
+	return json.Unmarshal(bytes, m)
+}
+
+func (m CookingMeals) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(m)
+}
+
+// CookingStep - шаг рецепта в общем плане сессии
+type CookingStep struct {
+	Meal         int        `json:"meal"` // индекс приема пищи в meals
+	RecipeID     int        `json:"recipe_id"`
+	RecipeName   string     `json:"recipe_name"`
+	MealType     string     `json:"meal_type"`
+	Number       int        `json:"number"` // номер шага в рецепте, с 1
+	Text         string     `json:"text"`
+	Duration     int        `json:"duration,omitempty"` // мин.; для шагов с длительностью запускается таймер
+	Passive      bool       `json:"passive,omitempty"`
+	Ingredients  []string   `json:"ingredients,omitempty"`
+	Equipment    []string   `json:"equipment,omitempty"`
+	PlannedStart int        `json:"planned_start"` // мин. от начала сессии по плану
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+type CookingSteps []CookingStep
+
+func (s *CookingSteps) Scan(value interface{}) error {
+	if value == nil {
+		*s = CookingSteps{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return json.Unmarshal([]byte(value.(string)), s)
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+func (s CookingSteps) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(s)
+}
+
+// CookingTimer - таймер начатого шага с длительностью
+type CookingTimer struct {
+	Step             int       `json:"step"` // индекс шага в steps
+	RecipeName       string    `json:"recipe_name"`
+	Text             string    `json:"text"`
+	StartedAt        time.Time `json:"started_at"`
+	EndsAt           time.Time `json:"ends_at"`
+	RemainingSeconds int       `json:"remaining_seconds"`
+	Done             bool      `json:"done"`
+}
+
+// CurrentCookingStep - текущий шаг сессии
+type CurrentCookingStep struct {
+	Index int          `json:"index"`
+	Total int          `json:"total"`
+	Step  *CookingStep `json:"step"` // nil - все шаги выполнены
+	// ReadyAt - шаг можно начинать только после таймера предыдущего шага того же рецепта
+	ReadyAt *time.Time     `json:"ready_at,omitempty"`
+	Timers  []CookingTimer `json:"timers"`
+}
+
+// CookingSessionRequest - запуск сессии: приемы пищи по ID или по дате и типам
+type CookingSessionRequest struct {
+	MenuMealIDs []int    `json:"menu_meal_ids,omitempty"`
+	Date        string   `json:"date,omitempty"`       // YYYY-MM-DD, по умолчанию сегодня
+	MealTypes   []string `json:"meal_types,omitempty"` // breakfast, lunch, dinner, snack
+	Adults      int      `json:"adults,omitempty"`     // по умолчанию 1
+	Children    int      `json:"children,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// DietaryTag - диета или аллерген из справочника
+type DietaryTag struct {
+	Code    string   `json:"code"`
+	Kind    string   `json:"kind"` // diet или allergen
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	// Для диеты - запрещенные категории продуктов, для аллергена - категории, в которых он содержится
+	Categories []string `json:"categories,omitempty"`
+}
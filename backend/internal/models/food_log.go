@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// FoodLogEntry - запись дневника питания (что фактически съедено)
+type FoodLogEntry struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Date       time.Time `json:"date"`
+	MealType   string    `json:"meal_type"`
+	RecipeID   *int      `json:"recipe_id,omitempty"`
+	MenuMealID *int      `json:"menu_meal_id,omitempty"`
+	Portion    float64   `json:"portion"`
+	Name       string    `json:"name"`
+	Calories   int       `json:"calories"`
+	Proteins   float64   `json:"proteins"`
+	Fats       float64   `json:"fats"`
+	Carbs      float64   `json:"carbs"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// FoodLogRequest - запрос на добавление записи в дневник.
+// Запись может ссылаться на рецепт (recipe_id + portion), на запланированный
+// прием пищи (planned: true) или быть произвольной (name + КБЖУ).
+type FoodLogRequest struct {
+	Date     string  `json:"date"` // YYYY-MM-DD, по умолчанию - сегодня
+	MealType string  `json:"meal_type"`
+	RecipeID int     `json:"recipe_id,omitempty"`
+	Planned  bool    `json:"planned,omitempty"`
+	Portion  float64 `json:"portion,omitempty"` // количество порций (по умолчанию 1)
+	Name     string  `json:"name,omitempty"`
+	Calories int     `json:"calories,omitempty"`
+	Proteins float64 `json:"proteins,omitempty"`
+	Fats     float64 `json:"fats,omitempty"`
+	Carbs    float64 `json:"carbs,omitempty"`
+}
+
+// NutritionTotals - суммарные калории и БЖУ
+type NutritionTotals struct {
+	Calories int     `json:"calories"`
+	Proteins float64 `json:"proteins"`
+	Fats     float64 `json:"fats"`
+	Carbs    float64 `json:"carbs"`
+}
+
+// GoalComparison - сравнение фактического питания с целями пользователя
+type GoalComparison struct {
+	Goal            NutritionTotals `json:"goal"`
+	Difference      NutritionTotals `json:"difference"` // факт - цель
+	CaloriesPercent float64         `json:"calories_percent"`
+}
+
+// FoodLogDay - дневник за день
+type FoodLogDay struct {
+	Date    string          `json:"date"`
+	Entries []FoodLogEntry  `json:"entries"`
+	Totals  NutritionTotals `json:"totals"`
+	Goals   *GoalComparison `json:"goals,omitempty"`
+}
+
+// FoodLogWeek - дневник за неделю
+type FoodLogWeek struct {
+	StartDate string          `json:"start_date"`
+	EndDate   string          `json:"end_date"`
+	Days      []FoodLogDay    `json:"days"`
+	Totals    NutritionTotals `json:"totals"`
+	Average   NutritionTotals `json:"average"` // среднее за день
+	Goals     *GoalComparison `json:"goals,omitempty"`
+}
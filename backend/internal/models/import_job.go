@@ -0,0 +1,58 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// ImportJob - фоновое задание импорта рецептов
+type ImportJob struct {
+	ID         int             `json:"id"`
+	Format     string          `json:"format"` // json, csv, xlsx, schema-org
+	Mode       string          `json:"mode"`   // fail, skip, update
+	DryRun     bool            `json:"dry_run"`
+	Status     string          `json:"status"` // pending, running, completed, failed
+	Total      int             `json:"total"`
+	Processed  int             `json:"processed"`
+	Result     json.RawMessage `json:"result,omitempty"` // ImportResult после завершения
+	Error      string          `json:"error,omitempty"`
+	Attempts   int             `json:"attempts"`
+	CreatedBy  *int            `json:"created_by,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Files      ImportJobFiles  `json:"-"`
+}
+
+// Finished - задание завершено (успешно или с ошибкой)
+func (j *ImportJob) Finished() bool {
+	return j.Status == "completed" || j.Status == "failed"
+}
+
+// ImportJobFile - загруженный файл задания: recipes, ingredients или имя файла schema.org
+type ImportJobFile struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+type ImportJobFiles []ImportJobFile
+
+func (f *ImportJobFiles) Scan(value interface{}) error {
+	if value == nil {
+		*f = ImportJobFiles{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return json.Unmarshal([]byte(value.(string)), f)
+	}
+	return json.Unmarshal(bytes, f)
+}
+
+func (f ImportJobFiles) Value() (driver.Value, error) {
+	if len(f) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(f)
+}
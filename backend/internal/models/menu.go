@@ -12,8 +12,10 @@ type Menu struct {
 	Date               time.Time `json:"date"`
 	TotalCalories      int       `json:"total_calories"`
 	TotalTime          int       `json:"total_time"`
+	TotalMicronutrients Micronutrients `json:"total_micronutrients,omitempty"`
 	MenuType           string    `json:"menu_type"` // "daily" or "weekly"
 	Meals              MenuMeals `json:"meals"`
+	Week               []WeeklyDayMenu `json:"week,omitempty"` // Дни недельного меню
 	IngredientsUsed    Ingredients `json:"ingredients_used,omitempty"`
 	MissingIngredients Ingredients `json:"missing_ingredients,omitempty"`
 	CreatedAt          time.Time `json:"created_at"`
@@ -52,7 +54,9 @@ type MenuGenerateRequest struct {
 	UserID            int     `json:"user_id"`
 	TargetCalories    int     `json:"target_calories"`
 	DietType          string  `json:"diet_type,omitempty"`
+	DietTypes         []string `json:"diet_types,omitempty"` // Несколько диет одновременно (И)
 	Allergies         []string `json:"allergies,omitempty"`
+	AllowSubstitutions bool   `json:"allow_substitutions,omitempty"` // предлагать рецепты с аллергенами, если их можно заменить
 	MaxTotalTime      int     `json:"max_total_time,omitempty"`
 	MaxTimePerMeal    int     `json:"max_time_per_meal,omitempty"`
 	SpeedLevel        string  `json:"speed_level,omitempty"` // fast, normal, slow
@@ -60,26 +64,41 @@ type MenuGenerateRequest struct {
 	PantryImportance  string  `json:"pantry_importance"` // strict, prefer, ignore
 	Adults            int     `json:"adults,omitempty"` // Количество взрослых (по умолчанию 1)
 	Children          int     `json:"children,omitempty"` // Количество детей (по умолчанию 0)
+	NutrientLimits    Micronutrients `json:"nutrient_limits,omitempty"` // Верхние лимиты на человека в день (по умолчанию - из целей)
 }
 
+// DateLayout - формат дат меню (YYYY-MM-DD)
+const DateLayout = "2006-01-02"
+
 type WeeklyMenuRequest struct {
 	UserID            int     `json:"user_id"`
+	StartDate         time.Time `json:"start_date,omitempty"` // Дата первого дня недели
 	Adults            int     `json:"adults"` // Количество взрослых
 	Children          int     `json:"children"` // Количество детей
-	DietType          string  `json:"diet_type,omitempty"`
+	DietType          string  `json:"diet_type,omitempty"` // Одна или несколько диет через запятую
 	Allergies         []string `json:"allergies,omitempty"`
-	MaxTotalTime      int     `json:"max_total_time,omitempty"`
+	AllowSubstitutions bool   `json:"allow_substitutions,omitempty"` // предлагать рецепты с аллергенами, если их можно заменить
+	MaxTotalTime      int     `json:"max_total_time,omitempty"` // на день, с учетом параллельной готовки
 	MaxTimePerMeal    int     `json:"max_time_per_meal,omitempty"`
+	WeekdayUnavailableEquipment []string `json:"weekday_unavailable_equipment,omitempty"` // оборудование, недоступное пн-пт (oven)
+	WeekdayMaxHard    *int    `json:"weekday_max_hard,omitempty"` // не больше N сложных рецептов в день пн-пт
+	PreferSeasonal    bool    `json:"prefer_seasonal,omitempty"` // предпочитать рецепты сезона (по месяцу дня меню)
+	BalanceCuisines   bool    `json:"balance_cuisines,omitempty"` // чередовать кухни в течение недели
 	ConsiderPantry    bool    `json:"consider_pantry"`
 	PantryImportance  string  `json:"pantry_importance"` // strict, prefer, ignore
+	NutrientLimits    Micronutrients `json:"nutrient_limits,omitempty"` // Верхние лимиты на человека в день (по умолчанию - из целей)
 }
 
 type WeeklyMenu struct {
-	Week []WeeklyDayMenu `json:"week"`
+	StartDate string          `json:"start_date,omitempty"` // YYYY-MM-DD, дата первого дня
+	Week      []WeeklyDayMenu `json:"week"`
+	TotalMicronutrients Micronutrients `json:"total_micronutrients,omitempty"` // итого за неделю
+	Score     float64         `json:"score,omitempty"` // 0-1: калорийность дней и баланс БЖУ
 }
 
 type WeeklyDayMenu struct {
 	Day            int                `json:"day"` // 1-7
+	Date           string             `json:"date,omitempty"` // YYYY-MM-DD
 	Breakfast      *RecipeDTO         `json:"breakfast"`
 	Lunch          *RecipeDTO         `json:"lunch"`
 	Dinner         *RecipeDTO         `json:"dinner"`
@@ -87,11 +106,37 @@ type WeeklyDayMenu struct {
 	TotalProteins  float64            `json:"totalProteins"`
 	TotalFats      float64            `json:"totalFats"`
 	TotalCarbs     float64            `json:"totalCarbs"`
+	TotalMicronutrients Micronutrients `json:"totalMicronutrients,omitempty"`
 	TotalTime      int                `json:"totalTime,omitempty"`
+	LimitViolations []string          `json:"limit_violations,omitempty"` // нарушенные лимиты и ограничения (время, оборудование), если их не удалось соблюсти
 	IngredientsUsed    Ingredients     `json:"ingredients_used,omitempty"`
 	MissingIngredients Ingredients     `json:"missing_ingredients,omitempty"`
 }
 
+// MenuMeals возвращает приемы пищи дня
+func (d *WeeklyDayMenu) MenuMeals() MenuMeals {
+	meals := MenuMeals{}
+	for _, meal := range []struct {
+		recipe   *RecipeDTO
+		mealType string
+	}{
+		{d.Breakfast, "breakfast"},
+		{d.Lunch, "lunch"},
+		{d.Dinner, "dinner"},
+	} {
+		if meal.recipe == nil {
+			continue
+		}
+		meals = append(meals, MenuMeal{
+			RecipeID: meal.recipe.ID,
+			MealType: meal.mealType,
+			Calories: meal.recipe.Calories,
+			Time:     meal.recipe.CookingTime,
+		})
+	}
+	return meals
+}
+
 // RecipeDTO - упрощенное представление рецепта для ответа
 type RecipeDTO struct {
 	ID           int       `json:"id"`
@@ -101,11 +146,29 @@ type RecipeDTO struct {
 	Proteins     float64   `json:"proteins"`
 	Fats         float64   `json:"fats"`
 	Carbs        float64   `json:"carbs"`
+	Micronutrients Micronutrients `json:"micronutrients,omitempty"`
 	CookingTime  int       `json:"cooking_time"`
 	Servings     int       `json:"servings"`
 	MealType     string    `json:"meal_type"`
+	Cuisine      string    `json:"cuisine,omitempty"`
+	Seasons      []string  `json:"seasons,omitempty"`
+	Difficulty   string    `json:"difficulty,omitempty"`
 	Ingredients  Ingredients `json:"ingredients"`
 	Instructions []string  `json:"instructions,omitempty"`
+	Steps        RecipeSteps `json:"steps,omitempty"`
+	ActiveTime   int       `json:"active_time,omitempty"`
+	Equipment    []string  `json:"equipment,omitempty"`
+	Substitutions []AppliedSubstitution `json:"substitutions,omitempty"` // рецепт предложен с заменами
+	NutritionApproximate bool `json:"nutrition_approximate,omitempty"` // КБЖУ рассчитаны до замен
 }
 
 
+
+// PlannedMeal - запланированный прием пищи из сохраненного меню
+type PlannedMeal struct {
+	MenuMealID int       `json:"menu_meal_id"`
+	MenuID     int       `json:"menu_id"`
+	RecipeID   int       `json:"recipe_id"`
+	MealType   string    `json:"meal_type"`
+	Date       time.Time `json:"date"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// MenuJob - фоновая генерация недельного меню
+type MenuJob struct {
+	ID         int                `json:"id"`
+	UserID     int                `json:"user_id"`
+	Status     string             `json:"status"` // pending, running, completed, failed, cancelled
+	Progress   WeeklyMenuProgress `json:"progress"`
+	Result     *WeeklyMenu        `json:"result,omitempty"` // после завершения
+	Error      string             `json:"error,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	StartedAt  *time.Time         `json:"started_at,omitempty"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+}
+
+// Finished - генерация завершена, завершилась с ошибкой или отменена
+func (j *MenuJob) Finished() bool {
+	return j.Status == "completed" || j.Status == "failed" || j.Status == "cancelled"
+}
+
+// WeeklyMenuProgress - прогресс генерации недельного меню
+type WeeklyMenuProgress struct {
+	Phase     string  `json:"phase"` // generating, optimizing, done
+	DaysDone  int     `json:"days_done"`
+	TotalDays int     `json:"total_days"`
+	BestScore float64 `json:"best_score"` // оценка меню из готовых дней, 0-1
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"sort"
+)
+
+// Micronutrients - дополнительные пищевые показатели: ключ -> значение
+// в единицах из MicronutrientUnits. Для рецепта значения указываются
+// на весь рецепт (как и КБЖУ), для лимитов - на одного человека в день.
+type Micronutrients map[string]float64
+
+// MicronutrientUnits - поддерживаемые показатели и их единицы измерения
+var MicronutrientUnits = map[string]string{
+	"fiber":         "г",
+	"sugar":         "г",
+	"saturated_fat": "г",
+	"sodium":        "мг",
+	"potassium":     "мг",
+	"calcium":       "мг",
+	"iron":          "мг",
+	"magnesium":     "мг",
+	"vitamin_a":     "мкг",
+	"vitamin_c":     "мг",
+	"vitamin_d":     "мкг",
+	"vitamin_b12":   "мкг",
+}
+
+// Add прибавляет other, умноженные на factor
+func (m Micronutrients) Add(other Micronutrients, factor float64) {
+	for key, value := range other {
+		m[key] += value * factor
+	}
+}
+
+// Keys возвращает показатели в алфавитном порядке
+func (m Micronutrients) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m *Micronutrients) Scan(value interface{}) error {
+	if value == nil {
+		*m = Micronutrients{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return json.Unmarshal([]byte(value.(string)), m)
+	}
+	return json.Unmarshal(bytes, m)
+}
+
+func (m Micronutrients) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return "{}", nil
+	}
+	return json.Marshal(m)
+}
+
+// IngredientNutrients - справочные показатели продукта для расчета рецепта по ингредиентам
+type IngredientNutrients struct {
+	Name        string         `json:"name"`
+	Per100g     Micronutrients `json:"per_100g"`               // на 100 г (или 100 мл)
+	PieceWeight float64        `json:"piece_weight,omitempty"` // вес одной штуки, г
+}
@@ -6,22 +6,50 @@ import (
 	"time"
 )
 
+// RecipeFilter - фильтры поиска и экспорта рецептов общего каталога. Рецепт должен
+// соответствовать всем диетам и не содержать ни одного аллергена из Allergies.
+type RecipeFilter struct {
+	DietTypes    []string
+	Allergies    []string
+	MealTypes    []string
+	MaxCalories  *int
+	MaxTime      *int
+	Cuisines     []string // любая из кухонь
+	Seasons      []string // рецепт уместен хотя бы в одном сезоне (рецепты на круглый год подходят всегда)
+	Difficulties []string
+	UpdatedSince *time.Time // изменены не раньше (инкрементальный экспорт)
+}
+
 type Recipe struct {
 	ID           int       `json:"id"`
+	OwnerID      *int      `json:"owner_id,omitempty"` // владелец личного рецепта; nil - общий каталог
 	Name         string    `json:"name"`
 	Description  string    `json:"description"`
 	Calories     int       `json:"calories"`
 	Proteins     float64   `json:"proteins"`
 	Fats         float64   `json:"fats"`
 	Carbs        float64   `json:"carbs"`
-	CookingTime  int       `json:"cooking_time"`
+	Micronutrients       Micronutrients `json:"micronutrients,omitempty"`        // на весь рецепт
+	MicronutrientsSource string         `json:"micronutrients_source,omitempty"` // declared или computed
+	CookingTime  int       `json:"cooking_time"` // общее время, минут
+	PrepTime     int       `json:"prep_time,omitempty"`   // подготовка (нарезка, замачивание), минут
+	ActiveTime   int       `json:"active_time,omitempty"` // активная работа; остальное время - пассивные шаги
 	Servings     int       `json:"servings"`
 	MealType     string    `json:"meal_type"`
+	Cuisine      string    `json:"cuisine,omitempty"`    // код кухни: russian, italian, ...
+	Seasons      []string  `json:"seasons,omitempty"`    // winter, spring, summer, autumn; пусто - круглый год
+	Difficulty   string    `json:"difficulty,omitempty"` // easy, medium, hard
 	DietType     []string  `json:"diet_type"`
 	Allergens    []string  `json:"allergens"`
 	Ingredients  Ingredients `json:"ingredients"`
 	Instructions []string  `json:"instructions"`
+	Steps        RecipeSteps `json:"steps,omitempty"`     // структурированные шаги (Instructions - их тексты)
+	Equipment    []string  `json:"equipment,omitempty"` // оборудование всех шагов (не хранится)
 	ImageURL     string    `json:"image_url"`
+	Thumbnails   ImageThumbnails `json:"thumbnails,omitempty"` // размер -> URL уменьшенной копии загруженного изображения
+	ComplianceIssues ComplianceIssues `json:"compliance_issues,omitempty"` // противоречия тегов и ингредиентов
+	Substitutions []AppliedSubstitution `json:"substitutions,omitempty"` // замены для аллергий (не хранятся)
+	NutritionApproximate bool `json:"nutrition_approximate,omitempty"` // КБЖУ рассчитаны до замен и приблизительны (не хранится)
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -30,6 +58,7 @@ type Ingredient struct {
 	Name     string  `json:"name"`
 	Quantity float64 `json:"quantity"`
 	Unit     string  `json:"unit"`
+	Substitutes []SubstituteOption `json:"substitutes,omitempty"` // для недостающих ингредиентов
 }
 
 type Ingredients []Ingredient
@@ -53,4 +82,24 @@ func (i Ingredients) Value() (driver.Value, error) {
 	return json.Marshal(i)
 }
 
+// ImageThumbnails - URL уменьшенных копий изображения по размерам (small, medium, large)
+type ImageThumbnails map[string]string
+
+func (t *ImageThumbnails) Scan(value interface{}) error {
+	if value == nil {
+		*t = ImageThumbnails{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return json.Unmarshal([]byte(value.(string)), t)
+	}
+	return json.Unmarshal(bytes, t)
+}
 
+func (t ImageThumbnails) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return "{}", nil
+	}
+	return json.Marshal(t)
+}
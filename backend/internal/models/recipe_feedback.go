@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// RecipeFeedback - оценка и отметки пользователя для рецепта
+type RecipeFeedback struct {
+	UserID     int       `json:"user_id"`
+	RecipeID   int       `json:"recipe_id"`
+	RecipeName string    `json:"recipe_name,omitempty"`
+	Rating     *int      `json:"rating,omitempty"` // 1-5
+	Favorite   bool      `json:"favorite"`
+	Banned     bool      `json:"banned"` // рецепт не предлагается при генерации меню
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// RecipeRatingRequest - запрос на оценку рецепта
+type RecipeRatingRequest struct {
+	Rating int `json:"rating"`
+}
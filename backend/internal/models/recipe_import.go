@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // RecipeImportDTO - DTO для импорта рецепта из JSON
 type RecipeImportDTO struct {
 	Title       string              `json:"title"`
@@ -10,9 +12,12 @@ type RecipeImportDTO struct {
 	Proteins    float64             `json:"proteins"`
 	Fats        float64             `json:"fats"`
 	Carbs       float64             `json:"carbs"`
-	CookingTime int                 `json:"cooking_time,omitempty"`
+	Micronutrients Micronutrients     `json:"micronutrients,omitempty"` // на весь рецепт; для импорта можно указать salt (г)
+	CookingTime int                 `json:"cooking_time,omitempty"` // общее время; по умолчанию - сумма длительностей шагов
+	PrepTime    int                 `json:"prep_time,omitempty"`
 	Servings    int                 `json:"servings,omitempty"`
 	Instructions []string            `json:"instructions,omitempty"`
+	Steps       []RecipeStep        `json:"steps,omitempty"` // вместо instructions
 }
 
 type IngredientImport struct {
@@ -31,9 +36,12 @@ type RecipeExportDTO struct {
 	Proteins    float64             `json:"proteins"`
 	Fats        float64             `json:"fats"`
 	Carbs       float64             `json:"carbs"`
+	Micronutrients Micronutrients     `json:"micronutrients,omitempty"` // на весь рецепт
 	CookingTime int                 `json:"cooking_time,omitempty"`
+	PrepTime    int                 `json:"prep_time,omitempty"`
 	Servings    int                 `json:"servings,omitempty"`
 	Instructions []string            `json:"instructions,omitempty"`
+	Steps       []RecipeStep        `json:"steps,omitempty"`
 }
 
 // RecipeImportRequest - запрос на импорт рецептов
@@ -46,3 +54,13 @@ type RecipeExportResponse struct {
 	Recipes []RecipeExportDTO `json:"recipes"`
 }
 
+// ExportManifest - итог потокового экспорта. Checksum - SHA-256 строк рецептов в
+// формате NDJSON (JSON рецепта + "\n") в порядке экспорта; одинаковые каталоги
+// с одинаковыми фильтрами дают одинаковую сумму.
+type ExportManifest struct {
+	Count            int        `json:"count"`
+	Checksum         string     `json:"checksum"` // sha256:<hex>
+	GeneratedAt      time.Time  `json:"generated_at"`
+	UpdatedSince     *time.Time `json:"updated_since,omitempty"`
+	NextUpdatedSince *time.Time `json:"next_updated_since,omitempty"` // updated_since для следующего инкрементального экспорта
+}
@@ -0,0 +1,11 @@
+package models
+
+// ScaledRecipe - рецепт, пересчитанный на ScaledServings порций: ингредиенты
+// округлены до кухонных мер, КБЖУ и микронутриенты - на все порции. Servings
+// рецепта остается исходным.
+type ScaledRecipe struct {
+	Recipe
+	ScaledServings float64         `json:"scaled_servings"`
+	Multiplier     float64         `json:"multiplier"`  // ScaledServings / Servings
+	PerServing     NutritionTotals `json:"per_serving"` // КБЖУ одной порции
+}
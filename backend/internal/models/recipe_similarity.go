@@ -0,0 +1,16 @@
+package models
+
+// SimilarRecipe - рецепт, похожий на заданный, с оценкой сходства
+type SimilarRecipe struct {
+	Recipe
+	Similarity RecipeSimilarity `json:"similarity"`
+}
+
+// RecipeSimilarity - сходство двух рецептов: общая оценка от 0 до 1 и ее составляющие
+type RecipeSimilarity struct {
+	Score             float64  `json:"score"`
+	IngredientOverlap float64  `json:"ingredient_overlap"` // коэффициент Жаккара по ингредиентам
+	MacroSimilarity   float64  `json:"macro_similarity"`   // близость долей БЖУ в калорийности
+	SameMealType      bool     `json:"same_meal_type"`
+	SharedIngredients []string `json:"shared_ingredients"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// RecipeStep - шаг рецепта
+type RecipeStep struct {
+	Text        string   `json:"text"`
+	Duration    int      `json:"duration,omitempty"`    // минут
+	Passive     bool     `json:"passive,omitempty"`     // не требует участия: запекание, маринование, расстойка
+	Ingredients []string `json:"ingredients,omitempty"` // названия ингредиентов рецепта, используемых в шаге
+	Equipment   []string `json:"equipment,omitempty"`   // oven, blender, ...
+}
+
+type RecipeSteps []RecipeStep
+
+func (s *RecipeSteps) Scan(value interface{}) error {
+	if value == nil {
+		*s = RecipeSteps{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return json.Unmarshal([]byte(value.(string)), s)
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+func (s RecipeSteps) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(s)
+}
+
+// Equipment возвращает оборудование всех шагов без повторов
+func (s RecipeSteps) Equipment() []string {
+	var equipment []string
+	seen := make(map[string]bool)
+	for _, step := range s {
+		for _, item := range step.Equipment {
+			if !seen[item] {
+				seen[item] = true
+				equipment = append(equipment, item)
+			}
+		}
+	}
+	return equipment
+}
+
+// HandsOnTime возвращает время активной работы над рецептом; если оно неизвестно
+// (нет шагов с длительностью), все время приготовления считается активным
+func (r *Recipe) HandsOnTime() int {
+	if r.ActiveTime > 0 {
+		return r.ActiveTime
+	}
+	return r.CookingTime
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// RecipeSubmission - рецепт, предложенный пользователем в общий каталог
+type RecipeSubmission struct {
+	ID         int             `json:"id"`
+	UserID     int             `json:"user_id"`
+	Recipe     RecipeImportDTO `json:"recipe"`
+	Status     string          `json:"status"`              // pending, approved, rejected
+	Reason     string          `json:"reason,omitempty"`    // причина отклонения
+	RecipeID   *int            `json:"recipe_id,omitempty"` // рецепт каталога после одобрения
+	ReviewedBy *int            `json:"reviewed_by,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ReviewedAt *time.Time      `json:"reviewed_at,omitempty"`
+}
+
+// SubmissionReviewRequest - решение модератора
+type SubmissionReviewRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (d *RecipeImportDTO) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return json.Unmarshal([]byte(value.(string)), d)
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+func (d RecipeImportDTO) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
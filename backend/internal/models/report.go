@@ -0,0 +1,62 @@
+package models
+
+// PlannedMealDetail - запланированный прием пищи с данными рецепта (КБЖУ на все порции рецепта)
+type PlannedMealDetail struct {
+	Date        string  `json:"date"`
+	MenuMealID  int     `json:"menu_meal_id"`
+	MealType    string  `json:"meal_type"`
+	RecipeID    int     `json:"recipe_id"`
+	RecipeName  string  `json:"recipe_name"`
+	Calories    int     `json:"calories"`
+	Proteins    float64 `json:"proteins"`
+	Fats        float64 `json:"fats"`
+	Carbs       float64 `json:"carbs"`
+	Servings    int     `json:"servings"`
+	CookingTime int     `json:"cooking_time"`
+}
+
+// NutritionReport - отчет о питании за период
+type NutritionReport struct {
+	From               string               `json:"from"`
+	To                 string               `json:"to"`
+	DailyGoal          *NutritionTotals     `json:"daily_goal,omitempty"`
+	Days               []NutritionReportDay `json:"days"`
+	Totals             NutritionTotals      `json:"totals"`
+	Adherence          PlanAdherence        `json:"adherence"`
+	TopRecipes         []RecipeFrequency    `json:"top_recipes"`
+	AverageCookingTime float64              `json:"average_cooking_time"` // минут на запланированное блюдо
+	PantryUsage        PantryUsage          `json:"pantry_usage"`
+}
+
+// NutritionReportDay - питание за день: факт, план и цель
+type NutritionReportDay struct {
+	Date            string          `json:"date"`
+	Eaten           NutritionTotals `json:"eaten"`
+	Planned         NutritionTotals `json:"planned"`
+	CaloriesPercent float64         `json:"calories_percent,omitempty"` // факт / цель, %
+	PlannedMeals    int             `json:"planned_meals"`
+	EatenAsPlanned  int             `json:"eaten_as_planned"`
+}
+
+// PlanAdherence - соблюдение плана питания
+type PlanAdherence struct {
+	PlannedMeals     int     `json:"planned_meals"`
+	EatenAsPlanned   int     `json:"eaten_as_planned"`
+	LoggedMeals      int     `json:"logged_meals"`
+	AdherencePercent float64 `json:"adherence_percent"`
+	PlannedCalories  int     `json:"planned_calories"`
+	EatenCalories    int     `json:"eaten_calories"`
+}
+
+// RecipeFrequency - сколько раз рецепт встречался в планах
+type RecipeFrequency struct {
+	RecipeID int    `json:"recipe_id"`
+	Name     string `json:"name"`
+	Count    int    `json:"count"`
+}
+
+// PantryUsage - продукты из кладовой, использованные в планах (не пропали)
+type PantryUsage struct {
+	ItemsUsed   int         `json:"items_used"`
+	Ingredients Ingredients `json:"ingredients"`
+}
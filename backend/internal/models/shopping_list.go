@@ -20,6 +20,7 @@ type ShoppingItem struct {
 	Quantity float64  `json:"quantity"`
 	Unit     string   `json:"unit"`
 	Reason   []string `json:"reason"` // meal types that need this ingredient
+	Substitutes []SubstituteOption `json:"substitutes,omitempty"`
 }
 
 type ShoppingItems []ShoppingItem
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// SubstituteComponent - составная часть замены
+type SubstituteComponent struct {
+	Name  string  `json:"name"`
+	Ratio float64 `json:"ratio"`          // количество на 1 единицу исходного ингредиента
+	Unit  string  `json:"unit,omitempty"` // по умолчанию - единица исходного ингредиента
+}
+
+type SubstituteComponents []SubstituteComponent
+
+func (s *SubstituteComponents) Scan(value interface{}) error {
+	if value == nil {
+		*s = SubstituteComponents{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return json.Unmarshal([]byte(value.(string)), s)
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+func (s SubstituteComponents) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(s)
+}
+
+// IngredientSubstitution - запись таблицы замен для канонического ингредиента
+type IngredientSubstitution struct {
+	ID         int                  `json:"id"`
+	Ingredient string               `json:"ingredient"`
+	Aliases    []string             `json:"aliases,omitempty"`
+	Components SubstituteComponents `json:"components"`
+	Context    string               `json:"context,omitempty"` // где замена уместна; пусто - везде
+	Note       string               `json:"note,omitempty"`
+}
+
+// SubstituteOption - предложенная замена, пересчитанная на нужное количество
+type SubstituteOption struct {
+	Ingredients Ingredients `json:"ingredients"`
+	Context     string      `json:"context,omitempty"`
+	Note        string      `json:"note,omitempty"`
+	InPantry    bool        `json:"in_pantry,omitempty"` // все составляющие есть в кладовой
+}
+
+// AppliedSubstitution - замена, примененная к рецепту из-за аллергии
+type AppliedSubstitution struct {
+	Original   Ingredient       `json:"original"`
+	Substitute SubstituteOption `json:"substitute"`
+	Allergens  []string         `json:"allergens"` // аллергены исходного ингредиента
+}
@@ -25,6 +25,9 @@ type UserGoals struct {
 	ProteinRatio  float64  `json:"protein_ratio"`
 	FatRatio      float64  `json:"fat_ratio"`
 	CarbRatio     float64  `json:"carb_ratio"`
+	WeeklyWeightChange *float64 `json:"weekly_weight_change,omitempty"` // кг в неделю, < 0 - похудение
+	AutoAdjustCalories bool     `json:"auto_adjust_calories"`
+	NutrientLimits     Micronutrients `json:"nutrient_limits,omitempty"` // верхние лимиты в день, например {"sodium": 2000}
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/pkg/database"
+)
+
+type BodyRepository struct{}
+
+func NewBodyRepository() *BodyRepository {
+	return &BodyRepository{}
+}
+
+// UpsertMeasurement сохраняет измерение (одно на пользователя в день)
+func (r *BodyRepository) UpsertMeasurement(ctx context.Context, m *models.BodyMeasurement) error {
+	query := `
+		INSERT INTO body_measurements (user_id, date, weight, waist, note)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, date)
+		DO UPDATE SET
+			weight = EXCLUDED.weight,
+			waist = EXCLUDED.waist,
+			note = EXCLUDED.note
+		RETURNING id, created_at
+	`
+
+	return database.DB.QueryRowContext(ctx, query, m.UserID, m.Date, m.Weight, m.Waist, m.Note).Scan(&m.ID, &m.CreatedAt)
+}
+
+// GetMeasurements возвращает измерения за период [from, to] включительно
+func (r *BodyRepository) GetMeasurements(ctx context.Context, userID int, from, to time.Time) ([]models.BodyMeasurement, error) {
+	query := `
+		SELECT id, user_id, date, weight, waist, note, created_at
+		FROM body_measurements
+		WHERE user_id = $1 AND date BETWEEN $2 AND $3
+		ORDER BY date
+	`
+
+	rows, err := database.DB.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	measurements := []models.BodyMeasurement{}
+	for rows.Next() {
+		var m models.BodyMeasurement
+		if err := rows.Scan(&m.ID, &m.UserID, &m.Date, &m.Weight, &m.Waist, &m.Note, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		measurements = append(measurements, m)
+	}
+	return measurements, rows.Err()
+}
+
+func (r *BodyRepository) DeleteMeasurement(ctx context.Context, id, userID int) error {
+	result, err := database.DB.ExecContext(ctx, `DELETE FROM body_measurements WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const adjustmentColumns = `
+	id, user_id, period_start, period_end, measurements_count, target_weekly_change, actual_weekly_change,
+	previous_calories, proposed_calories, reason, status, created_at, resolved_at
+`
+
+// CreateAdjustmentInTx сохраняет корректировку. Если за этот период корректировка
+// уже есть, возвращает false (задача не должна предлагать ее дважды).
+func (r *BodyRepository) CreateAdjustmentInTx(ctx context.Context, tx *sql.Tx, a *models.GoalAdjustment) (bool, error) {
+	query := `
+		INSERT INTO goal_adjustments (user_id, period_start, period_end, measurements_count, target_weekly_change,
+		                              actual_weekly_change, previous_calories, proposed_calories, reason, status, resolved_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (user_id, period_end) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	err := tx.QueryRowContext(ctx, query,
+		a.UserID, a.PeriodStart, a.PeriodEnd, a.MeasurementsCount, a.TargetWeeklyChange,
+		a.ActualWeeklyChange, a.PreviousCalories, a.ProposedCalories, a.Reason, a.Status, a.ResolvedAt,
+	).Scan(&a.ID, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *BodyRepository) GetAdjustmentByID(ctx context.Context, id, userID int) (*models.GoalAdjustment, error) {
+	query := `SELECT ` + adjustmentColumns + ` FROM goal_adjustments WHERE id = $1 AND user_id = $2`
+
+	a := &models.GoalAdjustment{}
+	err := scanAdjustment(database.DB.QueryRowContext(ctx, query, id, userID), a)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetLatestAdjustment возвращает последнюю по периоду корректировку пользователя (nil, если их нет)
+func (r *BodyRepository) GetLatestAdjustment(ctx context.Context, userID int) (*models.GoalAdjustment, error) {
+	query := `SELECT ` + adjustmentColumns + ` FROM goal_adjustments WHERE user_id = $1 ORDER BY period_end DESC, id DESC LIMIT 1`
+
+	a := &models.GoalAdjustment{}
+	err := scanAdjustment(database.DB.QueryRowContext(ctx, query, userID), a)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetAdjustments возвращает журнал корректировок пользователя (новые первыми)
+func (r *BodyRepository) GetAdjustments(ctx context.Context, userID int) ([]models.GoalAdjustment, error) {
+	query := `SELECT ` + adjustmentColumns + ` FROM goal_adjustments WHERE user_id = $1 ORDER BY period_end DESC, id DESC`
+
+	rows, err := database.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := []models.GoalAdjustment{}
+	for rows.Next() {
+		var a models.GoalAdjustment
+		if err := scanAdjustment(rows, &a); err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, a)
+	}
+	return adjustments, rows.Err()
+}
+
+// ResolveAdjustment переводит предложенную корректировку в статус applied или rejected
+// (sql.ErrNoRows, если она уже не в статусе proposed)
+func (r *BodyRepository) ResolveAdjustment(ctx context.Context, id int, status string) error {
+	return resolveAdjustment(ctx, database.DB, id, status)
+}
+
+// ResolveAdjustmentInTx - ResolveAdjustment в транзакции. Строка корректировки
+// блокируется до конца транзакции, поэтому одновременное применение ждет и
+// получает sql.ErrNoRows
+func (r *BodyRepository) ResolveAdjustmentInTx(ctx context.Context, tx *sql.Tx, id int, status string) error {
+	return resolveAdjustment(ctx, tx, id, status)
+}
+
+func resolveAdjustment(ctx context.Context, db interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}, id int, status string) error {
+	query := `
+		UPDATE goal_adjustments
+		SET status = $2, resolved_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'proposed'
+	`
+	result, err := db.ExecContext(ctx, query, id, status)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanAdjustment(row interface{ Scan(...interface{}) error }, a *models.GoalAdjustment) error {
+	return row.Scan(
+		&a.ID, &a.UserID, &a.PeriodStart, &a.PeriodEnd, &a.MeasurementsCount, &a.TargetWeeklyChange,
+		&a.ActualWeeklyChange, &a.PreviousCalories, &a.ProposedCalories, &a.Reason, &a.Status,
+		&a.CreatedAt, &a.ResolvedAt,
+	)
+}
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/pkg/database"
+)
+
+type CookingSessionRepository struct{}
+
+func NewCookingSessionRepository() *CookingSessionRepository {
+	return &CookingSessionRepository{}
+}
+
+const cookingSessionColumns = `id, user_id, status, servings, meals, steps, current_step, pantry_used,
+	started_at, finished_at, updated_at`
+
+// Create сохраняет новую сессию в статусе active
+func (r *CookingSessionRepository) Create(ctx context.Context, session *models.CookingSession) error {
+	query := `
+		INSERT INTO cooking_sessions (user_id, servings, meals, steps, current_step)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, started_at, updated_at
+	`
+
+	return database.DB.QueryRowContext(ctx, query, session.UserID, session.Servings, session.Meals, session.Steps, session.CurrentStep).
+		Scan(&session.ID, &session.Status, &session.StartedAt, &session.UpdatedAt)
+}
+
+// GetByID возвращает сессию пользователя (nil, если не найдена)
+func (r *CookingSessionRepository) GetByID(ctx context.Context, id, userID int) (*models.CookingSession, error) {
+	query := `SELECT ` + cookingSessionColumns + ` FROM cooking_sessions WHERE id = $1 AND user_id = $2`
+
+	var session models.CookingSession
+	err := scanCookingSession(database.DB.QueryRowContext(ctx, query, id, userID), &session)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActive возвращает незавершенные сессии пользователя, последние - первыми
+func (r *CookingSessionRepository) GetActive(ctx context.Context, userID int) ([]models.CookingSession, error) {
+	query := `SELECT ` + cookingSessionColumns + ` FROM cooking_sessions
+		WHERE user_id = $1 AND status = 'active' ORDER BY started_at DESC, id DESC`
+
+	rows, err := database.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.CookingSession{}
+	for rows.Next() {
+		var session models.CookingSession
+		if err := scanCookingSession(rows, &session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// UpdateProgress сохраняет шаги и текущий шаг активной сессии. Обновление применяется,
+// только если текущий шаг в базе равен expectedStep (сессию не продвинули с другого
+// устройства); иначе возвращается sql.ErrNoRows.
+func (r *CookingSessionRepository) UpdateProgress(ctx context.Context, session *models.CookingSession, expectedStep int) error {
+	query := `
+		UPDATE cooking_sessions SET steps = $4, current_step = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND status = 'active' AND current_step = $3
+		RETURNING updated_at
+	`
+
+	return database.DB.QueryRowContext(ctx, query, session.ID, session.UserID, expectedStep, session.Steps, session.CurrentStep).
+		Scan(&session.UpdatedAt)
+}
+
+// Cancel отменяет активную сессию без списания продуктов
+func (r *CookingSessionRepository) Cancel(ctx context.Context, id, userID int) error {
+	query := `
+		UPDATE cooking_sessions SET status = 'cancelled', finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND status = 'active'
+	`
+	result, err := database.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Finish завершает активную сессию и в той же транзакции списывает продукты из
+// кладовой: deduct получает продукты пользователя (заблокированные до конца
+// транзакции) и возвращает новые количества и списанное. Продукты с нулевым
+// остатком удаляются. Если сессия уже не активна, возвращается sql.ErrNoRows.
+func (r *CookingSessionRepository) Finish(
+	ctx context.Context,
+	session *models.CookingSession,
+	deduct func(pantry []models.PantryItem) ([]models.PantryItem, models.Ingredients),
+) error {
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокировка сессии не дает завершить ее дважды одновременно
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM cooking_sessions WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+		session.ID, session.UserID).Scan(&status)
+	if err != nil {
+		return err
+	}
+	if status != "active" {
+		return sql.ErrNoRows
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, user_id, name, quantity, unit, created_at, updated_at
+		FROM pantry_items WHERE user_id = $1 ORDER BY id FOR UPDATE
+	`, session.UserID)
+	if err != nil {
+		return err
+	}
+	var pantry []models.PantryItem
+	for rows.Next() {
+		var item models.PantryItem
+		err := rows.Scan(&item.ID, &item.UserID, &item.Name, &item.Quantity, &item.Unit, &item.CreatedAt, &item.UpdatedAt)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		pantry = append(pantry, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	updated, used := deduct(pantry)
+	for _, item := range updated {
+		if item.Quantity <= 0 {
+			_, err = tx.ExecContext(ctx, `DELETE FROM pantry_items WHERE id = $1`, item.ID)
+		} else {
+			_, err = tx.ExecContext(ctx, `UPDATE pantry_items SET quantity = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+				item.ID, item.Quantity)
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка при списании продукта %s: %w", item.Name, err)
+		}
+	}
+
+	query := `
+		UPDATE cooking_sessions
+		SET status = 'finished', pantry_used = $2, finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING status, finished_at, updated_at
+	`
+	err = tx.QueryRowContext(ctx, query, session.ID, used).Scan(&session.Status, &session.FinishedAt, &session.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	session.PantryUsed = used
+
+	return tx.Commit()
+}
+
+func scanCookingSession(row interface{ Scan(...interface{}) error }, session *models.CookingSession) error {
+	return row.Scan(
+		&session.ID, &session.UserID, &session.Status, &session.Servings, &session.Meals, &session.Steps,
+		&session.CurrentStep, &session.PantryUsed, &session.StartedAt, &session.FinishedAt, &session.UpdatedAt,
+	)
+}
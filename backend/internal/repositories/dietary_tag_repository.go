@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"github.com/lib/pq"
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/pkg/database"
+)
+
+type DietaryTagRepository struct{}
+
+func NewDietaryTagRepository() *DietaryTagRepository {
+	return &DietaryTagRepository{}
+}
+
+// GetAll возвращает справочник диет и аллергенов
+func (r *DietaryTagRepository) GetAll(ctx context.Context) ([]models.DietaryTag, error) {
+	query := `SELECT code, kind, name, aliases, categories FROM dietary_tags ORDER BY kind, code`
+
+	rows, err := database.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.DietaryTag{}
+	for rows.Next() {
+		var tag models.DietaryTag
+		if err := rows.Scan(&tag.Code, &tag.Kind, &tag.Name, pq.Array(&tag.Aliases), pq.Array(&tag.Categories)); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// GetIngredientCategories возвращает ключевые слова категорий продуктов
+func (r *DietaryTagRepository) GetIngredientCategories(ctx context.Context) ([]models.IngredientCategory, error) {
+	query := `SELECT keyword, category, exceptions FROM ingredient_categories ORDER BY keyword, category`
+
+	rows, err := database.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.IngredientCategory{}
+	for rows.Next() {
+		var category models.IngredientCategory
+		if err := rows.Scan(&category.Keyword, &category.Category, pq.Array(&category.Exceptions)); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/pkg/database"
+)
+
+type FoodLogRepository struct{}
+
+func NewFoodLogRepository() *FoodLogRepository {
+	return &FoodLogRepository{}
+}
+
+func (r *FoodLogRepository) Create(ctx context.Context, entry *models.FoodLogEntry) error {
+	query := `
+		INSERT INTO food_log (user_id, date, meal_type, recipe_id, menu_meal_id, portion, name,
+		                      calories, proteins, fats, carbs)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at
+	`
+
+	return database.DB.QueryRowContext(ctx, query,
+		entry.UserID, entry.Date, entry.MealType, entry.RecipeID, entry.MenuMealID, entry.Portion, entry.Name,
+		entry.Calories, entry.Proteins, entry.Fats, entry.Carbs,
+	).Scan(&entry.ID, &entry.CreatedAt, &entry.UpdatedAt)
+}
+
+// GetByUserIDAndDateRange возвращает записи за период [from, to] включительно
+func (r *FoodLogRepository) GetByUserIDAndDateRange(ctx context.Context, userID int, from, to time.Time) ([]models.FoodLogEntry, error) {
+	query := `
+		SELECT id, user_id, date, meal_type, recipe_id, menu_meal_id, portion, name,
+		       calories, proteins, fats, carbs, created_at, updated_at
+		FROM food_log
+		WHERE user_id = $1 AND date BETWEEN $2 AND $3
+		ORDER BY date, created_at
+	`
+
+	rows, err := database.DB.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.FoodLogEntry{}
+	for rows.Next() {
+		var entry models.FoodLogEntry
+		var recipeID, menuMealID sql.NullInt64
+		err := rows.Scan(
+			&entry.ID, &entry.UserID, &entry.Date, &entry.MealType, &recipeID, &menuMealID, &entry.Portion, &entry.Name,
+			&entry.Calories, &entry.Proteins, &entry.Fats, &entry.Carbs, &entry.CreatedAt, &entry.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if recipeID.Valid {
+			id := int(recipeID.Int64)
+			entry.RecipeID = &id
+		}
+		if menuMealID.Valid {
+			id := int(menuMealID.Int64)
+			entry.MenuMealID = &id
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *FoodLogRepository) Delete(ctx context.Context, id, userID int) error {
+	query := `DELETE FROM food_log WHERE id = $1 AND user_id = $2`
+	result, err := database.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/pkg/database"
@@ -12,43 +13,59 @@ func NewGoalsRepository() *GoalsRepository {
 	return &GoalsRepository{}
 }
 
-func (r *GoalsRepository) CreateOrUpdate(goals *models.UserGoals) error {
-	query := `
-		INSERT INTO user_goals (
-			user_id, daily_calories, target_proteins, target_fats, target_carbs,
-			protein_ratio, fat_ratio, carb_ratio
-		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		ON CONFLICT (user_id) 
-		DO UPDATE SET 
-			daily_calories = EXCLUDED.daily_calories,
-			target_proteins = EXCLUDED.target_proteins,
-			target_fats = EXCLUDED.target_fats,
-			target_carbs = EXCLUDED.target_carbs,
-			protein_ratio = EXCLUDED.protein_ratio,
-			fat_ratio = EXCLUDED.fat_ratio,
-			carb_ratio = EXCLUDED.carb_ratio,
-			updated_at = CURRENT_TIMESTAMP
-	`
-	
-	_, err := database.DB.Exec(query,
-		goals.UserID, goals.DailyCalories, goals.TargetProteins, goals.TargetFats, goals.TargetCarbs,
-		goals.ProteinRatio, goals.FatRatio, goals.CarbRatio,
-	)
+func (r *GoalsRepository) CreateOrUpdate(ctx context.Context, goals *models.UserGoals) error {
+	_, err := database.DB.ExecContext(ctx, upsertGoalsQuery, upsertGoalsArgs(goals)...)
 	return err
 }
 
-func (r *GoalsRepository) GetByUserID(userID int) (*models.UserGoals, error) {
+// CreateOrUpdateInTx сохраняет цели пользователя в транзакции
+func (r *GoalsRepository) CreateOrUpdateInTx(ctx context.Context, tx *sql.Tx, goals *models.UserGoals) error {
+	_, err := tx.ExecContext(ctx, upsertGoalsQuery, upsertGoalsArgs(goals)...)
+	return err
+}
+
+const upsertGoalsQuery = `
+	INSERT INTO user_goals (
+		user_id, daily_calories, target_proteins, target_fats, target_carbs,
+		protein_ratio, fat_ratio, carb_ratio, weekly_weight_change, auto_adjust_calories, nutrient_limits
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	ON CONFLICT (user_id) 
+	DO UPDATE SET 
+		daily_calories = EXCLUDED.daily_calories,
+		target_proteins = EXCLUDED.target_proteins,
+		target_fats = EXCLUDED.target_fats,
+		target_carbs = EXCLUDED.target_carbs,
+		protein_ratio = EXCLUDED.protein_ratio,
+		fat_ratio = EXCLUDED.fat_ratio,
+		carb_ratio = EXCLUDED.carb_ratio,
+		weekly_weight_change = EXCLUDED.weekly_weight_change,
+		auto_adjust_calories = EXCLUDED.auto_adjust_calories,
+		nutrient_limits = EXCLUDED.nutrient_limits,
+		updated_at = CURRENT_TIMESTAMP
+`
+
+func upsertGoalsArgs(goals *models.UserGoals) []interface{} {
+	return []interface{}{
+		goals.UserID, goals.DailyCalories, goals.TargetProteins, goals.TargetFats, goals.TargetCarbs,
+		goals.ProteinRatio, goals.FatRatio, goals.CarbRatio, goals.WeeklyWeightChange, goals.AutoAdjustCalories,
+		goals.NutrientLimits,
+	}
+}
+
+func (r *GoalsRepository) GetByUserID(ctx context.Context, userID int) (*models.UserGoals, error) {
 	query := `
 		SELECT id, user_id, daily_calories, target_proteins, target_fats, target_carbs,
-		       protein_ratio, fat_ratio, carb_ratio, created_at, updated_at
+		       protein_ratio, fat_ratio, carb_ratio, weekly_weight_change, auto_adjust_calories,
+		       nutrient_limits, created_at, updated_at
 		FROM user_goals WHERE user_id = $1
 	`
 	
 	goals := &models.UserGoals{}
-	err := database.DB.QueryRow(query, userID).Scan(
+	err := database.DB.QueryRowContext(ctx, query, userID).Scan(
 		&goals.ID, &goals.UserID, &goals.DailyCalories, &goals.TargetProteins, &goals.TargetFats, &goals.TargetCarbs,
-		&goals.ProteinRatio, &goals.FatRatio, &goals.CarbRatio, &goals.CreatedAt, &goals.UpdatedAt,
+		&goals.ProteinRatio, &goals.FatRatio, &goals.CarbRatio, &goals.WeeklyWeightChange, &goals.AutoAdjustCalories,
+		&goals.NutrientLimits, &goals.CreatedAt, &goals.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -59,4 +76,23 @@ func (r *GoalsRepository) GetByUserID(userID int) (*models.UserGoals, error) {
 	return goals, nil
 }
 
-
+// GetUserIDsWithWeightGoal возвращает пользователей, у которых задана цель по изменению веса
+func (r *GoalsRepository) GetUserIDsWithWeightGoal(ctx context.Context) ([]int, error) {
+	query := `SELECT user_id FROM user_goals WHERE weekly_weight_change IS NOT NULL ORDER BY user_id`
+	
+	rows, err := database.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
@@ -0,0 +1,217 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/pkg/database"
+)
+
+type ImportJobRepository struct{}
+
+func NewImportJobRepository() *ImportJobRepository {
+	return &ImportJobRepository{}
+}
+
+// Файлы задания (files) читаются только при запуске задания
+const importJobColumns = `id, format, mode, dry_run, status, total, processed, result, error, attempts,
+	created_by, created_at, started_at, finished_at`
+
+// Create сохраняет новое задание в статусе pending
+func (r *ImportJobRepository) Create(ctx context.Context, job *models.ImportJob) error {
+	query := `
+		INSERT INTO import_jobs (format, mode, dry_run, files, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, created_at
+	`
+
+	return database.DB.QueryRowContext(ctx, query, job.Format, job.Mode, job.DryRun, job.Files, job.CreatedBy).
+		Scan(&job.ID, &job.Status, &job.CreatedAt)
+}
+
+// GetByID возвращает задание (nil, если не найдено)
+func (r *ImportJobRepository) GetByID(ctx context.Context, id int) (*models.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE id = $1`
+
+	var job models.ImportJob
+	err := scanImportJob(database.DB.QueryRowContext(ctx, query, id), &job)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetRecent возвращает последние задания
+func (r *ImportJobRepository) GetRecent(ctx context.Context, limit int) ([]models.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs ORDER BY created_at DESC, id DESC LIMIT $1`
+
+	rows, err := database.DB.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.ImportJob{}
+	for rows.Next() {
+		var job models.ImportJob
+		if err := scanImportJob(rows, &job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// ClaimNext переводит самое старое ожидающее задание в статус running и возвращает
+// его вместе с файлами (nil, если заданий нет). SKIP LOCKED позволяет нескольким
+// экземплярам сервера разбирать очередь без повторного выполнения.
+func (r *ImportJobRepository) ClaimNext(ctx context.Context) (*models.ImportJob, error) {
+	query := `
+		UPDATE import_jobs
+		SET status = 'running', processed = 0, attempts = attempts + 1,
+		    started_at = CURRENT_TIMESTAMP, heartbeat_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM import_jobs WHERE status = 'pending'
+			ORDER BY created_at, id LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + importJobColumns + `, files
+	`
+
+	var job models.ImportJob
+	err := scanImportJob(database.DB.QueryRowContext(ctx, query), &job, &job.Files)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Методы выполнения задания принимают номер попытки (attempt - attempts из ClaimNext)
+// и меняют задание, только пока эта попытка выполняется: если задание вернули в
+// очередь и забрал другой воркер, прежний воркер его больше не изменит.
+
+// UpdateProgress сохраняет прогресс выполняющегося задания
+func (r *ImportJobRepository) UpdateProgress(ctx context.Context, id, attempt, processed, total int) error {
+	query := `
+		UPDATE import_jobs SET processed = $3, total = $4, heartbeat_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'running' AND attempts = $2
+	`
+	_, err := database.DB.ExecContext(ctx, query, id, attempt, processed, total)
+	return err
+}
+
+// Heartbeat отмечает, что задание еще выполняется
+func (r *ImportJobRepository) Heartbeat(ctx context.Context, id, attempt int) error {
+	query := `
+		UPDATE import_jobs SET heartbeat_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'running' AND attempts = $2
+	`
+	_, err := database.DB.ExecContext(ctx, query, id, attempt)
+	return err
+}
+
+// Complete сохраняет результат задания; файлы больше не нужны и удаляются.
+// sql.ErrNoRows - попытка уже не выполняется (задание передано другому воркеру)
+func (r *ImportJobRepository) Complete(ctx context.Context, id, attempt int, result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE import_jobs
+		SET status = 'completed', result = $3, error = NULL, processed = total, files = '[]',
+		    finished_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'running' AND attempts = $2
+	`
+	return execAffectingRow(ctx, query, id, attempt, data)
+}
+
+// Fail завершает задание с ошибкой.
+// sql.ErrNoRows - попытка уже не выполняется (задание передано другому воркеру)
+func (r *ImportJobRepository) Fail(ctx context.Context, id, attempt int, message string) error {
+	query := `
+		UPDATE import_jobs
+		SET status = 'failed', error = $3, files = '[]', finished_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'running' AND attempts = $2
+	`
+	return execAffectingRow(ctx, query, id, attempt, message)
+}
+
+// RequeueStale возвращает в очередь задания, которые числятся выполняющимися, но
+// не обновлялись дольше staleAfter (сервер был остановлен во время импорта).
+// Задания, исчерпавшие maxAttempts попыток, не возвращаются, а завершаются с
+// ошибкой message: иначе задание, из-за которого падает сервер, выполнялось бы
+// бесконечно. Возвращает количество возвращенных и завершенных заданий.
+func (r *ImportJobRepository) RequeueStale(ctx context.Context, staleAfter time.Duration, maxAttempts int, message string) (requeued, failed int64, err error) {
+	query := `
+		UPDATE import_jobs
+		SET status = 'failed', error = $3, files = '[]', finished_at = CURRENT_TIMESTAMP
+		WHERE status = 'running' AND heartbeat_at < CURRENT_TIMESTAMP - $1 * INTERVAL '1 second'
+		  AND attempts >= $2
+	`
+	res, err := database.DB.ExecContext(ctx, query, staleAfter.Seconds(), maxAttempts, message)
+	if err != nil {
+		return 0, 0, err
+	}
+	if failed, err = res.RowsAffected(); err != nil {
+		return 0, 0, err
+	}
+
+	query = `
+		UPDATE import_jobs SET status = 'pending'
+		WHERE status = 'running' AND heartbeat_at < CURRENT_TIMESTAMP - $1 * INTERVAL '1 second'
+	`
+	res, err = database.DB.ExecContext(ctx, query, staleAfter.Seconds())
+	if err != nil {
+		return 0, failed, err
+	}
+	requeued, err = res.RowsAffected()
+	return requeued, failed, err
+}
+
+// execAffectingRow выполняет запрос, который должен изменить строку (sql.ErrNoRows, если не изменил)
+func execAffectingRow(ctx context.Context, query string, args ...interface{}) error {
+	res, err := database.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanImportJob(row interface{ Scan(...interface{}) error }, job *models.ImportJob, extra ...interface{}) error {
+	var result []byte
+	var errorText sql.NullString
+	var createdBy sql.NullInt64
+	dest := []interface{}{
+		&job.ID, &job.Format, &job.Mode, &job.DryRun, &job.Status, &job.Total, &job.Processed,
+		&result, &errorText, &job.Attempts, &createdBy, &job.CreatedAt, &job.StartedAt, &job.FinishedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	if len(result) > 0 {
+		job.Result = result
+	}
+	job.Error = errorText.String
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		job.CreatedBy = &id
+	}
+	return nil
+}
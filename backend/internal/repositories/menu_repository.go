@@ -1,10 +1,13 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/pkg/database"
 )
@@ -15,238 +18,547 @@ func NewMenuRepository() *MenuRepository {
 	return &MenuRepository{}
 }
 
-func (r *MenuRepository) Create(menu *models.Menu) error {
+const menuColumns = `id, user_id, date, total_calories, total_time, menu_type,
+		       ingredients_used, missing_ingredients, created_at, updated_at`
+
+func (r *MenuRepository) Create(ctx context.Context, menu *models.Menu) error {
 	// Устанавливаем menu_type по умолчанию, если не указан
 	if menu.MenuType == "" {
 		menu.MenuType = "daily"
 	}
-	
-	// Для дневных меню используем ON CONFLICT, для недельных - простой INSERT
-	if menu.MenuType == "daily" {
-		query := `
-			INSERT INTO menus (user_id, date, total_calories, total_price, total_time, menu_type, meals, ingredients_used, missing_ingredients)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-			ON CONFLICT (user_id, date) 
-			WHERE menu_type = 'daily'
-			DO UPDATE SET 
-				total_calories = EXCLUDED.total_calories,
-				total_price = EXCLUDED.total_price,
-				total_time = EXCLUDED.total_time,
-				meals = EXCLUDED.meals,
-				ingredients_used = EXCLUDED.ingredients_used,
-				missing_ingredients = EXCLUDED.missing_ingredients,
-				updated_at = CURRENT_TIMESTAMP
-			RETURNING id, created_at, updated_at
-		`
-		
-		mealsJSON, _ := json.Marshal(menu.Meals)
-		ingredientsUsedJSON, _ := json.Marshal(menu.IngredientsUsed)
-		missingIngredientsJSON, _ := json.Marshal(menu.MissingIngredients)
-		
-		var totalPrice float64 = 0
-		err := database.DB.QueryRow(query,
-			menu.UserID, menu.Date, menu.TotalCalories, totalPrice, menu.TotalTime, menu.MenuType,
-			mealsJSON, ingredientsUsedJSON, missingIngredientsJSON,
-		).Scan(&menu.ID, &menu.CreatedAt, &menu.UpdatedAt)
-		_ = totalPrice
-		
+
+	// Для недельных меню используем CreateWeeklyMenu
+	if menu.MenuType != "daily" {
+		return r.CreateWeeklyMenu(ctx, menu)
+	}
+
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Для дневных меню используем ON CONFLICT - одно меню на дату
+	query := `
+		INSERT INTO menus (user_id, date, total_calories, total_price, total_time, menu_type, ingredients_used, missing_ingredients)
+		VALUES ($1, $2, $3, 0, $4, $5, $6, $7)
+		ON CONFLICT (user_id, date)
+		WHERE menu_type = 'daily'
+		DO UPDATE SET
+			total_calories = EXCLUDED.total_calories,
+			total_time = EXCLUDED.total_time,
+			ingredients_used = EXCLUDED.ingredients_used,
+			missing_ingredients = EXCLUDED.missing_ingredients,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at, updated_at
+	`
+
+	ingredientsUsedJSON, _ := json.Marshal(menu.IngredientsUsed)
+	missingIngredientsJSON, _ := json.Marshal(menu.MissingIngredients)
+
+	err = tx.QueryRowContext(ctx, query,
+		menu.UserID, menu.Date, menu.TotalCalories, menu.TotalTime, menu.MenuType,
+		ingredientsUsedJSON, missingIngredientsJSON,
+	).Scan(&menu.ID, &menu.CreatedAt, &menu.UpdatedAt)
+	if err != nil {
 		return err
 	}
-	
-	// Для недельных меню используем CreateWeeklyMenu
-	return r.CreateWeeklyMenu(menu)
+
+	// Заменяем день меню и приемы пищи
+	if _, err := tx.ExecContext(ctx, `DELETE FROM menu_days WHERE menu_id = $1`, menu.ID); err != nil {
+		return fmt.Errorf("ошибка при очистке дней меню: %w", err)
+	}
+
+	day := models.WeeklyDayMenu{
+		Day:                 1,
+		Date:                menu.Date.Format(models.DateLayout),
+		TotalCalories:       menu.TotalCalories,
+		TotalMicronutrients: menu.TotalMicronutrients,
+		TotalTime:           menu.TotalTime,
+		IngredientsUsed:     menu.IngredientsUsed,
+		MissingIngredients:  menu.MissingIngredients,
+	}
+	dayID, err := r.insertDay(ctx, tx, menu.ID, &day)
+	if err != nil {
+		return err
+	}
+	for i, meal := range menu.Meals {
+		if err := r.insertMeal(ctx, tx, dayID, meal, i+1); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-// CreateWeeklyMenu сохраняет недельное меню
-func (r *MenuRepository) CreateWeeklyMenu(menu *models.Menu) error {
+// CreateWeeklyMenu сохраняет недельное меню вместе с днями (menu.Week)
+func (r *MenuRepository) CreateWeeklyMenu(ctx context.Context, menu *models.Menu) error {
 	menu.MenuType = "weekly"
-	
+
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
 	// Для недельного меню используем простой INSERT без конфликта
 	query := `
-		INSERT INTO menus (user_id, date, total_calories, total_price, total_time, menu_type, meals, ingredients_used, missing_ingredients)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO menus (user_id, date, total_calories, total_price, total_time, menu_type, ingredients_used, missing_ingredients)
+		VALUES ($1, $2, $3, 0, $4, $5, $6, $7)
 		RETURNING id, created_at, updated_at
 	`
-	
-	mealsJSON, _ := json.Marshal(menu.Meals)
+
 	ingredientsUsedJSON, _ := json.Marshal(menu.IngredientsUsed)
 	missingIngredientsJSON, _ := json.Marshal(menu.MissingIngredients)
-	
-	var totalPrice float64 = 0
-	err := database.DB.QueryRow(query,
-		menu.UserID, menu.Date, menu.TotalCalories, totalPrice, menu.TotalTime, menu.MenuType,
-		mealsJSON, ingredientsUsedJSON, missingIngredientsJSON,
+
+	err = tx.QueryRowContext(ctx, query,
+		menu.UserID, menu.Date, menu.TotalCalories, menu.TotalTime, menu.MenuType,
+		ingredientsUsedJSON, missingIngredientsJSON,
 	).Scan(&menu.ID, &menu.CreatedAt, &menu.UpdatedAt)
-	
-	return err
+	if err != nil {
+		return err
+	}
+
+	for i := range menu.Week {
+		day := &menu.Week[i]
+		dayID, err := r.insertDay(ctx, tx, menu.ID, day)
+		if err != nil {
+			return err
+		}
+		for position, meal := range day.MenuMeals() {
+			if err := r.insertMeal(ctx, tx, dayID, meal, position+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
 }
 
-func (r *MenuRepository) GetByUserIDAndDate(userID int, date time.Time) (*models.Menu, error) {
+// insertDay сохраняет день меню
+func (r *MenuRepository) insertDay(ctx context.Context, tx *sql.Tx, menuID int, day *models.WeeklyDayMenu) (int, error) {
 	query := `
-		SELECT id, user_id, date, total_calories, total_price, total_time, menu_type, meals, 
-		       ingredients_used, missing_ingredients, created_at, updated_at
-		FROM menus WHERE user_id = $1 AND date = $2 AND menu_type = 'daily'
+		INSERT INTO menu_days (menu_id, day_number, date, total_calories, total_proteins, total_fats, total_carbs,
+		                       total_micronutrients, total_time, ingredients_used, missing_ingredients)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
 	`
-	
-	var menu models.Menu
-	var mealsJSON, ingredientsUsedJSON, missingIngredientsJSON []byte
-	var totalPrice float64 // Временная переменная (поле в БД есть, но не используем)
-	
-	err := database.DB.QueryRow(query, userID, date).Scan(
-		&menu.ID, &menu.UserID, &menu.Date, &menu.TotalCalories, &totalPrice, &menu.TotalTime, &menu.MenuType,
-		&mealsJSON, &ingredientsUsedJSON, &missingIngredientsJSON,
-		&menu.CreatedAt, &menu.UpdatedAt,
-	)
-	_ = totalPrice // Игнорируем цену
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
+
+	ingredientsUsedJSON, _ := json.Marshal(day.IngredientsUsed)
+	missingIngredientsJSON, _ := json.Marshal(day.MissingIngredients)
+
+	var dayID int
+	err := tx.QueryRowContext(ctx, query,
+		menuID, day.Day, day.Date, day.TotalCalories, day.TotalProteins, day.TotalFats, day.TotalCarbs,
+		day.TotalMicronutrients, day.TotalTime, ingredientsUsedJSON, missingIngredientsJSON,
+	).Scan(&dayID)
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("ошибка при сохранении дня %d: %w", day.Day, err)
 	}
-	
-	json.Unmarshal(mealsJSON, &menu.Meals)
-	json.Unmarshal(ingredientsUsedJSON, &menu.IngredientsUsed)
-	json.Unmarshal(missingIngredientsJSON, &menu.MissingIngredients)
-	
-	return &menu, nil
+	return dayID, nil
 }
 
-func (r *MenuRepository) GetByID(id int) (*models.Menu, error) {
+// insertMeal сохраняет прием пищи дня
+func (r *MenuRepository) insertMeal(ctx context.Context, tx *sql.Tx, dayID int, meal models.MenuMeal, position int) error {
 	query := `
-		SELECT id, user_id, date, total_calories, total_price, total_time, menu_type, meals, 
-		       ingredients_used, missing_ingredients, created_at, updated_at
-		FROM menus WHERE id = $1
+		INSERT INTO menu_meals (menu_day_id, recipe_id, meal_type, calories, cooking_time, position)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
-	
-	var menu models.Menu
-	var mealsJSON, ingredientsUsedJSON, missingIngredientsJSON []byte
-	var totalPrice float64 // Временная переменная (поле в БД есть, но не используем)
-	
-	err := database.DB.QueryRow(query, id).Scan(
-		&menu.ID, &menu.UserID, &menu.Date, &menu.TotalCalories, &totalPrice, &menu.TotalTime, &menu.MenuType,
-		&mealsJSON, &ingredientsUsedJSON, &missingIngredientsJSON,
-		&menu.CreatedAt, &menu.UpdatedAt,
-	)
-	_ = totalPrice // Игнорируем цену
-	if err == sql.ErrNoRows {
-		return nil, nil
+	_, err := tx.ExecContext(ctx, query, dayID, meal.RecipeID, meal.MealType, meal.Calories, meal.Time, position)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении приема пищи (рецепт %d): %w", meal.RecipeID, err)
 	}
+	return nil
+}
+
+func (r *MenuRepository) GetByUserIDAndDate(ctx context.Context, userID int, date time.Time) (*models.Menu, error) {
+	query := `SELECT ` + menuColumns + `
+		FROM menus WHERE user_id = $1 AND date = $2 AND menu_type = 'daily'`
+
+	return r.queryOne(ctx, query, userID, date)
+}
+
+func (r *MenuRepository) GetByID(ctx context.Context, id int) (*models.Menu, error) {
+	query := `SELECT ` + menuColumns + `
+		FROM menus WHERE id = $1`
+
+	return r.queryOne(ctx, query, id)
+}
+
+// GetWeeklyMenusByUserID получает все недельные меню пользователя
+func (r *MenuRepository) GetWeeklyMenusByUserID(ctx context.Context, userID int) ([]models.Menu, error) {
+	query := `SELECT ` + menuColumns + `
+		FROM menus WHERE user_id = $1 AND menu_type = 'weekly' ORDER BY date DESC`
+
+	return r.queryMany(ctx, query, userID)
+}
+
+func (r *MenuRepository) GetAllByUserID(ctx context.Context, userID int) ([]models.Menu, error) {
+	query := `SELECT ` + menuColumns + `
+		FROM menus WHERE user_id = $1 AND menu_type = 'daily' ORDER BY date DESC`
+
+	return r.queryMany(ctx, query, userID)
+}
+
+// GetWeeklyByUserIDAndDate находит последнее недельное меню пользователя, покрывающее дату,
+// и возвращает его вместе с днями недели
+func (r *MenuRepository) GetWeeklyByUserIDAndDate(ctx context.Context, userID int, date time.Time) (*models.Menu, []models.WeeklyDayMenu, error) {
+	query := `SELECT ` + menuColumns + `
+		FROM menus m
+		WHERE user_id = $1 AND menu_type = 'weekly'
+		  AND EXISTS (SELECT 1 FROM menu_days d WHERE d.menu_id = m.id AND d.date = $2)
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	menu, err := r.queryOne(ctx, query, userID, date)
+	if err != nil || menu == nil {
+		return nil, nil, err
+	}
+	return menu, menu.Week, nil
+}
+
+// GetWeeklyDays возвращает дни недельного меню
+func (r *MenuRepository) GetWeeklyDays(ctx context.Context, menuID int) ([]models.WeeklyDayMenu, error) {
+	days, _, err := r.loadDays(ctx, []int{menuID})
 	if err != nil {
 		return nil, err
 	}
-	
-	json.Unmarshal(mealsJSON, &menu.Meals)
-	json.Unmarshal(ingredientsUsedJSON, &menu.IngredientsUsed)
-	json.Unmarshal(missingIngredientsJSON, &menu.MissingIngredients)
-	
-	return &menu, nil
+	return days[menuID], nil
 }
 
-// GetWeeklyMenusByUserID получает все недельные меню пользователя
-func (r *MenuRepository) GetWeeklyMenusByUserID(userID int) ([]models.Menu, error) {
-	query := `
-		SELECT id, user_id, date, total_calories, total_price, total_time, menu_type, meals, 
-		       ingredients_used, missing_ingredients, created_at, updated_at
-		FROM menus WHERE user_id = $1 AND menu_type = 'weekly' ORDER BY date DESC
-	`
-	
-	rows, err := database.DB.Query(query, userID)
+// Delete удаляет меню по ID, проверяя принадлежность пользователю
+func (r *MenuRepository) Delete(ctx context.Context, id, userID int) error {
+	query := `DELETE FROM menus WHERE id = $1 AND user_id = $2`
+	result, err := database.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// queryOne выполняет запрос одного меню и загружает его дни
+func (r *MenuRepository) queryOne(ctx context.Context, query string, args ...interface{}) (*models.Menu, error) {
+	menus, err := r.queryMany(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(menus) == 0 {
+		return nil, nil
+	}
+	return &menus[0], nil
+}
+
+// queryMany выполняет запрос меню и загружает дни всех меню одним запросом
+func (r *MenuRepository) queryMany(ctx context.Context, query string, args ...interface{}) ([]models.Menu, error) {
+	rows, err := database.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	
-	var menus []models.Menu
+
+	menus := []models.Menu{}
 	for rows.Next() {
 		var menu models.Menu
-		var mealsJSON, ingredientsUsedJSON, missingIngredientsJSON []byte
-		var totalPrice float64
-		
+		var ingredientsUsedJSON, missingIngredientsJSON []byte
+
 		err := rows.Scan(
-			&menu.ID, &menu.UserID, &menu.Date, &menu.TotalCalories, &totalPrice, &menu.TotalTime, &menu.MenuType,
-			&mealsJSON, &ingredientsUsedJSON, &missingIngredientsJSON,
+			&menu.ID, &menu.UserID, &menu.Date, &menu.TotalCalories, &menu.TotalTime, &menu.MenuType,
+			&ingredientsUsedJSON, &missingIngredientsJSON,
 			&menu.CreatedAt, &menu.UpdatedAt,
 		)
-		_ = totalPrice
 		if err != nil {
 			return nil, err
 		}
-		
-		// Для недельных меню meals содержит JSON с данными недели, а не MenuMeals
-		// Поэтому мы просто сохраняем JSON как есть, без парсинга в MenuMeals
-		// При чтении фронтенд сам распарсит JSON
-		// Но для совместимости с моделью Menu, мы создаем пустой MenuMeals
-		menu.Meals = models.MenuMeals{}
-		
-		// Сохраняем сырой JSON в поле Meals через интерфейс Value()
-		// Но это не сработает, так как MenuMeals имеет свой Value()
-		// Вместо этого, мы просто оставляем Meals пустым, а фронтенд будет читать meals напрямую из JSON ответа
-		
+
 		json.Unmarshal(ingredientsUsedJSON, &menu.IngredientsUsed)
 		json.Unmarshal(missingIngredientsJSON, &menu.MissingIngredients)
-		
+
 		menus = append(menus, menu)
 	}
-	
-	return menus, rows.Err()
-}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(menus) == 0 {
+		return menus, nil
+	}
 
-// Delete удаляет меню по ID, проверяя принадлежность пользователю
-func (r *MenuRepository) Delete(id, userID int) error {
-	query := `DELETE FROM menus WHERE id = $1 AND user_id = $2`
-	result, err := database.DB.Exec(query, id, userID)
+	menuIDs := make([]int, 0, len(menus))
+	for _, menu := range menus {
+		menuIDs = append(menuIDs, menu.ID)
+	}
+	days, meals, err := r.loadDays(ctx, menuIDs)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	
-	rowsAffected, err := result.RowsAffected()
+
+	for i := range menus {
+		menu := &menus[i]
+		menu.Meals = models.MenuMeals{}
+		if menu.MenuType == "weekly" {
+			menu.Week = days[menu.ID]
+			continue
+		}
+		if menuMeals, ok := meals[menu.ID]; ok {
+			menu.Meals = menuMeals
+		}
+		if menuDays := days[menu.ID]; len(menuDays) > 0 {
+			menu.TotalMicronutrients = menuDays[0].TotalMicronutrients
+		}
+	}
+
+	return menus, nil
+}
+
+// loadDays загружает дни (с рецептами) и приемы пищи для списка меню.
+// Чужие личные рецепты не возвращаются: такие приемы пищи пропускаются
+func (r *MenuRepository) loadDays(ctx context.Context, menuIDs []int) (map[int][]models.WeeklyDayMenu, map[int]models.MenuMeals, error) {
+	query := `
+		SELECT d.menu_id, d.id, d.day_number, d.date, d.total_calories, d.total_proteins, d.total_fats, d.total_carbs,
+		       d.total_micronutrients, d.total_time, d.ingredients_used, d.missing_ingredients,
+		       mm.meal_type, mm.calories, mm.cooking_time, r.id, r.name, r.description, r.calories, r.proteins, r.fats, r.carbs,
+		       r.micronutrients, r.cooking_time, r.servings, r.meal_type, r.ingredients, r.instructions,
+		       r.steps, r.active_time
+		FROM menu_days d
+		JOIN menus m ON m.id = d.menu_id
+		LEFT JOIN menu_meals mm ON mm.menu_day_id = d.id
+		LEFT JOIN recipes r ON r.id = mm.recipe_id AND (r.owner_id IS NULL OR r.owner_id = m.user_id)
+		WHERE d.menu_id = ANY($1)
+		ORDER BY d.menu_id, d.day_number, mm.position
+	`
+
+	rows, err := database.DB.QueryContext(ctx, query, pq.Array(menuIDs))
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
+	defer rows.Close()
+
+	result := make(map[int][]models.WeeklyDayMenu)
+	meals := make(map[int]models.MenuMeals)
+	lastDayID := 0
+	var current *models.WeeklyDayMenu
+	var currentMenuID int
+
+	flush := func() {
+		if current != nil {
+			result[currentMenuID] = append(result[currentMenuID], *current)
+		}
 	}
-	return nil
+
+	for rows.Next() {
+		var menuID, dayID int
+		var day models.WeeklyDayMenu
+		var date time.Time
+		var ingredientsUsedJSON, missingIngredientsJSON []byte
+		var mealCalories, mealTime sql.NullInt64
+		var mealType, recipeName, recipeDescription, recipeMealType sql.NullString
+		var recipeID, recipeCalories, recipeCookingTime, recipeServings sql.NullInt64
+		var recipeProteins, recipeFats, recipeCarbs sql.NullFloat64
+		var recipeIngredientsJSON, recipeMicronutrientsJSON []byte
+		var recipeInstructions []string
+		var recipeSteps models.RecipeSteps
+		var recipeActiveTime sql.NullInt64
+
+		err := rows.Scan(
+			&menuID, &dayID, &day.Day, &date, &day.TotalCalories, &day.TotalProteins, &day.TotalFats, &day.TotalCarbs,
+			&day.TotalMicronutrients, &day.TotalTime, &ingredientsUsedJSON, &missingIngredientsJSON,
+			&mealType, &mealCalories, &mealTime, &recipeID, &recipeName, &recipeDescription, &recipeCalories, &recipeProteins, &recipeFats, &recipeCarbs,
+			&recipeMicronutrientsJSON, &recipeCookingTime, &recipeServings, &recipeMealType, &recipeIngredientsJSON, pq.Array(&recipeInstructions),
+			&recipeSteps, &recipeActiveTime,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if dayID != lastDayID {
+			flush()
+			day.Date = date.Format(models.DateLayout)
+			json.Unmarshal(ingredientsUsedJSON, &day.IngredientsUsed)
+			json.Unmarshal(missingIngredientsJSON, &day.MissingIngredients)
+			current = &day
+			currentMenuID = menuID
+			lastDayID = dayID
+		}
+
+		if !recipeID.Valid {
+			continue
+		}
+
+		meals[menuID] = append(meals[menuID], models.MenuMeal{
+			RecipeID: int(recipeID.Int64),
+			MealType: mealType.String,
+			Calories: int(mealCalories.Int64),
+			Time:     int(mealTime.Int64),
+		})
+
+		recipe := &models.RecipeDTO{
+			ID:           int(recipeID.Int64),
+			Name:         recipeName.String,
+			Description:  recipeDescription.String,
+			Calories:     int(recipeCalories.Int64),
+			Proteins:     recipeProteins.Float64,
+			Fats:         recipeFats.Float64,
+			Carbs:        recipeCarbs.Float64,
+			CookingTime:  int(recipeCookingTime.Int64),
+			Servings:     int(recipeServings.Int64),
+			MealType:     recipeMealType.String,
+			Instructions: recipeInstructions,
+			Steps:        recipeSteps,
+			ActiveTime:   int(recipeActiveTime.Int64),
+			Equipment:    recipeSteps.Equipment(),
+		}
+		if len(recipeMicronutrientsJSON) > 0 {
+			json.Unmarshal(recipeMicronutrientsJSON, &recipe.Micronutrients)
+		}
+		if len(recipeIngredientsJSON) > 0 {
+			json.Unmarshal(recipeIngredientsJSON, &recipe.Ingredients)
+		} else {
+			recipe.Ingredients = models.Ingredients{}
+		}
+
+		switch mealType.String {
+		case "breakfast":
+			current.Breakfast = recipe
+		case "lunch":
+			current.Lunch = recipe
+		case "dinner":
+			current.Dinner = recipe
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	flush()
+
+	return result, meals, nil
 }
 
-func (r *MenuRepository) GetAllByUserID(userID int) ([]models.Menu, error) {
+// FindPlannedMeal ищет запланированный прием пищи пользователя на дату.
+// Дневное меню имеет приоритет над недельным, среди недельных - последнее созданное.
+func (r *MenuRepository) FindPlannedMeal(ctx context.Context, userID int, date time.Time, mealType string) (*models.PlannedMeal, error) {
 	query := `
-		SELECT id, user_id, date, total_calories, total_price, total_time, menu_type, meals, 
-		       ingredients_used, missing_ingredients, created_at, updated_at
-		FROM menus WHERE user_id = $1 AND menu_type = 'daily' ORDER BY date DESC
+		SELECT mm.id, m.id, mm.recipe_id, mm.meal_type, d.date
+		FROM menu_meals mm
+		JOIN menu_days d ON d.id = mm.menu_day_id
+		JOIN menus m ON m.id = d.menu_id
+		WHERE m.user_id = $1 AND d.date = $2 AND mm.meal_type = $3
+		ORDER BY (m.menu_type = 'daily') DESC, m.created_at DESC
+		LIMIT 1
+	`
+
+	var meal models.PlannedMeal
+	err := database.DB.QueryRowContext(ctx, query, userID, date, mealType).Scan(
+		&meal.MenuMealID, &meal.MenuID, &meal.RecipeID, &meal.MealType, &meal.Date,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &meal, nil
+}
+
+// GetPlannedMeal возвращает прием пищи из меню пользователя (nil, если не найден)
+func (r *MenuRepository) GetPlannedMeal(ctx context.Context, userID, menuMealID int) (*models.PlannedMeal, error) {
+	query := `
+		SELECT mm.id, m.id, mm.recipe_id, mm.meal_type, d.date
+		FROM menu_meals mm
+		JOIN menu_days d ON d.id = mm.menu_day_id
+		JOIN menus m ON m.id = d.menu_id
+		WHERE m.user_id = $1 AND mm.id = $2
+	`
+
+	var meal models.PlannedMeal
+	err := database.DB.QueryRowContext(ctx, query, userID, menuMealID).Scan(
+		&meal.MenuMealID, &meal.MenuID, &meal.RecipeID, &meal.MealType, &meal.Date,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &meal, nil
+}
+
+// plannedDaysCTE выбирает по одному дню меню на дату: дневное меню имеет приоритет
+// над недельным, среди недельных - последнее созданное
+const plannedDaysCTE = `
+	WITH planned_days AS (
+		SELECT DISTINCT ON (d.date) d.id, d.date, d.ingredients_used
+		FROM menu_days d
+		JOIN menus m ON m.id = d.menu_id
+		WHERE m.user_id = $1 AND d.date BETWEEN $2 AND $3
+		ORDER BY d.date, (m.menu_type = 'daily') DESC, m.created_at DESC
+	)`
+
+// GetPlannedMealsInRange возвращает запланированные приемы пищи за период [from, to]
+func (r *MenuRepository) GetPlannedMealsInRange(ctx context.Context, userID int, from, to time.Time) ([]models.PlannedMealDetail, error) {
+	query := plannedDaysCTE + `
+		SELECT pd.date, mm.id, mm.meal_type, r.id, r.name, r.calories, r.proteins, r.fats, r.carbs,
+		       r.servings, r.cooking_time
+		FROM planned_days pd
+		JOIN menu_meals mm ON mm.menu_day_id = pd.id
+		JOIN recipes r ON r.id = mm.recipe_id AND (r.owner_id IS NULL OR r.owner_id = $1)
+		ORDER BY pd.date, mm.position
 	`
-	
-	rows, err := database.DB.Query(query, userID)
+
+	rows, err := database.DB.QueryContext(ctx, query, userID, from, to)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	
-	var menus []models.Menu
+
+	meals := []models.PlannedMealDetail{}
 	for rows.Next() {
-		var menu models.Menu
-		var mealsJSON, ingredientsUsedJSON, missingIngredientsJSON []byte
-		var totalPrice float64 // Временная переменная (поле в БД есть, но не используем)
-		
+		var meal models.PlannedMealDetail
+		var date time.Time
+		var servings sql.NullInt64
 		err := rows.Scan(
-			&menu.ID, &menu.UserID, &menu.Date, &menu.TotalCalories, &totalPrice, &menu.TotalTime, &menu.MenuType,
-			&mealsJSON, &ingredientsUsedJSON, &missingIngredientsJSON,
-			&menu.CreatedAt, &menu.UpdatedAt,
+			&date, &meal.MenuMealID, &meal.MealType, &meal.RecipeID, &meal.RecipeName,
+			&meal.Calories, &meal.Proteins, &meal.Fats, &meal.Carbs, &servings, &meal.CookingTime,
 		)
-		_ = totalPrice // Игнорируем цену
 		if err != nil {
 			return nil, err
 		}
-		
-		json.Unmarshal(mealsJSON, &menu.Meals)
-		json.Unmarshal(ingredientsUsedJSON, &menu.IngredientsUsed)
-		json.Unmarshal(missingIngredientsJSON, &menu.MissingIngredients)
-		
-		menus = append(menus, menu)
+		meal.Date = date.Format(models.DateLayout)
+		meal.Servings = int(servings.Int64)
+		meals = append(meals, meal)
 	}
-	
-	return menus, rows.Err()
+
+	return meals, rows.Err()
 }
 
+// GetPantryUsageInRange возвращает продукты из кладовой, использованные в планах за период
+func (r *MenuRepository) GetPantryUsageInRange(ctx context.Context, userID int, from, to time.Time) (models.Ingredients, error) {
+	query := plannedDaysCTE + `
+		SELECT pd.ingredients_used FROM planned_days pd
+	`
+
+	rows, err := database.DB.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	used := models.Ingredients{}
+	for rows.Next() {
+		var ingredientsJSON []byte
+		if err := rows.Scan(&ingredientsJSON); err != nil {
+			return nil, err
+		}
+		var dayUsed models.Ingredients
+		json.Unmarshal(ingredientsJSON, &dayUsed)
+		used = append(used, dayUsed...)
+	}
+
+	return used, rows.Err()
+}
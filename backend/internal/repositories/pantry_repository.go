@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/pkg/database"
@@ -12,11 +13,11 @@ func NewPantryRepository() *PantryRepository {
 	return &PantryRepository{}
 }
 
-func (r *PantryRepository) GetByUserID(userID int) ([]models.PantryItem, error) {
+func (r *PantryRepository) GetByUserID(ctx context.Context, userID int) ([]models.PantryItem, error) {
 	query := `SELECT id, user_id, name, quantity, unit, created_at, updated_at 
 	         FROM pantry_items WHERE user_id = $1 ORDER BY name`
 	
-	rows, err := database.DB.Query(query, userID)
+	rows, err := database.DB.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -38,19 +39,19 @@ func (r *PantryRepository) GetByUserID(userID int) ([]models.PantryItem, error)
 	return items, rows.Err()
 }
 
-func (r *PantryRepository) Create(item *models.PantryItem) error {
+func (r *PantryRepository) Create(ctx context.Context, item *models.PantryItem) error {
 	query := `INSERT INTO pantry_items (user_id, name, quantity, unit)
 	         VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
 	
-	err := database.DB.QueryRow(query, item.UserID, item.Name, item.Quantity, item.Unit).Scan(
+	err := database.DB.QueryRowContext(ctx, query, item.UserID, item.Name, item.Quantity, item.Unit).Scan(
 		&item.ID, &item.CreatedAt, &item.UpdatedAt,
 	)
 	return err
 }
 
-func (r *PantryRepository) Delete(id, userID int) error {
+func (r *PantryRepository) Delete(ctx context.Context, id, userID int) error {
 	query := `DELETE FROM pantry_items WHERE id = $1 AND user_id = $2`
-	result, err := database.DB.Exec(query, id, userID)
+	result, err := database.DB.ExecContext(ctx, query, id, userID)
 	if err != nil {
 		return err
 	}
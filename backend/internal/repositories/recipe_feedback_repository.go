@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/pkg/database"
+)
+
+type RecipeFeedbackRepository struct{}
+
+func NewRecipeFeedbackRepository() *RecipeFeedbackRepository {
+	return &RecipeFeedbackRepository{}
+}
+
+// Get возвращает отметки пользователя для рецепта (nil, если их нет)
+func (r *RecipeFeedbackRepository) Get(ctx context.Context, userID, recipeID int) (*models.RecipeFeedback, error) {
+	query := `
+		SELECT f.user_id, f.recipe_id, r.name, f.rating, f.favorite, f.banned, f.updated_at
+		FROM recipe_feedback f
+		JOIN recipes r ON r.id = f.recipe_id
+		WHERE f.user_id = $1 AND f.recipe_id = $2
+	`
+
+	feedback, err := scanRecipeFeedback(database.DB.QueryRowContext(ctx, query, userID, recipeID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return feedback, err
+}
+
+// GetByUserID возвращает все отметки пользователя
+func (r *RecipeFeedbackRepository) GetByUserID(ctx context.Context, userID int) ([]models.RecipeFeedback, error) {
+	query := `
+		SELECT f.user_id, f.recipe_id, r.name, f.rating, f.favorite, f.banned, f.updated_at
+		FROM recipe_feedback f
+		JOIN recipes r ON r.id = f.recipe_id
+		WHERE f.user_id = $1
+		ORDER BY f.updated_at DESC
+	`
+
+	rows, err := database.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feedback := []models.RecipeFeedback{}
+	for rows.Next() {
+		item, err := scanRecipeFeedback(rows)
+		if err != nil {
+			return nil, err
+		}
+		feedback = append(feedback, *item)
+	}
+	return feedback, rows.Err()
+}
+
+// Upsert сохраняет отметки пользователя для рецепта
+func (r *RecipeFeedbackRepository) Upsert(ctx context.Context, feedback *models.RecipeFeedback) error {
+	query := `
+		INSERT INTO recipe_feedback (user_id, recipe_id, rating, favorite, banned)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, recipe_id)
+		DO UPDATE SET
+			rating = EXCLUDED.rating,
+			favorite = EXCLUDED.favorite,
+			banned = EXCLUDED.banned,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	var rating sql.NullInt64
+	if feedback.Rating != nil {
+		rating = sql.NullInt64{Int64: int64(*feedback.Rating), Valid: true}
+	}
+	return database.DB.QueryRowContext(ctx, query, feedback.UserID, feedback.RecipeID, rating, feedback.Favorite, feedback.Banned).
+		Scan(&feedback.UpdatedAt)
+}
+
+// Delete удаляет отметки пользователя для рецепта
+func (r *RecipeFeedbackRepository) Delete(ctx context.Context, userID, recipeID int) error {
+	_, err := database.DB.ExecContext(ctx, `DELETE FROM recipe_feedback WHERE user_id = $1 AND recipe_id = $2`, userID, recipeID)
+	return err
+}
+
+func scanRecipeFeedback(row interface{ Scan(...interface{}) error }) (*models.RecipeFeedback, error) {
+	var feedback models.RecipeFeedback
+	var rating sql.NullInt64
+	if err := row.Scan(&feedback.UserID, &feedback.RecipeID, &feedback.RecipeName, &rating,
+		&feedback.Favorite, &feedback.Banned, &feedback.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if rating.Valid {
+		value := int(rating.Int64)
+		feedback.Rating = &value
+	}
+	return &feedback, nil
+}
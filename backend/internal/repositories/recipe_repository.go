@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -17,12 +18,154 @@ func NewRecipeRepository() *RecipeRepository {
 	return &RecipeRepository{}
 }
 
-func (r *RecipeRepository) GetAll() ([]models.Recipe, error) {
-	query := `SELECT id, name, description, calories, proteins, fats, carbs, price, cooking_time, servings,
-	         meal_type, diet_type, allergens, ingredients, instructions, image_url, created_at, updated_at
-	         FROM recipes ORDER BY name`
+const recipeColumns = `id, name, description, calories, proteins, fats, carbs, price, cooking_time, servings,
+	         meal_type, diet_type, allergens, ingredients, instructions, image_url, image_thumbnails,
+	         micronutrients, micronutrients_source, compliance_issues, owner_id, steps, prep_time, active_time,
+	         cuisine, seasons, difficulty, created_at, updated_at`
+
+// GetAll возвращает рецепты общего каталога (без личных рецептов пользователей)
+func (r *RecipeRepository) GetAll(ctx context.Context) ([]models.Recipe, error) {
+	query := `SELECT ` + recipeColumns + `
+	         FROM recipes WHERE owner_id IS NULL ORDER BY name`
+	
+	return r.queryRecipes(ctx, query)
+}
+
+// GetAllIncludingOwned возвращает все рецепты: общий каталог и личные рецепты пользователей
+func (r *RecipeRepository) GetAllIncludingOwned(ctx context.Context) ([]models.Recipe, error) {
+	query := `SELECT ` + recipeColumns + `
+	         FROM recipes ORDER BY name, id`
+	
+	return r.queryRecipes(ctx, query)
+}
+
+func (r *RecipeRepository) GetByID(ctx context.Context, id int) (*models.Recipe, error) {
+	query := `SELECT ` + recipeColumns + `
+	         FROM recipes WHERE id = $1`
+	
+	var recipe models.Recipe
+	err := scanRecipe(database.DB.QueryRowContext(ctx, query, id), &recipe)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	return &recipe, nil
+}
+
+// GetFiltered возвращает рецепты общего каталога и личные рецепты ownerID по фильтрам.
+// Рецепт должен соответствовать всем диетам из dietTypes и не содержать ни одного
+// аллергена из allergies.
+func (r *RecipeRepository) GetFiltered(ctx context.Context, ownerID int, dietTypes []string, allergies []string, mealTypes []string, maxCalories, maxPrice, maxTime *int) ([]models.Recipe, error) {
+	// maxPrice игнорируется - цены больше не используются
+	filter := &models.RecipeFilter{
+		DietTypes:   dietTypes,
+		Allergies:   allergies,
+		MealTypes:   mealTypes,
+		MaxCalories: maxCalories,
+		MaxTime:     maxTime,
+	}
+	conditions, args := recipeFilterConditions(filter, []string{"(owner_id IS NULL OR owner_id = $1)"}, []interface{}{ownerID})
+
+	query := `SELECT ` + recipeColumns + `
+	         FROM recipes WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY name`
+
+	return r.queryRecipes(ctx, query, args...)
+}
+
+// GetCatalog возвращает рецепты общего каталога по фильтрам
+func (r *RecipeRepository) GetCatalog(ctx context.Context, filter *models.RecipeFilter) ([]models.Recipe, error) {
+	conditions, args := recipeFilterConditions(filter, []string{"owner_id IS NULL"}, nil)
+	query := `SELECT ` + recipeColumns + `
+	         FROM recipes WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY name`
+	
+	return r.queryRecipes(ctx, query, args...)
+}
+
+// StreamCatalog передает рецепты общего каталога по фильтрам в fn по одному, не загружая
+// весь каталог в память. Порядок (по названию, затем по ID) не зависит от базы.
+func (r *RecipeRepository) StreamCatalog(ctx context.Context, filter *models.RecipeFilter, fn func(recipe *models.Recipe) error) error {
+	conditions, args := recipeFilterConditions(filter, []string{"owner_id IS NULL"}, nil)
+	query := `SELECT ` + recipeColumns + `
+	         FROM recipes WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY name, id`
+	
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	
+	for rows.Next() {
+		var recipe models.Recipe
+		if err := scanRecipe(rows, &recipe); err != nil {
+			return err
+		}
+		if err := fn(&recipe); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// recipeFilterConditions добавляет условия фильтра к conditions; номера параметров
+// продолжают args
+func recipeFilterConditions(filter *models.RecipeFilter, conditions []string, args []interface{}) ([]string, []interface{}) {
+	next := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	
+	if len(filter.DietTypes) > 0 {
+		conditions = append(conditions, "diet_type @> "+next(pq.Array(filter.DietTypes)))
+	}
+	
+	if len(filter.Allergies) > 0 {
+		var allergyConditions []string
+		for _, allergy := range filter.Allergies {
+			allergyConditions = append(allergyConditions, next(allergy)+" != ALL(allergens)")
+		}
+		conditions = append(conditions, "("+strings.Join(allergyConditions, " AND ")+")")
+	}
+	
+	if len(filter.MealTypes) > 0 {
+		conditions = append(conditions, "meal_type = ANY("+next(pq.Array(filter.MealTypes))+")")
+	}
+	
+	if filter.MaxCalories != nil {
+		conditions = append(conditions, "calories <= "+next(*filter.MaxCalories))
+	}
+	
+	if filter.MaxTime != nil {
+		conditions = append(conditions, "cooking_time <= "+next(*filter.MaxTime))
+	}
 	
-	rows, err := database.DB.Query(query)
+	if len(filter.Cuisines) > 0 {
+		conditions = append(conditions, "cuisine = ANY("+next(pq.Array(filter.Cuisines))+")")
+	}
+	
+	if len(filter.Seasons) > 0 {
+		conditions = append(conditions, "(seasons = '{}' OR seasons && "+next(pq.Array(filter.Seasons))+")")
+	}
+	
+	if len(filter.Difficulties) > 0 {
+		conditions = append(conditions, "difficulty = ANY("+next(pq.Array(filter.Difficulties))+")")
+	}
+	
+	if filter.UpdatedSince != nil {
+		conditions = append(conditions, "updated_at >= "+next(*filter.UpdatedSince))
+	}
+	
+	if len(conditions) == 0 {
+		conditions = append(conditions, "TRUE")
+	}
+	return conditions, args
+}
+
+// queryRecipes выполняет запрос списка рецептов (колонки recipeColumns)
+func (r *RecipeRepository) queryRecipes(ctx context.Context, query string, args ...interface{}) ([]models.Recipe, error) {
+	rows, err := database.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -31,171 +174,100 @@ func (r *RecipeRepository) GetAll() ([]models.Recipe, error) {
 	var recipes []models.Recipe
 	for rows.Next() {
 		var recipe models.Recipe
-		var dietType, allergens, instructions []string
-		var ingredientsJSON []byte
-		var description, mealType, imageURL sql.NullString
-		
-		var price float64 // Временная переменная для сканирования (поле в БД есть, но не используем)
-		err := rows.Scan(
-			&recipe.ID, &recipe.Name, &description, &recipe.Calories, &recipe.Proteins,
-			&recipe.Fats, &recipe.Carbs, &price, &recipe.CookingTime, &recipe.Servings,
-			&mealType, pq.Array(&dietType), pq.Array(&allergens), &ingredientsJSON, pq.Array(&instructions),
-			&imageURL, &recipe.CreatedAt, &recipe.UpdatedAt,
-		)
-		_ = price // Игнорируем цену
-		if err != nil {
+		if err := scanRecipe(rows, &recipe); err != nil {
 			return nil, err
 		}
-		
-		recipe.Description = description.String
-		recipe.MealType = mealType.String
-		recipe.ImageURL = imageURL.String
-		recipe.DietType = dietType
-		recipe.Allergens = allergens
-		recipe.Instructions = instructions
-		if ingredientsJSON != nil && len(ingredientsJSON) > 0 {
-			json.Unmarshal(ingredientsJSON, &recipe.Ingredients)
-		} else {
-			recipe.Ingredients = models.Ingredients{}
-		}
-		
 		recipes = append(recipes, recipe)
 	}
 	
 	return recipes, rows.Err()
 }
 
-func (r *RecipeRepository) GetByID(id int) (*models.Recipe, error) {
-	query := `SELECT id, name, description, calories, proteins, fats, carbs, price, cooking_time, servings,
-	         meal_type, diet_type, allergens, ingredients, instructions, image_url, created_at, updated_at
-	         FROM recipes WHERE id = $1`
-	
-	var recipe models.Recipe
-	var dietType, allergens, instructions []string
+// scanRecipe сканирует строку с колонками recipeColumns
+func scanRecipe(row interface{ Scan(...interface{}) error }, recipe *models.Recipe) error {
+	var dietType, allergens, instructions, seasons []string
 	var ingredientsJSON []byte
-	var description, mealType, imageURL sql.NullString
+	var description, mealType, imageURL, micronutrientsSource, cuisine, difficulty sql.NullString
+	var ownerID sql.NullInt64
 	
 	var price float64 // Временная переменная для сканирования (поле в БД есть, но не используем)
-	err := database.DB.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&recipe.ID, &recipe.Name, &description, &recipe.Calories, &recipe.Proteins,
 		&recipe.Fats, &recipe.Carbs, &price, &recipe.CookingTime, &recipe.Servings,
 		&mealType, pq.Array(&dietType), pq.Array(&allergens), &ingredientsJSON, pq.Array(&instructions),
-		&imageURL, &recipe.CreatedAt, &recipe.UpdatedAt,
+		&imageURL, &recipe.Thumbnails, &recipe.Micronutrients, &micronutrientsSource, &recipe.ComplianceIssues, &ownerID,
+		&recipe.Steps, &recipe.PrepTime, &recipe.ActiveTime,
+		&cuisine, pq.Array(&seasons), &difficulty, &recipe.CreatedAt, &recipe.UpdatedAt,
 	)
 	_ = price // Игнорируем цену
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 	
 	recipe.Description = description.String
 	recipe.MealType = mealType.String
 	recipe.ImageURL = imageURL.String
+	recipe.MicronutrientsSource = micronutrientsSource.String
+	recipe.Cuisine = cuisine.String
+	recipe.Seasons = seasons
+	recipe.Difficulty = difficulty.String
+	if ownerID.Valid {
+		owner := int(ownerID.Int64)
+		recipe.OwnerID = &owner
+	}
 	recipe.DietType = dietType
 	recipe.Allergens = allergens
 	recipe.Instructions = instructions
+	recipe.Equipment = recipe.Steps.Equipment()
 	if ingredientsJSON != nil && len(ingredientsJSON) > 0 {
 		json.Unmarshal(ingredientsJSON, &recipe.Ingredients)
 	} else {
 		recipe.Ingredients = models.Ingredients{}
 	}
-	
-	return &recipe, nil
+	return nil
 }
 
-func (r *RecipeRepository) GetFiltered(dietType string, allergies []string, mealTypes []string, maxCalories, maxPrice, maxTime *int) ([]models.Recipe, error) {
-	var conditions []string
-	var args []interface{}
-	argIndex := 1
-
-	if dietType != "" {
-		conditions = append(conditions, fmt.Sprintf("$%d = ANY(diet_type)", argIndex))
-		args = append(args, dietType)
-		argIndex++
-	}
-
-	if len(allergies) > 0 {
-		var allergyConditions []string
-		for _, allergy := range allergies {
-			allergyConditions = append(allergyConditions, fmt.Sprintf("$%d != ALL(allergens)", argIndex))
-			args = append(args, allergy)
-			argIndex++
-		}
-		conditions = append(conditions, "("+strings.Join(allergyConditions, " AND ")+")")
-	}
+// GetByOwner возвращает личные рецепты пользователя
+func (r *RecipeRepository) GetByOwner(ctx context.Context, ownerID int) ([]models.Recipe, error) {
+	query := `SELECT ` + recipeColumns + `
+	         FROM recipes WHERE owner_id = $1 ORDER BY name`
+	
+	return r.queryRecipes(ctx, query, ownerID)
+}
 
-	if len(mealTypes) > 0 {
-		conditions = append(conditions, fmt.Sprintf("meal_type = ANY($%d)", argIndex))
-		args = append(args, pq.Array(mealTypes))
-		argIndex++
+// DeleteOwned удаляет личный рецепт пользователя
+func (r *RecipeRepository) DeleteOwned(ctx context.Context, id, ownerID int) error {
+	result, err := database.DB.ExecContext(ctx, `DELETE FROM recipes WHERE id = $1 AND owner_id = $2`, id, ownerID)
+	if err != nil {
+		return err
 	}
-
-	if maxCalories != nil {
-		conditions = append(conditions, fmt.Sprintf("calories <= $%d", argIndex))
-		args = append(args, *maxCalories)
-		argIndex++
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
 	}
-
-	// maxPrice игнорируется - цены больше не используются
-
-	if maxTime != nil {
-		conditions = append(conditions, fmt.Sprintf("cooking_time <= $%d", argIndex))
-		args = append(args, *maxTime)
-		argIndex++
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
 	}
+	return nil
+}
 
-	query := `SELECT id, name, description, calories, proteins, fats, carbs, price, cooking_time, servings,
-	         meal_type, diet_type, allergens, ingredients, instructions, image_url, created_at, updated_at
-	         FROM recipes`
-	
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+// UpdateImage сохраняет изображение рецепта и его уменьшенные копии
+func (r *RecipeRepository) UpdateImage(ctx context.Context, id int, imageURL string, thumbnails models.ImageThumbnails) error {
+	result, err := database.DB.ExecContext(ctx, `
+		UPDATE recipes SET image_url = $2, image_thumbnails = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, id, imageURL, thumbnails)
+	if err != nil {
+		return err
 	}
 	
-	query += " ORDER BY name"
-
-	rows, err := database.DB.Query(query, args...)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
-
-	var recipes []models.Recipe
-	for rows.Next() {
-		var recipe models.Recipe
-		var dietTypeArr, allergensArr, instructions []string
-		var ingredientsJSON []byte
-		var description, mealType, imageURL sql.NullString
-		
-		var price float64 // Временная переменная для сканирования (поле в БД есть, но не используем)
-		err := rows.Scan(
-			&recipe.ID, &recipe.Name, &description, &recipe.Calories, &recipe.Proteins,
-			&recipe.Fats, &recipe.Carbs, &price, &recipe.CookingTime, &recipe.Servings,
-			&mealType, pq.Array(&dietTypeArr), pq.Array(&allergensArr), &ingredientsJSON, pq.Array(&instructions),
-			&imageURL, &recipe.CreatedAt, &recipe.UpdatedAt,
-		)
-		_ = price // Игнорируем цену
-		if err != nil {
-			return nil, err
-		}
-		
-		recipe.Description = description.String
-		recipe.MealType = mealType.String
-		recipe.ImageURL = imageURL.String
-		recipe.DietType = dietTypeArr
-		recipe.Allergens = allergensArr
-		recipe.Instructions = instructions
-		if ingredientsJSON != nil && len(ingredientsJSON) > 0 {
-			json.Unmarshal(ingredientsJSON, &recipe.Ingredients)
-		} else {
-			recipe.Ingredients = models.Ingredients{}
-		}
-		
-		recipes = append(recipes, recipe)
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
 	}
-	
-	return recipes, rows.Err()
+	return nil
 }
-
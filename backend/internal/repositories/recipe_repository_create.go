@@ -12,8 +12,7 @@ import (
 )
 
 // Create создает новый рецепт
-func (r *RecipeRepository) Create(recipe *models.Recipe) (*models.Recipe, error) {
-	ctx := context.Background()
+func (r *RecipeRepository) Create(ctx context.Context, recipe *models.Recipe) (*models.Recipe, error) {
 	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при начале транзакции: %w", err)
@@ -36,8 +35,11 @@ func (r *RecipeRepository) Create(recipe *models.Recipe) (*models.Recipe, error)
 func (r *RecipeRepository) CreateInTx(ctx context.Context, tx *sql.Tx, recipe *models.Recipe) error {
 	query := `
 		INSERT INTO recipes (name, description, calories, proteins, fats, carbs, cooking_time, servings,
-		                     meal_type, diet_type, allergens, ingredients, instructions, image_url)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		                     meal_type, diet_type, allergens, ingredients, instructions, image_url,
+		                     micronutrients, micronutrients_source, compliance_issues, compliance_checked_at, owner_id,
+		                     steps, prep_time, active_time, cuisine, seasons, difficulty)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, NOW(), $18, $19, $20, $21,
+		        $22, $23, $24)
 		RETURNING id, created_at, updated_at
 	`
 	
@@ -60,12 +62,21 @@ func (r *RecipeRepository) CreateInTx(ctx context.Context, tx *sql.Tx, recipe *m
 		imageURL.Valid = true
 	}
 	
+	var micronutrientsSource sql.NullString
+	if recipe.MicronutrientsSource != "" {
+		micronutrientsSource.String = recipe.MicronutrientsSource
+		micronutrientsSource.Valid = true
+	}
+	
 	var price float64 = 0 // Цена не используется, но поле есть в БД
 	
 	err = tx.QueryRowContext(ctx, query,
 		recipe.Name, description, recipe.Calories, recipe.Proteins, recipe.Fats, recipe.Carbs,
 		recipe.CookingTime, recipe.Servings, mealType, pq.Array(recipe.DietType),
 		pq.Array(recipe.Allergens), ingredientsJSON, pq.Array(recipe.Instructions), imageURL,
+		recipe.Micronutrients, micronutrientsSource, recipe.ComplianceIssues, recipe.OwnerID,
+		recipe.Steps, recipe.PrepTime, recipe.ActiveTime,
+		nullString(recipe.Cuisine), pq.Array(recipeSeasons(recipe.Seasons)), nullString(recipe.Difficulty),
 	).Scan(&recipe.ID, &recipe.CreatedAt, &recipe.UpdatedAt)
 	_ = price // Игнорируем цену
 	
@@ -76,12 +87,13 @@ func (r *RecipeRepository) CreateInTx(ctx context.Context, tx *sql.Tx, recipe *m
 	return nil
 }
 
-// ExistsByName проверяет, существует ли рецепт с таким названием
-func (r *RecipeRepository) ExistsByName(ctx context.Context, tx *sql.Tx, name string) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM recipes WHERE LOWER(name) = LOWER($1))`
+// ExistsByName проверяет, существует ли рецепт с таким названием в общем каталоге
+// (ownerID == nil) или среди личных рецептов пользователя
+func (r *RecipeRepository) ExistsByName(ctx context.Context, tx *sql.Tx, name string, ownerID *int) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM recipes WHERE LOWER(name) = LOWER($1) AND owner_id IS NOT DISTINCT FROM $2::int)`
 	
 	var exists bool
-	err := tx.QueryRowContext(ctx, query, name).Scan(&exists)
+	err := tx.QueryRowContext(ctx, query, name, ownerID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("ошибка при проверке существования рецепта: %w", err)
 	}
@@ -89,3 +101,144 @@ func (r *RecipeRepository) ExistsByName(ctx context.Context, tx *sql.Tx, name st
 	return exists, nil
 }
 
+// FindIDByName возвращает ID рецепта с таким названием в общем каталоге (ownerID == nil)
+// или среди личных рецептов пользователя; 0, если рецепта нет
+func (r *RecipeRepository) FindIDByName(ctx context.Context, tx *sql.Tx, name string, ownerID *int) (int, error) {
+	query := `SELECT id FROM recipes WHERE LOWER(name) = LOWER($1) AND owner_id IS NOT DISTINCT FROM $2::int ORDER BY id LIMIT 1`
+	
+	var id int
+	err := tx.QueryRowContext(ctx, query, name, ownerID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при поиске рецепта по названию: %w", err)
+	}
+	
+	return id, nil
+}
+
+// UpdateInTx обновляет рецепт по ID в транзакции (владелец рецепта не меняется)
+func (r *RecipeRepository) UpdateInTx(ctx context.Context, tx *sql.Tx, recipe *models.Recipe) error {
+	query := `
+		UPDATE recipes SET name = $2, description = $3, calories = $4, proteins = $5, fats = $6, carbs = $7,
+		                   cooking_time = $8, servings = $9, meal_type = $10, diet_type = $11, allergens = $12,
+		                   ingredients = $13, instructions = $14, image_url = COALESCE($15, image_url),
+		                   micronutrients = $16, micronutrients_source = $17, compliance_issues = $18,
+		                   steps = $19, prep_time = $20, active_time = $21,
+		                   cuisine = $22, seasons = $23, difficulty = $24,
+		                   compliance_checked_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at, owner_id
+	`
+	
+	ingredientsJSON, err := json.Marshal(recipe.Ingredients)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации ингредиентов: %w", err)
+	}
+	
+	var ownerID sql.NullInt64
+	err = tx.QueryRowContext(ctx, query,
+		recipe.ID, recipe.Name, nullString(recipe.Description), recipe.Calories, recipe.Proteins, recipe.Fats, recipe.Carbs,
+		recipe.CookingTime, recipe.Servings, nullString(recipe.MealType), pq.Array(recipe.DietType),
+		pq.Array(recipe.Allergens), ingredientsJSON, pq.Array(recipe.Instructions), nullString(recipe.ImageURL),
+		recipe.Micronutrients, nullString(recipe.MicronutrientsSource), recipe.ComplianceIssues,
+		recipe.Steps, recipe.PrepTime, recipe.ActiveTime,
+		nullString(recipe.Cuisine), pq.Array(recipeSeasons(recipe.Seasons)), nullString(recipe.Difficulty),
+	).Scan(&recipe.CreatedAt, &recipe.UpdatedAt, &ownerID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("рецепт %d не найден", recipe.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении рецепта: %w", err)
+	}
+	if ownerID.Valid {
+		id := int(ownerID.Int64)
+		recipe.OwnerID = &id
+	}
+	
+	return nil
+}
+
+// recipeSeasons заменяет nil пустым списком (колонка seasons NOT NULL)
+func recipeSeasons(seasons []string) []string {
+	if seasons == nil {
+		return []string{}
+	}
+	return seasons
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+
+// GetIngredientNutrients возвращает справочные показатели продуктов по нормализованным названиям
+func (r *RecipeRepository) GetIngredientNutrients(ctx context.Context, names []string) (map[string]models.IngredientNutrients, error) {
+	query := `SELECT name, per_100g, piece_weight FROM ingredient_nutrients WHERE name = ANY($1)`
+	
+	rows, err := database.DB.QueryContext(ctx, query, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	result := make(map[string]models.IngredientNutrients)
+	for rows.Next() {
+		var item models.IngredientNutrients
+		var pieceWeight sql.NullFloat64
+		if err := rows.Scan(&item.Name, &item.Per100g, &pieceWeight); err != nil {
+			return nil, err
+		}
+		item.PieceWeight = pieceWeight.Float64
+		result[item.Name] = item
+	}
+	return result, rows.Err()
+}
+
+// UpsertIngredientNutrients добавляет или обновляет продукты справочника
+func (r *RecipeRepository) UpsertIngredientNutrients(ctx context.Context, items []models.IngredientNutrients) error {
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+	
+	query := `
+		INSERT INTO ingredient_nutrients (name, per_100g, piece_weight)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET
+			per_100g = EXCLUDED.per_100g,
+			piece_weight = EXCLUDED.piece_weight,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	for _, item := range items {
+		var pieceWeight sql.NullFloat64
+		if item.PieceWeight > 0 {
+			pieceWeight = sql.NullFloat64{Float64: item.PieceWeight, Valid: true}
+		}
+		if _, err := tx.ExecContext(ctx, query, item.Name, item.Per100g, pieceWeight); err != nil {
+			return fmt.Errorf("ошибка при сохранении продукта '%s': %w", item.Name, err)
+		}
+	}
+	
+	return tx.Commit()
+}
+
+// UpdateComplianceIssues сохраняет результаты проверки соответствия рецептов (recipe id -> противоречия)
+func (r *RecipeRepository) UpdateComplianceIssues(ctx context.Context, issues map[int]models.ComplianceIssues) error {
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+	
+	query := `UPDATE recipes SET compliance_issues = $2, compliance_checked_at = NOW() WHERE id = $1`
+	for id, recipeIssues := range issues {
+		if _, err := tx.ExecContext(ctx, query, id, recipeIssues); err != nil {
+			return fmt.Errorf("ошибка при сохранении проверки рецепта %d: %w", id, err)
+		}
+	}
+	
+	return tx.Commit()
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/pkg/database"
+)
+
+// GetSubstitutions возвращает таблицу замен ингредиентов
+func (r *RecipeRepository) GetSubstitutions(ctx context.Context) ([]models.IngredientSubstitution, error) {
+	query := `SELECT id, ingredient, aliases, components, context, note
+	          FROM ingredient_substitutions ORDER BY ingredient, id`
+
+	rows, err := database.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	substitutions := []models.IngredientSubstitution{}
+	for rows.Next() {
+		var substitution models.IngredientSubstitution
+		var note sql.NullString
+		if err := rows.Scan(&substitution.ID, &substitution.Ingredient, pq.Array(&substitution.Aliases),
+			&substitution.Components, &substitution.Context, &note); err != nil {
+			return nil, err
+		}
+		substitution.Note = note.String
+		substitutions = append(substitutions, substitution)
+	}
+	return substitutions, rows.Err()
+}
+
+// ReplaceSubstitutions заменяет все замены указанных ингредиентов новыми записями
+func (r *RecipeRepository) ReplaceSubstitutions(ctx context.Context, items []models.IngredientSubstitution) error {
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	ingredients := make([]string, 0, len(items))
+	for _, item := range items {
+		ingredients = append(ingredients, item.Ingredient)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ingredient_substitutions WHERE ingredient = ANY($1)`, pq.Array(ingredients)); err != nil {
+		return fmt.Errorf("ошибка при удалении замен: %w", err)
+	}
+
+	query := `
+		INSERT INTO ingredient_substitutions (ingredient, aliases, components, context, note)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	for _, item := range items {
+		var note sql.NullString
+		if item.Note != "" {
+			note = sql.NullString{String: item.Note, Valid: true}
+		}
+		if item.Aliases == nil {
+			item.Aliases = []string{}
+		}
+		if _, err := tx.ExecContext(ctx, query, item.Ingredient, pq.Array(item.Aliases), item.Components, item.Context, note); err != nil {
+			return fmt.Errorf("ошибка при сохранении замены '%s': %w", item.Ingredient, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/pkg/database"
+)
+
+type RecipeSubmissionRepository struct{}
+
+func NewRecipeSubmissionRepository() *RecipeSubmissionRepository {
+	return &RecipeSubmissionRepository{}
+}
+
+const submissionColumns = `id, user_id, payload, status, reason, recipe_id, reviewed_by, created_at, reviewed_at`
+
+// Create добавляет рецепт в очередь модерации
+func (r *RecipeSubmissionRepository) Create(ctx context.Context, submission *models.RecipeSubmission) error {
+	query := `
+		INSERT INTO recipe_submissions (user_id, payload)
+		VALUES ($1, $2)
+		RETURNING id, status, created_at
+	`
+
+	return database.DB.QueryRowContext(ctx, query, submission.UserID, submission.Recipe).
+		Scan(&submission.ID, &submission.Status, &submission.CreatedAt)
+}
+
+// GetByID возвращает заявку (nil, если не найдена)
+func (r *RecipeSubmissionRepository) GetByID(ctx context.Context, id int) (*models.RecipeSubmission, error) {
+	query := `SELECT ` + submissionColumns + ` FROM recipe_submissions WHERE id = $1`
+
+	var submission models.RecipeSubmission
+	err := scanSubmission(database.DB.QueryRowContext(ctx, query, id), &submission)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// GetByUserID возвращает заявки пользователя
+func (r *RecipeSubmissionRepository) GetByUserID(ctx context.Context, userID int) ([]models.RecipeSubmission, error) {
+	query := `SELECT ` + submissionColumns + ` FROM recipe_submissions WHERE user_id = $1 ORDER BY created_at DESC`
+	return r.query(ctx, query, userID)
+}
+
+// GetByStatus возвращает заявки с указанным статусом (старые первыми)
+func (r *RecipeSubmissionRepository) GetByStatus(ctx context.Context, status string) ([]models.RecipeSubmission, error) {
+	query := `SELECT ` + submissionColumns + ` FROM recipe_submissions WHERE status = $1 ORDER BY created_at`
+	return r.query(ctx, query, status)
+}
+
+// ResolveInTx закрывает заявку, ожидающую модерации; sql.ErrNoRows - если заявка уже рассмотрена
+func (r *RecipeSubmissionRepository) ResolveInTx(ctx context.Context, tx *sql.Tx, submission *models.RecipeSubmission) error {
+	query := `
+		UPDATE recipe_submissions
+		SET status = $2, reason = $3, recipe_id = $4, reviewed_by = $5, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+		RETURNING reviewed_at
+	`
+
+	var reason sql.NullString
+	if submission.Reason != "" {
+		reason = sql.NullString{String: submission.Reason, Valid: true}
+	}
+	return tx.QueryRowContext(ctx, query, submission.ID, submission.Status, reason, submission.RecipeID, submission.ReviewedBy).
+		Scan(&submission.ReviewedAt)
+}
+
+func (r *RecipeSubmissionRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.RecipeSubmission, error) {
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	submissions := []models.RecipeSubmission{}
+	for rows.Next() {
+		var submission models.RecipeSubmission
+		if err := scanSubmission(rows, &submission); err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, submission)
+	}
+	return submissions, rows.Err()
+}
+
+func scanSubmission(row interface{ Scan(...interface{}) error }, s *models.RecipeSubmission) error {
+	var reason sql.NullString
+	var recipeID, reviewedBy sql.NullInt64
+	var reviewedAt sql.NullTime
+	if err := row.Scan(&s.ID, &s.UserID, &s.Recipe, &s.Status, &reason, &recipeID, &reviewedBy,
+		&s.CreatedAt, &reviewedAt); err != nil {
+		return err
+	}
+	s.Reason = reason.String
+	if recipeID.Valid {
+		id := int(recipeID.Int64)
+		s.RecipeID = &id
+	}
+	if reviewedBy.Valid {
+		id := int(reviewedBy.Int64)
+		s.ReviewedBy = &id
+	}
+	if reviewedAt.Valid {
+		s.ReviewedAt = &reviewedAt.Time
+	}
+	return nil
+}
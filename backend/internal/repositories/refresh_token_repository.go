@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/pkg/database"
+)
+
+type RefreshTokenRepository struct{}
+
+func NewRefreshTokenRepository() *RefreshTokenRepository {
+	return &RefreshTokenRepository{}
+}
+
+const refreshTokenColumns = `id, user_id, family_id, token_hash, access_jti, access_expires_at,
+	expires_at, used_at, revoked_at, created_at`
+
+const insertRefreshTokenQuery = `
+	INSERT INTO refresh_tokens (user_id, family_id, token_hash, access_jti, access_expires_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, created_at
+`
+
+// Create сохраняет refresh-токен нового входа
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return database.DB.QueryRowContext(ctx, insertRefreshTokenQuery, token.UserID, token.FamilyID, token.TokenHash,
+		token.AccessJTI, token.AccessExpiresAt, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+}
+
+// GetByHash возвращает токен по хешу (nil, если не найден)
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1`
+	return scanRefreshToken(database.DB.QueryRowContext(ctx, query, tokenHash))
+}
+
+// GetByHashForUpdateInTx возвращает токен по хешу и блокирует его до конца транзакции,
+// чтобы один токен нельзя было обменять дважды одновременно (nil, если не найден)
+func (r *RefreshTokenRepository) GetByHashForUpdateInTx(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`
+	return scanRefreshToken(tx.QueryRowContext(ctx, query, tokenHash))
+}
+
+// RotateInTx помечает токен usedID использованным и сохраняет следующий токен семейства
+func (r *RefreshTokenRepository) RotateInTx(ctx context.Context, tx *sql.Tx, usedID int, next *models.RefreshToken, now time.Time) error {
+	_, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = $2 WHERE id = $1`, usedID, now)
+	if err != nil {
+		return err
+	}
+	return tx.QueryRowContext(ctx, insertRefreshTokenQuery, next.UserID, next.FamilyID, next.TokenHash,
+		next.AccessJTI, next.AccessExpiresAt, next.ExpiresAt).Scan(&next.ID, &next.CreatedAt)
+}
+
+// RevokeFamily отзывает все токены семейства и еще действующие access-токены,
+// выданные вместе с ними. Возвращает количество отозванных refresh-токенов
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, now time.Time) (int, error) {
+	query := `
+		WITH revoked AS (
+			UPDATE refresh_tokens SET revoked_at = $2
+			WHERE family_id = $1 AND revoked_at IS NULL
+			RETURNING access_jti, access_expires_at
+		), access AS (
+			INSERT INTO revoked_tokens (jti, expires_at)
+			SELECT access_jti, access_expires_at FROM revoked WHERE access_expires_at > $2
+			ON CONFLICT (jti) DO NOTHING
+		)
+		SELECT COUNT(*) FROM revoked
+	`
+
+	var count int
+	err := database.DB.QueryRowContext(ctx, query, familyID, now).Scan(&count)
+	return count, err
+}
+
+// IsRevoked проверяет, отозван ли access-токен с идентификатором jti
+func (r *RefreshTokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := database.DB.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)`, jti).Scan(&revoked)
+	return revoked, err
+}
+
+// DeleteExpired удаляет истекшие refresh-токены и записи об отозванных access-токенах,
+// срок действия которых уже закончился
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := database.DB.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err = database.DB.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return deleted + revoked, nil
+}
+
+func scanRefreshToken(row *sql.Row) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := row.Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.AccessJTI, &token.AccessExpiresAt,
+		&token.ExpiresAt, &token.UsedAt, &token.RevokedAt, &token.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
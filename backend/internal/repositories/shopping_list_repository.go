@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"github.com/myplate/backend/internal/models"
@@ -13,9 +14,9 @@ func NewShoppingListRepository() *ShoppingListRepository {
 	return &ShoppingListRepository{}
 }
 
-func (r *ShoppingListRepository) CreateOrUpdate(list *models.ShoppingList) error {
+func (r *ShoppingListRepository) CreateOrUpdate(ctx context.Context, list *models.ShoppingList) error {
 	// Try to get existing
-	existing, err := r.GetByMenuID(list.MenuID)
+	existing, err := r.GetByMenuID(ctx, list.MenuID)
 	if err != nil && err != sql.ErrNoRows {
 		return err
 	}
@@ -26,7 +27,7 @@ func (r *ShoppingListRepository) CreateOrUpdate(list *models.ShoppingList) error
 		// Update existing
 		updateQuery := `UPDATE shopping_lists SET items = $1, updated_at = CURRENT_TIMESTAMP 
 		               WHERE id = $2 RETURNING created_at, updated_at`
-		return database.DB.QueryRow(updateQuery, itemsJSON, existing.ID).Scan(
+		return database.DB.QueryRowContext(ctx, updateQuery, itemsJSON, existing.ID).Scan(
 			&list.CreatedAt, &list.UpdatedAt,
 		)
 	}
@@ -35,19 +36,19 @@ func (r *ShoppingListRepository) CreateOrUpdate(list *models.ShoppingList) error
 	query := `INSERT INTO shopping_lists (user_id, menu_id, items)
 	         VALUES ($1, $2, $3) RETURNING id, created_at, updated_at`
 	
-	return database.DB.QueryRow(query, list.UserID, list.MenuID, itemsJSON).Scan(
+	return database.DB.QueryRowContext(ctx, query, list.UserID, list.MenuID, itemsJSON).Scan(
 		&list.ID, &list.CreatedAt, &list.UpdatedAt,
 	)
 }
 
-func (r *ShoppingListRepository) GetByMenuID(menuID int) (*models.ShoppingList, error) {
+func (r *ShoppingListRepository) GetByMenuID(ctx context.Context, menuID int) (*models.ShoppingList, error) {
 	query := `SELECT id, user_id, menu_id, items, created_at, updated_at 
 	         FROM shopping_lists WHERE menu_id = $1`
 	
 	var list models.ShoppingList
 	var itemsJSON []byte
 	
-	err := database.DB.QueryRow(query, menuID).Scan(
+	err := database.DB.QueryRowContext(ctx, query, menuID).Scan(
 		&list.ID, &list.UserID, &list.MenuID, &itemsJSON, &list.CreatedAt, &list.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/myplate/backend/internal/models"
@@ -13,7 +14,7 @@ func NewUserRepository() *UserRepository {
 	return &UserRepository{}
 }
 
-func (r *UserRepository) CreateOrUpdate(telegramID int64, username, firstName, lastName string) (*models.User, error) {
+func (r *UserRepository) CreateOrUpdate(ctx context.Context, telegramID int64, username, firstName, lastName string) (*models.User, error) {
 	query := `
 		INSERT INTO users (telegram_id, username, first_name, last_name, role)
 		VALUES ($1, $2, $3, $4, 'user')
@@ -29,7 +30,7 @@ func (r *UserRepository) CreateOrUpdate(telegramID int64, username, firstName, l
 	user := &models.User{}
 	var telegramIDNull sql.NullInt64
 	var emailNull, usernameNull, firstNameNull, lastNameNull, roleNull sql.NullString
-	err := database.DB.QueryRow(query, telegramID, username, firstName, lastName).Scan(
+	err := database.DB.QueryRowContext(ctx, query, telegramID, username, firstName, lastName).Scan(
 		&user.ID, &telegramIDNull, &emailNull, &usernameNull, &firstNameNull, &lastNameNull, &roleNull, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
@@ -50,13 +51,13 @@ func (r *UserRepository) CreateOrUpdate(telegramID int64, username, firstName, l
 	return user, nil
 }
 
-func (r *UserRepository) GetByTelegramID(telegramID int64) (*models.User, error) {
+func (r *UserRepository) GetByTelegramID(ctx context.Context, telegramID int64) (*models.User, error) {
 	query := `SELECT id, telegram_id, email, username, first_name, last_name, role, created_at, updated_at FROM users WHERE telegram_id = $1`
 	
 	user := &models.User{}
 	var telegramIDNull sql.NullInt64
 	var emailNull, usernameNull, firstNameNull, lastNameNull, roleNull sql.NullString
-	err := database.DB.QueryRow(query, telegramID).Scan(
+	err := database.DB.QueryRowContext(ctx, query, telegramID).Scan(
 		&user.ID, &telegramIDNull, &emailNull, &usernameNull, &firstNameNull, &lastNameNull, &roleNull, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -80,13 +81,13 @@ func (r *UserRepository) GetByTelegramID(telegramID int64) (*models.User, error)
 	return user, nil
 }
 
-func (r *UserRepository) GetByID(id int) (*models.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
 	query := `SELECT id, telegram_id, email, username, first_name, last_name, role, created_at, updated_at FROM users WHERE id = $1`
 	
 	user := &models.User{}
 	var telegramIDNull sql.NullInt64
 	var emailNull, usernameNull, firstNameNull, lastNameNull, roleNull sql.NullString
-	err := database.DB.QueryRow(query, id).Scan(
+	err := database.DB.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &telegramIDNull, &emailNull, &usernameNull, &firstNameNull, &lastNameNull, &roleNull, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -111,13 +112,13 @@ func (r *UserRepository) GetByID(id int) (*models.User, error) {
 }
 
 // GetByEmail получает пользователя по email
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `SELECT id, telegram_id, email, username, first_name, last_name, password_hash, role, created_at, updated_at FROM users WHERE email = $1`
 	
 	user := &models.User{}
 	var telegramIDNull sql.NullInt64
 	var emailNull, usernameNull, firstNameNull, lastNameNull, passwordHashNull, roleNull sql.NullString
-	err := database.DB.QueryRow(query, email).Scan(
+	err := database.DB.QueryRowContext(ctx, query, email).Scan(
 		&user.ID, &telegramIDNull, &emailNull, &usernameNull, &firstNameNull, &lastNameNull, &passwordHashNull, &roleNull, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -143,7 +144,7 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 }
 
 // Create создает нового пользователя с email и паролем
-func (r *UserRepository) Create(email, passwordHash, firstName, lastName string) (*models.User, error) {
+func (r *UserRepository) Create(ctx context.Context, email, passwordHash, firstName, lastName string) (*models.User, error) {
 	query := `
 		INSERT INTO users (email, password_hash, first_name, last_name, username, role)
 		VALUES ($1, $2, $3, $4, $5, 'user')
@@ -154,7 +155,7 @@ func (r *UserRepository) Create(email, passwordHash, firstName, lastName string)
 	var telegramIDNull sql.NullInt64
 	var emailNull, usernameNull, firstNameNull, lastNameNull, roleNull sql.NullString
 	username := email // Используем email как username по умолчанию
-	err := database.DB.QueryRow(query, email, passwordHash, firstName, lastName, username).Scan(
+	err := database.DB.QueryRowContext(ctx, query, email, passwordHash, firstName, lastName, username).Scan(
 		&user.ID, &telegramIDNull, &emailNull, &usernameNull, &firstNameNull, &lastNameNull, &roleNull, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
@@ -176,16 +177,16 @@ func (r *UserRepository) Create(email, passwordHash, firstName, lastName string)
 }
 
 // UpdatePassword обновляет пароль пользователя
-func (r *UserRepository) UpdatePassword(userID int, passwordHash string) error {
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
 	query := `UPDATE users SET password_hash = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
-	_, err := database.DB.Exec(query, passwordHash, userID)
+	_, err := database.DB.ExecContext(ctx, query, passwordHash, userID)
 	return err
 }
 
 // UpdateProfile обновляет профиль пользователя
-func (r *UserRepository) UpdateProfile(userID int, firstName, lastName string) error {
+func (r *UserRepository) UpdateProfile(ctx context.Context, userID int, firstName, lastName string) error {
 	query := `UPDATE users SET first_name = $1, last_name = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
-	_, err := database.DB.Exec(query, firstName, lastName, userID)
+	_, err := database.DB.ExecContext(ctx, query, firstName, lastName, userID)
 	return err
 }
 
@@ -13,25 +13,80 @@ import (
 
 type AdminRecipeService struct {
 	recipeRepo *repositories.RecipeRepository
+	tagRepo    *repositories.DietaryTagRepository
 }
 
-func NewAdminRecipeService(recipeRepo *repositories.RecipeRepository) *AdminRecipeService {
+func NewAdminRecipeService(recipeRepo *repositories.RecipeRepository, tagRepo *repositories.DietaryTagRepository) *AdminRecipeService {
 	return &AdminRecipeService{
 		recipeRepo: recipeRepo,
+		tagRepo:    tagRepo,
 	}
 }
 
+// loadTaxonomy загружает справочник диет и аллергенов
+func (s *AdminRecipeService) loadTaxonomy(ctx context.Context) (*DietTaxonomy, error) {
+	tags, err := s.tagRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении справочника диет и аллергенов: %w", err)
+	}
+	return NewDietTaxonomy(tags), nil
+}
+
 // CreateRecipe создает новый рецепт из DTO
-func (s *AdminRecipeService) CreateRecipe(dto *models.RecipeImportDTO) (*models.Recipe, error) {
+func (s *AdminRecipeService) CreateRecipe(ctx context.Context, dto *models.RecipeImportDTO) (*models.Recipe, error) {
+	return s.createRecipe(ctx, dto, nil, nil)
+}
+
+// validateRecipeDTO проверяет обязательные поля, шаги и теги рецепта по справочнику;
+// шаги приводятся к каноническому виду (normalizeRecipeSteps)
+func (s *AdminRecipeService) validateRecipeDTO(dto *models.RecipeImportDTO, taxonomy *DietTaxonomy) error {
+	if strings.TrimSpace(dto.Title) == "" {
+		return fmt.Errorf("не указано название рецепта")
+	}
+	if len(dto.Ingredients) == 0 {
+		return fmt.Errorf("не указаны ингредиенты")
+	}
+	if dto.Calories < 0 || dto.Proteins < 0 || dto.Fats < 0 || dto.Carbs < 0 {
+		return fmt.Errorf("КБЖУ не может быть отрицательным")
+	}
+	if unknown := taxonomy.classifyTags(dto.Tags).Unknown; len(unknown) > 0 {
+		return fmt.Errorf("неизвестные теги: %s", strings.Join(unknown, ", "))
+	}
+	if dto.CookingTime < 0 || dto.PrepTime < 0 {
+		return fmt.Errorf("время приготовления не может быть отрицательным")
+	}
+	steps, err := normalizeRecipeSteps(dto.Steps, dto.Ingredients)
+	if err != nil {
+		return err
+	}
+	dto.Steps = steps
+	return nil
+}
+
+// createRecipe проверяет DTO и создает рецепт: общего каталога (ownerID == nil) или
+// личный рецепт пользователя. onCreated выполняется в той же транзакции.
+func (s *AdminRecipeService) createRecipe(ctx context.Context, dto *models.RecipeImportDTO, ownerID *int, onCreated func(ctx context.Context, tx *sql.Tx, recipe *models.Recipe) error) (*models.Recipe, error) {
+	// Проверяем теги по справочнику
+	taxonomy, err := s.loadTaxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateRecipeDTO(dto, taxonomy); err != nil {
+		return nil, err
+	}
+	checker, err := s.loadComplianceChecker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	
 	// Проверяем дубликаты
-	ctx := context.Background()
 	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при начале транзакции: %w", err)
 	}
 	defer tx.Rollback()
 	
-	exists, err := s.recipeRepo.ExistsByName(ctx, tx, dto.Title)
+	exists, err := s.recipeRepo.ExistsByName(ctx, tx, dto.Title, ownerID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при проверке дубликата: %w", err)
 	}
@@ -40,13 +95,24 @@ func (s *AdminRecipeService) CreateRecipe(dto *models.RecipeImportDTO) (*models.
 	}
 	
 	// Преобразуем DTO в модель Recipe
-	recipe := s.dtoToRecipe(dto)
+	recipe := s.dtoToRecipe(dto, taxonomy)
+	recipe.OwnerID = ownerID
+	if err := s.fillMicronutrients(ctx, recipe); err != nil {
+		return nil, err
+	}
+	// Противоречия тегов и ингредиентов не блокируют создание, а сохраняются в рецепте
+	recipe.ComplianceIssues = checker.Check(recipe)
 	
 	// Создаем рецепт в транзакции
 	err = s.recipeRepo.CreateInTx(ctx, tx, recipe)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при создании рецепта: %w", err)
 	}
+	if onCreated != nil {
+		if err := onCreated(ctx, tx, recipe); err != nil {
+			return nil, err
+		}
+	}
 	
 	// Коммитим транзакцию
 	if err := tx.Commit(); err != nil {
@@ -56,57 +122,170 @@ func (s *AdminRecipeService) CreateRecipe(dto *models.RecipeImportDTO) (*models.
 	return recipe, nil
 }
 
+// Режимы обработки рецептов, название которых уже есть в каталоге
+const (
+	ImportModeFail   = "fail"   // дубликат - ошибка строки (по умолчанию)
+	ImportModeSkip   = "skip"   // дубликат пропускается
+	ImportModeUpdate = "update" // существующий рецепт обновляется
+)
+
+// ImportOptions - параметры импорта рецептов
+type ImportOptions struct {
+	Mode     string
+	DryRun   bool                        // проверить и сформировать отчет без записи в базу
+	Progress func(processed, total int) // вызывается после каждого рецепта (для фоновых заданий)
+}
+
+// NewImportOptions проверяет режим импорта (пустой - fail)
+func NewImportOptions(mode string, dryRun bool) (ImportOptions, error) {
+	switch mode {
+	case "":
+		mode = ImportModeFail
+	case ImportModeFail, ImportModeSkip, ImportModeUpdate:
+	default:
+		return ImportOptions{}, fmt.Errorf("режим импорта должен быть skip, update или fail")
+	}
+	return ImportOptions{Mode: mode, DryRun: dryRun}, nil
+}
+
 // ImportRecipes импортирует несколько рецептов
 type ImportResult struct {
-	Imported int      `json:"imported"`
-	Failed   int      `json:"failed"`
-	Errors   []string `json:"errors"`
+	Imported    int      `json:"imported"`
+	Updated     int      `json:"updated"`             // обновлены существующие рецепты (mode=update)
+	Skipped     int      `json:"skipped"`             // пропущены дубликаты (mode=skip)
+	Failed      int      `json:"failed"`
+	Errors      []string `json:"errors"`
+	UnknownTags []string `json:"unknown_tags,omitempty"` // теги, которых нет в справочнике
+	Flagged     int      `json:"flagged"`                // импортированы с противоречиями тегов и ингредиентов
+	Warnings    []string `json:"warnings,omitempty"`
+	ParseReport []RecipeParseReport `json:"parse_report,omitempty"` // импорт schema.org: поля, которые не удалось разобрать
+	DryRun      bool     `json:"dry_run"`
+}
+
+func (s *AdminRecipeService) ImportRecipes(ctx context.Context, recipes []models.RecipeImportDTO, opts ImportOptions) (*ImportResult, error) {
+	labels := make([]string, len(recipes))
+	for i := range recipes {
+		labels[i] = fmt.Sprintf("Рецепт %d", i+1)
+	}
+	return s.importRecipes(ctx, recipes, labels, opts)
 }
 
-func (s *AdminRecipeService) ImportRecipes(recipes []models.RecipeImportDTO) (*ImportResult, error) {
+// importRecipes импортирует рецепты; labels - обозначения рецептов в сообщениях
+// об ошибках ("Рецепт 3", "Строка 5"). Каждый рецепт сохраняется в своей точке
+// сохранения: ошибка одного рецепта не прерывает импорт остальных. При DryRun
+// транзакция откатывается - отчет такой же, как при импорте, но без записи.
+func (s *AdminRecipeService) importRecipes(ctx context.Context, recipes []models.RecipeImportDTO, labels []string, opts ImportOptions) (*ImportResult, error) {
 	result := &ImportResult{
 		Errors: []string{},
+		DryRun: opts.DryRun,
 	}
 	
 	if len(recipes) == 0 {
 		return result, nil
 	}
 	
+	taxonomy, err := s.loadTaxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	unknownTags := make(map[string]bool)
+	checker, err := s.loadComplianceChecker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	
 	// Начинаем транзакцию
-	ctx := context.Background()
 	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при начале транзакции: %w", err)
 	}
 	defer tx.Rollback()
 	
+	progress := func(processed int) {
+		if opts.Progress != nil {
+			opts.Progress(processed, len(recipes))
+		}
+	}
+	progress(0)
+	
 	// Импортируем рецепты
 	for i, dto := range recipes {
-		// Проверяем дубликаты по названию
-		exists, err := s.recipeRepo.ExistsByName(ctx, tx, dto.Title)
-		if err != nil {
+		if i > 0 {
+			progress(i)
+		}
+		fail := func(err error) {
 			result.Failed++
-			result.Errors = append(result.Errors, fmt.Sprintf("Рецепт %d (%s): ошибка проверки дубликата: %v", i+1, dto.Title, err))
-			continue
+			result.Errors = append(result.Errors, fmt.Sprintf("%s (%s): %v", labels[i], dto.Title, err))
 		}
 		
-		if exists {
-			result.Failed++
-			result.Errors = append(result.Errors, fmt.Sprintf("Рецепт %d (%s): рецепт с таким названием уже существует", i+1, dto.Title))
+		// Проверяем обязательные поля и теги по справочнику
+		for _, tag := range taxonomy.classifyTags(dto.Tags).Unknown {
+			if !unknownTags[tag] {
+				unknownTags[tag] = true
+				result.UnknownTags = append(result.UnknownTags, tag)
+			}
+		}
+		if err := s.validateRecipeDTO(&dto, taxonomy); err != nil {
+			fail(err)
 			continue
 		}
 		
-		recipe := s.dtoToRecipe(&dto)
+		recipe := s.dtoToRecipe(&dto, taxonomy)
+		if err := s.fillMicronutrients(ctx, recipe); err != nil {
+			fail(err)
+			continue
+		}
+		recipe.ComplianceIssues = checker.Check(recipe)
 		
-		// Создаем рецепт в транзакции
-		err = s.recipeRepo.CreateInTx(ctx, tx, recipe)
+		// Сохраняем рецепт в точке сохранения: ошибка запроса откатывает только этот рецепт
+		skipped, updated := false, false
+		err := database.WithSavepoint(ctx, tx, "import_recipe", func() error {
+			// Проверяем дубликаты по названию
+			id, err := s.recipeRepo.FindIDByName(ctx, tx, dto.Title, nil)
+			if err != nil {
+				return err
+			}
+			if id == 0 {
+				return s.recipeRepo.CreateInTx(ctx, tx, recipe)
+			}
+			switch opts.Mode {
+			case ImportModeSkip:
+				skipped = true
+				return nil
+			case ImportModeUpdate:
+				recipe.ID = id
+				updated = true
+				return s.recipeRepo.UpdateInTx(ctx, tx, recipe)
+			default:
+				return fmt.Errorf("рецепт с таким названием уже существует")
+			}
+		})
 		if err != nil {
-			result.Failed++
-			result.Errors = append(result.Errors, fmt.Sprintf("Рецепт %d (%s): %v", i+1, dto.Title, err))
+			fail(err)
 			continue
 		}
 		
-		result.Imported++
+		switch {
+		case skipped:
+			result.Skipped++
+			continue
+		case updated:
+			result.Updated++
+		default:
+			result.Imported++
+		}
+		if len(recipe.ComplianceIssues) > 0 {
+			result.Flagged++
+			for _, issue := range recipe.ComplianceIssues {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s (%s): %s", labels[i], dto.Title, issue.Message))
+			}
+		}
+	}
+	
+	progress(len(recipes))
+	
+	if opts.DryRun {
+		return result, nil
 	}
 	
 	// Коммитим транзакцию
@@ -117,9 +296,9 @@ func (s *AdminRecipeService) ImportRecipes(recipes []models.RecipeImportDTO) (*I
 	return result, nil
 }
 
-// ExportRecipes экспортирует все рецепты
-func (s *AdminRecipeService) ExportRecipes() (*models.RecipeExportResponse, error) {
-	recipes, err := s.recipeRepo.GetAll()
+// ExportRecipes экспортирует рецепты общего каталога по фильтру
+func (s *AdminRecipeService) ExportRecipes(ctx context.Context, filter *models.RecipeFilter) (*models.RecipeExportResponse, error) {
+	recipes, err := s.recipeRepo.GetCatalog(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении рецептов: %w", err)
 	}
@@ -135,8 +314,8 @@ func (s *AdminRecipeService) ExportRecipes() (*models.RecipeExportResponse, erro
 	}, nil
 }
 
-// dtoToRecipe преобразует RecipeImportDTO в Recipe
-func (s *AdminRecipeService) dtoToRecipe(dto *models.RecipeImportDTO) *models.Recipe {
+// dtoToRecipe преобразует RecipeImportDTO в Recipe (неизвестные справочнику теги пропускаются)
+func (s *AdminRecipeService) dtoToRecipe(dto *models.RecipeImportDTO, taxonomy *DietTaxonomy) *models.Recipe {
 	// Преобразуем ингредиенты
 	ingredients := make(models.Ingredients, 0, len(dto.Ingredients))
 	for _, ing := range dto.Ingredients {
@@ -147,23 +326,9 @@ func (s *AdminRecipeService) dtoToRecipe(dto *models.RecipeImportDTO) *models.Re
 		})
 	}
 	
-	// Извлекаем meal_type, diet_type, allergens из tags
-	mealType := ""
-	dietType := []string{}
-	allergens := []string{}
-	
-	for _, tag := range dto.Tags {
-		tagLower := strings.ToLower(tag)
-		switch tagLower {
-		case "breakfast", "lunch", "dinner", "snack":
-			mealType = tagLower
-		case "vegetarian", "vegan", "gluten-free":
-			dietType = append(dietType, tagLower)
-		case "eggs", "dairy", "nuts", "fish", "gluten":
-			allergens = append(allergens, tagLower)
-		}
-	}
-	
+	// Извлекаем meal_type, кухню, сезоны, сложность, diet_type, allergens из tags по справочнику
+	tags := taxonomy.classifyTags(dto.Tags)
+	mealType := tags.MealType
 	if mealType == "" {
 		mealType = "lunch" // По умолчанию
 	}
@@ -173,10 +338,22 @@ func (s *AdminRecipeService) dtoToRecipe(dto *models.RecipeImportDTO) *models.Re
 		servings = 1
 	}
 	
+	// Общее время - указанное или сумма длительностей шагов
+	steps := models.RecipeSteps(dto.Steps)
+	stepsTime, activeTime := stepTimes(steps)
 	cookingTime := dto.CookingTime
+	if cookingTime == 0 {
+		cookingTime = stepsTime
+	}
 	if cookingTime == 0 {
 		cookingTime = 30 // По умолчанию
 	}
+	activeTime = min(activeTime, cookingTime)
+	
+	instructions := dto.Instructions
+	if len(instructions) == 0 && len(steps) > 0 {
+		instructions = stepInstructions(steps)
+	}
 	
 	return &models.Recipe{
 		Name:         dto.Title,
@@ -185,13 +362,21 @@ func (s *AdminRecipeService) dtoToRecipe(dto *models.RecipeImportDTO) *models.Re
 		Proteins:     dto.Proteins,
 		Fats:         dto.Fats,
 		Carbs:        dto.Carbs,
+		Micronutrients: dto.Micronutrients,
 		CookingTime:  cookingTime,
+		PrepTime:     dto.PrepTime,
+		ActiveTime:   activeTime,
 		Servings:     servings,
 		MealType:     mealType,
-		DietType:     dietType,
-		Allergens:    allergens,
+		Cuisine:      tags.Cuisine,
+		Seasons:      tags.Seasons,
+		Difficulty:   tags.Difficulty,
+		DietType:     tags.DietTypes,
+		Allergens:    tags.Allergens,
 		Ingredients:  ingredients,
-		Instructions: dto.Instructions,
+		Instructions: instructions,
+		Steps:        steps,
+		Equipment:    steps.Equipment(),
 	}
 }
 
@@ -207,13 +392,8 @@ func (s *AdminRecipeService) recipeToDTO(recipe *models.Recipe) models.RecipeExp
 		})
 	}
 	
-	// Формируем tags из meal_type, diet_type, allergens
-	tags := []string{}
-	if recipe.MealType != "" {
-		tags = append(tags, recipe.MealType)
-	}
-	tags = append(tags, recipe.DietType...)
-	tags = append(tags, recipe.Allergens...)
+	// Формируем tags из meal_type, кухни, сезонов, сложности, diet_type, allergens
+	tags := recipeTagList(recipe)
 	
 	return models.RecipeExportDTO{
 		Title:        recipe.Name,
@@ -224,9 +404,77 @@ func (s *AdminRecipeService) recipeToDTO(recipe *models.Recipe) models.RecipeExp
 		Proteins:     recipe.Proteins,
 		Fats:         recipe.Fats,
 		Carbs:        recipe.Carbs,
+		Micronutrients: recipe.Micronutrients,
 		CookingTime:  recipe.CookingTime,
+		PrepTime:     recipe.PrepTime,
 		Servings:     recipe.Servings,
 		Instructions: recipe.Instructions,
+		Steps:        recipe.Steps,
 	}
 }
 
+// fillMicronutrients проверяет указанные микронутриенты рецепта, а если они
+// не указаны - рассчитывает их по ингредиентам и справочнику продуктов
+func (s *AdminRecipeService) fillMicronutrients(ctx context.Context, recipe *models.Recipe) error {
+	if len(recipe.Micronutrients) > 0 {
+		micronutrients, err := normalizeMicronutrients(recipe.Micronutrients)
+		if err != nil {
+			return err
+		}
+		recipe.Micronutrients = micronutrients
+		recipe.MicronutrientsSource = "declared"
+		return nil
+	}
+	
+	names := make([]string, 0, len(recipe.Ingredients))
+	for _, ing := range recipe.Ingredients {
+		names = append(names, normalizeIngredientName(ing.Name))
+	}
+	reference, err := s.recipeRepo.GetIngredientNutrients(ctx, names)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении справочника продуктов: %w", err)
+	}
+	if micronutrients, ok := computeMicronutrients(recipe.Ingredients, reference); ok {
+		recipe.Micronutrients = micronutrients
+		recipe.MicronutrientsSource = "computed"
+	}
+	return nil
+}
+
+// UpsertIngredientNutrients обновляет справочник продуктов для расчета микронутриентов
+func (s *AdminRecipeService) UpsertIngredientNutrients(ctx context.Context, items []models.IngredientNutrients) error {
+	for i := range items {
+		items[i].Name = normalizeIngredientName(items[i].Name)
+		if items[i].Name == "" {
+			return fmt.Errorf("продукт %d: не указано название", i+1)
+		}
+		per100g, err := normalizeMicronutrients(items[i].Per100g)
+		if err != nil {
+			return fmt.Errorf("продукт '%s': %w", items[i].Name, err)
+		}
+		items[i].Per100g = per100g
+	}
+	return s.recipeRepo.UpsertIngredientNutrients(ctx, items)
+}
+
+// ReplaceSubstitutions заменяет записи таблицы замен для указанных ингредиентов
+func (s *AdminRecipeService) ReplaceSubstitutions(ctx context.Context, items []models.IngredientSubstitution) error {
+	for i := range items {
+		items[i].Ingredient = normalizeIngredientName(items[i].Ingredient)
+		if items[i].Ingredient == "" {
+			return fmt.Errorf("замена %d: не указан ингредиент", i+1)
+		}
+		for j := range items[i].Aliases {
+			items[i].Aliases[j] = normalizeIngredientName(items[i].Aliases[j])
+		}
+		if len(items[i].Components) == 0 {
+			return fmt.Errorf("замена '%s': не указан состав", items[i].Ingredient)
+		}
+		for _, component := range items[i].Components {
+			if strings.TrimSpace(component.Name) == "" || component.Ratio <= 0 {
+				return fmt.Errorf("замена '%s': у каждой составляющей должны быть название и положительный ratio", items[i].Ingredient)
+			}
+		}
+	}
+	return s.recipeRepo.ReplaceSubstitutions(ctx, items)
+}
@@ -26,7 +26,7 @@ func TestAdminRecipeService_DtoToRecipe(t *testing.T) {
 		Instructions: []string{"Шаг 1", "Шаг 2"},
 	}
 	
-	recipe := service.dtoToRecipe(dto)
+	recipe := service.dtoToRecipe(dto, testTaxonomy())
 	
 	if recipe == nil {
 		t.Fatal("Рецепт не создан")
@@ -108,3 +108,66 @@ func TestAdminRecipeService_RecipeToDTO(t *testing.T) {
 	}
 }
 
+// testTaxonomy - часть справочника из миграции 009_dietary_tags.sql
+func testTaxonomy() *DietTaxonomy {
+	return NewDietTaxonomy([]models.DietaryTag{
+		{Code: "vegetarian", Kind: "diet"},
+		{Code: "vegan", Kind: "diet"},
+		{Code: "halal", Kind: "diet"},
+		{Code: "low-fodmap", Kind: "diet", Aliases: []string{"low fodmap"}},
+		{Code: "eggs", Kind: "allergen", Aliases: []string{"egg"}},
+		{Code: "sesame", Kind: "allergen"},
+		{Code: "crustaceans", Kind: "allergen", Aliases: []string{"shellfish"}},
+	})
+}
+
+func TestDietTaxonomy_ClassifyTags(t *testing.T) {
+	tags := testTaxonomy().classifyTags([]string{"Dinner", "halal", "Low FODMAP", "Shellfish", "sesame", "egg", "eggs", "spicy"})
+	
+	if tags.MealType != "dinner" {
+		t.Errorf("Ожидался meal_type 'dinner', получен '%s'", tags.MealType)
+	}
+	if len(tags.DietTypes) != 2 || tags.DietTypes[0] != "halal" || tags.DietTypes[1] != "low-fodmap" {
+		t.Errorf("Ожидались диеты [halal low-fodmap], получено %v", tags.DietTypes)
+	}
+	// Синонимы приводятся к коду, дубликаты убираются
+	if len(tags.Allergens) != 3 || tags.Allergens[0] != "crustaceans" || tags.Allergens[2] != "eggs" {
+		t.Errorf("Ожидались аллергены [crustaceans sesame eggs], получено %v", tags.Allergens)
+	}
+	if len(tags.Unknown) != 1 || tags.Unknown[0] != "spicy" {
+		t.Errorf("Ожидался неизвестный тег 'spicy', получено %v", tags.Unknown)
+	}
+}
+
+func TestDietTaxonomy_ClassifyVarietyTags(t *testing.T) {
+	tags := testTaxonomy().classifyTags([]string{"lunch", "Cuisine: Middle Eastern", "summer", "season:autumn", "Summer", "difficulty:hard"})
+	
+	if tags.Cuisine != "middle-eastern" || tags.Difficulty != "hard" || len(tags.Unknown) != 0 {
+		t.Errorf("Неверно разобраны кухня и сложность: %+v", tags)
+	}
+	if len(tags.Seasons) != 2 || tags.Seasons[0] != "summer" || tags.Seasons[1] != "autumn" {
+		t.Errorf("Ожидались сезоны [summer autumn], получено %v", tags.Seasons)
+	}
+	
+	// Экспортированные теги разбираются обратно в те же атрибуты
+	recipe := &models.Recipe{MealType: "lunch", Cuisine: tags.Cuisine, Seasons: tags.Seasons, Difficulty: tags.Difficulty}
+	again := testTaxonomy().classifyTags(recipeTagList(recipe))
+	if again.Cuisine != recipe.Cuisine || again.Difficulty != "hard" || len(again.Seasons) != 2 || again.MealType != "lunch" {
+		t.Errorf("Теги экспорта не совпадают с рецептом: %v", recipeTagList(recipe))
+	}
+}
+
+func TestNewImportOptions(t *testing.T) {
+	opts, err := NewImportOptions("", true)
+	if err != nil || opts.Mode != ImportModeFail || !opts.DryRun {
+		t.Errorf("Ожидался режим fail с пробным импортом, получено %+v (%v)", opts, err)
+	}
+	for _, mode := range []string{ImportModeSkip, ImportModeUpdate, ImportModeFail} {
+		if opts, err := NewImportOptions(mode, false); err != nil || opts.Mode != mode {
+			t.Errorf("Режим %s: получено %+v (%v)", mode, opts, err)
+		}
+	}
+	if _, err := NewImportOptions("replace", false); err == nil {
+		t.Error("Ожидалась ошибка для неизвестного режима")
+	}
+}
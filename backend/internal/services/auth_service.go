@@ -1,10 +1,16 @@
 package services
 
 import (
+	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"net/url"
 	"os"
 	"sort"
@@ -14,18 +20,33 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/internal/repositories"
+	"github.com/myplate/backend/pkg/database"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("недействительный refresh-токен")
+	ErrRefreshTokenReused  = errors.New("refresh-токен уже использован: все сеансы этого входа завершены")
+	ErrTokenRevoked        = errors.New("токен отозван")
+	ErrInvalidToken        = errors.New("неверный токен")
+)
+
 type AuthService struct {
 	userRepo *repositories.UserRepository
+	tokenRepo *repositories.RefreshTokenRepository
 	jwtSecret string
 	telegramToken string
 }
 
-func NewAuthService(userRepo *repositories.UserRepository) *AuthService {
+func NewAuthService(userRepo *repositories.UserRepository, tokenRepo *repositories.RefreshTokenRepository) *AuthService {
 	return &AuthService{
 		userRepo:      userRepo,
+		tokenRepo:     tokenRepo,
 		jwtSecret:     os.Getenv("JWT_SECRET"),
 		telegramToken: os.Getenv("TELEGRAM_BOT_TOKEN"),
 	}
@@ -83,17 +104,17 @@ func (s *AuthService) ValidateTelegramInitData(initData string) (map[string]stri
 	return result, nil
 }
 
-func (s *AuthService) AuthenticateTelegram(initData string) (*models.User, string, error) {
+func (s *AuthService) AuthenticateTelegram(ctx context.Context, initData string) (*models.User, *models.TokenPair, error) {
 	// Validate initData
 	data, err := s.ValidateTelegramInitData(initData)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 	
 	// Extract user info (simplified - in production, parse JSON from 'user' field)
 	telegramID := data["id"]
 	if telegramID == "" {
-		return nil, "", fmt.Errorf("user ID not found")
+		return nil, nil, fmt.Errorf("user ID not found")
 	}
 	
 	// Create or update user
@@ -101,36 +122,43 @@ func (s *AuthService) AuthenticateTelegram(initData string) (*models.User, strin
 	fmt.Sscanf(telegramID, "%d", &telegramIDInt)
 	
 	user, err := s.userRepo.CreateOrUpdate(
+		ctx,
 		telegramIDInt,
 		data["username"],
 		data["first_name"],
 		data["last_name"],
 	)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 	
-	// Generate JWT token
-	token, err := s.GenerateJWT(user.ID, user.Role)
+	// Generate access and refresh tokens
+	tokens, err := s.issueTokens(ctx, user)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 	
-	return user, token, nil
+	return user, tokens, nil
 }
 
-func (s *AuthService) GenerateJWT(userID int, role string) (string, error) {
+// GenerateJWT подписывает access-токен с идентификатором jti, по которому токен
+// можно отозвать до истечения срока expiresAt
+func (s *AuthService) GenerateJWT(userID int, role, jti string, expiresAt time.Time) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"role":    role,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(), // 7 days
+		"jti":     jti,
+		"iat":     time.Now().Unix(),
+		"exp":     expiresAt.Unix(),
 	}
 	
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.jwtSecret))
 }
 
-func (s *AuthService) ValidateJWT(tokenString string) (int, string, error) {
+// ValidateJWT проверяет подпись и срок access-токена, а также что токен не отозван
+// (выход или повторное использование refresh-токена)
+func (s *AuthService) ValidateJWT(ctx context.Context, tokenString string) (int, string, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -139,13 +167,26 @@ func (s *AuthService) ValidateJWT(tokenString string) (int, string, error) {
 	})
 	
 	if err != nil {
-		return 0, "", err
+		return 0, "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
 	
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 		userID, ok := claims["user_id"].(float64)
 		if !ok {
-			return 0, "", fmt.Errorf("invalid user_id in token")
+			return 0, "", fmt.Errorf("%w: invalid user_id in token", ErrInvalidToken)
+		}
+		
+		// Токены без jti выданы до появления отзыва и не принимаются
+		jti, _ := claims["jti"].(string)
+		if jti == "" {
+			return 0, "", fmt.Errorf("%w: invalid jti in token", ErrInvalidToken)
+		}
+		revoked, err := s.tokenRepo.IsRevoked(ctx, jti)
+		if err != nil {
+			return 0, "", fmt.Errorf("ошибка при проверке отзыва токена: %w", err)
+		}
+		if revoked {
+			return 0, "", ErrTokenRevoked
 		}
 		
 		role, _ := claims["role"].(string)
@@ -156,99 +197,247 @@ func (s *AuthService) ValidateJWT(tokenString string) (int, string, error) {
 		return int(userID), role, nil
 	}
 	
-	return 0, "", fmt.Errorf("invalid token")
+	return 0, "", ErrInvalidToken
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов; предъявленный токен
+// становится использованным. Повторное предъявление использованного токена означает,
+// что он мог быть украден: все токены этого входа отзываются (ErrRefreshTokenReused)
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	now := time.Now()
+	
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+	
+	current, err := s.tokenRepo.GetByHashForUpdateInTx(ctx, tx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.RevokedAt != nil || !now.Before(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if current.UsedAt != nil {
+		// Блокировку токена нужно снять до отзыва семейства
+		tx.Rollback()
+		if _, err := s.tokenRepo.RevokeFamily(ctx, current.FamilyID, now); err != nil {
+			return nil, fmt.Errorf("ошибка при отзыве токенов: %w", err)
+		}
+		log.Printf("Повторное использование refresh-токена пользователя %d: отозваны токены входа %s", current.UserID, current.FamilyID)
+		return nil, ErrRefreshTokenReused
+	}
+	
+	// Роль берется из базы, поэтому ее изменение вступает в силу при обновлении токена
+	user, err := s.userRepo.GetByID(ctx, current.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	
+	tokens, next, err := s.newTokens(user, current.FamilyID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.RotateInTx(ctx, tx, current.ID, next, now); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении refresh-токена: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при коммите транзакции: %w", err)
+	}
+	return tokens, nil
+}
+
+// Logout завершает вход, которому принадлежит refresh-токен: отзываются все
+// refresh-токены этого входа и выданные по ним access-токены
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	current, err := s.tokenRepo.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return ErrInvalidRefreshToken
+	}
+	
+	if _, err := s.tokenRepo.RevokeFamily(ctx, current.FamilyID, time.Now()); err != nil {
+		return fmt.Errorf("ошибка при отзыве токенов: %w", err)
+	}
+	return nil
+}
+
+// StartTokenCleanup периодически удаляет истекшие refresh-токены и записи об
+// отозванных access-токенах
+func (s *AuthService) StartTokenCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for now := range ticker.C {
+			if _, err := s.tokenRepo.DeleteExpired(context.Background(), now); err != nil {
+				log.Printf("Ошибка очистки истекших токенов: %v", err)
+			}
+		}
+	}()
+}
+
+// issueTokens выдает пару токенов при новом входе - первую в новом семействе
+func (s *AuthService) issueTokens(ctx context.Context, user *models.User) (*models.TokenPair, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	
+	tokens, refresh, err := s.newTokens(user, familyID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.Create(ctx, refresh); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении refresh-токена: %w", err)
+	}
+	return tokens, nil
+}
+
+// newTokens создает access-токен и refresh-токен семейства familyID; в базу
+// сохраняется только хеш refresh-токена
+func (s *AuthService) newTokens(user *models.User, familyID string, now time.Time) (*models.TokenPair, *models.RefreshToken, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	tokens := &models.TokenPair{
+		ExpiresAt:        now.Add(accessTokenTTL),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: now.Add(refreshTokenTTL),
+	}
+	tokens.AccessToken, err = s.GenerateJWT(user.ID, user.Role, jti, tokens.ExpiresAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	refresh := &models.RefreshToken{
+		UserID:          user.ID,
+		FamilyID:        familyID,
+		TokenHash:       hashRefreshToken(refreshToken),
+		AccessJTI:       jti,
+		AccessExpiresAt: tokens.ExpiresAt,
+		ExpiresAt:       tokens.RefreshExpiresAt,
+	}
+	return tokens, refresh, nil
+}
+
+// hashRefreshToken возвращает хеш refresh-токена для хранения и поиска. Токен
+// случайный и длинный, поэтому достаточно SHA-256 без соли
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken возвращает случайную строку из n байт в base64url
+func randomToken(n int) (string, error) {
+	data := make([]byte, n)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("ошибка генерации токена: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
 }
 
 // CreateTestUser - создает тестового пользователя и возвращает токен (для разработки)
-func (s *AuthService) CreateTestUser() (*models.User, string, error) {
+func (s *AuthService) CreateTestUser(ctx context.Context) (*models.User, *models.TokenPair, error) {
 	// Создаем или получаем тестового пользователя
 	user, err := s.userRepo.CreateOrUpdate(
+		ctx,
 		123456789, // Тестовый telegram_id
 		"testuser",
 		"Test",
 		"User",
 	)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 	
-	// Генерируем токен
-	token, err := s.GenerateJWT(user.ID, user.Role)
+	// Генерируем токены
+	tokens, err := s.issueTokens(ctx, user)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 	
-	return user, token, nil
+	return user, tokens, nil
 }
 
 // Register создает нового пользователя с email и паролем
-func (s *AuthService) Register(email, password, firstName, lastName string) (*models.User, string, error) {
+func (s *AuthService) Register(ctx context.Context, email, password, firstName, lastName string) (*models.User, *models.TokenPair, error) {
 	// Проверяем, существует ли пользователь с таким email
-	existingUser, err := s.userRepo.GetByEmail(email)
+	existingUser, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return nil, "", fmt.Errorf("ошибка при проверке email: %w", err)
+		return nil, nil, fmt.Errorf("ошибка при проверке email: %w", err)
 	}
 	if existingUser != nil {
-		return nil, "", fmt.Errorf("пользователь с таким email уже существует")
+		return nil, nil, fmt.Errorf("пользователь с таким email уже существует")
 	}
 
 	// Хешируем пароль
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, "", fmt.Errorf("ошибка при хешировании пароля: %w", err)
+		return nil, nil, fmt.Errorf("ошибка при хешировании пароля: %w", err)
 	}
 
 	// Создаем пользователя
-	user, err := s.userRepo.Create(email, string(passwordHash), firstName, lastName)
+	user, err := s.userRepo.Create(ctx, email, string(passwordHash), firstName, lastName)
 	if err != nil {
-		return nil, "", fmt.Errorf("ошибка при создании пользователя: %w", err)
+		return nil, nil, fmt.Errorf("ошибка при создании пользователя: %w", err)
 	}
 
-	// Генерируем JWT токен
-	token, err := s.GenerateJWT(user.ID, user.Role)
+	// Генерируем access- и refresh-токены
+	tokens, err := s.issueTokens(ctx, user)
 	if err != nil {
-		return nil, "", fmt.Errorf("ошибка при генерации токена: %w", err)
+		return nil, nil, fmt.Errorf("ошибка при генерации токена: %w", err)
 	}
 
-	return user, token, nil
+	return user, tokens, nil
 }
 
 // Login авторизует пользователя по email и паролю
-func (s *AuthService) Login(email, password string) (*models.User, string, error) {
+func (s *AuthService) Login(ctx context.Context, email, password string) (*models.User, *models.TokenPair, error) {
 	// Получаем пользователя по email
-	user, err := s.userRepo.GetByEmail(email)
+	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return nil, "", fmt.Errorf("ошибка при поиске пользователя: %w", err)
+		return nil, nil, fmt.Errorf("ошибка при поиске пользователя: %w", err)
 	}
 	if user == nil {
-		return nil, "", fmt.Errorf("неверный email или пароль")
+		return nil, nil, fmt.Errorf("неверный email или пароль")
 	}
 
 	// Проверяем пароль
 	if user.PasswordHash == "" {
-		return nil, "", fmt.Errorf("у пользователя не установлен пароль")
+		return nil, nil, fmt.Errorf("у пользователя не установлен пароль")
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 	if err != nil {
-		return nil, "", fmt.Errorf("неверный email или пароль")
+		return nil, nil, fmt.Errorf("неверный email или пароль")
 	}
 
-	// Генерируем JWT токен
-	token, err := s.GenerateJWT(user.ID, user.Role)
+	// Генерируем access- и refresh-токены
+	tokens, err := s.issueTokens(ctx, user)
 	if err != nil {
-		return nil, "", fmt.Errorf("ошибка при генерации токена: %w", err)
+		return nil, nil, fmt.Errorf("ошибка при генерации токена: %w", err)
 	}
 
 	// Очищаем password_hash перед возвратом
 	user.PasswordHash = ""
 
-	return user, token, nil
+	return user, tokens, nil
 }
 
 // GetUserProfile получает профиль пользователя по ID
-func (s *AuthService) GetUserProfile(userID int) (*models.User, error) {
-	user, err := s.userRepo.GetByID(userID)
+func (s *AuthService) GetUserProfile(ctx context.Context, userID int) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении профиля: %w", err)
 	}
@@ -259,9 +448,9 @@ func (s *AuthService) GetUserProfile(userID int) (*models.User, error) {
 }
 
 // UpdatePassword обновляет пароль пользователя
-func (s *AuthService) UpdatePassword(userID int, oldPassword, newPassword string) error {
+func (s *AuthService) UpdatePassword(ctx context.Context, userID int, oldPassword, newPassword string) error {
 	// Получаем пользователя
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("ошибка при получении пользователя: %w", err)
 	}
@@ -271,7 +460,7 @@ func (s *AuthService) UpdatePassword(userID int, oldPassword, newPassword string
 
 	// Если у пользователя есть пароль, проверяем старый
 	if user.PasswordHash != "" {
-		userWithPassword, err := s.userRepo.GetByEmail(user.Email)
+		userWithPassword, err := s.userRepo.GetByEmail(ctx, user.Email)
 		if err != nil {
 			return fmt.Errorf("ошибка при проверке пароля: %w", err)
 		}
@@ -292,7 +481,7 @@ func (s *AuthService) UpdatePassword(userID int, oldPassword, newPassword string
 	}
 
 	// Обновляем пароль
-	err = s.userRepo.UpdatePassword(userID, string(newPasswordHash))
+	err = s.userRepo.UpdatePassword(ctx, userID, string(newPasswordHash))
 	if err != nil {
 		return fmt.Errorf("ошибка при обновлении пароля: %w", err)
 	}
@@ -301,13 +490,13 @@ func (s *AuthService) UpdatePassword(userID int, oldPassword, newPassword string
 }
 
 // UpdateProfile обновляет профиль пользователя
-func (s *AuthService) UpdateProfile(userID int, firstName, lastName string) (*models.User, error) {
-	err := s.userRepo.UpdateProfile(userID, firstName, lastName)
+func (s *AuthService) UpdateProfile(ctx context.Context, userID int, firstName, lastName string) (*models.User, error) {
+	err := s.userRepo.UpdateProfile(ctx, userID, firstName, lastName)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при обновлении профиля: %w", err)
 	}
 
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении обновленного профиля: %w", err)
 	}
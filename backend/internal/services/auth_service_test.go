@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestAuthService_NewTokens(t *testing.T) {
+	service := &AuthService{jwtSecret: "secret"}
+	user := &models.User{ID: 7, Role: "admin"}
+	now := time.Now()
+
+	tokens, refresh, err := service.newTokens(user, "family", now)
+	if err != nil {
+		t.Fatalf("Ошибка выдачи токенов: %v", err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("Ожидались оба токена, получено %+v", tokens)
+	}
+	if !tokens.ExpiresAt.Equal(now.Add(accessTokenTTL)) || !tokens.RefreshExpiresAt.Equal(now.Add(refreshTokenTTL)) {
+		t.Errorf("Неверные сроки: access %v, refresh %v", tokens.ExpiresAt, tokens.RefreshExpiresAt)
+	}
+
+	// В базу попадает только хеш refresh-токена
+	if refresh.TokenHash == tokens.RefreshToken || refresh.TokenHash != hashRefreshToken(tokens.RefreshToken) {
+		t.Errorf("Ожидался хеш refresh-токена, получено %q", refresh.TokenHash)
+	}
+	if refresh.UserID != 7 || refresh.FamilyID != "family" || refresh.AccessJTI == "" {
+		t.Errorf("Неверная запись refresh-токена: %+v", refresh)
+	}
+
+	// Каждая пара уникальна, даже в одном семействе
+	next, _, err := service.newTokens(user, "family", now)
+	if err != nil {
+		t.Fatalf("Ошибка выдачи токенов: %v", err)
+	}
+	if next.RefreshToken == tokens.RefreshToken || next.AccessToken == tokens.AccessToken {
+		t.Error("Ожидались новые токены при повторной выдаче")
+	}
+}
+
+func TestAuthService_ValidateJWTRejectsBeforeRevocationCheck(t *testing.T) {
+	// Репозиторий не задан: эти токены должны отклоняться без обращения к базе
+	service := &AuthService{jwtSecret: "secret"}
+	expiresAt := time.Now().Add(time.Minute)
+
+	withoutJTI, err := service.GenerateJWT(1, "user", "", expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := service.ValidateJWT(context.Background(), withoutJTI); !errors.Is(err, ErrInvalidToken) {
+		t.Error("Ожидалась ошибка для токена без jti")
+	}
+
+	expired, err := service.GenerateJWT(1, "user", "jti", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := service.ValidateJWT(context.Background(), expired); !errors.Is(err, ErrInvalidToken) {
+		t.Error("Ожидалась ошибка для истекшего токена")
+	}
+
+	foreign, err := (&AuthService{jwtSecret: "other"}).GenerateJWT(1, "user", "jti", expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := service.ValidateJWT(context.Background(), foreign); !errors.Is(err, ErrInvalidToken) {
+		t.Error("Ожидалась ошибка для токена с чужой подписью")
+	}
+}
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/repositories"
+	"github.com/myplate/backend/pkg/database"
+)
+
+const (
+	// kcalPerKg - энергетическая ценность 1 кг массы тела (ккал)
+	kcalPerKg = 7700.0
+	// adjustmentWindowDays - за сколько дней оценивается тренд веса
+	adjustmentWindowDays = 14
+	// minTrendMeasurements и minTrendSpanDays - минимум данных для оценки тренда
+	minTrendMeasurements = 3
+	minTrendSpanDays     = 7
+	// weeklyChangeTolerance - допустимое отклонение от цели (кг в неделю), при котором калорийность не меняется
+	weeklyChangeTolerance = 0.1
+	// maxCalorieStep - максимальное изменение калорийности за одну корректировку
+	maxCalorieStep = 200
+	// minDailyCalories - калорийность, ниже которой корректировка не опускает цель
+	minDailyCalories = 1200
+	// adjustmentIntervalDays - не чаще чем раз в столько дней пользователю предлагается корректировка
+	adjustmentIntervalDays = 7
+)
+
+// errAdjustmentResolved - корректировку успели обработать параллельным запросом
+var errAdjustmentResolved = errors.New("корректировка уже обработана")
+
+type BodyService struct {
+	bodyRepo  *repositories.BodyRepository
+	goalsRepo *repositories.GoalsRepository
+}
+
+func NewBodyService(bodyRepo *repositories.BodyRepository, goalsRepo *repositories.GoalsRepository) *BodyService {
+	return &BodyService{
+		bodyRepo:  bodyRepo,
+		goalsRepo: goalsRepo,
+	}
+}
+
+// AddMeasurement сохраняет измерение веса и талии
+func (s *BodyService) AddMeasurement(ctx context.Context, userID int, req *models.BodyMeasurementRequest) (*models.BodyMeasurement, error) {
+	if req.Weight <= 0 || req.Weight > 500 {
+		return nil, fmt.Errorf("вес должен быть в диапазоне 0-500 кг")
+	}
+	if req.Waist != nil && (*req.Waist <= 0 || *req.Waist > 300) {
+		return nil, fmt.Errorf("обхват талии должен быть в диапазоне 0-300 см")
+	}
+
+	date := truncateToDate(time.Now())
+	if req.Date != "" {
+		parsed, err := time.Parse(models.DateLayout, req.Date)
+		if err != nil {
+			return nil, fmt.Errorf("неверный формат даты, ожидается YYYY-MM-DD")
+		}
+		date = parsed
+	}
+
+	measurement := &models.BodyMeasurement{
+		UserID: userID,
+		Date:   date,
+		Weight: req.Weight,
+		Waist:  req.Waist,
+		Note:   req.Note,
+	}
+	if err := s.bodyRepo.UpsertMeasurement(ctx, measurement); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении измерения: %w", err)
+	}
+	return measurement, nil
+}
+
+// GetMeasurements возвращает измерения за период
+func (s *BodyService) GetMeasurements(ctx context.Context, userID int, from, to time.Time) ([]models.BodyMeasurement, error) {
+	from = truncateToDate(from)
+	to = truncateToDate(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("дата 'from' должна быть не позже 'to'")
+	}
+	return s.bodyRepo.GetMeasurements(ctx, userID, from, to)
+}
+
+func (s *BodyService) DeleteMeasurement(ctx context.Context, userID, id int) error {
+	return s.bodyRepo.DeleteMeasurement(ctx, id, userID)
+}
+
+// GetAdjustments возвращает журнал корректировок калорийности
+func (s *BodyService) GetAdjustments(ctx context.Context, userID int) ([]models.GoalAdjustment, error) {
+	return s.bodyRepo.GetAdjustments(ctx, userID)
+}
+
+// ApplyAdjustment применяет предложенную калорийность к целям пользователя
+func (s *BodyService) ApplyAdjustment(ctx context.Context, userID, id int) (*models.UserGoals, error) {
+	adjustment, err := s.bodyRepo.GetAdjustmentByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if adjustment == nil {
+		return nil, sql.ErrNoRows
+	}
+	if adjustment.Status != "proposed" {
+		return nil, fmt.Errorf("корректировка уже обработана (статус '%s')", adjustment.Status)
+	}
+
+	goals, err := s.goalsRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении целей пользователя: %w", err)
+	}
+	if goals == nil {
+		return nil, fmt.Errorf("цели пользователя не заданы")
+	}
+	if goals.DailyCalories != adjustment.PreviousCalories {
+		return nil, fmt.Errorf("цели изменились после предложения корректировки (%d ккал вместо %d)",
+			goals.DailyCalories, adjustment.PreviousCalories)
+	}
+
+	// Статус корректировки и цели меняются вместе: при одновременном применении
+	// второй запрос не найдет корректировку в статусе proposed и ничего не изменит
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.bodyRepo.ResolveAdjustmentInTx(ctx, tx, id, "applied"); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errAdjustmentResolved
+		}
+		return nil, err
+	}
+	applyCalories(goals, adjustment.ProposedCalories)
+	if err := s.goalsRepo.CreateOrUpdateInTx(ctx, tx, goals); err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении целей: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при коммите транзакции: %w", err)
+	}
+	return goals, nil
+}
+
+// RejectAdjustment отклоняет предложенную корректировку
+func (s *BodyService) RejectAdjustment(ctx context.Context, userID, id int) error {
+	adjustment, err := s.bodyRepo.GetAdjustmentByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if adjustment == nil {
+		return sql.ErrNoRows
+	}
+	if adjustment.Status != "proposed" {
+		return fmt.Errorf("корректировка уже обработана (статус '%s')", adjustment.Status)
+	}
+	if err := s.bodyRepo.ResolveAdjustment(ctx, id, "rejected"); err != nil {
+		if err == sql.ErrNoRows {
+			return errAdjustmentResolved
+		}
+		return err
+	}
+	return nil
+}
+
+// RunWeeklyAdjustments сравнивает тренд веса с целью для всех пользователей
+// с заданной целью по весу и предлагает новую калорийность. Возвращает
+// количество созданных корректировок.
+func (s *BodyService) RunWeeklyAdjustments(ctx context.Context, now time.Time) (int, error) {
+	userIDs, err := s.goalsRepo.GetUserIDsWithWeightGoal(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении пользователей: %w", err)
+	}
+
+	created := 0
+	for _, userID := range userIDs {
+		adjustment, err := s.ProposeAdjustment(ctx, userID, now)
+		if err != nil {
+			// Ошибка одного пользователя не должна останавливать остальных
+			log.Printf("Корректировка калорийности для пользователя %d: %v", userID, err)
+			continue
+		}
+		if adjustment != nil {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// ProposeAdjustment предлагает корректировку калорийности пользователю.
+// Возвращает nil, если данных недостаточно или вес меняется в соответствии с целью.
+func (s *BodyService) ProposeAdjustment(ctx context.Context, userID int, now time.Time) (*models.GoalAdjustment, error) {
+	goals, err := s.goalsRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении целей пользователя: %w", err)
+	}
+	if goals == nil || goals.WeeklyWeightChange == nil {
+		return nil, nil
+	}
+
+	periodEnd := truncateToDate(now)
+	latest, err := s.bodyRepo.GetLatestAdjustment(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении последней корректировки: %w", err)
+	}
+	if !adjustmentDue(latest, periodEnd) {
+		return nil, nil
+	}
+	periodStart := periodEnd.AddDate(0, 0, -(adjustmentWindowDays - 1))
+	measurements, err := s.bodyRepo.GetMeasurements(ctx, userID, periodStart, periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении измерений: %w", err)
+	}
+
+	adjustment := calculateCalorieAdjustment(measurements, goals)
+	if adjustment == nil {
+		return nil, nil
+	}
+	adjustment.UserID = userID
+	adjustment.PeriodStart = periodStart
+	adjustment.PeriodEnd = periodEnd
+	adjustment.Status = "proposed"
+	if goals.AutoAdjustCalories {
+		adjustment.Status = "applied"
+		resolvedAt := time.Now()
+		adjustment.ResolvedAt = &resolvedAt
+	}
+
+	// Автоматически примененная корректировка и новые цели сохраняются вместе
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	created, err := s.bodyRepo.CreateAdjustmentInTx(ctx, tx, adjustment)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении корректировки: %w", err)
+	}
+	if !created {
+		return nil, nil
+	}
+
+	if goals.AutoAdjustCalories {
+		applyCalories(goals, adjustment.ProposedCalories)
+		if err := s.goalsRepo.CreateOrUpdateInTx(ctx, tx, goals); err != nil {
+			return nil, fmt.Errorf("ошибка при обновлении целей: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при коммите транзакции: %w", err)
+	}
+	return adjustment, nil
+}
+
+// StartWeeklyAdjustments запускает корректировку в фоне: сразу при старте и затем
+// каждые interval. Проверка выполняется чаще раза в неделю, чтобы перезапуски сервера
+// не откладывали ее; пользователю корректировка предлагается не чаще раза в
+// adjustmentIntervalDays дней (см. adjustmentDue).
+func (s *BodyService) StartWeeklyAdjustments(interval time.Duration) {
+	run := func(now time.Time) {
+		created, err := s.RunWeeklyAdjustments(context.Background(), now)
+		if err != nil {
+			log.Printf("Ошибка еженедельной корректировки калорийности: %v", err)
+			return
+		}
+		if created > 0 {
+			log.Printf("Еженедельная корректировка калорийности: предложено %d", created)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		run(time.Now())
+		for now := range ticker.C {
+			run(now)
+		}
+	}()
+}
+
+// adjustmentDue проверяет, прошло ли adjustmentIntervalDays дней с периода последней
+// корректировки (latest - nil, если их не было)
+func adjustmentDue(latest *models.GoalAdjustment, periodEnd time.Time) bool {
+	if latest == nil {
+		return true
+	}
+	return !periodEnd.Before(truncateToDate(latest.PeriodEnd).AddDate(0, 0, adjustmentIntervalDays))
+}
+
+// calculateCalorieAdjustment сравнивает тренд веса с целью и рассчитывает новую калорийность.
+// 1 кг массы тела ~ 7700 ккал, поэтому отклонение на 0.5 кг/нед соответствует ~550 ккал/день;
+// шаг ограничен maxCalorieStep, чтобы цель менялась постепенно.
+func calculateCalorieAdjustment(measurements []models.BodyMeasurement, goals *models.UserGoals) *models.GoalAdjustment {
+	if goals == nil || goals.WeeklyWeightChange == nil || goals.DailyCalories <= 0 {
+		return nil
+	}
+	if len(measurements) < minTrendMeasurements {
+		return nil
+	}
+	first := measurements[0].Date
+	last := measurements[len(measurements)-1].Date
+	if last.Sub(first).Hours()/24 < minTrendSpanDays {
+		return nil
+	}
+
+	target := *goals.WeeklyWeightChange
+	actual := math.Round(weightTrend(measurements)*7*100) / 100
+	deviation := actual - target
+	if math.Abs(deviation) < weeklyChangeTolerance {
+		return nil
+	}
+
+	// Вес растет быстрее цели (или падает медленнее) - уменьшаем калорийность, и наоборот
+	step := -deviation * kcalPerKg / 7
+	step = math.Max(-maxCalorieStep, math.Min(maxCalorieStep, step))
+	proposed := int(math.Round((float64(goals.DailyCalories)+step)/10) * 10)
+	if proposed < minDailyCalories {
+		proposed = minDailyCalories
+	}
+	if proposed == goals.DailyCalories {
+		return nil
+	}
+
+	trend := ""
+	if actual*target > 0 {
+		trend = " (медленнее цели)"
+		if math.Abs(actual) > math.Abs(target) {
+			trend = " (быстрее цели)"
+		}
+	}
+	reason := fmt.Sprintf(
+		"По %d измерениям с %s по %s вес меняется на %+.2f кг/нед при цели %+.2f кг/нед%s: калорийность %d → %d ккал",
+		len(measurements), first.Format(models.DateLayout), last.Format(models.DateLayout),
+		actual, target, trend, goals.DailyCalories, proposed)
+
+	return &models.GoalAdjustment{
+		MeasurementsCount:  len(measurements),
+		TargetWeeklyChange: target,
+		ActualWeeklyChange: actual,
+		PreviousCalories:   goals.DailyCalories,
+		ProposedCalories:   proposed,
+		Reason:             reason,
+	}
+}
+
+// weightTrend возвращает изменение веса в кг/день (наклон линейной регрессии),
+// чтобы единичные колебания веса не определяли корректировку
+func weightTrend(measurements []models.BodyMeasurement) float64 {
+	if len(measurements) < 2 {
+		return 0
+	}
+
+	first := measurements[0].Date
+	n := float64(len(measurements))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, m := range measurements {
+		x := m.Date.Sub(first).Hours() / 24
+		sumX += x
+		sumY += m.Weight
+		sumXY += x * m.Weight
+		sumXX += x * x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// applyCalories меняет калорийность цели, пропорционально пересчитывая БЖУ
+func applyCalories(goals *models.UserGoals, calories int) {
+	if goals.DailyCalories > 0 {
+		factor := float64(calories) / float64(goals.DailyCalories)
+		goals.TargetProteins = math.Round(goals.TargetProteins*factor*10) / 10
+		goals.TargetFats = math.Round(goals.TargetFats*factor*10) / 10
+		goals.TargetCarbs = math.Round(goals.TargetCarbs*factor*10) / 10
+	}
+	goals.DailyCalories = calories
+}
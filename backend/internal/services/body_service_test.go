@@ -0,0 +1,110 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func measurementsFrom(start time.Time, weights ...float64) []models.BodyMeasurement {
+	var measurements []models.BodyMeasurement
+	for i, w := range weights {
+		measurements = append(measurements, models.BodyMeasurement{
+			Date:   start.AddDate(0, 0, i*2),
+			Weight: w,
+		})
+	}
+	return measurements
+}
+
+func TestWeightTrend(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// -0.2 кг каждые 2 дня = -0.1 кг/день
+	measurements := measurementsFrom(start, 80.0, 79.8, 79.6, 79.4, 79.2)
+	
+	trend := weightTrend(measurements)
+	if math.Abs(trend-(-0.1)) > 1e-9 {
+		t.Errorf("Ожидался тренд -0.1 кг/день, получено %f", trend)
+	}
+}
+
+func TestCalculateCalorieAdjustment(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	target := -0.5
+	goals := &models.UserGoals{DailyCalories: 2000, TargetProteins: 100, WeeklyWeightChange: &target}
+	
+	t.Run("Вес стоит на месте - калорийность снижается не более чем на шаг", func(t *testing.T) {
+		measurements := measurementsFrom(start, 80, 80.1, 79.9, 80, 80)
+		adjustment := calculateCalorieAdjustment(measurements, goals)
+		if adjustment == nil {
+			t.Fatal("Ожидалась корректировка")
+		}
+		if adjustment.ProposedCalories != 2000-maxCalorieStep {
+			t.Errorf("Ожидалось %d ккал, получено %d", 2000-maxCalorieStep, adjustment.ProposedCalories)
+		}
+		if !strings.Contains(adjustment.Reason, "2000 → 1800") {
+			t.Errorf("Обоснование должно содержать изменение калорийности: %s", adjustment.Reason)
+		}
+	})
+	
+	t.Run("Вес снижается по плану - без корректировки", func(t *testing.T) {
+		// -0.5 кг/нед: по ~0.143 кг каждые 2 дня
+		measurements := measurementsFrom(start, 80, 79.857, 79.714, 79.571, 79.429)
+		if adjustment := calculateCalorieAdjustment(measurements, goals); adjustment != nil {
+			t.Errorf("Корректировка не ожидалась, получено %+v", adjustment)
+		}
+	})
+	
+	t.Run("Вес снижается слишком быстро - калорийность растет", func(t *testing.T) {
+		// -0.7 кг/нед = -0.2 кг/нед от цели -> +220 ккал, ограничено шагом
+		measurements := measurementsFrom(start, 80, 79.8, 79.6, 79.4, 79.2)
+		adjustment := calculateCalorieAdjustment(measurements, goals)
+		if adjustment == nil || adjustment.ProposedCalories <= 2000 {
+			t.Fatalf("Ожидалось увеличение калорийности, получено %+v", adjustment)
+		}
+	})
+	
+	t.Run("Недостаточно измерений", func(t *testing.T) {
+		measurements := measurementsFrom(start, 80, 81)
+		if adjustment := calculateCalorieAdjustment(measurements, goals); adjustment != nil {
+			t.Errorf("Корректировка не ожидалась, получено %+v", adjustment)
+		}
+	})
+}
+
+func TestApplyCalories(t *testing.T) {
+	goals := &models.UserGoals{DailyCalories: 2000, TargetProteins: 100, TargetFats: 70, TargetCarbs: 250}
+	applyCalories(goals, 1800)
+	
+	if goals.DailyCalories != 1800 {
+		t.Errorf("Ожидалось 1800 ккал, получено %d", goals.DailyCalories)
+	}
+	if goals.TargetProteins != 90 || goals.TargetCarbs != 225 {
+		t.Errorf("БЖУ должны пересчитаться пропорционально, получено %+v", goals)
+	}
+}
+
+func TestAdjustmentDue(t *testing.T) {
+	today := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	latest := func(daysAgo int) *models.GoalAdjustment {
+		return &models.GoalAdjustment{PeriodEnd: today.AddDate(0, 0, -daysAgo)}
+	}
+	
+	if !adjustmentDue(nil, today) {
+		t.Error("Первая корректировка должна предлагаться сразу")
+	}
+	// Проверка идет каждый час, но за неделю предлагается одна корректировка
+	for _, daysAgo := range []int{0, 1, 6} {
+		if adjustmentDue(latest(daysAgo), today) {
+			t.Errorf("Корректировка %d дн. назад: новая не ожидалась", daysAgo)
+		}
+	}
+	for _, daysAgo := range []int{7, 30} {
+		if !adjustmentDue(latest(daysAgo), today) {
+			t.Errorf("Корректировка %d дн. назад: ожидалась новая", daysAgo)
+		}
+	}
+}
@@ -0,0 +1,490 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/repositories"
+)
+
+var (
+	ErrCookingSessionFinished = errors.New("сессия приготовления уже завершена")
+	ErrCookingSessionChanged  = errors.New("сессия изменена с другого устройства, обновите ее")
+	ErrCookingStepsDone       = errors.New("все шаги уже выполнены")
+)
+
+// CookingSessionService ведет пошаговое приготовление блюд из меню
+type CookingSessionService struct {
+	sessionRepo *repositories.CookingSessionRepository
+	menuRepo    *repositories.MenuRepository
+	recipeRepo  *repositories.RecipeRepository
+}
+
+func NewCookingSessionService(
+	sessionRepo *repositories.CookingSessionRepository,
+	menuRepo *repositories.MenuRepository,
+	recipeRepo *repositories.RecipeRepository,
+) *CookingSessionService {
+	return &CookingSessionService{
+		sessionRepo: sessionRepo,
+		menuRepo:    menuRepo,
+		recipeRepo:  recipeRepo,
+	}
+}
+
+// Start начинает сессию для приемов пищи из меню: шаги всех блюд объединяются в
+// общий план, первый шаг сразу считается начатым
+func (s *CookingSessionService) Start(ctx context.Context, userID int, req *models.CookingSessionRequest) (*models.CookingSession, error) {
+	if req.Adults < 0 || req.Children < 0 {
+		return nil, fmt.Errorf("количество человек не может быть отрицательным")
+	}
+	servings := float64(peopleScale(req.Adults, req.Children))
+
+	planned, err := s.plannedMeals(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	meals := models.CookingMeals{}
+	recipes := []*models.Recipe{}
+	for _, meal := range planned {
+		recipe, err := s.recipeRepo.GetByID(ctx, meal.RecipeID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении рецепта: %w", err)
+		}
+		if recipe == nil || !recipeVisibleTo(recipe, userID) {
+			return nil, fmt.Errorf("рецепт %d не найден", meal.RecipeID)
+		}
+		meals = append(meals, models.CookingMeal{
+			MenuMealID: meal.MenuMealID,
+			RecipeID:   recipe.ID,
+			RecipeName: recipe.Name,
+			MealType:   meal.MealType,
+			Date:       meal.Date.Format(models.DateLayout),
+		})
+		recipes = append(recipes, recipe)
+	}
+
+	now := time.Now()
+	steps := planCookingSteps(meals, recipes)
+	if len(steps) > 0 {
+		steps[0].StartedAt = &now
+	}
+
+	session := &models.CookingSession{
+		UserID:   userID,
+		Servings: servings,
+		Meals:    meals,
+		Steps:    steps,
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении сессии: %w", err)
+	}
+	session.PantryUsed = models.Ingredients{}
+	session.Timers = cookingTimers(session.Steps, now)
+	return session, nil
+}
+
+// plannedMeals находит приемы пищи сессии по ID или по дате и типам (без повторов)
+func (s *CookingSessionService) plannedMeals(ctx context.Context, userID int, req *models.CookingSessionRequest) ([]*models.PlannedMeal, error) {
+	var meals []*models.PlannedMeal
+	seen := map[int]bool{}
+	add := func(meal *models.PlannedMeal) {
+		if !seen[meal.MenuMealID] {
+			seen[meal.MenuMealID] = true
+			meals = append(meals, meal)
+		}
+	}
+
+	if len(req.MenuMealIDs) > 0 {
+		for _, id := range req.MenuMealIDs {
+			meal, err := s.menuRepo.GetPlannedMeal(ctx, userID, id)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка при поиске приема пищи: %w", err)
+			}
+			if meal == nil {
+				return nil, fmt.Errorf("прием пищи %d не найден в ваших меню", id)
+			}
+			add(meal)
+		}
+		return meals, nil
+	}
+
+	if len(req.MealTypes) == 0 {
+		return nil, fmt.Errorf("укажите menu_meal_ids или meal_types")
+	}
+	date := truncateToDate(time.Now())
+	if req.Date != "" {
+		parsed, err := time.Parse(models.DateLayout, req.Date)
+		if err != nil {
+			return nil, fmt.Errorf("неверный формат даты, ожидается YYYY-MM-DD")
+		}
+		date = parsed
+	}
+	for _, mealType := range req.MealTypes {
+		if !validMealTypes[mealType] {
+			return nil, fmt.Errorf("неверный meal_type: '%s'", mealType)
+		}
+		meal, err := s.menuRepo.FindPlannedMeal(ctx, userID, date, mealType)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при поиске запланированного приема пищи: %w", err)
+		}
+		if meal == nil {
+			return nil, fmt.Errorf("на %s нет запланированного приема пищи '%s'", date.Format(models.DateLayout), mealType)
+		}
+		add(meal)
+	}
+	return meals, nil
+}
+
+// Get возвращает сессию с таймерами (sql.ErrNoRows, если не найдена)
+func (s *CookingSessionService) Get(ctx context.Context, userID, id int) (*models.CookingSession, error) {
+	session, err := s.sessionRepo.GetByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, sql.ErrNoRows
+	}
+	session.Timers = cookingTimers(session.Steps, time.Now())
+	return session, nil
+}
+
+// GetActive возвращает незавершенные сессии пользователя
+func (s *CookingSessionService) GetActive(ctx context.Context, userID int) ([]models.CookingSession, error) {
+	sessions, err := s.sessionRepo.GetActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range sessions {
+		sessions[i].Timers = cookingTimers(sessions[i].Steps, now)
+	}
+	return sessions, nil
+}
+
+// Timers возвращает таймеры сессии на текущий момент
+func (s *CookingSessionService) Timers(session *models.CookingSession) []models.CookingTimer {
+	return cookingTimers(session.Steps, time.Now())
+}
+
+// CurrentStep возвращает текущий шаг сессии и таймеры
+func (s *CookingSessionService) CurrentStep(ctx context.Context, userID, id int) (*models.CurrentCookingStep, error) {
+	session, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return currentCookingStep(session, time.Now()), nil
+}
+
+// Next завершает текущий шаг и начинает следующий. Таймер пассивного шага
+// продолжает идти после перехода к следующему шагу.
+func (s *CookingSessionService) Next(ctx context.Context, userID, id int) (*models.CurrentCookingStep, error) {
+	session, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !session.Active() {
+		return nil, ErrCookingSessionFinished
+	}
+
+	now := time.Now()
+	expected := session.CurrentStep
+	if err := advanceCookingSession(session, now); err != nil {
+		return nil, err
+	}
+	if err := s.sessionRepo.UpdateProgress(ctx, session, expected); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCookingSessionChanged
+		}
+		return nil, fmt.Errorf("ошибка при сохранении сессии: %w", err)
+	}
+	session.Timers = cookingTimers(session.Steps, now)
+	return currentCookingStep(session, now), nil
+}
+
+// Finish завершает сессию и списывает из кладовой ингредиенты приготовленных блюд,
+// пересчитанные на количество порций и округленные до кухонных мер. Списывается не больше, чем есть в кладовой.
+func (s *CookingSessionService) Finish(ctx context.Context, userID, id int) (*models.CookingSession, error) {
+	session, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !session.Active() {
+		return nil, ErrCookingSessionFinished
+	}
+
+	var need models.Ingredients
+	for _, meal := range session.Meals {
+		recipe, err := s.recipeRepo.GetByID(ctx, meal.RecipeID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении рецепта: %w", err)
+		}
+		if recipe == nil {
+			continue // рецепт удален во время приготовления
+		}
+		need = append(need, recipeScale(session.Servings).kitchenIngredients(recipe)...)
+	}
+
+	err = s.sessionRepo.Finish(ctx, session, func(pantry []models.PantryItem) ([]models.PantryItem, models.Ingredients) {
+		return deductPantry(pantry, need)
+	})
+	if err == sql.ErrNoRows {
+		return nil, ErrCookingSessionFinished
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при завершении сессии: %w", err)
+	}
+	session.Timers = []models.CookingTimer{}
+	return session, nil
+}
+
+// Cancel отменяет сессию без списания продуктов
+func (s *CookingSessionService) Cancel(ctx context.Context, userID, id int) error {
+	session, err := s.sessionRepo.GetByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return sql.ErrNoRows
+	}
+	if err := s.sessionRepo.Cancel(ctx, id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrCookingSessionFinished
+		}
+		return err
+	}
+	return nil
+}
+
+// planCookingSteps объединяет шаги блюд в один план для одного повара. Пассивный шаг
+// (запекание, маринование) занимает повара только на время запуска, поэтому пока он
+// идет, выполняются шаги других блюд. Из готовых к выполнению шагов выбирается шаг
+// блюда с наибольшим оставшимся временем - долгие блюда начинаются первыми.
+func planCookingSteps(meals models.CookingMeals, recipes []*models.Recipe) models.CookingSteps {
+	type mealPlan struct {
+		steps     []models.RecipeStep
+		durations []int
+		next      int
+		readyAt   int // мин., когда можно начинать следующий шаг блюда
+	}
+
+	plans := make([]*mealPlan, len(recipes))
+	remaining := 0
+	for i, recipe := range recipes {
+		steps, durations := recipeCookingSteps(recipe)
+		plans[i] = &mealPlan{steps: steps, durations: durations}
+		remaining += len(steps)
+	}
+	left := func(plan *mealPlan) int {
+		total := 0
+		for _, duration := range plan.durations[plan.next:] {
+			total += duration
+		}
+		return total
+	}
+
+	result := models.CookingSteps{}
+	cookFree := 0
+	for ; remaining > 0; remaining-- {
+		best := -1
+		for i, plan := range plans {
+			if plan.next >= len(plan.steps) {
+				continue
+			}
+			start := max(cookFree, plan.readyAt)
+			if best < 0 {
+				best = i
+				continue
+			}
+			bestStart := max(cookFree, plans[best].readyAt)
+			if start < bestStart || start == bestStart && left(plan) > left(plans[best]) {
+				best = i
+			}
+		}
+
+		plan := plans[best]
+		step := plan.steps[plan.next]
+		duration := plan.durations[plan.next]
+		start := max(cookFree, plan.readyAt)
+		result = append(result, models.CookingStep{
+			Meal:         best,
+			RecipeID:     recipes[best].ID,
+			RecipeName:   recipes[best].Name,
+			MealType:     meals[best].MealType,
+			Number:       plan.next + 1,
+			Text:         step.Text,
+			Duration:     step.Duration,
+			Passive:      step.Passive,
+			Ingredients:  step.Ingredients,
+			Equipment:    step.Equipment,
+			PlannedStart: start,
+		})
+
+		plan.next++
+		plan.readyAt = start + duration
+		if !step.Passive {
+			cookFree = start + duration
+		}
+	}
+	return result
+}
+
+// recipeCookingSteps возвращает шаги рецепта и их оценочную длительность для плана.
+// Рецепты без структурированных шагов выполняются по инструкциям; время рецепта,
+// не распределенное по шагам, делится поровну между шагами без длительности.
+func recipeCookingSteps(recipe *models.Recipe) ([]models.RecipeStep, []int) {
+	steps := []models.RecipeStep(recipe.Steps)
+	if len(steps) == 0 {
+		for _, text := range recipe.Instructions {
+			steps = append(steps, models.RecipeStep{Text: text})
+		}
+	}
+	if len(steps) == 0 {
+		steps = []models.RecipeStep{{Text: "Приготовить: " + recipe.Name, Duration: recipe.CookingTime}}
+	}
+
+	known, unknown := 0, 0
+	for _, step := range steps {
+		known += step.Duration
+		if step.Duration == 0 {
+			unknown++
+		}
+	}
+	share := 0
+	if unknown > 0 {
+		share = max(recipe.CookingTime-known, 0) / unknown
+	}
+
+	durations := make([]int, len(steps))
+	for i, step := range steps {
+		durations[i] = step.Duration
+		if step.Duration == 0 {
+			durations[i] = share
+		}
+	}
+	return steps, durations
+}
+
+// advanceCookingSession отмечает текущий шаг выполненным и начинает следующий
+func advanceCookingSession(session *models.CookingSession, now time.Time) error {
+	if session.CurrentStep >= len(session.Steps) {
+		return ErrCookingStepsDone
+	}
+
+	current := &session.Steps[session.CurrentStep]
+	if current.StartedAt == nil {
+		current.StartedAt = &now
+	}
+	current.CompletedAt = &now
+
+	session.CurrentStep++
+	if session.CurrentStep < len(session.Steps) {
+		session.Steps[session.CurrentStep].StartedAt = &now
+	}
+	return nil
+}
+
+// cookingTimers возвращает таймеры начатых шагов с длительностью. Таймер активного
+// шага снимается, когда шаг выполнен; пассивного - идет до конца.
+func cookingTimers(steps models.CookingSteps, now time.Time) []models.CookingTimer {
+	timers := []models.CookingTimer{}
+	for i, step := range steps {
+		if step.StartedAt == nil || step.Duration <= 0 || !step.Passive && step.CompletedAt != nil {
+			continue
+		}
+		endsAt := step.StartedAt.Add(time.Duration(step.Duration) * time.Minute)
+		remaining := int(math.Ceil(endsAt.Sub(now).Seconds()))
+		timers = append(timers, models.CookingTimer{
+			Step:             i,
+			RecipeName:       step.RecipeName,
+			Text:             step.Text,
+			StartedAt:        *step.StartedAt,
+			EndsAt:           endsAt,
+			RemainingSeconds: max(remaining, 0),
+			Done:             remaining <= 0,
+		})
+	}
+	return timers
+}
+
+// currentCookingStep описывает текущий шаг. Если предыдущий шаг того же блюда -
+// пассивный и его таймер еще идет, шаг можно начинать только после таймера (ready_at).
+func currentCookingStep(session *models.CookingSession, now time.Time) *models.CurrentCookingStep {
+	result := &models.CurrentCookingStep{
+		Index:  session.CurrentStep,
+		Total:  len(session.Steps),
+		Timers: cookingTimers(session.Steps, now),
+	}
+	if !session.Active() || session.CurrentStep >= len(session.Steps) {
+		return result
+	}
+
+	step := session.Steps[session.CurrentStep]
+	result.Step = &step
+	for i := session.CurrentStep - 1; i >= 0; i-- {
+		previous := session.Steps[i]
+		if previous.Meal != step.Meal {
+			continue
+		}
+		if previous.Passive && previous.StartedAt != nil {
+			readyAt := previous.StartedAt.Add(time.Duration(previous.Duration) * time.Minute)
+			if readyAt.After(now) {
+				result.ReadyAt = &readyAt
+			}
+		}
+		break
+	}
+	return result
+}
+
+// deductPantry списывает need из продуктов кладовой: продукты сопоставляются по
+// названию, как при генерации меню. Возвращает измененные продукты с новыми
+// количествами (0 - продукт закончился) и списанное.
+func deductPantry(pantry []models.PantryItem, need models.Ingredients) ([]models.PantryItem, models.Ingredients) {
+	byName := make(map[string]int, len(pantry))
+	for i, item := range pantry {
+		key := normalizeIngredientName(item.Name)
+		if _, found := byName[key]; !found {
+			byName[key] = i
+		}
+	}
+
+	changed := map[int]bool{}
+	usedIndex := map[int]int{}
+	used := models.Ingredients{}
+	for _, ing := range need {
+		i, found := byName[normalizeIngredientName(ing.Name)]
+		if !found || pantry[i].Quantity <= 0 || ing.Quantity <= 0 {
+			continue
+		}
+		take := math.Min(pantry[i].Quantity, ing.Quantity)
+		// Количества в кладовой хранятся с точностью до сотых
+		pantry[i].Quantity = math.Max(math.Round((pantry[i].Quantity-take)*100)/100, 0)
+		changed[i] = true
+
+		if j, ok := usedIndex[i]; ok {
+			used[j].Quantity = math.Round((used[j].Quantity+take)*100) / 100
+			continue
+		}
+		usedIndex[i] = len(used)
+		used = append(used, models.Ingredient{
+			Name:     pantry[i].Name,
+			Quantity: math.Round(take*100) / 100,
+			Unit:     pantry[i].Unit,
+		})
+	}
+
+	updated := []models.PantryItem{}
+	for i, item := range pantry {
+		if changed[i] {
+			updated = append(updated, item)
+		}
+	}
+	return updated, used
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func testCookingSession() *models.CookingSession {
+	lunch := &models.Recipe{ID: 1, Name: "Салат", CookingTime: 20, Instructions: []string{"Нарезать овощи", "Заправить"}}
+	dinner := &models.Recipe{ID: 2, Name: "Запеканка", CookingTime: 55, ActiveTime: 15, Steps: models.RecipeSteps{
+		{Text: "Смешать творог с яйцами", Duration: 15},
+		{Text: "Запекать", Duration: 40, Passive: true, Equipment: []string{"oven"}},
+	}}
+	meals := models.CookingMeals{{RecipeID: 1, MealType: "lunch"}, {RecipeID: 2, MealType: "dinner"}}
+	return &models.CookingSession{
+		Status: "active",
+		Meals:  meals,
+		Steps:  planCookingSteps(meals, []*models.Recipe{lunch, dinner}),
+	}
+}
+
+func TestPlanCookingSteps(t *testing.T) {
+	steps := testCookingSession().Steps
+
+	// Сначала долгое блюдо: пока запеканка в духовке, готовится салат
+	expected := []struct {
+		text  string
+		start int
+	}{
+		{"Смешать творог с яйцами", 0},
+		{"Запекать", 15},
+		{"Нарезать овощи", 15},
+		{"Заправить", 25},
+	}
+	if len(steps) != len(expected) {
+		t.Fatalf("Ожидалось %d шага, получено %d", len(expected), len(steps))
+	}
+	for i, want := range expected {
+		if steps[i].Text != want.text || steps[i].PlannedStart != want.start {
+			t.Errorf("Шаг %d: ожидалось %q с %d мин., получено %q с %d мин.", i, want.text, want.start, steps[i].Text, steps[i].PlannedStart)
+		}
+	}
+	if steps[2].Meal != 0 || steps[2].Number != 1 || steps[1].MealType != "dinner" {
+		t.Errorf("Неверная привязка шагов к блюдам: %+v", steps)
+	}
+
+	// Рецепт без шагов и инструкций - один шаг на все время
+	single := planCookingSteps(models.CookingMeals{{}}, []*models.Recipe{{Name: "Каша", CookingTime: 10}})
+	if len(single) != 1 || single[0].Duration != 10 {
+		t.Errorf("Ожидался один шаг на 10 мин., получено %+v", single)
+	}
+}
+
+func TestAdvanceCookingSession(t *testing.T) {
+	session := testCookingSession()
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+	session.Steps[0].StartedAt = &start
+
+	// Запеканка поставлена в духовку, затем начат салат
+	if err := advanceCookingSession(session, start.Add(15*time.Minute)); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if err := advanceCookingSession(session, start.Add(16*time.Minute)); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+
+	now := start.Add(20 * time.Minute)
+	current := currentCookingStep(session, now)
+	if current.Index != 2 || current.Step.Text != "Нарезать овощи" || current.ReadyAt != nil {
+		t.Errorf("Неверный текущий шаг: %+v", current)
+	}
+	if len(current.Timers) != 1 || current.Timers[0].Step != 1 || current.Timers[0].RemainingSeconds != 35*60 || current.Timers[0].Done {
+		t.Errorf("Ожидался таймер духовки на 35 мин., получено %+v", current.Timers)
+	}
+
+	timers := cookingTimers(session.Steps, start.Add(time.Hour))
+	if len(timers) != 1 || !timers[0].Done || timers[0].RemainingSeconds != 0 {
+		t.Errorf("Таймер духовки должен истечь: %+v", timers)
+	}
+
+	advanceCookingSession(session, now)
+	advanceCookingSession(session, now)
+	if err := advanceCookingSession(session, now); err != ErrCookingStepsDone {
+		t.Errorf("Ожидалась ошибка ErrCookingStepsDone, получено %v", err)
+	}
+	if current := currentCookingStep(session, now); current.Step != nil || current.Index != 4 {
+		t.Errorf("Все шаги выполнены, получено %+v", current)
+	}
+}
+
+func TestCurrentCookingStep_WaitsForPassiveStep(t *testing.T) {
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+	recipe := &models.Recipe{ID: 1, Name: "Курица", Steps: models.RecipeSteps{
+		{Text: "Мариновать", Duration: 30, Passive: true},
+		{Text: "Обжарить", Duration: 10},
+	}}
+	session := &models.CookingSession{Status: "active", Meals: models.CookingMeals{{}}}
+	session.Steps = planCookingSteps(session.Meals, []*models.Recipe{recipe})
+	session.Steps[0].StartedAt = &start
+	advanceCookingSession(session, start.Add(time.Minute))
+
+	current := currentCookingStep(session, start.Add(10*time.Minute))
+	if current.ReadyAt == nil || !current.ReadyAt.Equal(start.Add(30*time.Minute)) {
+		t.Errorf("Шаг можно начинать после маринования, получено %v", current.ReadyAt)
+	}
+	if current := currentCookingStep(session, start.Add(31*time.Minute)); current.ReadyAt != nil {
+		t.Errorf("Маринование закончилось, получено %v", current.ReadyAt)
+	}
+}
+
+func TestDeductPantry(t *testing.T) {
+	recipe := &models.Recipe{Servings: 2, Ingredients: models.Ingredients{
+		{Name: "Творог", Quantity: 400, Unit: "г"},
+		{Name: "яйца", Quantity: 2, Unit: "шт"},
+		{Name: "Сахар", Quantity: 50, Unit: "г"},
+	}}
+	need := recipeScale(3).kitchenIngredients(recipe) // 1.5 рецепта
+	need = append(need, models.Ingredient{Name: "Яйца ", Quantity: 1, Unit: "шт"})
+
+	pantry := []models.PantryItem{
+		{ID: 1, Name: "Творог", Quantity: 1000, Unit: "г"},
+		{ID: 2, Name: "Яйца", Quantity: 3, Unit: "шт"},
+		{ID: 3, Name: "Молоко", Quantity: 1, Unit: "л"},
+	}
+	updated, used := deductPantry(pantry, need)
+
+	if len(updated) != 2 || updated[0].Quantity != 400 || updated[1].Quantity != 0 {
+		t.Errorf("Ожидалось 400 г творога и 0 яиц, получено %+v", updated)
+	}
+	if len(used) != 2 || used[0].Quantity != 600 || used[1].Name != "Яйца" || used[1].Quantity != 3 {
+		t.Errorf("Неверно списано: %+v", used)
+	}
+}
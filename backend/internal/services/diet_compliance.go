@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/repositories"
+)
+
+// ComplianceChecker проверяет ингредиенты рецепта на соответствие его тегам:
+// диета не должна содержать запрещенных категорий продуктов, а каждый аллерген,
+// найденный в ингредиентах, должен быть указан в тегах рецепта
+type ComplianceChecker struct {
+	tags     map[string]models.DietaryTag
+	keywords []models.IngredientCategory
+}
+
+func NewComplianceChecker(tags []models.DietaryTag, categories []models.IngredientCategory) *ComplianceChecker {
+	checker := &ComplianceChecker{
+		tags:     make(map[string]models.DietaryTag, len(tags)),
+		keywords: make([]models.IngredientCategory, 0, len(categories)),
+	}
+	for _, tag := range tags {
+		checker.tags[tag.Code] = tag
+	}
+	for _, category := range categories {
+		category.Keyword = normalizeIngredientName(category.Keyword)
+		for i := range category.Exceptions {
+			category.Exceptions[i] = normalizeIngredientName(category.Exceptions[i])
+		}
+		if category.Keyword != "" {
+			checker.keywords = append(checker.keywords, category)
+		}
+	}
+	return checker
+}
+
+// ingredientCategories возвращает категории продукта: ключевое слово должно
+// совпадать с началом одного из слов названия ("сливоч" -> "масло сливочное"),
+// а исключения - ни с одним ("молок", кроме "овсяное молоко"). Исключение,
+// которое само начинается с ключевого слова, отменяет только совпавшее слово:
+// "печень" не находится в "печенье", но "печень и печенье" - мясо.
+func (c *ComplianceChecker) ingredientCategories(name string) []string {
+	words := strings.FieldsFunc(normalizeIngredientName(name), func(r rune) bool {
+		return r == ' ' || r == '-' || r == ',' || r == '(' || r == ')'
+	})
+
+	seen := make(map[string]bool)
+	var categories []string
+	for _, keyword := range c.keywords {
+		if seen[keyword.Category] {
+			continue
+		}
+		if keywordMatches(words, keyword) {
+			seen[keyword.Category] = true
+			categories = append(categories, keyword.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// keywordMatches проверяет ключевое слово категории с учетом исключений
+func keywordMatches(words []string, keyword models.IngredientCategory) bool {
+	var wordExceptions, nameExceptions []string
+	for _, exception := range keyword.Exceptions {
+		if strings.HasPrefix(exception, keyword.Keyword) {
+			wordExceptions = append(wordExceptions, exception)
+		} else {
+			nameExceptions = append(nameExceptions, exception)
+		}
+	}
+	if hasWordPrefix(words, nameExceptions...) {
+		return false
+	}
+	for _, word := range words {
+		if strings.HasPrefix(word, keyword.Keyword) && !hasWordPrefix([]string{word}, wordExceptions...) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWordPrefix проверяет, начинается ли хотя бы одно слово с одного из префиксов
+func hasWordPrefix(words []string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		for _, word := range words {
+			if strings.HasPrefix(word, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ingredientAllergens возвращает коды аллергенов, которые содержит продукт
+func (c *ComplianceChecker) ingredientAllergens(name string) []string {
+	if c == nil {
+		return nil
+	}
+	categories := c.ingredientCategories(name)
+	if len(categories) == 0 {
+		return nil
+	}
+	var allergens []string
+	for code, tag := range c.tags {
+		if tag.Kind == "allergen" && firstCommon(categories, tag.Categories) != "" {
+			allergens = append(allergens, code)
+		}
+	}
+	sort.Strings(allergens)
+	return allergens
+}
+
+// Check возвращает противоречия между тегами рецепта и его ингредиентами
+func (c *ComplianceChecker) Check(recipe *models.Recipe) models.ComplianceIssues {
+	issues := models.ComplianceIssues{}
+	if c == nil {
+		return issues
+	}
+
+	declaredAllergens := make(map[string]bool, len(recipe.Allergens))
+	for _, code := range recipe.Allergens {
+		declaredAllergens[code] = true
+	}
+
+	// Аллергены справочника в порядке кодов - для стабильного результата
+	var allergens []models.DietaryTag
+	for _, tag := range c.tags {
+		if tag.Kind == "allergen" {
+			allergens = append(allergens, tag)
+		}
+	}
+	sort.Slice(allergens, func(i, j int) bool { return allergens[i].Code < allergens[j].Code })
+
+	for _, ing := range recipe.Ingredients {
+		categories := c.ingredientCategories(ing.Name)
+		if len(categories) == 0 {
+			continue
+		}
+
+		// Диеты рецепта, которым противоречит ингредиент
+		for _, code := range recipe.DietType {
+			diet, ok := c.tags[code]
+			if !ok {
+				continue
+			}
+			if category := firstCommon(categories, diet.Categories); category != "" {
+				issues = append(issues, models.ComplianceIssue{
+					Tag:        code,
+					Kind:       "diet",
+					Ingredient: ing.Name,
+					Category:   category,
+					Message:    fmt.Sprintf("'%s' (%s) не допускается диетой %s", ing.Name, category, code),
+				})
+			}
+		}
+
+		// Аллергены, которые содержит ингредиент, но которых нет в тегах рецепта
+		for _, allergen := range allergens {
+			if declaredAllergens[allergen.Code] {
+				continue
+			}
+			if category := firstCommon(categories, allergen.Categories); category != "" {
+				issues = append(issues, models.ComplianceIssue{
+					Tag:        allergen.Code,
+					Kind:       "allergen",
+					Ingredient: ing.Name,
+					Category:   category,
+					Message:    fmt.Sprintf("'%s' содержит аллерген %s, который не указан в тегах", ing.Name, allergen.Code),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// firstCommon возвращает первый элемент a, который есть в b
+func firstCommon(a, b []string) string {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return x
+			}
+		}
+	}
+	return ""
+}
+
+// loadComplianceChecker загружает правила диет, аллергенов и категории продуктов
+func (s *AdminRecipeService) loadComplianceChecker(ctx context.Context) (*ComplianceChecker, error) {
+	return loadComplianceChecker(ctx, s.tagRepo)
+}
+
+func loadComplianceChecker(ctx context.Context, tagRepo *repositories.DietaryTagRepository) (*ComplianceChecker, error) {
+	tags, err := tagRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении справочника диет и аллергенов: %w", err)
+	}
+	categories, err := tagRepo.GetIngredientCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении категорий продуктов: %w", err)
+	}
+	return NewComplianceChecker(tags, categories), nil
+}
+
+// AuditRecipes проверяет все рецепты, включая личные рецепты пользователей, и сохраняет
+// найденные противоречия
+func (s *AdminRecipeService) AuditRecipes(ctx context.Context) (*models.ComplianceAudit, error) {
+	checker, err := s.loadComplianceChecker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recipes, err := s.recipeRepo.GetAllIncludingOwned(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении рецептов: %w", err)
+	}
+
+	audit := &models.ComplianceAudit{
+		Recipes:   []models.RecipeCompliance{},
+		CheckedAt: time.Now(),
+	}
+	updates := make(map[int]models.ComplianceIssues, len(recipes))
+	for i := range recipes {
+		issues := checker.Check(&recipes[i])
+		updates[recipes[i].ID] = issues
+		audit.Checked++
+		if len(issues) > 0 {
+			audit.Flagged++
+			audit.Recipes = append(audit.Recipes, models.RecipeCompliance{
+				RecipeID: recipes[i].ID,
+				Name:     recipes[i].Name,
+				Issues:   issues,
+			})
+		}
+	}
+
+	if err := s.recipeRepo.UpdateComplianceIssues(ctx, updates); err != nil {
+		return nil, err
+	}
+	return audit, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func testComplianceChecker() *ComplianceChecker {
+	return NewComplianceChecker(
+		[]models.DietaryTag{
+			{Code: "vegan", Kind: "diet", Categories: []string{"meat", "dairy", "eggs", "honey"}},
+			{Code: "gluten-free", Kind: "diet", Categories: []string{"gluten"}},
+			{Code: "dairy", Kind: "allergen", Categories: []string{"dairy"}},
+			{Code: "gluten", Kind: "allergen", Categories: []string{"gluten"}},
+		},
+		[]models.IngredientCategory{
+			{Keyword: "сливоч", Category: "dairy"},
+			{Keyword: "молок", Category: "dairy"},
+			{Keyword: "мука", Category: "gluten"},
+			{Keyword: "мед", Category: "honey"},
+		},
+	)
+}
+
+func TestComplianceChecker_Check(t *testing.T) {
+	recipe := &models.Recipe{
+		DietType:  []string{"vegan"},
+		Allergens: []string{"gluten"},
+		Ingredients: models.Ingredients{
+			{Name: "Масло сливочное", Quantity: 20, Unit: "г"},
+			{Name: "Мука пшеничная", Quantity: 200, Unit: "г"},
+			{Name: "Помидоры", Quantity: 2, Unit: "шт"},
+		},
+	}
+
+	issues := testComplianceChecker().Check(recipe)
+
+	// Масло противоречит веганской диете и содержит неуказанный аллерген dairy;
+	// мука содержит глютен, но он указан в тегах
+	if len(issues) != 2 {
+		t.Fatalf("Ожидалось 2 противоречия, получено %d: %v", len(issues), issues)
+	}
+	if issues[0].Kind != "diet" || issues[0].Tag != "vegan" || issues[0].Category != "dairy" {
+		t.Errorf("Ожидалось нарушение диеты vegan (dairy), получено %+v", issues[0])
+	}
+	if issues[1].Kind != "allergen" || issues[1].Tag != "dairy" {
+		t.Errorf("Ожидался неуказанный аллерген dairy, получено %+v", issues[1])
+	}
+}
+
+func TestComplianceChecker_MatchesWordPrefix(t *testing.T) {
+	checker := testComplianceChecker()
+
+	// Ключевое слово совпадает с началом любого слова названия
+	if categories := checker.ingredientCategories("Цельное молоко"); len(categories) != 1 || categories[0] != "dairy" {
+		t.Errorf("Ожидалась категория dairy, получено %v", categories)
+	}
+	if categories := checker.ingredientCategories("Огурцы маринованные"); len(categories) != 0 {
+		t.Errorf("Не ожидалось категорий, получено %v", categories)
+	}
+
+	// Слова, которые начинаются с ключевого слова, но означают другое (исключения
+	// из 021_ingredient_keyword_exceptions.sql)
+	checker = NewComplianceChecker(nil, []models.IngredientCategory{
+		{Keyword: "печень", Category: "meat", Exceptions: []string{"печенье", "печенья", "печеньк"}},
+		{Keyword: "сыр", Category: "dairy", Exceptions: []string{"сырой", "сырая", "сырое", "сырые", "сырых", "сырым", "сырого", "сырую"}},
+		{Keyword: "гус", Category: "poultry", Exceptions: []string{"густ"}},
+		{Keyword: "круп", Category: "high_carb", Exceptions: []string{"крупн"}},
+	})
+	cases := map[string][]string{
+		"Печенье овсяное":      nil,
+		"Печень куриная":       {"meat"},
+		"Печень и печенье":     {"meat"},
+		"Сырые яйца":           nil,
+		"Сыр твердый":          {"dairy"},
+		"Сыр и сырой желток":   {"dairy"},
+		"Густой томатный соус": nil,
+		"Гусь":                 {"poultry"},
+		"Соль крупная":         nil,
+		"Крупа гречневая":      {"high_carb"},
+	}
+	for name, expected := range cases {
+		categories := checker.ingredientCategories(name)
+		if len(categories) != len(expected) || (len(expected) > 0 && categories[0] != expected[0]) {
+			t.Errorf("%s: ожидалось %v, получено %v", name, expected, categories)
+		}
+	}
+}
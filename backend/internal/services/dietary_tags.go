@@ -0,0 +1,158 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+// recipeMealTypes - теги приема пищи (не входят в справочник диет и аллергенов)
+var recipeMealTypes = map[string]bool{
+	"breakfast": true,
+	"lunch":     true,
+	"dinner":    true,
+	"snack":     true,
+}
+
+// recipeSeasonTags и recipeDifficulties - теги сезона и сложности; кухня задается
+// тегом с префиксом cuisine: (cuisine:italian). Сезон и сложность тоже можно
+// указать с префиксом: season:summer, difficulty:hard.
+var (
+	recipeSeasonTags = map[string]bool{
+		"winter": true,
+		"spring": true,
+		"summer": true,
+		"autumn": true,
+	}
+	recipeDifficulties = map[string]bool{
+		"easy":   true,
+		"medium": true,
+		"hard":   true,
+	}
+)
+
+const (
+	cuisineTagPrefix    = "cuisine:"
+	seasonTagPrefix     = "season:"
+	difficultyTagPrefix = "difficulty:"
+)
+
+// DietTaxonomy - справочник диет и аллергенов для разбора тегов рецепта
+type DietTaxonomy struct {
+	tags map[string]models.DietaryTag // код или синоним в нижнем регистре -> тег
+}
+
+func NewDietTaxonomy(tags []models.DietaryTag) *DietTaxonomy {
+	taxonomy := &DietTaxonomy{tags: make(map[string]models.DietaryTag)}
+	for _, tag := range tags {
+		taxonomy.tags[normalizeTag(tag.Code)] = tag
+		for _, alias := range tag.Aliases {
+			taxonomy.tags[normalizeTag(alias)] = tag
+		}
+	}
+	return taxonomy
+}
+
+// Resolve находит тег справочника по коду или синониму
+func (t *DietTaxonomy) Resolve(tag string) (models.DietaryTag, bool) {
+	if t == nil {
+		return models.DietaryTag{}, false
+	}
+	found, ok := t.tags[normalizeTag(tag)]
+	return found, ok
+}
+
+// recipeTags - теги рецепта, разобранные по справочнику
+type recipeTags struct {
+	MealType   string
+	Cuisine    string
+	Seasons    []string
+	Difficulty string
+	DietTypes  []string
+	Allergens  []string
+	Unknown    []string
+}
+
+// classifyTags раскладывает теги на прием пищи, кухню, сезоны, сложность, диеты и
+// аллергены; теги, которых нет в справочнике, попадают в Unknown
+func (t *DietTaxonomy) classifyTags(tags []string) recipeTags {
+	result := recipeTags{DietTypes: []string{}, Allergens: []string{}}
+	seen := make(map[string]bool)
+	for _, raw := range tags {
+		tag := normalizeTag(raw)
+		if tag == "" {
+			continue
+		}
+		if recipeMealTypes[tag] {
+			result.MealType = tag
+			continue
+		}
+		if cuisine, ok := strings.CutPrefix(tag, cuisineTagPrefix); ok && cuisine != "" {
+			result.Cuisine = strings.ReplaceAll(strings.TrimSpace(cuisine), " ", "-")
+			continue
+		}
+		if season := strings.TrimPrefix(tag, seasonTagPrefix); recipeSeasonTags[season] {
+			if !seen[seasonTagPrefix+season] {
+				seen[seasonTagPrefix+season] = true
+				result.Seasons = append(result.Seasons, season)
+			}
+			continue
+		}
+		if difficulty := strings.TrimPrefix(tag, difficultyTagPrefix); recipeDifficulties[difficulty] {
+			result.Difficulty = difficulty
+			continue
+		}
+
+		found, ok := t.Resolve(tag)
+		if !ok {
+			result.Unknown = append(result.Unknown, raw)
+			continue
+		}
+		if seen[found.Code] {
+			continue
+		}
+		seen[found.Code] = true
+		if found.Kind == "allergen" {
+			result.Allergens = append(result.Allergens, found.Code)
+		} else {
+			result.DietTypes = append(result.DietTypes, found.Code)
+		}
+	}
+	return result
+}
+
+// recipeTagList формирует теги рецепта для экспорта: прием пищи, кухня, сезоны,
+// сложность, диеты и аллергены (обратное к classifyTags)
+func recipeTagList(recipe *models.Recipe) []string {
+	tags := []string{}
+	if recipe.MealType != "" {
+		tags = append(tags, recipe.MealType)
+	}
+	if recipe.Cuisine != "" {
+		tags = append(tags, cuisineTagPrefix+recipe.Cuisine)
+	}
+	tags = append(tags, recipe.Seasons...)
+	if recipe.Difficulty != "" {
+		tags = append(tags, recipe.Difficulty)
+	}
+	tags = append(tags, recipe.DietType...)
+	return append(tags, recipe.Allergens...)
+}
+
+// normalizeTag приводит тег к нижнему регистру без лишних пробелов
+func normalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+// splitTags разбирает список тегов, переданных строкой через запятую
+func splitTags(values ...string) []string {
+	var tags []string
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = normalizeTag(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
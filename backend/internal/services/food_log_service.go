@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/repositories"
+)
+
+type FoodLogService struct {
+	foodLogRepo *repositories.FoodLogRepository
+	recipeRepo  *repositories.RecipeRepository
+	menuRepo    *repositories.MenuRepository
+	goalsRepo   *repositories.GoalsRepository
+}
+
+func NewFoodLogService(
+	foodLogRepo *repositories.FoodLogRepository,
+	recipeRepo *repositories.RecipeRepository,
+	menuRepo *repositories.MenuRepository,
+	goalsRepo *repositories.GoalsRepository,
+) *FoodLogService {
+	return &FoodLogService{
+		foodLogRepo: foodLogRepo,
+		recipeRepo:  recipeRepo,
+		menuRepo:    menuRepo,
+		goalsRepo:   goalsRepo,
+	}
+}
+
+var validMealTypes = map[string]bool{
+	"breakfast": true,
+	"lunch":     true,
+	"dinner":    true,
+	"snack":     true,
+}
+
+// AddEntry добавляет запись в дневник питания
+func (s *FoodLogService) AddEntry(ctx context.Context, userID int, req *models.FoodLogRequest) (*models.FoodLogEntry, error) {
+	if !validMealTypes[req.MealType] {
+		return nil, fmt.Errorf("неверный meal_type: '%s'", req.MealType)
+	}
+
+	date := truncateToDate(time.Now())
+	if req.Date != "" {
+		parsed, err := time.Parse(models.DateLayout, req.Date)
+		if err != nil {
+			return nil, fmt.Errorf("неверный формат даты, ожидается YYYY-MM-DD")
+		}
+		date = parsed
+	}
+
+	portion := req.Portion
+	if portion == 0 {
+		portion = 1
+	}
+	if portion < 0 {
+		return nil, fmt.Errorf("количество порций должно быть положительным")
+	}
+
+	entry := &models.FoodLogEntry{
+		UserID:   userID,
+		Date:     date,
+		MealType: req.MealType,
+		Portion:  portion,
+	}
+
+	recipeID := req.RecipeID
+	if req.Planned {
+		planned, err := s.menuRepo.FindPlannedMeal(ctx, userID, date, req.MealType)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при поиске запланированного приема пищи: %w", err)
+		}
+		if planned == nil {
+			return nil, fmt.Errorf("на %s нет запланированного приема пищи '%s'", date.Format(models.DateLayout), req.MealType)
+		}
+		entry.MenuMealID = &planned.MenuMealID
+		recipeID = planned.RecipeID
+	}
+
+	if recipeID > 0 {
+		recipe, err := s.recipeRepo.GetByID(ctx, recipeID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении рецепта: %w", err)
+		}
+		if recipe == nil || !recipeVisibleTo(recipe, userID) {
+			return nil, fmt.Errorf("рецепт %d не найден", recipeID)
+		}
+		entry.RecipeID = &recipe.ID
+		applyRecipePortion(entry, recipe, portion)
+	} else {
+		if req.Name == "" {
+			return nil, fmt.Errorf("укажите recipe_id, planned или название продукта")
+		}
+		if req.Calories < 0 || req.Proteins < 0 || req.Fats < 0 || req.Carbs < 0 {
+			return nil, fmt.Errorf("КБЖУ не могут быть отрицательными")
+		}
+		// Для произвольной записи КБЖУ указываются на порцию
+		entry.Name = req.Name
+		entry.Calories = int(math.Round(float64(req.Calories) * portion))
+		entry.Proteins = req.Proteins * portion
+		entry.Fats = req.Fats * portion
+		entry.Carbs = req.Carbs * portion
+	}
+
+	if err := s.foodLogRepo.Create(ctx, entry); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении записи: %w", err)
+	}
+	return entry, nil
+}
+
+// GetDay возвращает дневник за день с итогами и сравнением с целями
+func (s *FoodLogService) GetDay(ctx context.Context, userID int, date time.Time) (*models.FoodLogDay, error) {
+	date = truncateToDate(date)
+	entries, err := s.foodLogRepo.GetByUserIDAndDateRange(ctx, userID, date, date)
+	if err != nil {
+		return nil, err
+	}
+	goals, err := s.goalsRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении целей пользователя: %w", err)
+	}
+
+	day := &models.FoodLogDay{
+		Date:    date.Format(models.DateLayout),
+		Entries: entries,
+		Totals:  sumFoodLog(entries),
+	}
+	day.Goals = compareWithGoals(day.Totals, goals, 1)
+	return day, nil
+}
+
+// GetWeek возвращает дневник за 7 дней начиная со startDate
+func (s *FoodLogService) GetWeek(ctx context.Context, userID int, startDate time.Time) (*models.FoodLogWeek, error) {
+	startDate = truncateToDate(startDate)
+	endDate := startDate.AddDate(0, 0, 6)
+
+	entries, err := s.foodLogRepo.GetByUserIDAndDateRange(ctx, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	goals, err := s.goalsRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении целей пользователя: %w", err)
+	}
+
+	byDate := make(map[string][]models.FoodLogEntry)
+	for _, entry := range entries {
+		key := entry.Date.Format(models.DateLayout)
+		byDate[key] = append(byDate[key], entry)
+	}
+
+	week := &models.FoodLogWeek{
+		StartDate: startDate.Format(models.DateLayout),
+		EndDate:   endDate.Format(models.DateLayout),
+		Days:      make([]models.FoodLogDay, 0, 7),
+		Totals:    sumFoodLog(entries),
+	}
+	for i := 0; i < 7; i++ {
+		key := startDate.AddDate(0, 0, i).Format(models.DateLayout)
+		dayEntries := byDate[key]
+		if dayEntries == nil {
+			dayEntries = []models.FoodLogEntry{}
+		}
+		totals := sumFoodLog(dayEntries)
+		week.Days = append(week.Days, models.FoodLogDay{
+			Date:    key,
+			Entries: dayEntries,
+			Totals:  totals,
+			Goals:   compareWithGoals(totals, goals, 1),
+		})
+	}
+
+	week.Average = models.NutritionTotals{
+		Calories: int(math.Round(float64(week.Totals.Calories) / 7)),
+		Proteins: week.Totals.Proteins / 7,
+		Fats:     week.Totals.Fats / 7,
+		Carbs:    week.Totals.Carbs / 7,
+	}
+	week.Goals = compareWithGoals(week.Totals, goals, 7)
+	return week, nil
+}
+
+// DeleteEntry удаляет запись дневника пользователя
+func (s *FoodLogService) DeleteEntry(ctx context.Context, userID, id int) error {
+	return s.foodLogRepo.Delete(ctx, id, userID)
+}
+
+// applyRecipePortion рассчитывает КБЖУ записи по рецепту:
+// КБЖУ рецепта указаны на все порции, portion - количество съеденных порций
+func applyRecipePortion(entry *models.FoodLogEntry, recipe *models.Recipe, portion float64) {
+	totals := recipeScale(portion).recipeNutrition(recipe)
+
+	entry.Name = recipe.Name
+	entry.Calories = totals.Calories
+	entry.Proteins = totals.Proteins
+	entry.Fats = totals.Fats
+	entry.Carbs = totals.Carbs
+}
+
+// sumFoodLog суммирует КБЖУ записей
+func sumFoodLog(entries []models.FoodLogEntry) models.NutritionTotals {
+	var totals models.NutritionTotals
+	for _, entry := range entries {
+		totals.Calories += entry.Calories
+		totals.Proteins += entry.Proteins
+		totals.Fats += entry.Fats
+		totals.Carbs += entry.Carbs
+	}
+	return totals
+}
+
+// compareWithGoals сравнивает фактическое питание за days дней с целями пользователя
+func compareWithGoals(totals models.NutritionTotals, goals *models.UserGoals, days int) *models.GoalComparison {
+	if goals == nil {
+		return nil
+	}
+
+	goal := models.NutritionTotals{
+		Calories: goals.DailyCalories * days,
+		Proteins: goals.TargetProteins * float64(days),
+		Fats:     goals.TargetFats * float64(days),
+		Carbs:    goals.TargetCarbs * float64(days),
+	}
+
+	comparison := &models.GoalComparison{
+		Goal: goal,
+		Difference: models.NutritionTotals{
+			Calories: totals.Calories - goal.Calories,
+			Proteins: totals.Proteins - goal.Proteins,
+			Fats:     totals.Fats - goal.Fats,
+			Carbs:    totals.Carbs - goal.Carbs,
+		},
+	}
+	if goal.Calories > 0 {
+		comparison.CaloriesPercent = math.Round(float64(totals.Calories)/float64(goal.Calories)*1000) / 10
+	}
+	return comparison
+}
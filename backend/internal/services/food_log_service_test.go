@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestApplyRecipePortion(t *testing.T) {
+	recipe := &models.Recipe{
+		Name:     "Овсянка",
+		Calories: 600,
+		Proteins: 20.0,
+		Fats:     10.0,
+		Carbs:    100.0,
+		Servings: 2,
+	}
+	
+	entry := &models.FoodLogEntry{}
+	applyRecipePortion(entry, recipe, 1.5)
+	
+	// 1.5 порции из 2: 600 / 2 * 1.5 = 450
+	if entry.Calories != 450 {
+		t.Errorf("Ожидалось 450 ккал, получено %d", entry.Calories)
+	}
+	if entry.Proteins != 15.0 {
+		t.Errorf("Ожидалось 15 г белка, получено %f", entry.Proteins)
+	}
+	if entry.Name != recipe.Name {
+		t.Errorf("Ожидалось название %s, получено %s", recipe.Name, entry.Name)
+	}
+}
+
+func TestCompareWithGoals(t *testing.T) {
+	goals := &models.UserGoals{
+		DailyCalories:  2000,
+		TargetProteins: 100,
+		TargetFats:     70,
+		TargetCarbs:    250,
+	}
+	totals := models.NutritionTotals{Calories: 12600, Proteins: 700, Fats: 490, Carbs: 1750}
+	
+	comparison := compareWithGoals(totals, goals, 7)
+	if comparison == nil {
+		t.Fatal("Сравнение не создано")
+	}
+	if comparison.Goal.Calories != 14000 {
+		t.Errorf("Ожидалась цель 14000 ккал за неделю, получено %d", comparison.Goal.Calories)
+	}
+	if comparison.Difference.Calories != -1400 {
+		t.Errorf("Ожидалась разница -1400 ккал, получено %d", comparison.Difference.Calories)
+	}
+	if comparison.CaloriesPercent != 90 {
+		t.Errorf("Ожидалось 90%%, получено %f", comparison.CaloriesPercent)
+	}
+	
+	if compareWithGoals(totals, nil, 1) != nil {
+		t.Error("Без целей сравнение должно быть nil")
+	}
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/repositories"
+)
+
+// Форматы заданий импорта
+const (
+	ImportFormatJSON      = "json"
+	ImportFormatSchemaOrg = "schema-org"
+)
+
+const (
+	importJobPollInterval      = 5 * time.Second
+	importJobHeartbeatInterval = 30 * time.Second
+	// Задание без heartbeat дольше этого времени считается прерванным перезапуском
+	// и возвращается в очередь. Все форматы импортируются через importRecipes в одной
+	// транзакции (точки сохранения рецептов вложены в нее и ничего не фиксируют сами),
+	// поэтому задание, прерванное остановкой сервера, ничего не записало. Воркер,
+	// который не остановлен, а завис дольше этого времени, может зафиксировать импорт
+	// уже после возврата задания в очередь: повторный запуск найдет эти рецепты по
+	// названию и обработает их по mode, но одновременно идущие запуски от дубликатов
+	// не защищены.
+	importJobStaleAfter = 2 * time.Minute
+	// Задание, прерванное столько раз подряд, больше не возвращается в очередь
+	importJobMaxAttempts = 3
+	// Прогресс сохраняется в базу не чаще раза в секунду
+	importJobProgressInterval = time.Second
+)
+
+// ImportJobService - фоновые задания импорта рецептов. Задания и загруженные файлы
+// хранятся в базе, воркеры забирают их из очереди; подписчики получают прогресс
+// выполняющихся на этом сервере заданий.
+type ImportJobService struct {
+	adminRecipeService *AdminRecipeService
+	jobRepo            *repositories.ImportJobRepository
+
+	wake        chan struct{}
+	mu          sync.Mutex
+	subscribers map[int]map[chan models.ImportJob]struct{}
+}
+
+func NewImportJobService(adminRecipeService *AdminRecipeService, jobRepo *repositories.ImportJobRepository) *ImportJobService {
+	return &ImportJobService{
+		adminRecipeService: adminRecipeService,
+		jobRepo:            jobRepo,
+		wake:               make(chan struct{}, 1),
+		subscribers:        make(map[int]map[chan models.ImportJob]struct{}),
+	}
+}
+
+// Submit проверяет файлы и ставит задание в очередь. files - recipes и ingredients
+// для json/csv/xlsx или загруженные файлы для schema-org.
+func (s *ImportJobService) Submit(ctx context.Context, userID int, format string, files []models.ImportJobFile, mode string, dryRun bool) (*models.ImportJob, error) {
+	opts, err := NewImportOptions(mode, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("файлы для импорта не переданы")
+	}
+
+	// Ошибки формата видны сразу, а не после запуска задания
+	switch format {
+	case ImportFormatJSON:
+		if _, err := importJobRecipes(files); err != nil {
+			return nil, err
+		}
+	case TableFormatCSV, TableFormatXLSX:
+		if _, err := ParseRecipeTable(format, importJobFile(files, "recipes"), importJobFile(files, "ingredients")); err != nil {
+			return nil, err
+		}
+	case ImportFormatSchemaOrg:
+	default:
+		return nil, fmt.Errorf("формат должен быть json, csv, xlsx или schema-org")
+	}
+
+	job := &models.ImportJob{
+		Format:    format,
+		Mode:      opts.Mode,
+		DryRun:    opts.DryRun,
+		Files:     files,
+		CreatedBy: &userID,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("ошибка при создании задания: %w", err)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Get возвращает задание по ID
+func (s *ImportJobService) Get(ctx context.Context, id int) (*models.ImportJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, sql.ErrNoRows
+	}
+	return job, nil
+}
+
+// GetRecent возвращает последние задания
+func (s *ImportJobService) GetRecent(ctx context.Context) ([]models.ImportJob, error) {
+	return s.jobRepo.GetRecent(ctx, 50)
+}
+
+// Subscribe подписывает на прогресс задания. Обновления приходят только от заданий,
+// выполняющихся на этом сервере, поэтому подписчику стоит также периодически
+// перечитывать задание (Get). Возвращаемую функцию нужно вызвать для отписки.
+func (s *ImportJobService) Subscribe(id int) (<-chan models.ImportJob, func()) {
+	ch := make(chan models.ImportJob, 16)
+	s.mu.Lock()
+	if s.subscribers[id] == nil {
+		s.subscribers[id] = make(map[chan models.ImportJob]struct{})
+	}
+	s.subscribers[id][ch] = struct{}{}
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.subscribers[id], ch)
+		if len(s.subscribers[id]) == 0 {
+			delete(s.subscribers, id)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// publish рассылает состояние задания подписчикам; медленный подписчик пропускает
+// промежуточные обновления
+func (s *ImportJobService) publish(job models.ImportJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers[job.ID] {
+		select {
+		case ch <- job:
+		default:
+		}
+	}
+}
+
+// Start запускает воркеры очереди. Задания, прерванные остановкой сервера,
+// возвращаются в очередь и выполняются заново (не больше importJobMaxAttempts раз).
+func (s *ImportJobService) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		go s.worker()
+	}
+}
+
+// worker выполняет задания вне HTTP-запросов, поэтому работает с фоновым контекстом
+func (s *ImportJobService) worker() {
+	ctx := context.Background()
+	ticker := time.NewTicker(importJobPollInterval)
+	defer ticker.Stop()
+	for {
+		message := fmt.Sprintf("задание прервано %d раз подряд и больше не запускается", importJobMaxAttempts)
+		requeued, failed, err := s.jobRepo.RequeueStale(ctx, importJobStaleAfter, importJobMaxAttempts, message)
+		if err != nil {
+			log.Printf("Ошибка при возврате прерванных заданий импорта: %v", err)
+		}
+		if requeued > 0 {
+			log.Printf("Возвращено в очередь прерванных заданий импорта: %d", requeued)
+		}
+		if failed > 0 {
+			log.Printf("Завершено с ошибкой заданий импорта, исчерпавших попытки: %d", failed)
+		}
+
+		job, err := s.jobRepo.ClaimNext(ctx)
+		if err != nil {
+			log.Printf("Ошибка при получении задания импорта: %v", err)
+		}
+		if job != nil {
+			s.run(ctx, job)
+			continue
+		}
+
+		select {
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// run выполняет задание и сохраняет результат или ошибку
+func (s *ImportJobService) run(ctx context.Context, job *models.ImportJob) {
+	log.Printf("Задание импорта %d: запуск (%s, попытка %d)", job.ID, job.Format, job.Attempts)
+	s.publish(*job)
+
+	// Heartbeat показывает, что задание не прервано
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(importJobHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := s.jobRepo.Heartbeat(ctx, job.ID, job.Attempts); err != nil {
+					log.Printf("Задание импорта %d: ошибка heartbeat: %v", job.ID, err)
+				}
+			}
+		}
+	}()
+
+	var lastSaved time.Time
+	opts := ImportOptions{
+		Mode:   job.Mode,
+		DryRun: job.DryRun,
+		Progress: func(processed, total int) {
+			job.Processed, job.Total = processed, total
+			s.publish(*job)
+			if time.Since(lastSaved) >= importJobProgressInterval || processed == total {
+				lastSaved = time.Now()
+				if err := s.jobRepo.UpdateProgress(ctx, job.ID, job.Attempts, processed, total); err != nil {
+					log.Printf("Задание импорта %d: ошибка сохранения прогресса: %v", job.ID, err)
+				}
+			}
+		},
+	}
+
+	result, err := s.execute(ctx, job, opts)
+	if err != nil {
+		log.Printf("Задание импорта %d: ошибка: %v", job.ID, err)
+		err = s.jobRepo.Fail(ctx, job.ID, job.Attempts, err.Error())
+	} else {
+		log.Printf("Задание импорта %d: импортировано %d, обновлено %d, пропущено %d, ошибок %d",
+			job.ID, result.Imported, result.Updated, result.Skipped, result.Failed)
+		err = s.jobRepo.Complete(ctx, job.ID, job.Attempts, result)
+	}
+	if err == sql.ErrNoRows {
+		log.Printf("Задание импорта %d: попытка %d устарела, задание уже возвращено в очередь - результат не сохранен", job.ID, job.Attempts)
+	} else if err != nil {
+		log.Printf("Задание импорта %d: ошибка сохранения результата: %v", job.ID, err)
+	}
+
+	if finished, err := s.jobRepo.GetByID(ctx, job.ID); err == nil && finished != nil {
+		s.publish(*finished)
+	}
+}
+
+// execute разбирает файлы задания и импортирует рецепты
+func (s *ImportJobService) execute(ctx context.Context, job *models.ImportJob, opts ImportOptions) (*ImportResult, error) {
+	switch job.Format {
+	case ImportFormatJSON:
+		recipes, err := importJobRecipes(job.Files)
+		if err != nil {
+			return nil, err
+		}
+		return s.adminRecipeService.ImportRecipes(ctx, recipes, opts)
+	case TableFormatCSV, TableFormatXLSX:
+		table, err := ParseRecipeTable(job.Format, importJobFile(job.Files, "recipes"), importJobFile(job.Files, "ingredients"))
+		if err != nil {
+			return nil, err
+		}
+		return s.adminRecipeService.ImportRecipeTable(ctx, table, opts)
+	case ImportFormatSchemaOrg:
+		sources := make([]SchemaOrgSource, 0, len(job.Files))
+		for _, file := range job.Files {
+			sources = append(sources, SchemaOrgSource{Name: file.Name, Data: file.Data})
+		}
+		return s.adminRecipeService.ImportSchemaOrg(ctx, sources, opts)
+	}
+	return nil, fmt.Errorf("неизвестный формат задания: %s", job.Format)
+}
+
+// importJobFile возвращает файл задания по имени (nil, если его нет)
+func importJobFile(files []models.ImportJobFile, name string) []byte {
+	for _, file := range files {
+		if file.Name == name {
+			return file.Data
+		}
+	}
+	return nil
+}
+
+// importJobRecipes читает рецепты из JSON задания ({"recipes": [...]})
+func importJobRecipes(files []models.ImportJobFile) ([]models.RecipeImportDTO, error) {
+	var req models.RecipeImportRequest
+	if err := json.Unmarshal(importJobFile(files, "recipes"), &req); err != nil {
+		return nil, fmt.Errorf("неверный JSON: %w", err)
+	}
+	if len(req.Recipes) == 0 {
+		return nil, fmt.Errorf("список рецептов пуст")
+	}
+	return req.Recipes, nil
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestImportJobService_SubmitValidation(t *testing.T) {
+	service := NewImportJobService(nil, nil)
+	files := []models.ImportJobFile{{Name: "recipes", Data: []byte(`{"recipes": []}`)}}
+
+	cases := []struct {
+		name   string
+		format string
+		files  []models.ImportJobFile
+		mode   string
+	}{
+		{"неизвестный режим", ImportFormatJSON, files, "replace"},
+		{"неизвестный формат", "yaml", files, ""},
+		{"нет файлов", ImportFormatJSON, nil, ""},
+		{"пустой список рецептов", ImportFormatJSON, files, ""},
+		{"таблица без title", TableFormatCSV, []models.ImportJobFile{{Name: "recipes", Data: []byte("name\nСуп\n")}}, ""},
+	}
+	for _, c := range cases {
+		if _, err := service.Submit(context.Background(), 1, c.format, c.files, c.mode, false); err == nil {
+			t.Errorf("%s: ожидалась ошибка до создания задания", c.name)
+		}
+	}
+}
+
+func TestImportJobService_Subscribe(t *testing.T) {
+	service := NewImportJobService(nil, nil)
+	updates, unsubscribe := service.Subscribe(7)
+
+	service.publish(models.ImportJob{ID: 7, Status: "running", Processed: 3, Total: 10})
+	service.publish(models.ImportJob{ID: 8, Status: "running"})
+
+	select {
+	case job := <-updates:
+		if job.ID != 7 || job.Processed != 3 {
+			t.Errorf("Ожидался прогресс задания 7, получено %+v", job)
+		}
+	default:
+		t.Fatal("Подписчик не получил обновление")
+	}
+	select {
+	case job := <-updates:
+		t.Errorf("Обновление чужого задания: %+v", job)
+	default:
+	}
+
+	unsubscribe()
+	if len(service.subscribers) != 0 {
+		t.Error("После отписки подписчики должны быть удалены")
+	}
+	// Публикация без подписчиков не блокируется
+	service.publish(models.ImportJob{ID: 7})
+}
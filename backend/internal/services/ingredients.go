@@ -0,0 +1,16 @@
+package services
+
+import "strings"
+
+// normalizeIngredientName приводит название ингредиента к виду для сравнения
+func normalizeIngredientName(name string) string {
+	// Улучшенная нормализация: приводим к нижнему регистру и убираем лишние пробелы
+	normalized := strings.ToLower(name)
+	// Убираем лишние пробелы
+	normalized = strings.TrimSpace(normalized)
+	// Заменяем множественные пробелы на один
+	for strings.Contains(normalized, "  ") {
+		normalized = strings.ReplaceAll(normalized, "  ", " ")
+	}
+	return normalized
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+// DefaultMealTimes - время приема пищи по умолчанию (ЧЧ:ММ)
+var DefaultMealTimes = map[string]string{
+	"breakfast": "08:00",
+	"lunch":     "13:00",
+	"dinner":    "19:00",
+	"snack":     "16:00",
+}
+
+var mealTypeTitles = map[string]string{
+	"breakfast": "Завтрак",
+	"lunch":     "Обед",
+	"dinner":    "Ужин",
+	"snack":     "Перекус",
+}
+
+// CalendarMeal - прием пищи, привязанный к дате
+type CalendarMeal struct {
+	Date        time.Time
+	MealType    string
+	RecipeID    int
+	Name        string
+	CookingTime int
+}
+
+// ExportCalendar формирует iCalendar (.ics) для меню: событие начинается
+// за CookingTime минут до приема пищи, чтобы было видно, когда начинать готовить
+func (s *MenuService) ExportCalendar(ctx context.Context, menu *models.Menu, mealTimes map[string]string) (string, error) {
+	meals, err := s.calendarMeals(ctx, menu)
+	if err != nil {
+		return "", err
+	}
+
+	times := make(map[string]string, len(DefaultMealTimes))
+	for mealType, t := range DefaultMealTimes {
+		times[mealType] = t
+	}
+	for mealType, t := range mealTimes {
+		if t != "" {
+			times[mealType] = t
+		}
+	}
+
+	return buildMenuCalendar(menu.ID, meals, times, time.Now())
+}
+
+// calendarMeals собирает приемы пищи меню с датами
+func (s *MenuService) calendarMeals(ctx context.Context, menu *models.Menu) ([]CalendarMeal, error) {
+	var meals []CalendarMeal
+
+	if menu.MenuType == "weekly" {
+		days, err := s.menuRepo.GetWeeklyDays(ctx, menu.ID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении дней недельного меню: %w", err)
+		}
+		for _, day := range days {
+			date, err := time.Parse(models.DateLayout, day.Date)
+			if err != nil {
+				return nil, fmt.Errorf("неверная дата дня %d: %w", day.Day, err)
+			}
+			for _, meal := range []struct {
+				recipe   *models.RecipeDTO
+				mealType string
+			}{
+				{day.Breakfast, "breakfast"},
+				{day.Lunch, "lunch"},
+				{day.Dinner, "dinner"},
+			} {
+				if meal.recipe == nil {
+					continue
+				}
+				meals = append(meals, CalendarMeal{
+					Date:        date,
+					MealType:    meal.mealType,
+					RecipeID:    meal.recipe.ID,
+					Name:        meal.recipe.Name,
+					CookingTime: meal.recipe.CookingTime,
+				})
+			}
+		}
+		return meals, nil
+	}
+
+	for _, meal := range menu.Meals {
+		calendarMeal := CalendarMeal{
+			Date:        menu.Date,
+			MealType:    meal.MealType,
+			RecipeID:    meal.RecipeID,
+			Name:        fmt.Sprintf("Рецепт #%d", meal.RecipeID),
+			CookingTime: meal.Time,
+		}
+		recipe, err := s.recipeRepo.GetByID(ctx, meal.RecipeID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении рецепта %d: %w", meal.RecipeID, err)
+		}
+		if recipe != nil {
+			calendarMeal.Name = recipe.Name
+			calendarMeal.CookingTime = recipe.CookingTime
+		}
+		meals = append(meals, calendarMeal)
+	}
+	return meals, nil
+}
+
+// buildMenuCalendar формирует текст календаря в формате RFC 5545.
+// Время указывается как "плавающее" (без часового пояса) - в локальном времени пользователя.
+func buildMenuCalendar(menuID int, meals []CalendarMeal, mealTimes map[string]string, now time.Time) (string, error) {
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldICSLine(line))
+		b.WriteString("\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//MyPlate//Menu Calendar//RU")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine(fmt.Sprintf("X-WR-CALNAME:%s", escapeICSText(fmt.Sprintf("Меню #%d", menuID))))
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, meal := range meals {
+		mealTime, ok := mealTimes[meal.MealType]
+		if !ok {
+			return "", fmt.Errorf("не задано время для приема пищи '%s'", meal.MealType)
+		}
+		clock, err := time.Parse("15:04", mealTime)
+		if err != nil {
+			return "", fmt.Errorf("неверный формат времени '%s' для '%s', ожидается ЧЧ:ММ", mealTime, meal.MealType)
+		}
+
+		end := time.Date(meal.Date.Year(), meal.Date.Month(), meal.Date.Day(),
+			clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+		start := end.Add(-time.Duration(meal.CookingTime) * time.Minute)
+
+		title := mealTypeTitles[meal.MealType]
+		if title == "" {
+			title = meal.MealType
+		}
+
+		writeLine("BEGIN:VEVENT")
+		writeLine(fmt.Sprintf("UID:menu-%d-%s-%s@myplate", menuID, meal.Date.Format("20060102"), meal.MealType))
+		writeLine("DTSTAMP:" + stamp)
+		writeLine("DTSTART:" + start.Format("20060102T150405"))
+		writeLine("DTEND:" + end.Format("20060102T150405"))
+		writeLine("SUMMARY:" + escapeICSText(fmt.Sprintf("%s: %s", title, meal.Name)))
+		writeLine("DESCRIPTION:" + escapeICSText(fmt.Sprintf(
+			"Начните готовить в %s, время приготовления %d мин.", start.Format("15:04"), meal.CookingTime)))
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+	return b.String(), nil
+}
+
+// escapeICSText экранирует спецсимволы текстовых значений iCalendar
+func escapeICSText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(text)
+}
+
+// foldICSLine переносит строки длиннее 75 байт, не разрывая UTF-8 символы
+func foldICSLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var b strings.Builder
+	lineLen := 0
+	for _, r := range line {
+		size := len(string(r))
+		if lineLen+size > limit {
+			b.WriteString("\r\n ")
+			lineLen = 1
+		}
+		b.WriteRune(r)
+		lineLen += size
+	}
+	return b.String()
+}
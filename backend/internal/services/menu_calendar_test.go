@@ -0,0 +1,50 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMenuCalendar_StartsBeforeMeal(t *testing.T) {
+	meals := []CalendarMeal{
+		{
+			Date:        time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+			MealType:    "dinner",
+			RecipeID:    7,
+			Name:        "Паста, томаты; базилик",
+			CookingTime: 45,
+		},
+	}
+	
+	ics, err := buildMenuCalendar(1, meals, DefaultMealTimes, time.Now())
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	
+	// Ужин в 19:00, готовка 45 минут - начинаем в 18:15
+	if !strings.Contains(ics, "DTSTART:20240304T181500\r\n") {
+		t.Errorf("Ожидалось начало события в 18:15, календарь:\n%s", ics)
+	}
+	if !strings.Contains(ics, "DTEND:20240304T190000\r\n") {
+		t.Errorf("Ожидалось окончание события в 19:00, календарь:\n%s", ics)
+	}
+	if !strings.Contains(ics, `Паста\, томаты\; базилик`) {
+		t.Errorf("Ожидалось экранирование запятых и точек с запятой, календарь:\n%s", ics)
+	}
+	
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Строка длиннее 75 байт: %q", line)
+		}
+	}
+}
+
+func TestBuildMenuCalendar_InvalidMealTime(t *testing.T) {
+	meals := []CalendarMeal{{Date: time.Now(), MealType: "lunch", CookingTime: 10}}
+	
+	_, err := buildMenuCalendar(1, meals, map[string]string{"lunch": "25:99"}, time.Now())
+	if err == nil {
+		t.Error("Ожидалась ошибка для неверного времени")
+	}
+}
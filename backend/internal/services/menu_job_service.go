@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+var (
+	ErrMenuJobFinished = errors.New("генерация меню уже завершена")
+	ErrTooManyMenuJobs = errors.New("слишком много незавершенных генераций меню, дождитесь их окончания или отмените")
+)
+
+const (
+	// Завершенные задания хранятся в памяти, чтобы клиент успел забрать результат
+	menuJobRetention = time.Hour
+	// Незавершенных заданий на пользователя не больше
+	maxActiveMenuJobs = 2
+)
+
+// MenuJobService - фоновая генерация недельного меню. Задания выполняются на этом
+// сервере и хранятся в памяти: генерацию дешевле запустить заново, чем переносить
+// между серверами. Отмена задания отменяет контекст генерации.
+type MenuJobService struct {
+	menuService *MenuService
+	slots       chan struct{} // одновременно выполняемые генерации
+
+	mu     sync.Mutex
+	nextID int
+	jobs   map[int]*menuJobState
+}
+
+// menuJobState - задание, функция его отмены и подписчики на прогресс
+type menuJobState struct {
+	job         models.MenuJob
+	cancel      context.CancelFunc
+	subscribers map[chan models.MenuJob]struct{}
+}
+
+// NewMenuJobService создает сервис; workers - сколько генераций выполняется одновременно
+func NewMenuJobService(menuService *MenuService, workers int) *MenuJobService {
+	return &MenuJobService{
+		menuService: menuService,
+		slots:       make(chan struct{}, max(workers, 1)),
+		jobs:        make(map[int]*menuJobState),
+	}
+}
+
+// Submit ставит генерацию недельного меню в очередь
+func (s *MenuJobService) Submit(req *models.WeeklyMenuRequest) (*models.MenuJob, error) {
+	s.mu.Lock()
+	active := 0
+	for _, state := range s.jobs {
+		if state.job.UserID == req.UserID && !state.job.Finished() {
+			active++
+		}
+	}
+	if active >= maxActiveMenuJobs {
+		s.mu.Unlock()
+		return nil, ErrTooManyMenuJobs
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.nextID++
+	state := &menuJobState{
+		job: models.MenuJob{
+			ID:        s.nextID,
+			UserID:    req.UserID,
+			Status:    "pending",
+			Progress:  models.WeeklyMenuProgress{TotalDays: 7},
+			CreatedAt: time.Now(),
+		},
+		cancel:      cancel,
+		subscribers: make(map[chan models.MenuJob]struct{}),
+	}
+	s.jobs[state.job.ID] = state
+	job := state.job
+	s.mu.Unlock()
+
+	go s.run(ctx, job.ID, req)
+	return &job, nil
+}
+
+// Get возвращает задание пользователя
+func (s *MenuJobService) Get(userID, id int) (*models.MenuJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.jobs[id]
+	if !ok || state.job.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	job := state.job
+	return &job, nil
+}
+
+// Cancel отменяет незавершенное задание пользователя
+func (s *MenuJobService) Cancel(userID, id int) (*models.MenuJob, error) {
+	s.mu.Lock()
+	state, ok := s.jobs[id]
+	if !ok || state.job.UserID != userID {
+		s.mu.Unlock()
+		return nil, sql.ErrNoRows
+	}
+	if state.job.Finished() {
+		s.mu.Unlock()
+		return nil, ErrMenuJobFinished
+	}
+	state.cancel()
+	s.finishLocked(state, "cancelled", nil, "")
+	job := state.job
+	s.mu.Unlock()
+
+	log.Printf("Генерация меню %d: отменена", id)
+	return &job, nil
+}
+
+// Subscribe подписывает на прогресс задания пользователя. Возвращаемую функцию
+// нужно вызвать для отписки.
+func (s *MenuJobService) Subscribe(userID, id int) (<-chan models.MenuJob, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.jobs[id]
+	if !ok || state.job.UserID != userID {
+		return nil, nil, sql.ErrNoRows
+	}
+
+	ch := make(chan models.MenuJob, 16)
+	state.subscribers[ch] = struct{}{}
+	return ch, func() {
+		s.mu.Lock()
+		delete(state.subscribers, ch)
+		s.mu.Unlock()
+	}, nil
+}
+
+// run ждет свободного слота и генерирует меню
+func (s *MenuJobService) run(ctx context.Context, id int, req *models.WeeklyMenuRequest) {
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		return // отменено в очереди
+	}
+
+	if !s.update(id, func(job *models.MenuJob) {
+		now := time.Now()
+		job.Status = "running"
+		job.StartedAt = &now
+	}) {
+		return
+	}
+
+	weeklyMenu, err := s.menuService.GenerateWeeklyMenu(ctx, req, func(progress models.WeeklyMenuProgress) {
+		s.update(id, func(job *models.MenuJob) {
+			job.Progress = progress
+		})
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.jobs[id]
+	if !ok || state.job.Finished() {
+		return // отменено во время генерации и, возможно, уже удалено
+	}
+	if err != nil {
+		log.Printf("Генерация меню %d: ошибка: %v", id, err)
+		s.finishLocked(state, "failed", nil, err.Error())
+		return
+	}
+	state.job.Progress.BestScore = weeklyMenu.Score
+	s.finishLocked(state, "completed", weeklyMenu, "")
+}
+
+// update меняет незавершенное задание и рассылает его подписчикам; false - задание
+// уже завершено (отменено) или удалено из памяти
+func (s *MenuJobService) update(id int, change func(job *models.MenuJob)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.jobs[id]
+	if !ok || state.job.Finished() {
+		return false
+	}
+	change(&state.job)
+	s.publishLocked(state)
+	return true
+}
+
+// finishLocked завершает задание и через menuJobRetention удаляет его из памяти
+func (s *MenuJobService) finishLocked(state *menuJobState, status string, result *models.WeeklyMenu, errorText string) {
+	now := time.Now()
+	state.job.Status = status
+	state.job.Result = result
+	state.job.Error = errorText
+	state.job.FinishedAt = &now
+	state.cancel()
+	s.publishLocked(state)
+
+	id := state.job.ID
+	time.AfterFunc(menuJobRetention, func() {
+		s.mu.Lock()
+		delete(s.jobs, id)
+		s.mu.Unlock()
+	})
+}
+
+// publishLocked рассылает состояние задания подписчикам; медленный подписчик
+// пропускает промежуточные обновления
+func (s *MenuJobService) publishLocked(state *menuJobState) {
+	for ch := range state.subscribers {
+		select {
+		case ch <- state.job:
+		default:
+		}
+	}
+}
+
+// weeklyMenuScore оценивает меню из готовых дней от 0 до 1: поровну близость
+// калорийности дней к цели (adults*2000 + children*1400) и среднее отклонение БЖУ
+// от 25/30/45% энергии, как в MenuOptimizer
+func weeklyMenuScore(days []models.WeeklyDayMenu, adults, children int) float64 {
+	if len(days) == 0 {
+		return 0
+	}
+	if adults == 0 {
+		adults = 1
+	}
+	targetCalories := float64(adults*2000 + children*1400)
+
+	calorieFit := 0.0
+	var proteins, fats, carbs float64
+	for _, day := range days {
+		calorieFit += math.Max(0, 1-math.Abs(float64(day.TotalCalories)/targetCalories-1))
+		proteins += day.TotalProteins
+		fats += day.TotalFats
+		carbs += day.TotalCarbs
+	}
+	calorieFit /= float64(len(days))
+
+	total := targetCalories * float64(len(days))
+	deviation := (math.Abs(proteins/(total*0.25/4)-1) +
+		math.Abs(fats/(total*0.30/9)-1) +
+		math.Abs(carbs/(total*0.45/4)-1)) / 3
+	macroFit := math.Max(0, 1-deviation)
+
+	return math.Round((calorieFit+macroFit)/2*1000) / 1000
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestMenuJobService_QueueAndCancel(t *testing.T) {
+	service := NewMenuJobService(nil, 1)
+	// Единственный слот занят - задания ждут в очереди и не обращаются к базе
+	service.slots <- struct{}{}
+
+	first, err := service.Submit(&models.WeeklyMenuRequest{UserID: 1, Adults: 2})
+	if err != nil || first.Status != "pending" || first.Progress.TotalDays != 7 {
+		t.Fatalf("Ожидалось задание в очереди, получено %+v, %v", first, err)
+	}
+	second, _ := service.Submit(&models.WeeklyMenuRequest{UserID: 1, Adults: 2})
+	if _, err := service.Submit(&models.WeeklyMenuRequest{UserID: 1, Adults: 2}); err != ErrTooManyMenuJobs {
+		t.Errorf("Ожидалась ошибка ErrTooManyMenuJobs, получено %v", err)
+	}
+
+	if _, err := service.Get(2, first.ID); err != sql.ErrNoRows {
+		t.Errorf("Чужое задание не должно быть доступно, получено %v", err)
+	}
+
+	updates, unsubscribe, err := service.Subscribe(1, first.ID)
+	if err != nil {
+		t.Fatalf("Ошибка подписки: %v", err)
+	}
+	defer unsubscribe()
+
+	cancelled, err := service.Cancel(1, first.ID)
+	if err != nil || cancelled.Status != "cancelled" || cancelled.FinishedAt == nil {
+		t.Fatalf("Ожидалась отмена задания, получено %+v, %v", cancelled, err)
+	}
+	if update := <-updates; update.Status != "cancelled" {
+		t.Errorf("Подписчик должен получить отмену, получено %s", update.Status)
+	}
+	if _, err := service.Cancel(1, first.ID); err != ErrMenuJobFinished {
+		t.Errorf("Ожидалась ошибка ErrMenuJobFinished, получено %v", err)
+	}
+
+	// После отмены можно запустить новую генерацию
+	third, err := service.Submit(&models.WeeklyMenuRequest{UserID: 1, Adults: 2})
+	if err != nil {
+		t.Fatalf("Ожидалось новое задание, получено %v", err)
+	}
+	service.Cancel(1, second.ID)
+	service.Cancel(1, third.ID)
+}
+
+func TestMenuJobService_UpdateAfterRemoval(t *testing.T) {
+	service := NewMenuJobService(nil, 1)
+	service.slots <- struct{}{}
+
+	job, err := service.Submit(&models.WeeklyMenuRequest{UserID: 1, Adults: 2})
+	if err != nil {
+		t.Fatalf("Ошибка постановки в очередь: %v", err)
+	}
+	service.Cancel(1, job.ID)
+
+	// Задание удалено по истечении menuJobRetention, а генерация еще сообщает прогресс
+	service.mu.Lock()
+	delete(service.jobs, job.ID)
+	service.mu.Unlock()
+
+	if service.update(job.ID, func(job *models.MenuJob) { job.Status = "running" }) {
+		t.Error("Удаленное задание не должно обновляться")
+	}
+	if _, err := service.Get(1, job.ID); err != sql.ErrNoRows {
+		t.Errorf("Ожидалась sql.ErrNoRows, получено %v", err)
+	}
+}
+
+func TestWeeklyMenuScore(t *testing.T) {
+	// 2000 ккал: 125 г белка, 66.7 г жиров, 225 г углеводов - идеальный день
+	ideal := models.WeeklyDayMenu{TotalCalories: 2000, TotalProteins: 125, TotalFats: 2000 * 0.30 / 9, TotalCarbs: 225}
+	if score := weeklyMenuScore([]models.WeeklyDayMenu{ideal, ideal}, 1, 0); score != 1 {
+		t.Errorf("Ожидалась оценка 1, получено %v", score)
+	}
+
+	// Половина калорий и только углеводы
+	poor := models.WeeklyDayMenu{TotalCalories: 1000, TotalCarbs: 250}
+	if score := weeklyMenuScore([]models.WeeklyDayMenu{poor}, 1, 0); score >= 0.5 {
+		t.Errorf("Несбалансированный день должен получить низкую оценку, получено %v", score)
+	}
+	if weeklyMenuScore(nil, 1, 0) != 0 {
+		t.Error("Пустое меню оценивается в 0")
+	}
+}
@@ -2,12 +2,20 @@ package services
 
 import (
 	"math"
+	"time"
 
 	"github.com/myplate/backend/internal/models"
 )
 
 // MenuOptimizer оптимизирует недельное меню по балансу БЖУ
-type MenuOptimizer struct{}
+type MenuOptimizer struct {
+	// nutrientLimits - лимиты по микронутриентам на человека в день, замены не должны их нарушать
+	nutrientLimits models.Micronutrients
+	// maxTotalTime, weekdayUnavailableEquipment и weekdayMaxHard - ограничения запроса, замены их не нарушают
+	maxTotalTime                int
+	weekdayUnavailableEquipment []string
+	weekdayMaxHard              *int
+}
 
 func NewMenuOptimizer() *MenuOptimizer {
 	return &MenuOptimizer{}
@@ -89,12 +97,23 @@ func (o *MenuOptimizer) OptimizeWeeklyMacros(weeklyMenu *models.WeeklyMenu, allR
 				alternative := o.findAlternative(
 					allRecipes, meal.mealType, usedRecipes, dayIdx,
 					deviationP, deviationF, deviationC,
-					dayMenu.TotalCalories, fullRecipe.Calories,
+					fullRecipe, o.unavailableEquipment(dayMenu),
 				)
 				
 				if alternative != nil {
 					// Заменяем блюдо
+					previous := *dayMenu
 					o.replaceMeal(dayMenu, meal.mealType, alternative, adults, children)
+					if len(dayMenu.LimitViolations) > len(previous.LimitViolations) {
+						// Замена нарушает лимиты по микронутриентам или ограничения дня - откатываем
+						*dayMenu = previous
+						continue
+					}
+					if o.maxTotalTime > 0 && dayMenu.TotalTime > o.maxTotalTime && dayMenu.TotalTime > previous.TotalTime {
+						// Замена не укладывается во время приготовления - откатываем
+						*dayMenu = previous
+						continue
+					}
 					
 					// Обновляем использованные рецепты
 					if usedRecipes[dayIdx] == nil {
@@ -124,41 +143,14 @@ func (o *MenuOptimizer) OptimizeWeeklyMacros(weeklyMenu *models.WeeklyMenu, allR
 
 // calculateWeeklyMacros подсчитывает суммарные БЖУ за неделю
 func (o *MenuOptimizer) calculateWeeklyMacros(weeklyMenu *models.WeeklyMenu, adults int, children int) (float64, float64, float64) {
-	totalServings := float64(adults) + float64(children)*0.7
-	if totalServings == 0 {
-		totalServings = 1.0
-	}
+	scale := peopleScale(adults, children)
 	
 	var totalP, totalF, totalC float64
-	
-	for _, day := range weeklyMenu.Week {
-		if day.Breakfast != nil {
-			servingMultiplier := totalServings / float64(day.Breakfast.Servings)
-			if servingMultiplier == 0 {
-				servingMultiplier = 1.0
-			}
-			totalP += day.Breakfast.Proteins * servingMultiplier
-			totalF += day.Breakfast.Fats * servingMultiplier
-			totalC += day.Breakfast.Carbs * servingMultiplier
-		}
-		if day.Lunch != nil {
-			servingMultiplier := totalServings / float64(day.Lunch.Servings)
-			if servingMultiplier == 0 {
-				servingMultiplier = 1.0
-			}
-			totalP += day.Lunch.Proteins * servingMultiplier
-			totalF += day.Lunch.Fats * servingMultiplier
-			totalC += day.Lunch.Carbs * servingMultiplier
-		}
-		if day.Dinner != nil {
-			servingMultiplier := totalServings / float64(day.Dinner.Servings)
-			if servingMultiplier == 0 {
-				servingMultiplier = 1.0
-			}
-			totalP += day.Dinner.Proteins * servingMultiplier
-			totalF += day.Dinner.Fats * servingMultiplier
-			totalC += day.Dinner.Carbs * servingMultiplier
-		}
+	for i := range weeklyMenu.Week {
+		totals := scale.dayNutrition(&weeklyMenu.Week[i])
+		totalP += totals.Proteins
+		totalF += totals.Fats
+		totalC += totals.Carbs
 	}
 	
 	return totalP, totalF, totalC
@@ -205,12 +197,15 @@ func (o *MenuOptimizer) shouldReplaceMeal(
 	return false
 }
 
-// findAlternative находит альтернативный рецепт
+// findAlternative ищет замену блюду current, которая корректирует баланс БЖУ лучше него.
+// Из таких рецептов предпочитаются похожие на current (recipeSimilarity): сходство
+// увеличивает выигрыш в балансе в 0.5-1.5 раза, поэтому замена остается узнаваемой,
+// но заметно лучшая коррекция все равно побеждает.
 func (o *MenuOptimizer) findAlternative(
 	allRecipes []models.Recipe, mealType string,
 	usedRecipes map[int][]int, currentDay int,
 	deviationP, deviationF, deviationC float64,
-	targetDayCalories int, currentCalories int,
+	current *models.Recipe, unavailableEquipment []string,
 ) *models.Recipe {
 	// Получаем список рецептов, которые нельзя использовать (последние 3 дня)
 	excludedIDs := make(map[int]bool)
@@ -222,12 +217,14 @@ func (o *MenuOptimizer) findAlternative(
 		}
 	}
 	
+	currentScore := o.calculateReplacementScore(current, deviationP, deviationF, deviationC)
+	
 	var bestRecipe *models.Recipe
-	bestScore := math.Inf(-1)
+	bestScore := 0.0
 	
 	for _, recipe := range allRecipes {
 		// Проверяем категорию
-		if recipe.MealType != mealType {
+		if recipe.MealType != mealType || recipe.ID == current.ID {
 			continue
 		}
 		
@@ -236,16 +233,25 @@ func (o *MenuOptimizer) findAlternative(
 			continue
 		}
 		
+		// Оборудование должно быть доступно в этот день
+		if usesEquipment(&recipe, unavailableEquipment) {
+			continue
+		}
+		
 		// Проверяем близость калорий (в пределах ±20%)
-		calDiff := math.Abs(float64(recipe.Calories-currentCalories)) / float64(currentCalories)
+		calDiff := math.Abs(float64(recipe.Calories-current.Calories)) / float64(current.Calories)
 		if calDiff > 0.2 {
 			continue
 		}
 		
-		// Вычисляем score: насколько хорошо рецепт корректирует баланс
+		// Замена должна корректировать баланс лучше текущего блюда
 		recipePtr := recipe // Создаем указатель на копию
-		score := o.calculateReplacementScore(&recipePtr, deviationP, deviationF, deviationC)
+		gain := o.calculateReplacementScore(&recipePtr, deviationP, deviationF, deviationC) - currentScore
+		if gain <= 0 {
+			continue
+		}
 		
+		score := gain * (0.5 + recipeSimilarity(current, &recipePtr).Score)
 		if score > bestScore {
 			bestScore = score
 			bestRecipe = &recipePtr
@@ -307,52 +313,37 @@ func (o *MenuOptimizer) replaceMeal(
 	}
 	
 	// Пересчитываем итоги дня
-	dayMenu.TotalCalories = 0
-	dayMenu.TotalProteins = 0
-	dayMenu.TotalFats = 0
-	dayMenu.TotalCarbs = 0
-	dayMenu.TotalTime = 0
-	
-	totalServings := float64(adults) + float64(children)*0.7
-	if totalServings == 0 {
-		totalServings = 1.0
-	}
-	
-	if dayMenu.Breakfast != nil {
-		multiplier := totalServings / float64(dayMenu.Breakfast.Servings)
-		if multiplier == 0 {
-			multiplier = 1.0
-		}
-		dayMenu.TotalCalories += int(float64(dayMenu.Breakfast.Calories) * multiplier)
-		dayMenu.TotalProteins += dayMenu.Breakfast.Proteins * multiplier
-		dayMenu.TotalFats += dayMenu.Breakfast.Fats * multiplier
-		dayMenu.TotalCarbs += dayMenu.Breakfast.Carbs * multiplier
-		dayMenu.TotalTime += dayMenu.Breakfast.CookingTime
-	}
+	scale := peopleScale(adults, children)
+	totalServings := float64(scale)
+	totals := scale.dayNutrition(dayMenu)
+	dayMenu.TotalCalories = totals.Calories
+	dayMenu.TotalProteins = totals.Proteins
+	dayMenu.TotalFats = totals.Fats
+	dayMenu.TotalCarbs = totals.Carbs
 	
-	if dayMenu.Lunch != nil {
-		multiplier := totalServings / float64(dayMenu.Lunch.Servings)
-		if multiplier == 0 {
-			multiplier = 1.0
-		}
-		dayMenu.TotalCalories += int(float64(dayMenu.Lunch.Calories) * multiplier)
-		dayMenu.TotalProteins += dayMenu.Lunch.Proteins * multiplier
-		dayMenu.TotalFats += dayMenu.Lunch.Fats * multiplier
-		dayMenu.TotalCarbs += dayMenu.Lunch.Carbs * multiplier
-		dayMenu.TotalTime += dayMenu.Lunch.CookingTime
+	dayMenu.TotalTime = dayMenuCookingTime(dayMenu)
+	dayMenu.TotalMicronutrients = dayMicronutrients(dayMenu, totalServings)
+	dayMenu.LimitViolations = append(nutrientLimitViolations(dayMenu.TotalMicronutrients, o.nutrientLimits, totalServings),
+		dayConstraintViolations(dayMenu, o.maxTotalTime, o.unavailableEquipment(dayMenu))...)
+	dayMenu.LimitViolations = append(dayMenu.LimitViolations, difficultyViolations(dayMenu, o.hardRecipeLimit(dayMenu))...)
+}
+
+// unavailableEquipment возвращает оборудование, недоступное в день меню
+func (o *MenuOptimizer) unavailableEquipment(dayMenu *models.WeeklyDayMenu) []string {
+	date, err := time.Parse(models.DateLayout, dayMenu.Date)
+	if err != nil {
+		return o.weekdayUnavailableEquipment
 	}
-	
-	if dayMenu.Dinner != nil {
-		multiplier := totalServings / float64(dayMenu.Dinner.Servings)
-		if multiplier == 0 {
-			multiplier = 1.0
-		}
-		dayMenu.TotalCalories += int(float64(dayMenu.Dinner.Calories) * multiplier)
-		dayMenu.TotalProteins += dayMenu.Dinner.Proteins * multiplier
-		dayMenu.TotalFats += dayMenu.Dinner.Fats * multiplier
-		dayMenu.TotalCarbs += dayMenu.Dinner.Carbs * multiplier
-		dayMenu.TotalTime += dayMenu.Dinner.CookingTime
+	return unavailableEquipment(date, o.weekdayUnavailableEquipment)
+}
+
+// hardRecipeLimit возвращает допустимое число сложных рецептов в день меню
+func (o *MenuOptimizer) hardRecipeLimit(dayMenu *models.WeeklyDayMenu) *int {
+	date, err := time.Parse(models.DateLayout, dayMenu.Date)
+	if err != nil {
+		return o.weekdayMaxHard
 	}
+	return hardRecipeLimit(date, o.weekdayMaxHard)
 }
 
 // recipeToDTO преобразует Recipe в RecipeDTO
@@ -365,11 +356,20 @@ func (o *MenuOptimizer) recipeToDTO(recipe *models.Recipe) *models.RecipeDTO {
 		Proteins:     recipe.Proteins,
 		Fats:         recipe.Fats,
 		Carbs:        recipe.Carbs,
+		Micronutrients: recipe.Micronutrients,
 		CookingTime:  recipe.CookingTime,
 		Servings:     recipe.Servings,
 		MealType:     recipe.MealType,
+		Cuisine:      recipe.Cuisine,
+		Seasons:      recipe.Seasons,
+		Difficulty:   recipe.Difficulty,
 		Ingredients:  recipe.Ingredients,
 		Instructions: recipe.Instructions,
+		Steps:        recipe.Steps,
+		ActiveTime:   recipe.ActiveTime,
+		Equipment:    recipe.Equipment,
+		Substitutions: recipe.Substitutions,
+		NutritionApproximate: recipe.NutritionApproximate,
 	}
 }
 
@@ -1,24 +1,28 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
-	"strings"
 	"time"
 
 	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/internal/repositories"
-	"github.com/myplate/backend/pkg/database"
 )
 
+// ErrInvalidWeeklyMenu - недельное меню для сохранения содержит неверные дни или рецепты
+var ErrInvalidWeeklyMenu = errors.New("неверное недельное меню")
+
 type MenuService struct {
 	recipeRepo  *repositories.RecipeRepository
 	menuRepo    *repositories.MenuRepository
 	pantryRepo  *repositories.PantryRepository
 	shoppingRepo *repositories.ShoppingListRepository
 	goalsRepo   *repositories.GoalsRepository
+	tagRepo     *repositories.DietaryTagRepository
+	feedbackRepo *repositories.RecipeFeedbackRepository
 }
 
 func NewMenuService(
@@ -27,6 +31,8 @@ func NewMenuService(
 	pantryRepo *repositories.PantryRepository,
 	shoppingRepo *repositories.ShoppingListRepository,
 	goalsRepo *repositories.GoalsRepository,
+	tagRepo *repositories.DietaryTagRepository,
+	feedbackRepo *repositories.RecipeFeedbackRepository,
 ) *MenuService {
 	return &MenuService{
 		recipeRepo:  recipeRepo,
@@ -34,23 +40,35 @@ func NewMenuService(
 		pantryRepo:  pantryRepo,
 		shoppingRepo: shoppingRepo,
 		goalsRepo:   goalsRepo,
+		tagRepo:     tagRepo,
+		feedbackRepo: feedbackRepo,
 	}
 }
 
-func (s *MenuService) GenerateMenu(req *models.MenuGenerateRequest) (*models.Menu, error) {
-	// Если целевые калории не указаны, берем из целей пользователя
-	if req.TargetCalories == 0 {
-		goals, err := s.goalsRepo.GetByUserID(req.UserID)
+func (s *MenuService) GenerateMenu(ctx context.Context, req *models.MenuGenerateRequest) (*models.Menu, error) {
+	// Если целевые калории или лимиты не указаны, берем из целей пользователя
+	if req.TargetCalories == 0 || len(req.NutrientLimits) == 0 {
+		goals, err := s.goalsRepo.GetByUserID(ctx, req.UserID)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка при получении целей пользователя: %w", err)
 		}
-		if goals != nil && goals.DailyCalories > 0 {
-			req.TargetCalories = goals.DailyCalories
-		} else {
-			// Значение по умолчанию
-			req.TargetCalories = 2000
+		if req.TargetCalories == 0 {
+			if goals != nil && goals.DailyCalories > 0 {
+				req.TargetCalories = goals.DailyCalories
+			} else {
+				// Значение по умолчанию
+				req.TargetCalories = 2000
+			}
+		}
+		if len(req.NutrientLimits) == 0 && goals != nil {
+			req.NutrientLimits = goals.NutrientLimits
 		}
 	}
+	limits, err := normalizeMicronutrients(req.NutrientLimits)
+	if err != nil {
+		return nil, fmt.Errorf("неверные лимиты: %w", err)
+	}
+	req.NutrientLimits = limits
 	
 	// Get filtered recipes
 	mealTypes := []string{"breakfast", "lunch", "dinner"}
@@ -60,27 +78,47 @@ func (s *MenuService) GenerateMenu(req *models.MenuGenerateRequest) (*models.Men
 		maxTime = &req.MaxTimePerMeal
 	}
 	
-	recipes, err := s.recipeRepo.GetFiltered(req.DietType, req.Allergies, mealTypes, maxCalories, nil, maxTime)
+	dietTypes := splitTags(append([]string{req.DietType}, req.DietTypes...)...)
+	recipes, err := s.recipeRepo.GetFiltered(ctx, req.UserID, dietTypes, s.excludedAllergens(req.Allergies, req.AllowSubstitutions), mealTypes, maxCalories, nil, maxTime)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Замены ингредиентов: рецепты с аллергенами (если разрешено) и недостающие продукты
+	substitutions, err := loadSubstitutionIndex(ctx, s.recipeRepo, s.tagRepo)
 	if err != nil {
 		return nil, err
 	}
+	if req.AllowSubstitutions {
+		recipes = substitutions.FilterByAllergies(recipes, req.Allergies)
+	}
+	
+	// Запрещенные пользователем рецепты не предлагаем
+	prefs, err := s.loadPreferences(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	recipes = prefs.excludeBanned(recipes)
 	
 	// Get pantry items if needed
 	var pantryItems []models.PantryItem
 	if req.ConsiderPantry {
-		pantryItems, err = s.pantryRepo.GetByUserID(req.UserID)
+		pantryItems, err = s.pantryRepo.GetByUserID(ctx, req.UserID)
 		if err != nil {
 			return nil, err
 		}
 	}
 	
 	// Score and filter recipes based on pantry
-	scoredRecipes := s.scoreRecipesByPantry(recipes, pantryItems, req.PantryImportance)
+	scoredRecipes := s.scoreRecipesByPantry(recipes, pantryItems, req.PantryImportance, prefs)
 	
 	// Generate menu combinations
 	bestMenu := s.findBestMenuCombination(scoredRecipes, req, pantryItems)
 	
 	if bestMenu == nil {
+		if len(req.NutrientLimits) > 0 {
+			return nil, fmt.Errorf("не найдена комбинация меню, укладывающаяся в лимиты по микронутриентам")
+		}
 		return nil, fmt.Errorf("не найдена подходящая комбинация меню")
 	}
 	
@@ -105,10 +143,11 @@ func (s *MenuService) GenerateMenu(req *models.MenuGenerateRequest) (*models.Men
 		}
 		children := req.Children
 		bestMenu.IngredientsUsed, bestMenu.MissingIngredients = s.calculateIngredientUsage(bestMenu.Meals, recipes, pantryItems, adults, children)
+		substitutions.suggestForMissing(bestMenu.MissingIngredients, pantryItems, req.Allergies)
 	}
 	
 	// Save menu
-	err = s.menuRepo.Create(bestMenu)
+	err = s.menuRepo.Create(ctx, bestMenu)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при сохранении меню: %w", err)
 	}
@@ -120,9 +159,10 @@ func (s *MenuService) GenerateMenu(req *models.MenuGenerateRequest) (*models.Men
 	}
 	children := req.Children
 	shoppingList := s.generateShoppingList(bestMenu, recipes, pantryItems, adults, children)
+	substitutions.suggestForShopping(shoppingList, pantryItems, req.Allergies)
 	shoppingList.UserID = req.UserID
 	shoppingList.MenuID = bestMenu.ID
-	err = s.shoppingRepo.CreateOrUpdate(shoppingList)
+	err = s.shoppingRepo.CreateOrUpdate(ctx, shoppingList)
 	if err != nil {
 		// Логируем ошибку, но не прерываем выполнение - меню уже сохранено
 		fmt.Printf("Предупреждение: не удалось сохранить список покупок для меню %d: %v\n", bestMenu.ID, err)
@@ -131,8 +171,10 @@ func (s *MenuService) GenerateMenu(req *models.MenuGenerateRequest) (*models.Men
 	return bestMenu, nil
 }
 
-// GenerateWeeklyMenu генерирует меню на неделю (7 дней) с учетом анти-повторов и баланса БЖУ
-func (s *MenuService) GenerateWeeklyMenu(req *models.WeeklyMenuRequest) (*models.WeeklyMenu, error) {
+// GenerateWeeklyMenu генерирует меню на неделю (7 дней) с учетом анти-повторов и баланса БЖУ.
+// progress (может быть nil) вызывается после каждого дня и перед оптимизацией; при
+// отмене ctx генерация прерывается между днями и возвращает ошибку ctx.
+func (s *MenuService) GenerateWeeklyMenu(ctx context.Context, req *models.WeeklyMenuRequest, progress func(models.WeeklyMenuProgress)) (*models.WeeklyMenu, error) {
 	// Рассчитываем целевые калории на день с учетом количества людей
 	adults := req.Adults
 	if adults == 0 {
@@ -140,6 +182,26 @@ func (s *MenuService) GenerateWeeklyMenu(req *models.WeeklyMenuRequest) (*models
 	}
 	children := req.Children
 	
+	// Количество порций с учетом детей (ребенок = 0.7 порции)
+	scale := peopleScale(adults, children)
+	totalServings := float64(scale)
+	
+	// Лимиты по микронутриентам на человека в день: из запроса или из целей пользователя
+	nutrientLimits := req.NutrientLimits
+	if len(nutrientLimits) == 0 {
+		goals, err := s.goalsRepo.GetByUserID(ctx, req.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении целей пользователя: %w", err)
+		}
+		if goals != nil {
+			nutrientLimits = goals.NutrientLimits
+		}
+	}
+	nutrientLimits, err := normalizeMicronutrients(nutrientLimits)
+	if err != nil {
+		return nil, fmt.Errorf("неверные лимиты: %w", err)
+	}
+	
 	// Калорийная цель дня: adults*2000 + children*1400
 	targetDayCalories := adults*2000 + children*1400
 	
@@ -154,34 +216,56 @@ func (s *MenuService) GenerateWeeklyMenu(req *models.WeeklyMenuRequest) (*models
 		maxTime = &req.MaxTimePerMeal
 	}
 	
-	breakfastRecipes, err := s.recipeRepo.GetFiltered(req.DietType, req.Allergies, []string{"breakfast"}, nil, nil, maxTime)
+	// Несколько диет (через запятую) - рецепт должен соответствовать всем
+	dietTypes := splitTags(req.DietType)
+	excludedAllergens := s.excludedAllergens(req.Allergies, req.AllowSubstitutions)
+	breakfastRecipes, err := s.recipeRepo.GetFiltered(ctx, req.UserID, dietTypes, excludedAllergens, []string{"breakfast"}, nil, nil, maxTime)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении рецептов для завтрака: %w", err)
 	}
 	
-	lunchRecipes, err := s.recipeRepo.GetFiltered(req.DietType, req.Allergies, []string{"lunch"}, nil, nil, maxTime)
+	lunchRecipes, err := s.recipeRepo.GetFiltered(ctx, req.UserID, dietTypes, excludedAllergens, []string{"lunch"}, nil, nil, maxTime)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении рецептов для обеда: %w", err)
 	}
 	
-	dinnerRecipes, err := s.recipeRepo.GetFiltered(req.DietType, req.Allergies, []string{"dinner"}, nil, nil, maxTime)
+	dinnerRecipes, err := s.recipeRepo.GetFiltered(ctx, req.UserID, dietTypes, excludedAllergens, []string{"dinner"}, nil, nil, maxTime)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении рецептов для ужина: %w", err)
 	}
 	
+	substitutions, err := loadSubstitutionIndex(ctx, s.recipeRepo, s.tagRepo)
+	if err != nil {
+		return nil, err
+	}
+	if req.AllowSubstitutions {
+		breakfastRecipes = substitutions.FilterByAllergies(breakfastRecipes, req.Allergies)
+		lunchRecipes = substitutions.FilterByAllergies(lunchRecipes, req.Allergies)
+		dinnerRecipes = substitutions.FilterByAllergies(dinnerRecipes, req.Allergies)
+	}
+	
+	// Запрещенные пользователем рецепты не предлагаем (в том числе в fallback)
+	prefs, err := s.loadPreferences(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	breakfastRecipes = prefs.excludeBanned(breakfastRecipes)
+	lunchRecipes = prefs.excludeBanned(lunchRecipes)
+	dinnerRecipes = prefs.excludeBanned(dinnerRecipes)
+	
 	// Получаем ингредиенты из кладовой
 	var pantryItems []models.PantryItem
 	if req.ConsiderPantry {
-		pantryItems, err = s.pantryRepo.GetByUserID(req.UserID)
+		pantryItems, err = s.pantryRepo.GetByUserID(ctx, req.UserID)
 		if err != nil {
 			return nil, err
 		}
 	}
 	
 	// Оцениваем рецепты
-	scoredBreakfast := s.scoreRecipesByPantry(breakfastRecipes, pantryItems, req.PantryImportance)
-	scoredLunch := s.scoreRecipesByPantry(lunchRecipes, pantryItems, req.PantryImportance)
-	scoredDinner := s.scoreRecipesByPantry(dinnerRecipes, pantryItems, req.PantryImportance)
+	scoredBreakfast := s.scoreRecipesByPantry(breakfastRecipes, pantryItems, req.PantryImportance, prefs)
+	scoredLunch := s.scoreRecipesByPantry(lunchRecipes, pantryItems, req.PantryImportance, prefs)
+	scoredDinner := s.scoreRecipesByPantry(dinnerRecipes, pantryItems, req.PantryImportance, prefs)
 	
 	// Проверяем, что есть хотя бы один рецепт в каждой категории
 	if len(breakfastRecipes) == 0 {
@@ -194,14 +278,37 @@ func (s *MenuService) GenerateWeeklyMenu(req *models.WeeklyMenuRequest) (*models
 		return nil, fmt.Errorf("не найдено рецептов для ужина")
 	}
 	
+	// Неделя привязана к дате первого дня (по умолчанию - сегодня)
+	startDate := req.StartDate
+	if startDate.IsZero() {
+		startDate = time.Now()
+	}
+	startDate = truncateToDate(startDate)
+	
 	// Генерируем меню на каждый день недели с анти-повторами
 	weeklyMenu := &models.WeeklyMenu{
-		Week: make([]models.WeeklyDayMenu, 7),
+		StartDate: startDate.Format(models.DateLayout),
+		Week:      make([]models.WeeklyDayMenu, 7),
 	}
 	
 	// Карта использованных рецептов для анти-повторов (не использовать 3 дня подряд)
 	usedRecipeIDs := make(map[int][]int) // day -> []recipeIDs
 	
+	// Сезонные рецепты и чередование кухонь в течение недели
+	variety := newMenuVariety(req)
+	
+	// Прогресс: готовые дни и оценка меню из них
+	report := func(phase string, daysDone int) {
+		if progress != nil {
+			progress(models.WeeklyMenuProgress{
+				Phase:     phase,
+				DaysDone:  daysDone,
+				TotalDays: len(weeklyMenu.Week),
+				BestScore: weeklyMenuScore(weeklyMenu.Week[:daysDone], adults, children),
+			})
+		}
+	}
+	
 	// Сохраняем ссылки на исходные списки для гарантированного fallback
 	// Это важно, так как мы будем использовать их в финальном fallback
 	_ = breakfastRecipes // Используем для fallback
@@ -209,6 +316,10 @@ func (s *MenuService) GenerateWeeklyMenu(req *models.WeeklyMenuRequest) (*models
 	_ = dinnerRecipes    // Используем для fallback
 	
 	for day := 0; day < 7; day++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		
 		// Получаем список рецептов, которые нельзя использовать (последние 3 дня)
 		excludedIDs := make(map[int]bool)
 		for d := day - 3; d < day; d++ {
@@ -219,41 +330,49 @@ func (s *MenuService) GenerateWeeklyMenu(req *models.WeeklyMenuRequest) (*models
 			}
 		}
 		
+		// Рецепты с недоступным в этот день оборудованием не предлагаем
+		date := startDate.AddDate(0, 0, day)
+		dayEquipment := unavailableEquipment(date, req.WeekdayUnavailableEquipment)
+		dayBreakfast := variety.adjust(withoutEquipment(scoredBreakfast, dayEquipment), date)
+		dayLunch := variety.adjust(withoutEquipment(scoredLunch, dayEquipment), date)
+		dayDinner := variety.adjust(withoutEquipment(scoredDinner, dayEquipment), date)
+		dayMaxHard := hardRecipeLimit(date, req.WeekdayMaxHard)
+		
 		// Выбираем рецепты для каждого приема пищи
 		var breakfastRecipe, lunchRecipe, dinnerRecipe *models.Recipe
 		
 		// Попытка 1: С учетом anti-repeat и калорий (если есть scored рецепты)
-		if len(scoredBreakfast) > 0 {
-			breakfastRecipe = s.selectRecipeForMeal(scoredBreakfast, targetBreakfastCalories, excludedIDs)
+		if len(dayBreakfast) > 0 {
+			breakfastRecipe = s.selectRecipeForMeal(dayBreakfast, targetBreakfastCalories, excludedIDs)
 		}
-		if len(scoredLunch) > 0 {
-			lunchRecipe = s.selectRecipeForMeal(scoredLunch, targetLunchCalories, excludedIDs)
+		if len(dayLunch) > 0 {
+			lunchRecipe = s.selectRecipeForMeal(dayLunch, targetLunchCalories, excludedIDs)
 		}
-		if len(scoredDinner) > 0 {
-			dinnerRecipe = s.selectRecipeForMeal(scoredDinner, targetDinnerCalories, excludedIDs)
+		if len(dayDinner) > 0 {
+			dinnerRecipe = s.selectRecipeForMeal(dayDinner, targetDinnerCalories, excludedIDs)
 		}
 		
 		// Попытка 2: Без anti-repeat (разрешаем повторы)
 		emptyExcludedIDs := make(map[int]bool)
-		if breakfastRecipe == nil && len(scoredBreakfast) > 0 {
-			breakfastRecipe = s.selectRecipeForMeal(scoredBreakfast, targetBreakfastCalories, emptyExcludedIDs)
+		if breakfastRecipe == nil && len(dayBreakfast) > 0 {
+			breakfastRecipe = s.selectRecipeForMeal(dayBreakfast, targetBreakfastCalories, emptyExcludedIDs)
 		}
-		if lunchRecipe == nil && len(scoredLunch) > 0 {
-			lunchRecipe = s.selectRecipeForMeal(scoredLunch, targetLunchCalories, emptyExcludedIDs)
+		if lunchRecipe == nil && len(dayLunch) > 0 {
+			lunchRecipe = s.selectRecipeForMeal(dayLunch, targetLunchCalories, emptyExcludedIDs)
 		}
-		if dinnerRecipe == nil && len(scoredDinner) > 0 {
-			dinnerRecipe = s.selectRecipeForMeal(scoredDinner, targetDinnerCalories, emptyExcludedIDs)
+		if dinnerRecipe == nil && len(dayDinner) > 0 {
+			dinnerRecipe = s.selectRecipeForMeal(dayDinner, targetDinnerCalories, emptyExcludedIDs)
 		}
 		
 		// Попытка 3: Игнорируем калории
-		if breakfastRecipe == nil && len(scoredBreakfast) > 0 {
-			breakfastRecipe = s.selectRecipeForMealIgnoreCalories(scoredBreakfast, emptyExcludedIDs)
+		if breakfastRecipe == nil && len(dayBreakfast) > 0 {
+			breakfastRecipe = s.selectRecipeForMealIgnoreCalories(dayBreakfast, emptyExcludedIDs)
 		}
-		if lunchRecipe == nil && len(scoredLunch) > 0 {
-			lunchRecipe = s.selectRecipeForMealIgnoreCalories(scoredLunch, emptyExcludedIDs)
+		if lunchRecipe == nil && len(dayLunch) > 0 {
+			lunchRecipe = s.selectRecipeForMealIgnoreCalories(dayLunch, emptyExcludedIDs)
 		}
-		if dinnerRecipe == nil && len(scoredDinner) > 0 {
-			dinnerRecipe = s.selectRecipeForMealIgnoreCalories(scoredDinner, emptyExcludedIDs)
+		if dinnerRecipe == nil && len(dayDinner) > 0 {
+			dinnerRecipe = s.selectRecipeForMealIgnoreCalories(dayDinner, emptyExcludedIDs)
 		}
 		
 		// ФИНАЛЬНЫЙ FALLBACK: ВСЕГДА используем исходные списки, если рецепт не найден
@@ -278,46 +397,52 @@ func (s *MenuService) GenerateWeeklyMenu(req *models.WeeklyMenuRequest) (*models
 				day+1, len(breakfastRecipes), len(lunchRecipes), len(dinnerRecipes))
 		}
 		
-		// Сохраняем использованные рецепты
-		usedRecipeIDs[day] = []int{breakfastRecipe.ID, lunchRecipe.ID, dinnerRecipe.ID}
-		
-		// Рассчитываем итоги дня с учетом количества людей
-		totalServings := float64(adults) + float64(children)*0.7
-		if totalServings == 0 {
-			totalServings = 1.0
+		// Соблюдаем лимиты по микронутриентам: при превышении заменяем одно из блюд
+		var limitViolations []string
+		if len(nutrientLimits) > 0 {
+			dayRecipes := []*models.Recipe{breakfastRecipe, lunchRecipe, dinnerRecipe}
+			dayRecipes, limitViolations = fitRecipesToNutrientLimits(dayRecipes,
+				[][]ScoredRecipe{dayBreakfast, dayLunch, dayDinner}, nutrientLimits, totalServings, excludedIDs)
+			breakfastRecipe, lunchRecipe, dinnerRecipe = dayRecipes[0], dayRecipes[1], dayRecipes[2]
 		}
 		
-		// Пересчитываем калории и макронутриенты с учетом порций
-		breakfastMultiplier := totalServings / float64(breakfastRecipe.Servings)
-		if breakfastMultiplier == 0 {
-			breakfastMultiplier = 1.0
-		}
-		lunchMultiplier := totalServings / float64(lunchRecipe.Servings)
-		if lunchMultiplier == 0 {
-			lunchMultiplier = 1.0
-		}
-		dinnerMultiplier := totalServings / float64(dinnerRecipe.Servings)
-		if dinnerMultiplier == 0 {
-			dinnerMultiplier = 1.0
+		// Укладываемся в MaxTotalTime с учетом параллельной готовки; замена не должна
+		// добавлять превышений лимитов по микронутриентам. Если подходящих рецептов
+		// не нашлось, нарушение ограничения указывается в limit_violations.
+		if req.MaxTotalTime > 0 {
+			dayRecipes := fitRecipesToTime([]*models.Recipe{breakfastRecipe, lunchRecipe, dinnerRecipe},
+				[][]ScoredRecipe{dayBreakfast, dayLunch, dayDinner}, req.MaxTotalTime, excludedIDs,
+				func(candidate []*models.Recipe) bool {
+					_, violations := fitRecipesToNutrientLimits(candidate, nil, nutrientLimits, totalServings, nil)
+					return len(violations) <= len(limitViolations)
+				})
+			breakfastRecipe, lunchRecipe, dinnerRecipe = dayRecipes[0], dayRecipes[1], dayRecipes[2]
 		}
 		
-		totalCalories := int(float64(breakfastRecipe.Calories)*breakfastMultiplier) +
-			int(float64(lunchRecipe.Calories)*lunchMultiplier) +
-			int(float64(dinnerRecipe.Calories)*dinnerMultiplier)
-		
-		totalProteins := breakfastRecipe.Proteins*breakfastMultiplier +
-			lunchRecipe.Proteins*lunchMultiplier +
-			dinnerRecipe.Proteins*dinnerMultiplier
+		// В будни не больше weekday_max_hard сложных рецептов; замена не должна
+		// нарушать время приготовления и добавлять превышений лимитов
+		if dayMaxHard != nil {
+			dayTime := dayCookingTime(breakfastRecipe, lunchRecipe, dinnerRecipe)
+			dayRecipes := fitRecipesToDifficulty([]*models.Recipe{breakfastRecipe, lunchRecipe, dinnerRecipe},
+				[][]ScoredRecipe{dayBreakfast, dayLunch, dayDinner}, dayMaxHard, excludedIDs,
+				func(candidate []*models.Recipe) bool {
+					if req.MaxTotalTime > 0 && dayCookingTime(candidate...) > max(req.MaxTotalTime, dayTime) {
+						return false
+					}
+					_, violations := fitRecipesToNutrientLimits(candidate, nil, nutrientLimits, totalServings, nil)
+					return len(violations) <= len(limitViolations)
+				})
+			breakfastRecipe, lunchRecipe, dinnerRecipe = dayRecipes[0], dayRecipes[1], dayRecipes[2]
+		}
+		variety.add(breakfastRecipe, lunchRecipe, dinnerRecipe)
 		
-		totalFats := breakfastRecipe.Fats*breakfastMultiplier +
-			lunchRecipe.Fats*lunchMultiplier +
-			dinnerRecipe.Fats*dinnerMultiplier
+		// Сохраняем использованные рецепты
+		usedRecipeIDs[day] = []int{breakfastRecipe.ID, lunchRecipe.ID, dinnerRecipe.ID}
 		
-		totalCarbs := breakfastRecipe.Carbs*breakfastMultiplier +
-			lunchRecipe.Carbs*lunchMultiplier +
-			dinnerRecipe.Carbs*dinnerMultiplier
+		// Рассчитываем итоги дня с учетом количества людей
+		totals := scale.recipesNutrition(breakfastRecipe, lunchRecipe, dinnerRecipe)
 		
-		totalTime := breakfastRecipe.CookingTime + lunchRecipe.CookingTime + dinnerRecipe.CookingTime
+		totalTime := dayCookingTime(breakfastRecipe, lunchRecipe, dinnerRecipe)
 		
 		// Рассчитываем ингредиенты
 		var ingredientsUsed, missingIngredients models.Ingredients
@@ -329,6 +454,7 @@ func (s *MenuService) GenerateWeeklyMenu(req *models.WeeklyMenuRequest) (*models
 			}
 			allRecipes := append(append(breakfastRecipes, lunchRecipes...), dinnerRecipes...)
 			ingredientsUsed, missingIngredients = s.calculateIngredientUsage(meals, allRecipes, pantryItems, adults, children)
+			substitutions.suggestForMissing(missingIngredients, pantryItems, req.Allergies)
 		}
 		
 		// Преобразуем рецепты в DTO
@@ -338,21 +464,38 @@ func (s *MenuService) GenerateWeeklyMenu(req *models.WeeklyMenuRequest) (*models
 		
 		weeklyMenu.Week[day] = models.WeeklyDayMenu{
 			Day:                day + 1,
+			Date:               date.Format(models.DateLayout),
 			Breakfast:          breakfastDTO,
 			Lunch:              lunchDTO,
 			Dinner:             dinnerDTO,
-			TotalCalories:      totalCalories,
-			TotalProteins:      totalProteins,
-			TotalFats:          totalFats,
-			TotalCarbs:         totalCarbs,
+			TotalCalories:      totals.Calories,
+			TotalProteins:      totals.Proteins,
+			TotalFats:          totals.Fats,
+			TotalCarbs:         totals.Carbs,
 			TotalTime:          totalTime,
+			LimitViolations:    limitViolations,
 			IngredientsUsed:    ingredientsUsed,
 			MissingIngredients: missingIngredients,
 		}
+		weeklyMenu.Week[day].TotalMicronutrients = dayMicronutrients(&weeklyMenu.Week[day], totalServings)
+		weeklyMenu.Week[day].LimitViolations = append(limitViolations,
+			dayConstraintViolations(&weeklyMenu.Week[day], req.MaxTotalTime, dayEquipment)...)
+		weeklyMenu.Week[day].LimitViolations = append(weeklyMenu.Week[day].LimitViolations,
+			difficultyViolations(&weeklyMenu.Week[day], dayMaxHard)...)
+		report("generating", day+1)
 	}
 	
-	// Применяем оптимизацию баланса БЖУ
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	report("optimizing", len(weeklyMenu.Week))
+	
+	// Применяем оптимизацию баланса БЖУ (замены не должны нарушать лимиты)
 	optimizer := NewMenuOptimizer()
+	optimizer.nutrientLimits = nutrientLimits
+	optimizer.maxTotalTime = req.MaxTotalTime
+	optimizer.weekdayUnavailableEquipment = req.WeekdayUnavailableEquipment
+	optimizer.weekdayMaxHard = req.WeekdayMaxHard
 	allRecipes := append(append(breakfastRecipes, lunchRecipes...), dinnerRecipes...)
 	err = optimizer.OptimizeWeeklyMacros(weeklyMenu, allRecipes, adults, children)
 	if err != nil {
@@ -360,91 +503,118 @@ func (s *MenuService) GenerateWeeklyMenu(req *models.WeeklyMenuRequest) (*models
 		fmt.Printf("Предупреждение: ошибка при оптимизации БЖУ: %v\n", err)
 	}
 	
+	weeklyMenu.TotalMicronutrients = models.Micronutrients{}
+	for _, day := range weeklyMenu.Week {
+		weeklyMenu.TotalMicronutrients.Add(day.TotalMicronutrients, 1)
+	}
+	weeklyMenu.Score = weeklyMenuScore(weeklyMenu.Week, adults, children)
+	report("done", len(weeklyMenu.Week))
+	
 	return weeklyMenu, nil
 }
 
 // SaveWeeklyMenu сохраняет недельное меню в базу данных
-func (s *MenuService) SaveWeeklyMenu(userID int, weeklyMenu *models.WeeklyMenu) (*models.Menu, error) {
-	// Рассчитываем общие калории и время за неделю
-	totalCalories := 0
-	totalTime := 0
-	for _, day := range weeklyMenu.Week {
-		totalCalories += day.TotalCalories
-		totalTime += day.TotalTime
+func (s *MenuService) SaveWeeklyMenu(ctx context.Context, userID int, weeklyMenu *models.WeeklyMenu) (*models.Menu, error) {
+	// Определяем дату начала недели и проставляем даты дням
+	startDate, err := weeklyStartDate(weeklyMenu)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWeeklyMenu, err)
+	}
+	for i := range weeklyMenu.Week {
+		if weeklyMenu.Week[i].Day == 0 {
+			weeklyMenu.Week[i].Day = i + 1
+		}
 	}
 	
-	// Сохраняем недельное меню в JSON формате в поле meals
-	var weeklyMealsData []map[string]interface{}
+	// Проверяем дни и рецепты до записи, чтобы не упираться в ограничения базы
+	recipes := make(map[int]*models.Recipe)
 	for _, day := range weeklyMenu.Week {
-		dayData := map[string]interface{}{
-			"day":                day.Day,
-			"breakfast":          day.Breakfast,
-			"lunch":              day.Lunch,
-			"dinner":             day.Dinner,
-			"totalCalories":      day.TotalCalories,
-			"totalProteins":      day.TotalProteins,
-			"totalFats":          day.TotalFats,
-			"totalCarbs":          day.TotalCarbs,
-			"totalTime":          day.TotalTime,
-			"ingredients_used":   day.IngredientsUsed,
-			"missing_ingredients": day.MissingIngredients,
+		for _, meal := range day.MenuMeals() {
+			if _, ok := recipes[meal.RecipeID]; ok {
+				continue
+			}
+			recipe, err := s.recipeRepo.GetByID(ctx, meal.RecipeID)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка при загрузке рецепта %d: %w", meal.RecipeID, err)
+			}
+			recipes[meal.RecipeID] = recipe
 		}
-		weeklyMealsData = append(weeklyMealsData, dayData)
+	}
+	if err := validateWeeklyMenu(weeklyMenu.Week, recipes, userID); err != nil {
+		return nil, err
 	}
 	
-	mealsJSON, err := json.Marshal(weeklyMealsData)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка при сериализации данных недели: %w", err)
+	weeklyMenu.StartDate = startDate.Format(models.DateLayout)
+	for i := range weeklyMenu.Week {
+		day := &weeklyMenu.Week[i]
+		day.Date = startDate.AddDate(0, 0, day.Day-1).Format(models.DateLayout)
+	}
+	
+	// Рассчитываем общие калории и время за неделю
+	totalCalories := 0
+	totalTime := 0
+	for _, day := range weeklyMenu.Week {
+		totalCalories += day.TotalCalories
+		totalTime += day.TotalTime
 	}
 	
-	// Создаем Menu объект с недельным меню в формате JSON
-	menu := &models.Menu{
-		UserID:        userID,
-		Date:          time.Now(), // Дата создания недельного меню
-		TotalCalories: totalCalories,
-		TotalTime:     totalTime,
-		MenuType:      "weekly",
-		Meals:         models.MenuMeals{}, // Будет заполнено через прямой SQL
-	}
-	
-	// Сохраняем через прямой SQL запрос, так как нужно сохранить JSON напрямую
-	query := `
-		INSERT INTO menus (user_id, date, total_calories, total_price, total_time, menu_type, meals, ingredients_used, missing_ingredients)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, created_at, updated_at
-	`
-	
-	var totalPrice float64 = 0
-	var ingredientsUsedJSON, missingIngredientsJSON []byte
 	// Объединяем все ингредиенты из всех дней
 	var allIngredientsUsed, allMissingIngredients models.Ingredients
 	for _, day := range weeklyMenu.Week {
 		allIngredientsUsed = append(allIngredientsUsed, day.IngredientsUsed...)
 		allMissingIngredients = append(allMissingIngredients, day.MissingIngredients...)
 	}
-	ingredientsUsedJSON, _ = json.Marshal(allIngredientsUsed)
-	missingIngredientsJSON, _ = json.Marshal(allMissingIngredients)
 	
-	err = database.DB.QueryRow(query,
-		menu.UserID, menu.Date, menu.TotalCalories, totalPrice, menu.TotalTime, menu.MenuType,
-		mealsJSON, ingredientsUsedJSON, missingIngredientsJSON,
-	).Scan(&menu.ID, &menu.CreatedAt, &menu.UpdatedAt)
-	
-	if err != nil {
+	menu := &models.Menu{
+		UserID:             userID,
+		Date:               startDate, // Дата первого дня недели
+		TotalCalories:      totalCalories,
+		TotalTime:          totalTime,
+		MenuType:           "weekly",
+		Meals:              models.MenuMeals{},
+		Week:               weeklyMenu.Week,
+		IngredientsUsed:    allIngredientsUsed,
+		MissingIngredients: allMissingIngredients,
+	}
+	
+	if err := s.menuRepo.CreateWeeklyMenu(ctx, menu); err != nil {
 		return nil, fmt.Errorf("ошибка при сохранении недельного меню: %w", err)
 	}
 	
 	return menu, nil
 }
 
+// validateWeeklyMenu проверяет, что номера дней лежат в 1..7 и не повторяются,
+// а все рецепты найдены и доступны пользователю (recipes - загруженные рецепты,
+// nil для отсутствующих). Чужой личный рецепт считается ненайденным
+func validateWeeklyMenu(week []models.WeeklyDayMenu, recipes map[int]*models.Recipe, userID int) error {
+	seen := make(map[int]bool)
+	for _, day := range week {
+		if day.Day < 1 || day.Day > 7 {
+			return fmt.Errorf("%w: день %d вне диапазона 1-7", ErrInvalidWeeklyMenu, day.Day)
+		}
+		if seen[day.Day] {
+			return fmt.Errorf("%w: день %d указан несколько раз", ErrInvalidWeeklyMenu, day.Day)
+		}
+		seen[day.Day] = true
+		
+		for _, meal := range day.MenuMeals() {
+			if recipe := recipes[meal.RecipeID]; recipe == nil || !recipeVisibleTo(recipe, userID) {
+				return fmt.Errorf("%w: рецепт %d (день %d) не найден", ErrInvalidWeeklyMenu, meal.RecipeID, day.Day)
+			}
+		}
+	}
+	return nil
+}
+
 // GetWeeklyMenus получает все сохраненные недельные меню пользователя
-func (s *MenuService) GetWeeklyMenus(userID int) ([]models.Menu, error) {
-	return s.menuRepo.GetWeeklyMenusByUserID(userID)
+func (s *MenuService) GetWeeklyMenus(ctx context.Context, userID int) ([]models.Menu, error) {
+	return s.menuRepo.GetWeeklyMenusByUserID(ctx, userID)
 }
 
 // DeleteMenu удаляет меню по ID, проверяя принадлежность пользователю
-func (s *MenuService) DeleteMenu(menuID, userID int) error {
-	return s.menuRepo.Delete(menuID, userID)
+func (s *MenuService) DeleteMenu(ctx context.Context, menuID, userID int) error {
+	return s.menuRepo.Delete(ctx, menuID, userID)
 }
 
 // selectRecipeForMeal выбирает рецепт для приема пищи с учетом калорий и анти-повторов
@@ -463,8 +633,8 @@ func (s *MenuService) selectRecipeForMeal(scoredRecipes []ScoredRecipe, targetCa
 		if targetCalories > 0 {
 			calDiff := math.Abs(float64(sr.Recipe.Calories - targetCalories)) / float64(targetCalories)
 			
-			// Комбинированный score: близость к калориям + pantry score
-			score := calDiff - sr.Score*0.3 // Чем выше pantry score, тем лучше
+			// Комбинированный score: близость к калориям + pantry score + предпочтения
+			score := calDiff - sr.Score*0.3 - sr.Preference // Чем выше pantry score, тем лучше
 			
 			if score < bestScore {
 				bestScore = score
@@ -494,8 +664,8 @@ func (s *MenuService) selectRecipeForMealIgnoreCalories(scoredRecipes []ScoredRe
 			continue
 		}
 		
-		// Используем только pantry score
-		score := sr.Score
+		// Используем только pantry score и предпочтения
+		score := sr.Score + sr.Preference
 		
 		if score > bestScore {
 			bestScore = score
@@ -523,22 +693,52 @@ func (s *MenuService) recipeToDTO(recipe *models.Recipe) *models.RecipeDTO {
 		Proteins:     recipe.Proteins,
 		Fats:         recipe.Fats,
 		Carbs:        recipe.Carbs,
+		Micronutrients: recipe.Micronutrients,
 		CookingTime:  recipe.CookingTime,
 		Servings:     recipe.Servings,
 		MealType:     recipe.MealType,
+		Cuisine:      recipe.Cuisine,
+		Seasons:      recipe.Seasons,
+		Difficulty:   recipe.Difficulty,
 		Ingredients:  recipe.Ingredients,
 		Instructions: recipe.Instructions,
+		Steps:        recipe.Steps,
+		ActiveTime:   recipe.ActiveTime,
+		Equipment:    recipe.Equipment,
+		Substitutions: recipe.Substitutions,
+		NutritionApproximate: recipe.NutritionApproximate,
 	}
 }
 
+// excludedAllergens возвращает аллергены для фильтрации рецептов в БД: если разрешены
+// замены, рецепты с аллергенами отбираются позже (SubstitutionIndex.FilterByAllergies)
+func (s *MenuService) excludedAllergens(allergies []string, allowSubstitutions bool) []string {
+	if allowSubstitutions {
+		return nil
+	}
+	return allergies
+}
+
 type ScoredRecipe struct {
 	Recipe      models.Recipe
 	Score       float64
+	Preference  float64 // бонус за избранное и оценку пользователя
 	MissingCount int
 	AvailableCount int
 }
 
-func (s *MenuService) scoreRecipesByPantry(recipes []models.Recipe, pantryItems []models.PantryItem, importance string) []ScoredRecipe {
+// loadPreferences загружает оценки и отметки рецептов пользователя
+func (s *MenuService) loadPreferences(ctx context.Context, userID int) (recipePreferences, error) {
+	feedback, err := s.feedbackRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении оценок рецептов: %w", err)
+	}
+	return newRecipePreferences(feedback), nil
+}
+
+// scoreRecipesByPantry оценивает рецепты по наличию ингредиентов в кладовой;
+// запрещенные пользователем рецепты исключаются, избранные получают бонус
+func (s *MenuService) scoreRecipesByPantry(recipes []models.Recipe, pantryItems []models.PantryItem, importance string, prefs recipePreferences) []ScoredRecipe {
 	pantryMap := make(map[string]float64)
 	for _, item := range pantryItems {
 		key := s.normalizeIngredientName(item.Name)
@@ -547,6 +747,10 @@ func (s *MenuService) scoreRecipesByPantry(recipes []models.Recipe, pantryItems
 	
 	var scored []ScoredRecipe
 	for _, recipe := range recipes {
+		if prefs.banned(recipe.ID) {
+			continue
+		}
+		
 		available := 0
 		missing := 0
 		
@@ -573,6 +777,7 @@ func (s *MenuService) scoreRecipesByPantry(recipes []models.Recipe, pantryItems
 		scored = append(scored, ScoredRecipe{
 			Recipe:        recipe,
 			Score:         score,
+			Preference:    prefs.boost(recipe.ID),
 			MissingCount:  missing,
 			AvailableCount: available,
 		})
@@ -582,15 +787,7 @@ func (s *MenuService) scoreRecipesByPantry(recipes []models.Recipe, pantryItems
 }
 
 func (s *MenuService) normalizeIngredientName(name string) string {
-	// Улучшенная нормализация: приводим к нижнему регистру и убираем лишние пробелы
-	normalized := strings.ToLower(name)
-	// Убираем лишние пробелы
-	normalized = strings.TrimSpace(normalized)
-	// Заменяем множественные пробелы на один
-	for strings.Contains(normalized, "  ") {
-		normalized = strings.ReplaceAll(normalized, "  ", " ")
-	}
-	return normalized
+	return normalizeIngredientName(name)
 }
 
 func (s *MenuService) findBestMenuCombination(scoredRecipes []ScoredRecipe, req *models.MenuGenerateRequest, pantryItems []models.PantryItem) *models.Menu {
@@ -654,8 +851,14 @@ func (s *MenuService) findBestMenuCombination(scoredRecipes []ScoredRecipe, req
 				lunch := &lunchRecipes[j]
 				dinner := &dinnerRecipes[k]
 				
+				// Лимиты по микронутриентам - жесткое ограничение
+				micronutrients := comboMicronutrients(breakfast, lunch, dinner)
+				if len(nutrientLimitViolations(micronutrients, req.NutrientLimits, 1)) > 0 {
+					continue
+				}
+				
 				totalCal := breakfast.Recipe.Calories + lunch.Recipe.Calories + dinner.Recipe.Calories
-				totalTime := breakfast.Recipe.CookingTime + lunch.Recipe.CookingTime + dinner.Recipe.CookingTime
+				totalTime := dayCookingTime(&breakfast.Recipe, &lunch.Recipe, &dinner.Recipe)
 				
 				// Вычисляем отклонение от целевых калорий (приоритет #1)
 				calDiff := math.Abs(float64(totalCal - req.TargetCalories)) / float64(req.TargetCalories)
@@ -695,6 +898,7 @@ func (s *MenuService) findBestMenuCombination(scoredRecipes []ScoredRecipe, req
 				if score > bestScore {
 					bestScore = score
 					bestMenu = &models.Menu{
+						TotalMicronutrients: micronutrients,
 						Meals: models.MenuMeals{
 							{
 								RecipeID: breakfast.Recipe.ID,
@@ -731,8 +935,13 @@ func (s *MenuService) findBestMenuCombination(scoredRecipes []ScoredRecipe, req
 			lunch := &lunchRecipes[rand.Intn(len(lunchRecipes))]
 			dinner := &dinnerRecipes[rand.Intn(len(dinnerRecipes))]
 			
+			micronutrients := comboMicronutrients(breakfast, lunch, dinner)
+			if len(nutrientLimitViolations(micronutrients, req.NutrientLimits, 1)) > 0 {
+				continue
+			}
+			
 			totalCal := breakfast.Recipe.Calories + lunch.Recipe.Calories + dinner.Recipe.Calories
-			totalTime := breakfast.Recipe.CookingTime + lunch.Recipe.CookingTime + dinner.Recipe.CookingTime
+			totalTime := dayCookingTime(&breakfast.Recipe, &lunch.Recipe, &dinner.Recipe)
 			
 			score := s.calculateMenuScore(breakfast, lunch, dinner, req, totalCal, totalTime)
 			
@@ -756,6 +965,7 @@ func (s *MenuService) findBestMenuCombination(scoredRecipes []ScoredRecipe, req
 			if score > bestScore {
 				bestScore = score
 				bestMenu = &models.Menu{
+					TotalMicronutrients: micronutrients,
 					Meals: models.MenuMeals{
 						{
 							RecipeID: breakfast.Recipe.ID,
@@ -801,6 +1011,7 @@ func (s *MenuService) sortRecipesByFitness(recipes []ScoredRecipe, req *models.M
 // calculateRecipeFitness вычисляет пригодность отдельного рецепта
 func (s *MenuService) calculateRecipeFitness(sr *ScoredRecipe, req *models.MenuGenerateRequest) float64 {
 	score := sr.Score * 0.4 // Pantry score (40%)
+	score += sr.Preference  // Избранное и оценка пользователя
 	
 	// Бонус за подходящее время (30%)
 	if req.MaxTimePerMeal > 0 {
@@ -866,6 +1077,9 @@ func (s *MenuService) calculateMenuScore(breakfast, lunch, dinner *ScoredRecipe,
 		score += avgPantryScore * 0.2
 	}
 	
+	// Предпочтения пользователя: избранное и оценки
+	score += (breakfast.Preference + lunch.Preference + dinner.Preference) / 3.0 * 0.2
+	
 	// 4. Macro balance score (10%) - баланс макроэлементов
 	totalProteins := breakfast.Recipe.Proteins + lunch.Recipe.Proteins + dinner.Recipe.Proteins
 	totalFats := breakfast.Recipe.Fats + lunch.Recipe.Fats + dinner.Recipe.Fats
@@ -919,12 +1133,8 @@ func (s *MenuService) calculateMenuScore(breakfast, lunch, dinner *ScoredRecipe,
 // calculateIngredientUsage рассчитывает использование ингредиентов с учетом количества людей
 // adults - количество взрослых, children - количество детей (дети = 0.7 коэффициента)
 func (s *MenuService) calculateIngredientUsage(meals models.MenuMeals, allRecipes []models.Recipe, pantryItems []models.PantryItem, adults int, children int) (models.Ingredients, models.Ingredients) {
-	// Рассчитываем коэффициент для количества порций
-	// totalServings = adults + children * 0.7
-	totalServings := float64(adults) + float64(children)*0.7
-	if totalServings == 0 {
-		totalServings = 1.0 // По умолчанию 1 порция
-	}
+	// Количество порций: взрослые + дети * 0.7
+	scale := peopleScale(adults, children)
 	
 	pantryMap := make(map[string]float64)
 	for _, item := range pantryItems {
@@ -946,18 +1156,9 @@ func (s *MenuService) calculateIngredientUsage(meals models.MenuMeals, allRecipe
 			continue
 		}
 		
-		// Получаем количество порций рецепта (по умолчанию 1)
-		recipeServings := float64(recipe.Servings)
-		if recipeServings == 0 {
-			recipeServings = 1.0
-		}
-		
-		// Коэффициент для пересчета ингредиентов
-		servingMultiplier := totalServings / recipeServings
-		
-		for _, ing := range recipe.Ingredients {
-			// Пересчитываем количество ингредиента с учетом количества людей
-			adjustedQuantity := ing.Quantity * servingMultiplier
+		// Ингредиенты с учетом количества людей
+		for _, ing := range scale.ingredients(&recipe) {
+			adjustedQuantity := ing.Quantity
 			
 			ingName := s.normalizeIngredientName(ing.Name)
 			if qty, found := pantryMap[ingName]; found {
@@ -991,11 +1192,8 @@ func (s *MenuService) calculateIngredientUsage(meals models.MenuMeals, allRecipe
 
 // generateShoppingList генерирует список покупок с учетом количества людей
 func (s *MenuService) generateShoppingList(menu *models.Menu, allRecipes []models.Recipe, pantryItems []models.PantryItem, adults int, children int) *models.ShoppingList {
-	// Рассчитываем коэффициент для количества порций
-	totalServings := float64(adults) + float64(children)*0.7
-	if totalServings == 0 {
-		totalServings = 1.0
-	}
+	// Количество порций: взрослые + дети * 0.7
+	scale := peopleScale(adults, children)
 	
 	recipeMap := make(map[int]models.Recipe)
 	for _, recipe := range allRecipes {
@@ -1016,18 +1214,9 @@ func (s *MenuService) generateShoppingList(menu *models.Menu, allRecipes []model
 			continue
 		}
 		
-		// Получаем количество порций рецепта
-		recipeServings := float64(recipe.Servings)
-		if recipeServings == 0 {
-			recipeServings = 1.0
-		}
-		
-		// Коэффициент для пересчета ингредиентов
-		servingMultiplier := totalServings / recipeServings
-		
-		for _, ing := range recipe.Ingredients {
-			// Пересчитываем количество ингредиента
-			adjustedQuantity := ing.Quantity * servingMultiplier
+		// Ингредиенты с учетом количества людей
+		for _, ing := range scale.ingredients(&recipe) {
+			adjustedQuantity := ing.Quantity
 			
 			ingName := s.normalizeIngredientName(ing.Name)
 			available := pantryMap[ingName]
@@ -1060,15 +1249,74 @@ func (s *MenuService) generateShoppingList(menu *models.Menu, allRecipes []model
 	}
 }
 
-func (s *MenuService) GetDaily(userID int, date time.Time) (*models.Menu, error) {
-	return s.menuRepo.GetByUserIDAndDate(userID, date)
+// GetDaily возвращает меню на дату: сначала дневное меню, затем день из недельного плана
+func (s *MenuService) GetDaily(ctx context.Context, userID int, date time.Time) (*models.Menu, error) {
+	menu, err := s.menuRepo.GetByUserIDAndDate(ctx, userID, date)
+	if err != nil || menu != nil {
+		return menu, err
+	}
+	
+	weekly, days, err := s.menuRepo.GetWeeklyByUserIDAndDate(ctx, userID, date)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске недельного меню: %w", err)
+	}
+	if weekly == nil {
+		return nil, nil
+	}
+	
+	dateStr := date.Format(models.DateLayout)
+	for _, day := range days {
+		if day.Date != dateStr {
+			continue
+		}
+		return &models.Menu{
+			ID:                 weekly.ID,
+			UserID:             weekly.UserID,
+			Date:               truncateToDate(date),
+			TotalCalories:      day.TotalCalories,
+			TotalTime:          day.TotalTime,
+			TotalMicronutrients: day.TotalMicronutrients,
+			MenuType:           "weekly",
+			Meals:              day.MenuMeals(),
+			IngredientsUsed:    day.IngredientsUsed,
+			MissingIngredients: day.MissingIngredients,
+			CreatedAt:          weekly.CreatedAt,
+			UpdatedAt:          weekly.UpdatedAt,
+		}, nil
+	}
+	
+	return nil, nil
 }
 
-func (s *MenuService) GetAllByUserID(userID int) ([]models.Menu, error) {
-	return s.menuRepo.GetAllByUserID(userID)
+func (s *MenuService) GetAllByUserID(ctx context.Context, userID int) ([]models.Menu, error) {
+	return s.menuRepo.GetAllByUserID(ctx, userID)
 }
 
-func (s *MenuService) GetByID(id int) (*models.Menu, error) {
-	return s.menuRepo.GetByID(id)
+func (s *MenuService) GetByID(ctx context.Context, id int) (*models.Menu, error) {
+	return s.menuRepo.GetByID(ctx, id)
 }
 
+// weeklyStartDate определяет дату первого дня недельного меню:
+// start_date, затем дата первого дня, иначе - сегодня
+func weeklyStartDate(weeklyMenu *models.WeeklyMenu) (time.Time, error) {
+	if weeklyMenu.StartDate != "" {
+		date, err := time.Parse(models.DateLayout, weeklyMenu.StartDate)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("неверный формат start_date: %w", err)
+		}
+		return date, nil
+	}
+	if len(weeklyMenu.Week) > 0 && weeklyMenu.Week[0].Date != "" {
+		date, err := time.Parse(models.DateLayout, weeklyMenu.Week[0].Date)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("неверный формат даты дня: %w", err)
+		}
+		return date.AddDate(0, 0, 1-max(weeklyMenu.Week[0].Day, 1)), nil
+	}
+	return truncateToDate(time.Now()), nil
+}
+
+// truncateToDate отбрасывает время суток
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
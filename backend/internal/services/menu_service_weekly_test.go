@@ -1,6 +1,8 @@
 package services
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/myplate/backend/internal/models"
@@ -105,3 +107,44 @@ func TestMenuService_RecipeToDTO(t *testing.T) {
 	}
 }
 
+
+func TestValidateWeeklyMenu(t *testing.T) {
+	ownerID, otherID := 5, 6
+	recipes := map[int]*models.Recipe{1: {ID: 1}, 2: {ID: 2}, 3: {ID: 3, OwnerID: &ownerID}, 4: {ID: 4, OwnerID: &otherID}}
+	day := func(number int, recipeID int) models.WeeklyDayMenu {
+		return models.WeeklyDayMenu{Day: number, Breakfast: &models.RecipeDTO{ID: recipeID}}
+	}
+	
+	tests := []struct {
+		name    string
+		week    []models.WeeklyDayMenu
+		wantErr string
+	}{
+		{"корректное меню", []models.WeeklyDayMenu{day(1, 1), day(7, 2)}, ""},
+		{"день без рецептов", []models.WeeklyDayMenu{{Day: 3}}, ""},
+		{"день меньше 1", []models.WeeklyDayMenu{day(-1, 1)}, "день -1"},
+		{"день больше 7", []models.WeeklyDayMenu{day(1, 1), day(8, 2)}, "день 8"},
+		{"повтор дня", []models.WeeklyDayMenu{day(2, 1), day(2, 2)}, "день 2 указан несколько раз"},
+		{"неизвестный рецепт", []models.WeeklyDayMenu{day(1, 1), day(2, 99)}, "рецепт 99"},
+		{"свой личный рецепт", []models.WeeklyDayMenu{day(1, 3)}, ""},
+		{"чужой личный рецепт", []models.WeeklyDayMenu{day(1, 4)}, "рецепт 4"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWeeklyMenu(tt.week, recipes, ownerID)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Неожиданная ошибка: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidWeeklyMenu) {
+				t.Fatalf("Ожидалась ErrInvalidWeeklyMenu, получено %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Ожидалось %q в ошибке, получено %q", tt.wantErr, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+const (
+	seasonalBoost       = 0.2 // бонус (штраф) к пригодности рецепта своего (чужого) сезона
+	cuisineRepeatWeight = 0.3 // штраф за долю блюд той же кухни среди уже выбранных за неделю
+)
+
+// seasonOf возвращает сезон месяца (северное полушарие)
+func seasonOf(month time.Month) string {
+	switch month {
+	case time.December, time.January, time.February:
+		return "winter"
+	case time.March, time.April, time.May:
+		return "spring"
+	case time.June, time.July, time.August:
+		return "summer"
+	default:
+		return "autumn"
+	}
+}
+
+// menuVariety - правила разнообразия недельного меню: сезонные рецепты и
+// чередование кухонь. Поправки добавляются к Preference кандидатов дня.
+type menuVariety struct {
+	preferSeasonal  bool
+	balanceCuisines bool
+	cuisines        map[string]int // кухня -> блюд, уже выбранных на неделе
+	meals           int
+}
+
+func newMenuVariety(req *models.WeeklyMenuRequest) *menuVariety {
+	return &menuVariety{
+		preferSeasonal:  req.PreferSeasonal,
+		balanceCuisines: req.BalanceCuisines,
+		cuisines:        make(map[string]int),
+	}
+}
+
+// adjust возвращает кандидатов дня date с поправками пригодности: рецепты сезона
+// получают бонус, рецепты другого сезона - штраф (рецепты на круглый год - без
+// поправки); штраф за кухню пропорционален ее доле среди уже выбранных блюд
+func (v *menuVariety) adjust(recipes []ScoredRecipe, date time.Time) []ScoredRecipe {
+	if !v.preferSeasonal && !v.balanceCuisines {
+		return recipes
+	}
+	season := seasonOf(date.Month())
+	adjusted := make([]ScoredRecipe, len(recipes))
+	for i, recipe := range recipes {
+		adjusted[i] = recipe
+		if v.preferSeasonal && len(recipe.Recipe.Seasons) > 0 {
+			if slices.Contains(recipe.Recipe.Seasons, season) {
+				adjusted[i].Preference += seasonalBoost
+			} else {
+				adjusted[i].Preference -= seasonalBoost
+			}
+		}
+		if v.balanceCuisines && recipe.Recipe.Cuisine != "" && v.meals > 0 {
+			share := float64(v.cuisines[recipe.Recipe.Cuisine]) / float64(v.meals)
+			adjusted[i].Preference -= cuisineRepeatWeight * share
+		}
+	}
+	return adjusted
+}
+
+// add учитывает блюда, выбранные на день
+func (v *menuVariety) add(recipes ...*models.Recipe) {
+	for _, recipe := range recipes {
+		v.meals++
+		if recipe.Cuisine != "" {
+			v.cuisines[recipe.Cuisine]++
+		}
+	}
+}
+
+// hardRecipeLimit возвращает допустимое число сложных рецептов в день: ограничение
+// weekday_max_hard действует с понедельника по пятницу; nil - без ограничения
+func hardRecipeLimit(date time.Time, weekdayMaxHard *int) *int {
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return nil
+	}
+	return weekdayMaxHard
+}
+
+// countHardRecipes считает сложные рецепты среди блюд дня
+func countHardRecipes(recipes []*models.Recipe) int {
+	count := 0
+	for _, recipe := range recipes {
+		if recipe.Difficulty == "hard" {
+			count++
+		}
+	}
+	return count
+}
+
+// fitRecipesToDifficulty заменяет сложные блюда дня, пока их больше maxHard,
+// несложными кандидатами того же приема пищи - ближайшими по калорийности с учетом
+// пригодности; accept дополнительно проверяет новый набор блюд. Блюда без
+// подходящей замены остаются.
+func fitRecipesToDifficulty(
+	recipes []*models.Recipe,
+	candidates [][]ScoredRecipe,
+	maxHard *int,
+	excludedIDs map[int]bool,
+	accept func(day []*models.Recipe) bool,
+) []*models.Recipe {
+	if maxHard == nil || countHardRecipes(recipes) <= *maxHard {
+		return recipes
+	}
+
+	day := append([]*models.Recipe{}, recipes...)
+	for i := range day {
+		if countHardRecipes(day) <= *maxHard {
+			break
+		}
+		if day[i].Difficulty != "hard" || i >= len(candidates) {
+			continue
+		}
+
+		var best *models.Recipe
+		bestScore := math.Inf(1)
+		for j := range candidates[i] {
+			candidate := &candidates[i][j]
+			if candidate.Recipe.Difficulty == "hard" || excludedIDs[candidate.Recipe.ID] {
+				continue
+			}
+			next := append([]*models.Recipe{}, day...)
+			next[i] = &candidate.Recipe
+			if accept != nil && !accept(next) {
+				continue
+			}
+			score := -candidate.Score*0.3 - candidate.Preference
+			if day[i].Calories > 0 {
+				score += math.Abs(float64(candidate.Recipe.Calories-day[i].Calories)) / float64(day[i].Calories)
+			}
+			if score < bestScore {
+				bestScore = score
+				best = &candidate.Recipe
+			}
+		}
+		if best != nil {
+			day[i] = best
+		}
+	}
+	return day
+}
+
+// difficultyViolations описывает превышение числа сложных рецептов в день меню
+func difficultyViolations(day *models.WeeklyDayMenu, maxHard *int) []string {
+	if maxHard == nil {
+		return nil
+	}
+	count := 0
+	for _, recipe := range []*models.RecipeDTO{day.Breakfast, day.Lunch, day.Dinner} {
+		if recipe != nil && recipe.Difficulty == "hard" {
+			count++
+		}
+	}
+	if count <= *maxHard {
+		return nil
+	}
+	return []string{fmt.Sprintf("сложных рецептов %d, больше лимита %d для будних дней", count, *maxHard)}
+}
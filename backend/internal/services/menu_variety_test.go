@@ -0,0 +1,79 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestMenuVariety_Adjust(t *testing.T) {
+	variety := newMenuVariety(&models.WeeklyMenuRequest{PreferSeasonal: true, BalanceCuisines: true})
+	recipes := []ScoredRecipe{
+		{Recipe: models.Recipe{ID: 1, Cuisine: "italian", Seasons: []string{"summer"}}},
+		{Recipe: models.Recipe{ID: 2, Cuisine: "russian", Seasons: []string{"winter"}}},
+		{Recipe: models.Recipe{ID: 3}},
+	}
+
+	// Июль - лето: летний рецепт получает бонус, зимний - штраф
+	july := time.Date(2026, time.July, 6, 0, 0, 0, 0, time.UTC)
+	adjusted := variety.adjust(recipes, july)
+	if adjusted[0].Preference != seasonalBoost || adjusted[1].Preference != -seasonalBoost || adjusted[2].Preference != 0 {
+		t.Errorf("Неверные поправки сезона: %v, %v, %v", adjusted[0].Preference, adjusted[1].Preference, adjusted[2].Preference)
+	}
+	if recipes[0].Preference != 0 {
+		t.Error("Исходные кандидаты не должны меняться")
+	}
+
+	// Итальянская кухня уже выбрана дважды из трех блюд - штраф пропорционален доле
+	variety.add(&recipes[0].Recipe, &recipes[0].Recipe, &recipes[2].Recipe)
+	adjusted = variety.adjust(recipes, july)
+	if want := seasonalBoost - cuisineRepeatWeight*2/3; math.Abs(adjusted[0].Preference-want) > 1e-9 {
+		t.Errorf("Ожидалась поправка %v, получено %v", want, adjusted[0].Preference)
+	}
+	if adjusted[1].Preference != -seasonalBoost {
+		t.Errorf("Кухня без повторов не штрафуется, получено %v", adjusted[1].Preference)
+	}
+}
+
+func TestFitRecipesToDifficulty(t *testing.T) {
+	hardBreakfast := &models.Recipe{ID: 1, MealType: "breakfast", Calories: 400, Difficulty: "hard"}
+	hardLunch := &models.Recipe{ID: 2, MealType: "lunch", Calories: 600, Difficulty: "hard"}
+	dinner := &models.Recipe{ID: 3, MealType: "dinner", Calories: 500, Difficulty: "easy"}
+	candidates := [][]ScoredRecipe{
+		{
+			{Recipe: models.Recipe{ID: 4, Calories: 150, Difficulty: "easy"}},
+			{Recipe: models.Recipe{ID: 5, Calories: 420, Difficulty: "medium"}},
+		},
+		{{Recipe: models.Recipe{ID: 6, Calories: 600, Difficulty: "easy"}}},
+		nil,
+	}
+
+	monday := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	maxHard := 1
+	if hardRecipeLimit(monday.AddDate(0, 0, 5), &maxHard) != nil || hardRecipeLimit(monday, &maxHard) == nil {
+		t.Error("Ограничение сложных рецептов действует только в будни")
+	}
+
+	// Заменяется первое сложное блюдо - на ближайшее по калориям
+	day := fitRecipesToDifficulty([]*models.Recipe{hardBreakfast, hardLunch, dinner}, candidates, &maxHard, nil, nil)
+	if day[0].ID != 5 || day[1].ID != 2 {
+		t.Errorf("Ожидались блюда 5 и 2, получено %d и %d", day[0].ID, day[1].ID)
+	}
+
+	// Если замена не принимается, блюдо остается, а нарушение попадает в день меню
+	noHard := 0
+	day = fitRecipesToDifficulty([]*models.Recipe{hardBreakfast, hardLunch, dinner}, candidates, &noHard, nil,
+		func(day []*models.Recipe) bool { return day[1].ID != 6 })
+	if day[0].ID != 5 || day[1].ID != 2 {
+		t.Errorf("Ожидались блюда 5 и 2, получено %d и %d", day[0].ID, day[1].ID)
+	}
+	menuDay := &models.WeeklyDayMenu{
+		Breakfast: &models.RecipeDTO{Difficulty: day[0].Difficulty},
+		Lunch:     &models.RecipeDTO{Difficulty: day[1].Difficulty},
+	}
+	if len(difficultyViolations(menuDay, &noHard)) != 1 || difficultyViolations(menuDay, &maxHard) != nil {
+		t.Errorf("Неверные нарушения: %v", difficultyViolations(menuDay, &noHard))
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+// sodiumPerGramSalt - содержание натрия в 1 г соли, мг
+const sodiumPerGramSalt = 393.0
+
+// unitGrams - вес единицы измерения ингредиента в граммах (мл считаются равными г)
+var unitGrams = map[string]float64{
+	"г":     1,
+	"гр":    1,
+	"g":     1,
+	"кг":    1000,
+	"kg":    1000,
+	"мл":    1,
+	"ml":    1,
+	"л":     1000,
+	"l":     1000,
+	"ст.л.": 15,
+	"ч.л.":  5,
+}
+
+// normalizeMicronutrients проверяет показатели и приводит их к единицам MicronutrientUnits:
+// соль (salt, г) пересчитывается в натрий (sodium, мг)
+func normalizeMicronutrients(values models.Micronutrients) (models.Micronutrients, error) {
+	result := models.Micronutrients{}
+	for _, key := range values.Keys() {
+		value := values[key]
+		if value < 0 {
+			return nil, fmt.Errorf("показатель '%s' не может быть отрицательным", key)
+		}
+		if key == "salt" {
+			if _, ok := values["sodium"]; !ok {
+				result["sodium"] = math.Round(value * sodiumPerGramSalt)
+			}
+			continue
+		}
+		if _, ok := models.MicronutrientUnits[key]; !ok {
+			return nil, fmt.Errorf("неизвестный показатель '%s'", key)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// computeMicronutrients рассчитывает показатели рецепта по ингредиентам и справочнику.
+// Ингредиенты без справочных данных или с неизвестной единицей пропускаются;
+// возвращает false, если не удалось учесть ни один ингредиент.
+func computeMicronutrients(ingredients models.Ingredients, reference map[string]models.IngredientNutrients) (models.Micronutrients, bool) {
+	result := models.Micronutrients{}
+	matched := false
+	for _, ing := range ingredients {
+		item, ok := reference[normalizeIngredientName(ing.Name)]
+		if !ok {
+			continue
+		}
+
+		unit := strings.ToLower(strings.TrimSpace(ing.Unit))
+		grams, ok := unitGrams[unit]
+		if !ok && (unit == "шт" || unit == "шт." || unit == "pcs") && item.PieceWeight > 0 {
+			grams, ok = item.PieceWeight, true
+		}
+		if !ok {
+			continue
+		}
+
+		result.Add(item.Per100g, ing.Quantity*grams/100)
+		matched = true
+	}
+	if !matched {
+		return nil, false
+	}
+	for key, value := range result {
+		result[key] = math.Round(value*10) / 10
+	}
+	return result, true
+}
+
+// nutrientLimitViolations возвращает показатели, превышающие лимиты.
+// Лимиты заданы на человека в день, persons - количество человек (порций).
+func nutrientLimitViolations(totals, limits models.Micronutrients, persons float64) []string {
+	var violations []string
+	for _, key := range limits.Keys() {
+		limit := limits[key] * persons
+		if value, ok := totals[key]; ok && value > limit {
+			violations = append(violations, fmt.Sprintf("%s: %.1f > %.1f %s",
+				key, value, limit, models.MicronutrientUnits[key]))
+		}
+	}
+	return violations
+}
+
+// dayMicronutrients суммирует показатели блюд дня с учетом количества порций
+func dayMicronutrients(day *models.WeeklyDayMenu, totalServings float64) models.Micronutrients {
+	totals := models.Micronutrients{}
+	for _, recipe := range []*models.RecipeDTO{day.Breakfast, day.Lunch, day.Dinner} {
+		if recipe == nil {
+			continue
+		}
+		totals.Add(recipe.Micronutrients, recipeScale(totalServings).multiplier(recipe.Servings))
+	}
+	return totals
+}
+
+// comboMicronutrients суммирует показатели трех блюд дневного меню (рецепты целиком)
+func comboMicronutrients(meals ...*ScoredRecipe) models.Micronutrients {
+	totals := models.Micronutrients{}
+	for _, meal := range meals {
+		totals.Add(meal.Recipe.Micronutrients, 1)
+	}
+	return totals
+}
+
+// fitRecipesToNutrientLimits проверяет блюда дня на лимиты и при превышении пробует
+// заменить одно из них кандидатом того же приема пищи (кандидаты - в порядке предпочтения).
+// Если уложиться в лимиты не удалось, возвращает исходные блюда и список превышений.
+func fitRecipesToNutrientLimits(
+	recipes []*models.Recipe,
+	candidates [][]ScoredRecipe,
+	limits models.Micronutrients,
+	totalServings float64,
+	excludedIDs map[int]bool,
+) ([]*models.Recipe, []string) {
+	totals := func(day []*models.Recipe) models.Micronutrients {
+		result := models.Micronutrients{}
+		for _, recipe := range day {
+			result.Add(recipe.Micronutrients, recipeScale(totalServings).multiplier(recipe.Servings))
+		}
+		return result
+	}
+
+	violations := nutrientLimitViolations(totals(recipes), limits, totalServings)
+	if len(violations) == 0 {
+		return recipes, nil
+	}
+
+	for i := range recipes {
+		if i >= len(candidates) {
+			break
+		}
+		for j := range candidates[i] {
+			candidate := &candidates[i][j].Recipe
+			if candidate.ID == recipes[i].ID || excludedIDs[candidate.ID] {
+				continue
+			}
+			day := append([]*models.Recipe{}, recipes...)
+			day[i] = candidate
+			if len(nutrientLimitViolations(totals(day), limits, totalServings)) == 0 {
+				return day, nil
+			}
+		}
+	}
+	return recipes, violations
+}
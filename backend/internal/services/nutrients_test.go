@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestNormalizeMicronutrients(t *testing.T) {
+	result, err := normalizeMicronutrients(models.Micronutrients{"salt": 2, "fiber": 5})
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	// 2 г соли = 786 мг натрия
+	if result["sodium"] != 786 {
+		t.Errorf("Ожидалось 786 мг натрия, получено %f", result["sodium"])
+	}
+	if _, ok := result["salt"]; ok {
+		t.Error("Соль должна быть пересчитана в натрий")
+	}
+	
+	if _, err := normalizeMicronutrients(models.Micronutrients{"unknown": 1}); err == nil {
+		t.Error("Ожидалась ошибка для неизвестного показателя")
+	}
+	if _, err := normalizeMicronutrients(models.Micronutrients{"sugar": -1}); err == nil {
+		t.Error("Ожидалась ошибка для отрицательного значения")
+	}
+}
+
+func TestComputeMicronutrients(t *testing.T) {
+	reference := map[string]models.IngredientNutrients{
+		"молоко": {Name: "молоко", Per100g: models.Micronutrients{"calcium": 113}},
+		"яйцо":   {Name: "яйцо", Per100g: models.Micronutrients{"sodium": 124}, PieceWeight: 50},
+	}
+	ingredients := models.Ingredients{
+		{Name: "Молоко", Quantity: 0.5, Unit: "л"},
+		{Name: "Яйцо", Quantity: 2, Unit: "шт"},
+		{Name: "Неизвестный продукт", Quantity: 100, Unit: "г"},
+	}
+	
+	result, ok := computeMicronutrients(ingredients, reference)
+	if !ok {
+		t.Fatal("Ожидался расчет по ингредиентам")
+	}
+	if result["calcium"] != 565 {
+		t.Errorf("Ожидалось 565 мг кальция, получено %f", result["calcium"])
+	}
+	if result["sodium"] != 124 {
+		t.Errorf("Ожидалось 124 мг натрия, получено %f", result["sodium"])
+	}
+	
+	if _, ok := computeMicronutrients(models.Ingredients{{Name: "Вода", Quantity: 1, Unit: "л"}}, reference); ok {
+		t.Error("Без справочных данных расчет невозможен")
+	}
+}
+
+func TestFitRecipesToNutrientLimits(t *testing.T) {
+	salty := models.Recipe{ID: 1, Servings: 1, Micronutrients: models.Micronutrients{"sodium": 1500}}
+	light := models.Recipe{ID: 2, Servings: 1, Micronutrients: models.Micronutrients{"sodium": 300}}
+	lunch := models.Recipe{ID: 3, Servings: 1, Micronutrients: models.Micronutrients{"sodium": 600}}
+	dinner := models.Recipe{ID: 4, Servings: 1, Micronutrients: models.Micronutrients{"sodium": 500}}
+	limits := models.Micronutrients{"sodium": 2000}
+	
+	candidates := [][]ScoredRecipe{
+		{{Recipe: salty}, {Recipe: light}},
+		{{Recipe: lunch}},
+		{{Recipe: dinner}},
+	}
+	
+	recipes, violations := fitRecipesToNutrientLimits(
+		[]*models.Recipe{&salty, &lunch, &dinner}, candidates, limits, 1, map[int]bool{})
+	if len(violations) != 0 {
+		t.Fatalf("Ожидалось соблюдение лимитов, получено %v", violations)
+	}
+	if recipes[0].ID != light.ID {
+		t.Errorf("Ожидалась замена завтрака на рецепт %d, получено %d", light.ID, recipes[0].ID)
+	}
+	
+	// Замена запрещена анти-повтором - лимит остается превышенным
+	_, violations = fitRecipesToNutrientLimits(
+		[]*models.Recipe{&salty, &lunch, &dinner}, candidates, limits, 1, map[int]bool{light.ID: true})
+	if len(violations) != 1 {
+		t.Errorf("Ожидалось одно превышение, получено %v", violations)
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/internal/repositories"
 )
@@ -15,17 +16,17 @@ func NewPantryService(pantryRepo *repositories.PantryRepository) *PantryService
 	}
 }
 
-func (s *PantryService) GetByUserID(userID int) ([]models.PantryItem, error) {
-	return s.pantryRepo.GetByUserID(userID)
+func (s *PantryService) GetByUserID(ctx context.Context, userID int) ([]models.PantryItem, error) {
+	return s.pantryRepo.GetByUserID(ctx, userID)
 }
 
-func (s *PantryService) Create(userID int, item *models.PantryItem) error {
+func (s *PantryService) Create(ctx context.Context, userID int, item *models.PantryItem) error {
 	item.UserID = userID
-	return s.pantryRepo.Create(item)
+	return s.pantryRepo.Create(ctx, item)
 }
 
-func (s *PantryService) Delete(userID int, id int) error {
-	return s.pantryRepo.Delete(id, userID)
+func (s *PantryService) Delete(ctx context.Context, userID int, id int) error {
+	return s.pantryRepo.Delete(ctx, id, userID)
 }
 
 
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+// Форматы потокового экспорта
+const (
+	ExportFormatJSON   = "json"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// exportFlushEvery - через сколько рецептов буферизованный поток отправляется клиенту
+const exportFlushEvery = 100
+
+// StreamExport пишет рецепты общего каталога по фильтру в w, не загружая каталог
+// в память, и возвращает манифест. NDJSON - по рецепту на строку, последняя строка
+// {"manifest": {...}}; JSON - {"recipes": [...], "manifest": {...}}. Экспорт без
+// манифеста в конце оборван и не должен применяться. Если w умеет Flush (поток
+// ответа), он сбрасывается каждые exportFlushEvery рецептов: ошибка сброса
+// (клиент отключился) прерывает экспорт, не дочитывая каталог.
+func (s *AdminRecipeService) StreamExport(ctx context.Context, filter *models.RecipeFilter, format string, w io.Writer) (*models.ExportManifest, error) {
+	writer, err := newRecipeExportWriter(w, format, filter.UpdatedSince)
+	if err != nil {
+		return nil, err
+	}
+	if err := writer.begin(); err != nil {
+		return nil, err
+	}
+
+	err = s.recipeRepo.StreamCatalog(ctx, filter, func(recipe *models.Recipe) error {
+		return writer.write(s.recipeToDTO(recipe), recipe.UpdatedAt)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при экспорте рецептов: %w", err)
+	}
+
+	return writer.finish()
+}
+
+// recipeExportWriter пишет рецепты в формате экспорта и считает манифест
+type recipeExportWriter struct {
+	w        io.Writer
+	format   string
+	hash     hash.Hash
+	manifest models.ExportManifest
+}
+
+func newRecipeExportWriter(w io.Writer, format string, updatedSince *time.Time) (*recipeExportWriter, error) {
+	if format != ExportFormatJSON && format != ExportFormatNDJSON {
+		return nil, fmt.Errorf("формат экспорта должен быть json или ndjson")
+	}
+	return &recipeExportWriter{
+		w:      w,
+		format: format,
+		hash:   sha256.New(),
+		manifest: models.ExportManifest{
+			GeneratedAt:  time.Now().UTC(),
+			UpdatedSince: updatedSince,
+		},
+	}, nil
+}
+
+func (e *recipeExportWriter) begin() error {
+	if e.format == ExportFormatJSON {
+		_, err := io.WriteString(e.w, `{"recipes":[`)
+		return err
+	}
+	return nil
+}
+
+// write пишет рецепт; сумма считается по строке NDJSON, поэтому не зависит от формата
+func (e *recipeExportWriter) write(dto models.RecipeExportDTO, updatedAt time.Time) error {
+	line, err := json.Marshal(dto)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	e.hash.Write(line)
+
+	if e.format == ExportFormatJSON && e.manifest.Count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	if _, err := e.w.Write(line); err != nil {
+		return err
+	}
+
+	e.manifest.Count++
+	if e.manifest.NextUpdatedSince == nil || updatedAt.After(*e.manifest.NextUpdatedSince) {
+		next := updatedAt
+		e.manifest.NextUpdatedSince = &next
+	}
+
+	if flusher, ok := e.w.(interface{ Flush() error }); ok && e.manifest.Count%exportFlushEvery == 0 {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// finish дописывает манифест и возвращает его
+func (e *recipeExportWriter) finish() (*models.ExportManifest, error) {
+	manifest := e.manifest
+	manifest.Checksum = "sha256:" + hex.EncodeToString(e.hash.Sum(nil))
+	// Без новых рецептов следующий экспорт начинается с той же отметки
+	if manifest.NextUpdatedSince == nil {
+		manifest.NextUpdatedSince = manifest.UpdatedSince
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if e.format == ExportFormatJSON {
+		_, err = fmt.Fprintf(e.w, "],\"manifest\":%s}\n", data)
+	} else {
+		_, err = fmt.Fprintf(e.w, "{\"manifest\":%s}\n", data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
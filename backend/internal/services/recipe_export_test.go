@@ -0,0 +1,111 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func exportRecipes(t *testing.T, format string, since *time.Time, recipes []models.RecipeExportDTO, updated []time.Time) (string, *models.ExportManifest) {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := newRecipeExportWriter(&buf, format, since)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if err := writer.begin(); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	for i, recipe := range recipes {
+		if err := writer.write(recipe, updated[i]); err != nil {
+			t.Fatalf("Неожиданная ошибка: %v", err)
+		}
+	}
+	manifest, err := writer.finish()
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	return buf.String(), manifest
+}
+
+func TestRecipeExportWriter(t *testing.T) {
+	recipes := []models.RecipeExportDTO{
+		{Title: "Омлет", Tags: []string{"breakfast"}, Calories: 300, Servings: 1},
+		{Title: "Салат", Tags: []string{"lunch"}, Calories: 120, Servings: 2},
+	}
+	first := time.Date(2026, 10, 18, 17, 0, 0, 0, time.UTC)
+	updated := []time.Time{first.Add(time.Hour), first}
+
+	ndjson, manifest := exportRecipes(t, ExportFormatNDJSON, nil, recipes, updated)
+	lines := strings.Split(strings.TrimSuffix(ndjson, "\n"), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[2], `{"manifest":`) {
+		t.Fatalf("Ожидалось 2 рецепта и манифест, получено:\n%s", ndjson)
+	}
+	if manifest.Count != 2 || !manifest.NextUpdatedSince.Equal(first.Add(time.Hour)) {
+		t.Errorf("Неверный манифест: %+v", manifest)
+	}
+
+	// JSON читается как запрос импорта, сумма совпадает с NDJSON
+	jsonData, jsonManifest := exportRecipes(t, ExportFormatJSON, nil, recipes, updated)
+	var parsed struct {
+		models.RecipeImportRequest
+		Manifest models.ExportManifest `json:"manifest"`
+	}
+	if err := json.Unmarshal([]byte(jsonData), &parsed); err != nil {
+		t.Fatalf("Неверный JSON: %v\n%s", err, jsonData)
+	}
+	if len(parsed.Recipes) != 2 || parsed.Recipes[1].Title != "Салат" || parsed.Manifest.Checksum != manifest.Checksum {
+		t.Errorf("Неверный JSON экспорта: %+v", parsed)
+	}
+	if jsonManifest.Checksum != manifest.Checksum || !strings.HasPrefix(manifest.Checksum, "sha256:") {
+		t.Errorf("Суммы NDJSON и JSON должны совпадать: %s и %s", manifest.Checksum, jsonManifest.Checksum)
+	}
+
+	_, changed := exportRecipes(t, ExportFormatNDJSON, nil, recipes[:1], updated)
+	if changed.Checksum == manifest.Checksum {
+		t.Error("Сумма должна зависеть от содержимого")
+	}
+
+	// Пустой инкрементальный экспорт сохраняет отметку
+	empty, emptyManifest := exportRecipes(t, ExportFormatJSON, &first, nil, nil)
+	if !strings.HasPrefix(empty, `{"recipes":[],"manifest":`) || emptyManifest.Count != 0 || !emptyManifest.NextUpdatedSince.Equal(first) {
+		t.Errorf("Неверный пустой экспорт: %s", empty)
+	}
+
+	if _, err := newRecipeExportWriter(&bytes.Buffer{}, "xml", nil); err == nil {
+		t.Error("Ожидалась ошибка для неизвестного формата")
+	}
+}
+
+// failingWriter имитирует соединение отключившегося клиента
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("connection reset")
+}
+
+func TestRecipeExportWriter_StopsOnFlushError(t *testing.T) {
+	conn := &failingWriter{}
+	writer, err := newRecipeExportWriter(bufio.NewWriterSize(conn, 1<<20), ExportFormatNDJSON, nil)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+
+	recipe := models.RecipeExportDTO{Title: "Омлет", Calories: 300, Servings: 1}
+	for i := 1; i <= exportFlushEvery; i++ {
+		err = writer.write(recipe, time.Now())
+		if i < exportFlushEvery && err != nil {
+			t.Fatalf("Рецепт %d: до сброса ошибок быть не должно: %v", i, err)
+		}
+	}
+	if err == nil || conn.writes != 1 {
+		t.Errorf("Ожидалась ошибка сброса после %d рецептов, получено %v (записей %d)", exportFlushEvery, err, conn.writes)
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/repositories"
+)
+
+const (
+	favoriteBoost = 0.3 // бонус избранного рецепта к пригодности
+	ratingWeight  = 0.1 // бонус (штраф) за каждый балл оценки выше (ниже) 3
+)
+
+type RecipeFeedbackService struct {
+	feedbackRepo *repositories.RecipeFeedbackRepository
+	recipeRepo   *repositories.RecipeRepository
+}
+
+func NewRecipeFeedbackService(feedbackRepo *repositories.RecipeFeedbackRepository, recipeRepo *repositories.RecipeRepository) *RecipeFeedbackService {
+	return &RecipeFeedbackService{
+		feedbackRepo: feedbackRepo,
+		recipeRepo:   recipeRepo,
+	}
+}
+
+// GetFeedback возвращает оценки и отметки пользователя
+func (s *RecipeFeedbackService) GetFeedback(ctx context.Context, userID int) ([]models.RecipeFeedback, error) {
+	return s.feedbackRepo.GetByUserID(ctx, userID)
+}
+
+// Rate ставит оценку рецепту (1-5)
+func (s *RecipeFeedbackService) Rate(ctx context.Context, userID, recipeID, rating int) (*models.RecipeFeedback, error) {
+	if rating < 1 || rating > 5 {
+		return nil, fmt.Errorf("оценка должна быть от 1 до 5")
+	}
+	return s.update(ctx, userID, recipeID, func(f *models.RecipeFeedback) {
+		f.Rating = &rating
+	})
+}
+
+// ClearRating удаляет оценку рецепта
+func (s *RecipeFeedbackService) ClearRating(ctx context.Context, userID, recipeID int) (*models.RecipeFeedback, error) {
+	return s.update(ctx, userID, recipeID, func(f *models.RecipeFeedback) {
+		f.Rating = nil
+	})
+}
+
+// SetFavorite добавляет рецепт в избранное или убирает из него (избранный рецепт не может быть запрещен)
+func (s *RecipeFeedbackService) SetFavorite(ctx context.Context, userID, recipeID int, favorite bool) (*models.RecipeFeedback, error) {
+	return s.update(ctx, userID, recipeID, func(f *models.RecipeFeedback) {
+		f.Favorite = favorite
+		if favorite {
+			f.Banned = false
+		}
+	})
+}
+
+// SetBanned запрещает рецепт ("больше не показывать") или снимает запрет
+func (s *RecipeFeedbackService) SetBanned(ctx context.Context, userID, recipeID int, banned bool) (*models.RecipeFeedback, error) {
+	return s.update(ctx, userID, recipeID, func(f *models.RecipeFeedback) {
+		f.Banned = banned
+		if banned {
+			f.Favorite = false
+		}
+	})
+}
+
+// update изменяет отметки рецепта; пустая запись удаляется
+func (s *RecipeFeedbackService) update(ctx context.Context, userID, recipeID int, apply func(*models.RecipeFeedback)) (*models.RecipeFeedback, error) {
+	recipe, err := s.recipeRepo.GetByID(ctx, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении рецепта: %w", err)
+	}
+	if recipe == nil || !recipeVisibleTo(recipe, userID) {
+		return nil, sql.ErrNoRows
+	}
+
+	feedback, err := s.feedbackRepo.Get(ctx, userID, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении оценки: %w", err)
+	}
+	if feedback == nil {
+		feedback = &models.RecipeFeedback{UserID: userID, RecipeID: recipeID}
+	}
+	feedback.RecipeName = recipe.Name
+	apply(feedback)
+
+	if feedback.Rating == nil && !feedback.Favorite && !feedback.Banned {
+		return feedback, s.feedbackRepo.Delete(ctx, userID, recipeID)
+	}
+	if err := s.feedbackRepo.Upsert(ctx, feedback); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении оценки: %w", err)
+	}
+	return feedback, nil
+}
+
+// recipePreferences - отметки пользователя по ID рецепта для генерации меню
+type recipePreferences map[int]models.RecipeFeedback
+
+func newRecipePreferences(feedback []models.RecipeFeedback) recipePreferences {
+	prefs := make(recipePreferences, len(feedback))
+	for _, f := range feedback {
+		prefs[f.RecipeID] = f
+	}
+	return prefs
+}
+
+// banned проверяет, запретил ли пользователь рецепт
+func (p recipePreferences) banned(recipeID int) bool {
+	return p[recipeID].Banned
+}
+
+// boost возвращает бонус к пригодности рецепта: избранное и оценка выше 3
+// повышают его, оценка ниже 3 - понижает
+func (p recipePreferences) boost(recipeID int) float64 {
+	feedback, ok := p[recipeID]
+	if !ok {
+		return 0
+	}
+	boost := 0.0
+	if feedback.Favorite {
+		boost += favoriteBoost
+	}
+	if feedback.Rating != nil {
+		boost += float64(*feedback.Rating-3) * ratingWeight
+	}
+	return boost
+}
+
+// excludeBanned убирает запрещенные пользователем рецепты
+func (p recipePreferences) excludeBanned(recipes []models.Recipe) []models.Recipe {
+	if len(p) == 0 {
+		return recipes
+	}
+	result := make([]models.Recipe, 0, len(recipes))
+	for _, recipe := range recipes {
+		if !p.banned(recipe.ID) {
+			result = append(result, recipe)
+		}
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestScoreRecipesByPantry_Preferences(t *testing.T) {
+	service := &MenuService{}
+	five, one := 5, 1
+	prefs := newRecipePreferences([]models.RecipeFeedback{
+		{RecipeID: 1, Favorite: true, Rating: &five},
+		{RecipeID: 2, Banned: true},
+		{RecipeID: 3, Rating: &one},
+	})
+	recipes := []models.Recipe{
+		{ID: 1, Name: "Сырники", Ingredients: models.Ingredients{{Name: "Творог", Quantity: 200, Unit: "г"}}},
+		{ID: 2, Name: "Овсянка", Ingredients: models.Ingredients{{Name: "Овсяные хлопья", Quantity: 50, Unit: "г"}}},
+		{ID: 3, Name: "Омлет", Ingredients: models.Ingredients{{Name: "Яйца", Quantity: 2, Unit: "шт"}}},
+	}
+
+	scored := service.scoreRecipesByPantry(recipes, nil, "prefer", prefs)
+
+	// Запрещенный рецепт исключается
+	if len(scored) != 2 || scored[0].Recipe.ID != 1 || scored[1].Recipe.ID != 3 {
+		t.Fatalf("Ожидались рецепты 1 и 3, получено %+v", scored)
+	}
+	// Избранное с оценкой 5: 0.3 + 0.2; оценка 1: -0.2
+	if scored[0].Preference < 0.49 || scored[0].Preference > 0.51 {
+		t.Errorf("Ожидался бонус 0.5, получено %.2f", scored[0].Preference)
+	}
+	if scored[1].Preference > -0.19 || scored[1].Preference < -0.21 {
+		t.Errorf("Ожидался штраф -0.2, получено %.2f", scored[1].Preference)
+	}
+
+	// Избранный рецепт выигрывает у нелюбимого при прочих равных
+	req := &models.MenuGenerateRequest{}
+	if service.calculateRecipeFitness(&scored[0], req) <= service.calculateRecipeFitness(&scored[1], req) {
+		t.Error("Ожидалась более высокая пригодность избранного рецепта")
+	}
+
+	if len(prefs.excludeBanned(recipes)) != 2 {
+		t.Error("Ожидалось исключение запрещенного рецепта из списка")
+	}
+}
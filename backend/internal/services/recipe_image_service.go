@@ -0,0 +1,327 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/repositories"
+	"github.com/myplate/backend/pkg/storage"
+	_ "golang.org/x/image/webp" // декодер WebP для image.Decode
+)
+
+const (
+	// MaxRecipeImageSize - максимальный размер загружаемого изображения
+	MaxRecipeImageSize = 10 << 20
+	// Ограничение по пикселям защищает от "бомб" - маленьких файлов с огромным разрешением
+	maxRecipeImagePixels = 40_000_000
+	// RecipeImageURLPrefix - префикс URL файлов хранилища (GET /images/*)
+	RecipeImageURLPrefix = "/images/"
+)
+
+// RecipeThumbnailSizes - уменьшенные копии изображений: наибольшая сторона, px
+var RecipeThumbnailSizes = []struct {
+	Name string
+	Size int
+}{
+	{"small", 160},
+	{"medium", 480},
+	{"large", 1024},
+}
+
+var (
+	ErrUnsupportedImage = errors.New("поддерживаются изображения JPEG, PNG и WebP")
+	ErrImageTooLarge    = fmt.Errorf("размер изображения больше %d МБ", MaxRecipeImageSize>>20)
+	ErrInvalidImage     = errors.New("неверное изображение")
+)
+
+// RecipeImage - загруженное изображение рецепта
+type RecipeImage struct {
+	ImageURL    string                 `json:"image_url"`
+	Thumbnails  models.ImageThumbnails `json:"thumbnails"`
+	ContentType string                 `json:"content_type"`
+	Width       int                    `json:"width"`
+	Height      int                    `json:"height"`
+}
+
+// RecipeImageService загружает изображения рецептов в хранилище и делает уменьшенные копии
+type RecipeImageService struct {
+	recipeRepo *repositories.RecipeRepository
+	storage    storage.Storage
+}
+
+func NewRecipeImageService(recipeRepo *repositories.RecipeRepository, store storage.Storage) *RecipeImageService {
+	return &RecipeImageService{
+		recipeRepo: recipeRepo,
+		storage:    store,
+	}
+}
+
+// Upload проверяет изображение, сохраняет оригинал и уменьшенные копии и заменяет
+// изображение рецепта; файлы прежнего загруженного изображения удаляются.
+// Имена файлов содержат хеш содержимого, поэтому их можно кешировать бессрочно.
+func (s *RecipeImageService) Upload(ctx context.Context, recipeID int, data []byte) (*RecipeImage, error) {
+	recipe, err := s.recipeRepo.GetByID(ctx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if recipe == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	info, err := inspectImage(data)
+	if err != nil {
+		return nil, err
+	}
+	thumbnails, thumbnailInfo, err := makeThumbnails(data, info)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	base := fmt.Sprintf("recipes/%d/%s", recipeID, hex.EncodeToString(sum[:8]))
+	files := map[string]imageFile{base + "." + info.Ext: {data, info.ContentType}}
+	result := &RecipeImage{
+		ImageURL:    RecipeImageURLPrefix + base + "." + info.Ext,
+		Thumbnails:  models.ImageThumbnails{},
+		ContentType: info.ContentType,
+		Width:       info.Width,
+		Height:      info.Height,
+	}
+	for _, size := range RecipeThumbnailSizes {
+		key := base + "-" + size.Name + "." + thumbnailInfo.Ext
+		files[key] = imageFile{thumbnails[size.Name], thumbnailInfo.ContentType}
+		result.Thumbnails[size.Name] = RecipeImageURLPrefix + key
+	}
+
+	var written []string
+	for key, file := range files {
+		if err := s.storage.Put(ctx, key, file.Data, file.ContentType); err != nil {
+			s.deleteFiles(ctx, written)
+			return nil, fmt.Errorf("ошибка при сохранении изображения: %w", err)
+		}
+		written = append(written, key)
+	}
+
+	if err := s.recipeRepo.UpdateImage(ctx, recipeID, result.ImageURL, result.Thumbnails); err != nil {
+		s.deleteFiles(ctx, written)
+		return nil, err
+	}
+
+	// Прежнее изображение больше не используется (повторная загрузка того же файла
+	// дает те же ключи - их не удаляем)
+	var stale []string
+	for _, url := range append([]string{recipe.ImageURL}, thumbnailURLs(recipe.Thumbnails)...) {
+		key, ok := imageKey(url)
+		if _, current := files[key]; ok && !current {
+			stale = append(stale, key)
+		}
+	}
+	s.deleteFiles(ctx, stale)
+
+	return result, nil
+}
+
+// Get возвращает файл хранилища по ключу (путь после /images/)
+func (s *RecipeImageService) Get(ctx context.Context, key string) (*storage.Object, error) {
+	return s.storage.Get(ctx, key)
+}
+
+func (s *RecipeImageService) deleteFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("Не удалось удалить файл изображения %s: %v", key, err)
+		}
+	}
+}
+
+func thumbnailURLs(thumbnails models.ImageThumbnails) []string {
+	urls := make([]string, 0, len(thumbnails))
+	for _, url := range thumbnails {
+		urls = append(urls, url)
+	}
+	return urls
+}
+
+// imageKey возвращает ключ хранилища для URL загруженного изображения
+// (внешние URL, заданные при создании рецепта, не относятся к хранилищу)
+func imageKey(url string) (string, bool) {
+	if !strings.HasPrefix(url, RecipeImageURLPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(url, RecipeImageURLPrefix), true
+}
+
+// imageFile - файл изображения для записи в хранилище
+type imageFile struct {
+	Data        []byte
+	ContentType string
+}
+
+// imageInfo - тип и размеры изображения
+type imageInfo struct {
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// inspectImage проверяет размер файла, тип по содержимому (а не по имени или
+// заголовку запроса) и разрешение, не декодируя изображение целиком
+func inspectImage(data []byte) (*imageInfo, error) {
+	if len(data) > MaxRecipeImageSize {
+		return nil, ErrImageTooLarge
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: файл пуст", ErrInvalidImage)
+	}
+
+	info := &imageInfo{ContentType: http.DetectContentType(data)}
+	var err error
+	switch info.ContentType {
+	case "image/jpeg", "image/png":
+		var config image.Config
+		config, _, err = image.DecodeConfig(bytes.NewReader(data))
+		info.Width, info.Height = config.Width, config.Height
+		info.Ext = strings.TrimPrefix(info.ContentType, "image/")
+		if info.Ext == "jpeg" {
+			info.Ext = "jpg"
+		}
+	case "image/webp":
+		info.Width, info.Height, err = webpSize(data)
+		info.Ext = "webp"
+	default:
+		return nil, ErrUnsupportedImage
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if info.Width <= 0 || info.Height <= 0 || info.Width*info.Height > maxRecipeImagePixels {
+		return nil, fmt.Errorf("%w: недопустимое разрешение %dx%d", ErrInvalidImage, info.Width, info.Height)
+	}
+	return info, nil
+}
+
+// webpSize читает размеры из заголовка WebP (форматы VP8, VP8L и VP8X)
+func webpSize(data []byte) (int, int, error) {
+	if len(data) < 30 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, 0, errors.New("поврежденный заголовок WebP")
+	}
+	switch string(data[12:16]) {
+	case "VP8 ":
+		if data[23] != 0x9d || data[24] != 0x01 || data[25] != 0x2a {
+			return 0, 0, errors.New("поврежденный кадр VP8")
+		}
+		width := int(binary.LittleEndian.Uint16(data[26:28]) & 0x3fff)
+		height := int(binary.LittleEndian.Uint16(data[28:30]) & 0x3fff)
+		return width, height, nil
+	case "VP8L":
+		if data[20] != 0x2f {
+			return 0, 0, errors.New("поврежденный кадр VP8L")
+		}
+		bits := binary.LittleEndian.Uint32(data[21:25])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, nil
+	case "VP8X":
+		width := int(data[24]) | int(data[25])<<8 | int(data[26])<<16
+		height := int(data[27]) | int(data[28])<<8 | int(data[29])<<16
+		return width + 1, height + 1, nil
+	}
+	return 0, 0, errors.New("неизвестный формат WebP")
+}
+
+// makeThumbnails делает уменьшенные копии (изображения меньше размера копии не
+// увеличиваются) и возвращает их формат. Копии JPEG и PNG - в формате оригинала;
+// кодировщика WebP нет, поэтому копии WebP сохраняются в PNG, если в изображении
+// есть прозрачность, иначе в JPEG.
+func makeThumbnails(data []byte, info *imageInfo) (map[string][]byte, *imageInfo, error) {
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	src := image.NewRGBA(decoded.Bounds())
+	draw.Draw(src, src.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+
+	format := &imageInfo{ContentType: info.ContentType, Ext: info.Ext}
+	if info.Ext == "webp" {
+		format = &imageInfo{ContentType: "image/jpeg", Ext: "jpg"}
+		if !src.Opaque() {
+			format = &imageInfo{ContentType: "image/png", Ext: "png"}
+		}
+	}
+
+	thumbnails := map[string][]byte{}
+	for _, size := range RecipeThumbnailSizes {
+		width, height := thumbnailSize(info.Width, info.Height, size.Size)
+		resized := resizeBox(src, width, height)
+
+		var buf bytes.Buffer
+		if format.Ext == "png" {
+			err = png.Encode(&buf, resized)
+		} else {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("ошибка при создании уменьшенной копии: %w", err)
+		}
+		thumbnails[size.Name] = buf.Bytes()
+	}
+	return thumbnails, format, nil
+}
+
+// thumbnailSize вписывает изображение в квадрат maxSide с сохранением пропорций
+func thumbnailSize(width, height, maxSide int) (int, int) {
+	if width <= maxSide && height <= maxSide {
+		return width, height
+	}
+	if width >= height {
+		return maxSide, max(1, height*maxSide/width)
+	}
+	return max(1, width*maxSide/height), maxSide
+}
+
+// resizeBox уменьшает изображение усреднением пикселей исходной области
+// (значения RGBA премультиплицированы, поэтому прозрачность усредняется верно)
+func resizeBox(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max((y+1)*srcHeight/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max((x+1)*srcWidth/width, x0+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(src.Pix[offset+c])
+					}
+					offset += 4
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			offset := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestRecipeImageThumbnails(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 600, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 600; x++ {
+			src.Set(x, y, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("Ошибка кодирования PNG: %v", err)
+	}
+
+	info, err := inspectImage(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if info.ContentType != "image/png" || info.Ext != "png" || info.Width != 600 || info.Height != 300 {
+		t.Fatalf("Неверные данные изображения: %+v", info)
+	}
+
+	thumbnails, format, err := makeThumbnails(buf.Bytes(), info)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if format.Ext != "png" || format.ContentType != "image/png" {
+		t.Errorf("Копии PNG должны быть в PNG, получено %+v", format)
+	}
+	// large не увеличивается сверх оригинала
+	expected := map[string][2]int{"small": {160, 80}, "medium": {480, 240}, "large": {600, 300}}
+	for name, size := range expected {
+		decoded, err := png.Decode(bytes.NewReader(thumbnails[name]))
+		if err != nil {
+			t.Fatalf("%s: неверный PNG: %v", name, err)
+		}
+		bounds := decoded.Bounds()
+		if bounds.Dx() != size[0] || bounds.Dy() != size[1] {
+			t.Errorf("%s: ожидалось %dx%d, получено %dx%d", name, size[0], size[1], bounds.Dx(), bounds.Dy())
+		}
+		if r, g, b, _ := decoded.At(10, 10).RGBA(); r>>8 != 200 || g>>8 != 100 || b>>8 != 50 {
+			t.Errorf("%s: цвет искажен при уменьшении: %d %d %d", name, r>>8, g>>8, b>>8)
+		}
+	}
+}
+
+func TestInspectImage_Rejects(t *testing.T) {
+	if _, err := inspectImage([]byte("GIF89a\x01\x00\x01\x00")); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("GIF: ожидалась ErrUnsupportedImage, получено %v", err)
+	}
+	if _, err := inspectImage(make([]byte, MaxRecipeImageSize+1)); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("Ожидалась ErrImageTooLarge, получено %v", err)
+	}
+	if _, err := inspectImage([]byte("\x89PNG\r\n\x1a\n" + "обрезан")); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("Ожидалась ErrInvalidImage для поврежденного PNG, получено %v", err)
+	}
+}
+
+func TestInspectImage_WebP(t *testing.T) {
+	// Заголовок расширенного формата VP8X: холст 1200x800
+	header := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x00\x00\x00\x00")
+	header = append(header, 0xaf, 0x04, 0x00, 0x1f, 0x03, 0x00)
+
+	info, err := inspectImage(header)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if info.Ext != "webp" || info.Width != 1200 || info.Height != 800 {
+		t.Errorf("Неверные данные WebP: %+v", info)
+	}
+}
+
+func TestRecipeImageThumbnails_WebP(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string // изображение 1x1
+		decode func(data []byte) (image.Image, error)
+		ext    string
+	}{
+		{"непрозрачное (VP8)", "UklGRiIAAABXRUJQVlA4IBYAAAAwAQCdASoBAAEADsD+JaQAA3AAAAAA", func(data []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(data)) }, "jpg"},
+		{"прозрачное (VP8L)", "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==", func(data []byte) (image.Image, error) { return png.Decode(bytes.NewReader(data)) }, "png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := base64.StdEncoding.DecodeString(tt.data)
+			info, err := inspectImage(data)
+			if err != nil || info.Ext != "webp" {
+				t.Fatalf("Ожидался WebP, получено %+v, %v", info, err)
+			}
+
+			thumbnails, format, err := makeThumbnails(data, info)
+			if err != nil {
+				t.Fatalf("Неожиданная ошибка: %v", err)
+			}
+			if format.Ext != tt.ext {
+				t.Errorf("Ожидались копии %s, получено %+v", tt.ext, format)
+			}
+			for _, size := range RecipeThumbnailSizes {
+				decoded, err := tt.decode(thumbnails[size.Name])
+				if err != nil {
+					t.Fatalf("%s: копия не декодируется: %v", size.Name, err)
+				}
+				if bounds := decoded.Bounds(); bounds.Dx() != 1 || bounds.Dy() != 1 {
+					t.Errorf("%s: ожидалось 1x1, получено %v", size.Name, bounds)
+				}
+			}
+		})
+	}
+}
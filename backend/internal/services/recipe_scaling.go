@@ -0,0 +1,206 @@
+package services
+
+import (
+	"math"
+	"strings"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+// MaxScaledServings - наибольшее количество порций для пересчета рецепта
+const MaxScaledServings = 1000
+
+// recipeScale - количество порций, на которое пересчитываются рецепты. КБЖУ,
+// микронутриенты и ингредиенты рецепта указаны на все его порции (recipe.Servings).
+type recipeScale float64
+
+// peopleScale - порции на семью: взрослый - 1 порция, ребенок - 0.7 (никого - 1 порция)
+func peopleScale(adults, children int) recipeScale {
+	servings := float64(adults) + float64(children)*0.7
+	if servings <= 0 {
+		return 1
+	}
+	return recipeScale(servings)
+}
+
+// multiplier - коэффициент пересчета рецепта на recipeServings порций
+// (рецепт без указанных порций считается рассчитанным на одну)
+func (s recipeScale) multiplier(recipeServings int) float64 {
+	if recipeServings <= 0 {
+		recipeServings = 1
+	}
+	return float64(s) / float64(recipeServings)
+}
+
+// nutrition пересчитывает КБЖУ рецепта на recipeServings порций
+func (s recipeScale) nutrition(calories int, proteins, fats, carbs float64, recipeServings int) models.NutritionTotals {
+	factor := s.multiplier(recipeServings)
+	return models.NutritionTotals{
+		Calories: int(math.Round(float64(calories) * factor)),
+		Proteins: proteins * factor,
+		Fats:     fats * factor,
+		Carbs:    carbs * factor,
+	}
+}
+
+// recipeNutrition пересчитывает КБЖУ рецепта
+func (s recipeScale) recipeNutrition(recipe *models.Recipe) models.NutritionTotals {
+	return s.nutrition(recipe.Calories, recipe.Proteins, recipe.Fats, recipe.Carbs, recipe.Servings)
+}
+
+// dtoNutrition пересчитывает КБЖУ блюда меню (nil - нулевые значения)
+func (s recipeScale) dtoNutrition(recipe *models.RecipeDTO) models.NutritionTotals {
+	if recipe == nil {
+		return models.NutritionTotals{}
+	}
+	return s.nutrition(recipe.Calories, recipe.Proteins, recipe.Fats, recipe.Carbs, recipe.Servings)
+}
+
+// recipesNutrition суммирует пересчитанные КБЖУ блюд
+func (s recipeScale) recipesNutrition(recipes ...*models.Recipe) models.NutritionTotals {
+	var totals models.NutritionTotals
+	for _, recipe := range recipes {
+		addNutrition(&totals, s.recipeNutrition(recipe))
+	}
+	return totals
+}
+
+// dayNutrition суммирует пересчитанные КБЖУ блюд дня меню
+func (s recipeScale) dayNutrition(day *models.WeeklyDayMenu) models.NutritionTotals {
+	var totals models.NutritionTotals
+	for _, recipe := range []*models.RecipeDTO{day.Breakfast, day.Lunch, day.Dinner} {
+		addNutrition(&totals, s.dtoNutrition(recipe))
+	}
+	return totals
+}
+
+func addNutrition(totals *models.NutritionTotals, other models.NutritionTotals) {
+	totals.Calories += other.Calories
+	totals.Proteins += other.Proteins
+	totals.Fats += other.Fats
+	totals.Carbs += other.Carbs
+}
+
+// ingredients пересчитывает ингредиенты линейно - для сумм по меню (покупки, кладовая)
+func (s recipeScale) ingredients(recipe *models.Recipe) models.Ingredients {
+	factor := s.multiplier(recipe.Servings)
+	result := make(models.Ingredients, 0, len(recipe.Ingredients))
+	for _, ing := range recipe.Ingredients {
+		ing.Quantity *= factor
+		result = append(result, ing)
+	}
+	return result
+}
+
+// kitchenIngredients пересчитывает ингредиенты и округляет до кухонных мер -
+// количества, которые действительно отмеряют при готовке
+func (s recipeScale) kitchenIngredients(recipe *models.Recipe) models.Ingredients {
+	result := s.ingredients(recipe)
+	for i := range result {
+		result[i].Quantity = roundKitchenQuantity(result[i].Quantity, result[i].Unit)
+	}
+	return result
+}
+
+// scaleRecipe возвращает рецепт, пересчитанный на s порций
+func (s recipeScale) scaleRecipe(recipe *models.Recipe) *models.ScaledRecipe {
+	factor := s.multiplier(recipe.Servings)
+	scaled := &models.ScaledRecipe{
+		Recipe:         *recipe,
+		ScaledServings: float64(s),
+		Multiplier:     math.Round(factor*1000) / 1000,
+		PerServing:     recipeScale(1).recipeNutrition(recipe),
+	}
+
+	totals := s.recipeNutrition(recipe)
+	scaled.Calories = totals.Calories
+	scaled.Proteins = math.Round(totals.Proteins*10) / 10
+	scaled.Fats = math.Round(totals.Fats*10) / 10
+	scaled.Carbs = math.Round(totals.Carbs*10) / 10
+	scaled.PerServing.Proteins = math.Round(scaled.PerServing.Proteins*10) / 10
+	scaled.PerServing.Fats = math.Round(scaled.PerServing.Fats*10) / 10
+	scaled.PerServing.Carbs = math.Round(scaled.PerServing.Carbs*10) / 10
+	if len(recipe.Micronutrients) > 0 {
+		scaled.Micronutrients = models.Micronutrients{}
+		scaled.Micronutrients.Add(recipe.Micronutrients, factor)
+	}
+	scaled.Ingredients = s.kitchenIngredients(recipe)
+	return scaled
+}
+
+// kitchenUnit - шаг округления количества в единице измерения
+type kitchenUnit struct {
+	prefixes []string
+	step     func(quantity float64) float64
+}
+
+func fixedStep(step float64) func(float64) float64 {
+	return func(float64) float64 { return step }
+}
+
+// kitchenUnits - единицы измерения и шаги округления. Единицы сравниваются по началу
+// слова, чтобы учитывать падежи ("зубчика", "ломтика", "щепотки").
+var kitchenUnits = []kitchenUnit{
+	// Штучное - целыми (полтора яйца не разбить)
+	{[]string{"шт", "pcs", "piece", "зубч", "clove", "ломт", "slice", "щепот", "pinch", "пуч", "bunch", "бан", "can", "лист", "кус"}, fixedStep(1)},
+	{[]string{"ч.л", "tsp", "teaspoon"}, fixedStep(0.25)},
+	{[]string{"ст.л", "tbsp", "tablespoon"}, fixedStep(0.5)},
+	{[]string{"стакан", "cup"}, fixedStep(0.25)},
+	{[]string{"г", "гр", "g", "gram", "мл", "ml", "milliliter", "millilitre"}, func(q float64) float64 {
+		switch {
+		case q < 10:
+			return 1
+		case q < 100:
+			return 5
+		case q < 1000:
+			return 10
+		}
+		return 50
+	}},
+	{[]string{"кг", "kg", "kilogram", "л", "l", "литр", "liter", "litre"}, func(q float64) float64 {
+		if q < 1 {
+			return 0.05
+		}
+		return 0.1
+	}},
+}
+
+// roundKitchenQuantity округляет количество до удобной кухонной меры: штуки - до целых,
+// чайные ложки - до ¼, граммы и миллилитры - до 1, 5, 10 или 50 в зависимости от
+// величины. Ненулевое количество не округляется до нуля. Неизвестные единицы -
+// до сотых.
+func roundKitchenQuantity(quantity float64, unit string) float64 {
+	if quantity <= 0 {
+		return quantity
+	}
+	key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(unit)), " ", "")
+
+	step := 0.01
+	for _, u := range kitchenUnits {
+		if matchesUnit(key, u.prefixes) {
+			step = u.step(quantity)
+			break
+		}
+	}
+	rounded := math.Round(quantity/step) * step
+	// Устраняем погрешность вычислений (0.30000000000000004)
+	rounded = math.Round(rounded*100) / 100
+	return math.Max(rounded, step)
+}
+
+// matchesUnit - единица совпадает с одним из префиксов; однобуквенные единицы
+// ("г", "л", "g", "l") должны совпадать целиком (с точкой или без)
+func matchesUnit(unit string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if len([]rune(prefix)) == 1 {
+			if strings.TrimSuffix(unit, ".") == prefix {
+				return true
+			}
+			continue
+		}
+		if strings.HasPrefix(unit, prefix) {
+			return true
+		}
+	}
+	return false
+}
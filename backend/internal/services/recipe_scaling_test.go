@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestRoundKitchenQuantity(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		unit     string
+		expected float64
+	}{
+		{1.5, "шт", 2},
+		{0.3, "шт", 1},
+		{2.67, "зубчика", 3},
+		{0.4, "щепотка", 1},
+		{0.6, "ч.л.", 0.5},
+		{0.7, "ч. л.", 0.75},
+		{0.1, "tsp", 0.25},
+		{1.3, "ст.л.", 1.5},
+		{0.6, "стакан", 0.5},
+		{3.4, "г", 3},
+		{67, "г", 65},
+		{337.5, "мл", 340},
+		{1234, "г", 1250},
+		{0.37, "кг", 0.35},
+		{1.26, "л", 1.3},
+		{0.333, "пучка", 1},
+		{1.2345, "унция", 1.23},
+		{0, "г", 0},
+	}
+
+	for _, tt := range tests {
+		if got := roundKitchenQuantity(tt.quantity, tt.unit); got != tt.expected {
+			t.Errorf("%v %s: ожидалось %v, получено %v", tt.quantity, tt.unit, tt.expected, got)
+		}
+	}
+}
+
+func TestScaleRecipe(t *testing.T) {
+	recipe := &models.Recipe{
+		ID:             1,
+		Name:           "Сырники",
+		Servings:       4,
+		Calories:       1200,
+		Proteins:       80,
+		Fats:           40,
+		Carbs:          120,
+		Micronutrients: models.Micronutrients{"calcium": 800},
+		Ingredients: models.Ingredients{
+			{Name: "Творог", Quantity: 500, Unit: "г"},
+			{Name: "Яйца", Quantity: 2, Unit: "шт"},
+			{Name: "Соль", Quantity: 0.25, Unit: "ч.л."},
+		},
+	}
+
+	// 2 взрослых и ребенок - 2.7 порции
+	scaled := peopleScale(2, 1).scaleRecipe(recipe)
+	if scaled.ScaledServings != 2.7 || scaled.Multiplier != 0.675 || scaled.Servings != 4 {
+		t.Errorf("Неверные порции: %v, %v, %d", scaled.ScaledServings, scaled.Multiplier, scaled.Servings)
+	}
+	if scaled.Calories != 810 || scaled.Proteins != 54 || scaled.Micronutrients["calcium"] != 540 {
+		t.Errorf("Неверное КБЖУ: %d ккал, %v г белка, %v мг кальция", scaled.Calories, scaled.Proteins, scaled.Micronutrients["calcium"])
+	}
+	if scaled.PerServing.Calories != 300 || scaled.PerServing.Proteins != 20 {
+		t.Errorf("Неверное КБЖУ порции: %+v", scaled.PerServing)
+	}
+
+	expected := []float64{340, 1, 0.25}
+	for i, ing := range scaled.Ingredients {
+		if ing.Quantity != expected[i] {
+			t.Errorf("%s: ожидалось %v, получено %v", ing.Name, expected[i], ing.Quantity)
+		}
+	}
+	if recipe.Ingredients[0].Quantity != 500 || recipe.Micronutrients["calcium"] != 800 {
+		t.Error("Исходный рецепт не должен меняться")
+	}
+
+	// Линейный пересчет для сумм по меню не округляется
+	if linear := peopleScale(2, 1).ingredients(recipe); linear[1].Quantity != 1.35 {
+		t.Errorf("Ожидалось 1.35 яйца, получено %v", linear[1].Quantity)
+	}
+	if peopleScale(0, 0) != 1 || recipeScale(3).multiplier(0) != 3 {
+		t.Error("По умолчанию 1 порция, рецепт без порций - на одну порцию")
+	}
+}
+
+func TestRecipeService_GetScaledRejectsInvalidServings(t *testing.T) {
+	// Проверка выполняется до обращения к базе
+	service := &RecipeService{}
+	for _, servings := range []float64{math.NaN(), math.Inf(1), -2, MaxScaledServings + 1} {
+		if _, err := service.GetScaled(context.Background(), 1, servings, 0, 0); !errors.Is(err, ErrInvalidServings) {
+			t.Errorf("%v порций: ожидалась ErrInvalidServings, получено %v", servings, err)
+		}
+	}
+}
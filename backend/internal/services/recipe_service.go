@@ -1,26 +1,101 @@
 package services
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
 	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/internal/repositories"
 )
 
+// ErrInvalidServings - неверное количество порций или человек для пересчета рецепта
+var ErrInvalidServings = errors.New("неверное количество порций")
+
 type RecipeService struct {
 	recipeRepo *repositories.RecipeRepository
+	tagRepo    *repositories.DietaryTagRepository
 }
 
-func NewRecipeService(recipeRepo *repositories.RecipeRepository) *RecipeService {
+func NewRecipeService(recipeRepo *repositories.RecipeRepository, tagRepo *repositories.DietaryTagRepository) *RecipeService {
 	return &RecipeService{
 		recipeRepo: recipeRepo,
+		tagRepo:    tagRepo,
 	}
 }
 
-func (s *RecipeService) GetAll() ([]models.Recipe, error) {
-	return s.recipeRepo.GetAll()
+func (s *RecipeService) GetAll(ctx context.Context, filter *models.RecipeFilter) ([]models.Recipe, error) {
+	return s.recipeRepo.GetCatalog(ctx, filter)
 }
 
-func (s *RecipeService) GetByID(id int) (*models.Recipe, error) {
-	return s.recipeRepo.GetByID(id)
+// GetByID возвращает рецепт общего каталога (личные рецепты не показываются)
+func (s *RecipeService) GetByID(ctx context.Context, id int) (*models.Recipe, error) {
+	recipe, err := s.recipeRepo.GetByID(ctx, id)
+	if err != nil || recipe == nil || recipe.OwnerID != nil {
+		return nil, err
+	}
+	return recipe, nil
 }
 
+// GetScaled возвращает рецепт общего каталога, пересчитанный на servings порций
+// (если не задано - на adults взрослых и children детей) с округлением ингредиентов
+// до кухонных мер; nil, если рецепт не найден. Неверные порции - ErrInvalidServings
+func (s *RecipeService) GetScaled(ctx context.Context, id int, servings float64, adults, children int) (*models.ScaledRecipe, error) {
+	if math.IsNaN(servings) || math.IsInf(servings, 0) {
+		return nil, fmt.Errorf("%w: ожидается конечное число", ErrInvalidServings)
+	}
+	if servings < 0 || adults < 0 || children < 0 {
+		return nil, fmt.Errorf("%w: количество порций и человек не может быть отрицательным", ErrInvalidServings)
+	}
+	scale := recipeScale(servings)
+	if servings == 0 {
+		scale = peopleScale(adults, children)
+	}
+	if scale > MaxScaledServings {
+		return nil, fmt.Errorf("%w: должно быть не больше %d", ErrInvalidServings, MaxScaledServings)
+	}
 
+	recipe, err := s.GetByID(ctx, id)
+	if err != nil || recipe == nil {
+		return nil, err
+	}
+	return scale.scaleRecipe(recipe), nil
+}
+
+// GetSimilar возвращает до limit рецептов общего каталога, похожих на рецепт id
+// (sql.ErrNoRows, если рецепт не найден)
+func (s *RecipeService) GetSimilar(ctx context.Context, id, limit int) ([]models.SimilarRecipe, error) {
+	recipe, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if recipe == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	catalog, err := s.recipeRepo.GetCatalog(ctx, &models.RecipeFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return similarRecipes(recipe, catalog, limit), nil
+}
+
+// GetDietaryTags возвращает справочник диет и аллергенов
+func (s *RecipeService) GetDietaryTags(ctx context.Context) ([]models.DietaryTag, error) {
+	return s.tagRepo.GetAll(ctx)
+}
+
+// GetSubstitutions возвращает замены ингредиента на указанное количество без аллергенов из allergies
+func (s *RecipeService) GetSubstitutions(ctx context.Context, ingredient models.Ingredient, allergies []string) ([]models.SubstituteOption, error) {
+	index, err := loadSubstitutionIndex(ctx, s.recipeRepo, s.tagRepo)
+	if err != nil {
+		return nil, err
+	}
+	options := index.Options(ingredient, allergies, nil)
+	if options == nil {
+		options = []models.SubstituteOption{}
+	}
+	return options, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+// Веса составляющих сходства рецептов
+const (
+	similarityIngredientWeight = 0.5
+	similarityMacroWeight      = 0.35
+	similarityMealTypeWeight   = 0.15
+)
+
+// similarityIgnoredIngredients - приправы и основы, которые есть почти в каждом рецепте
+// и не делают блюда похожими
+var similarityIgnoredIngredients = map[string]bool{
+	"соль":               true,
+	"вода":               true,
+	"перец":              true,
+	"черный перец":       true,
+	"молотый перец":      true,
+	"растительное масло": true,
+	"оливковое масло":    true,
+}
+
+// canonicalIngredient приводит название ингредиента к виду для сравнения рецептов
+func canonicalIngredient(name string) string {
+	return strings.ReplaceAll(normalizeIngredientName(name), "ё", "е")
+}
+
+// canonicalIngredients возвращает множество ингредиентов рецепта без приправ
+func canonicalIngredients(recipe *models.Recipe) map[string]string {
+	result := make(map[string]string, len(recipe.Ingredients))
+	for _, ing := range recipe.Ingredients {
+		key := canonicalIngredient(ing.Name)
+		if key == "" || similarityIgnoredIngredients[key] {
+			continue
+		}
+		if _, found := result[key]; !found {
+			result[key] = ing.Name
+		}
+	}
+	return result
+}
+
+// macroProfile - доли белков, жиров и углеводов в калорийности рецепта
+// (false, если БЖУ не указаны)
+func macroProfile(recipe *models.Recipe) ([3]float64, bool) {
+	energy := recipe.Proteins*4 + recipe.Fats*9 + recipe.Carbs*4
+	if energy <= 0 {
+		return [3]float64{}, false
+	}
+	return [3]float64{recipe.Proteins * 4 / energy, recipe.Fats * 9 / energy, recipe.Carbs * 4 / energy}, true
+}
+
+// recipeSimilarity оценивает сходство рецептов: пересечение ингредиентов (Жаккар),
+// близость профиля БЖУ (доли в калорийности не зависят от порций) и тип приема пищи
+func recipeSimilarity(a, b *models.Recipe) models.RecipeSimilarity {
+	similarity := models.RecipeSimilarity{
+		SameMealType:      a.MealType != "" && a.MealType == b.MealType,
+		SharedIngredients: []string{},
+	}
+
+	ingredientsA, ingredientsB := canonicalIngredients(a), canonicalIngredients(b)
+	union := len(ingredientsA)
+	for key := range ingredientsB {
+		if name, found := ingredientsA[key]; found {
+			similarity.SharedIngredients = append(similarity.SharedIngredients, name)
+		} else {
+			union++
+		}
+	}
+	sort.Strings(similarity.SharedIngredients)
+	if union > 0 {
+		similarity.IngredientOverlap = float64(len(similarity.SharedIngredients)) / float64(union)
+	}
+
+	// Наибольшее расстояние между профилями (вся энергия из разных макронутриентов) - √2
+	profileA, okA := macroProfile(a)
+	profileB, okB := macroProfile(b)
+	if okA && okB {
+		var distance float64
+		for i := range profileA {
+			distance += (profileA[i] - profileB[i]) * (profileA[i] - profileB[i])
+		}
+		similarity.MacroSimilarity = 1 - math.Sqrt(distance)/math.Sqrt2
+	}
+
+	similarity.Score = similarityIngredientWeight*similarity.IngredientOverlap +
+		similarityMacroWeight*similarity.MacroSimilarity
+	if similarity.SameMealType {
+		similarity.Score += similarityMealTypeWeight
+	}
+
+	similarity.IngredientOverlap = math.Round(similarity.IngredientOverlap*1000) / 1000
+	similarity.MacroSimilarity = math.Round(similarity.MacroSimilarity*1000) / 1000
+	similarity.Score = math.Round(similarity.Score*1000) / 1000
+	return similarity
+}
+
+// similarRecipes ранжирует рецепты по сходству с recipe (сам рецепт исключается)
+func similarRecipes(recipe *models.Recipe, candidates []models.Recipe, limit int) []models.SimilarRecipe {
+	result := []models.SimilarRecipe{}
+	for i := range candidates {
+		if candidates[i].ID == recipe.ID {
+			continue
+		}
+		result = append(result, models.SimilarRecipe{
+			Recipe:     candidates[i],
+			Similarity: recipeSimilarity(recipe, &candidates[i]),
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Similarity.Score != result[j].Similarity.Score {
+			return result[i].Similarity.Score > result[j].Similarity.Score
+		}
+		return result[i].ID < result[j].ID
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
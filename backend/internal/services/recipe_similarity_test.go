@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func similarityTestRecipes() []models.Recipe {
+	ingredients := func(names ...string) models.Ingredients {
+		result := models.Ingredients{}
+		for _, name := range names {
+			result = append(result, models.Ingredient{Name: name, Quantity: 100, Unit: "г"})
+		}
+		return result
+	}
+	return []models.Recipe{
+		{ID: 1, Name: "Омлет со шпинатом", MealType: "breakfast", Calories: 400, Proteins: 30, Fats: 28, Carbs: 6,
+			Ingredients: ingredients("Яйца", "Шпинат", "Молоко", "Соль")},
+		{ID: 2, Name: "Фриттата", MealType: "breakfast", Calories: 420, Proteins: 32, Fats: 29, Carbs: 8,
+			Ingredients: ingredients("яйца", "Шпинат", "Сыр", "Соль")},
+		{ID: 3, Name: "Овсянка", MealType: "breakfast", Calories: 380, Proteins: 12, Fats: 8, Carbs: 64,
+			Ingredients: ingredients("Овсянка", "Молоко", "Мёд", "Соль")},
+		{ID: 4, Name: "Шакшука", MealType: "dinner", Calories: 410, Proteins: 28, Fats: 26, Carbs: 14,
+			Ingredients: ingredients("Яйца", "Помидоры", "Шпинат")},
+	}
+}
+
+func TestRecipeSimilarity(t *testing.T) {
+	recipes := similarityTestRecipes()
+
+	similarity := recipeSimilarity(&recipes[0], &recipes[1])
+	// Общие яйца и шпинат (соль не учитывается) из 4 разных ингредиентов
+	if similarity.IngredientOverlap != 0.5 || len(similarity.SharedIngredients) != 2 || !similarity.SameMealType {
+		t.Errorf("Неверное сходство ингредиентов: %+v", similarity)
+	}
+	if similarity.MacroSimilarity < 0.95 {
+		t.Errorf("Профили БЖУ почти совпадают, получено %v", similarity.MacroSimilarity)
+	}
+
+	similar := similarRecipes(&recipes[0], recipes, 2)
+	if len(similar) != 2 || similar[0].ID != 2 || similar[1].ID != 4 {
+		t.Errorf("Ожидались фриттата и шакшука, получено %+v", similar)
+	}
+	if same := recipeSimilarity(&recipes[0], &recipes[0]); same.Score != 1 {
+		t.Errorf("Рецепт полностью похож на себя, получено %v", same.Score)
+	}
+}
+
+func TestMenuOptimizer_FindAlternativePrefersSimilar(t *testing.T) {
+	recipes := similarityTestRecipes()
+	current := &models.Recipe{ID: 5, Name: "Сырники", MealType: "breakfast", Calories: 400, Proteins: 20, Fats: 20, Carbs: 35,
+		Ingredients: models.Ingredients{{Name: "Творог"}, {Name: "Яйца"}, {Name: "Шпинат"}}}
+	recipes = append(recipes, models.Recipe{ID: 6, Name: "Протеиновый коктейль", MealType: "breakfast", Calories: 400,
+		Proteins: 31, Fats: 6, Carbs: 40, Ingredients: models.Ingredients{{Name: "Протеин"}, {Name: "Банан"}}})
+
+	// Нужно больше белка: фриттата дает меньше белка, чем коктейль, но намного похожее блюдо
+	optimizer := NewMenuOptimizer()
+	alternative := optimizer.findAlternative(recipes, "breakfast", nil, 0, -0.2, 0, 0, current, nil)
+	if alternative == nil || alternative.ID != 2 {
+		t.Errorf("Ожидалась фриттата, получено %+v", alternative)
+	}
+
+	// Если ни один рецепт не улучшает баланс, замены нет
+	if alternative := optimizer.findAlternative(recipes, "breakfast", nil, 0, -0.2, 0, 0, &recipes[1], nil); alternative != nil {
+		t.Errorf("Замена не улучшает баланс, получено %d", alternative.ID)
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+// normalizeRecipeSteps проверяет шаги рецепта и приводит их к каноническому виду:
+// оборудование - в нижнем регистре без повторов, ингредиенты шага - названиями из
+// списка ингредиентов рецепта
+func normalizeRecipeSteps(steps []models.RecipeStep, ingredients []models.IngredientImport) ([]models.RecipeStep, error) {
+	names := make(map[string]string, len(ingredients))
+	for _, ingredient := range ingredients {
+		names[strings.ToLower(strings.TrimSpace(ingredient.Name))] = ingredient.Name
+	}
+
+	normalized := make([]models.RecipeStep, 0, len(steps))
+	for i, step := range steps {
+		step.Text = strings.TrimSpace(step.Text)
+		if step.Text == "" {
+			return nil, fmt.Errorf("шаг %d: не указан текст", i+1)
+		}
+		if step.Duration < 0 {
+			return nil, fmt.Errorf("шаг %d: длительность не может быть отрицательной", i+1)
+		}
+		if step.Passive && step.Duration == 0 {
+			return nil, fmt.Errorf("шаг %d: для пассивного шага нужна длительность", i+1)
+		}
+
+		stepIngredients := make([]string, 0, len(step.Ingredients))
+		for _, name := range step.Ingredients {
+			canonical, ok := names[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return nil, fmt.Errorf("шаг %d: ингредиент '%s' не указан в рецепте", i+1, name)
+			}
+			stepIngredients = append(stepIngredients, canonical)
+		}
+		step.Ingredients = stepIngredients
+		var equipment []string
+		for _, item := range splitTags(step.Equipment...) {
+			if !slices.Contains(equipment, item) {
+				equipment = append(equipment, item)
+			}
+		}
+		step.Equipment = equipment
+		normalized = append(normalized, step)
+	}
+	return normalized, nil
+}
+
+// stepTimes возвращает сумму длительностей шагов и время активной работы (шаги
+// выполняются последовательно)
+func stepTimes(steps models.RecipeSteps) (total, active int) {
+	for _, step := range steps {
+		total += step.Duration
+		if !step.Passive {
+			active += step.Duration
+		}
+	}
+	return total, active
+}
+
+// stepInstructions возвращает тексты шагов - для клиентов, читающих instructions
+func stepInstructions(steps models.RecipeSteps) []string {
+	instructions := make([]string, 0, len(steps))
+	for _, step := range steps {
+		instructions = append(instructions, step.Text)
+	}
+	return instructions
+}
+
+// dayCookingTime оценивает время приготовления блюд дня с параллельной готовкой:
+// активную работу повар выполняет по очереди, а пассивные шаги (духовка,
+// маринование) одного блюда идут во время работы над другими. Время не меньше
+// суммы активной работы и не меньше самого долгого блюда. Для рецептов без
+// шагов все время считается активным, и оценка совпадает с суммой cooking_time.
+func dayCookingTime(recipes ...*models.Recipe) int {
+	var estimate cookingTimeEstimate
+	for _, recipe := range recipes {
+		if recipe != nil {
+			estimate.add(recipe.CookingTime, recipe.HandsOnTime())
+		}
+	}
+	return estimate.minutes()
+}
+
+// dayMenuCookingTime - dayCookingTime для блюд дня недельного меню
+func dayMenuCookingTime(day *models.WeeklyDayMenu) int {
+	var estimate cookingTimeEstimate
+	for _, recipe := range []*models.RecipeDTO{day.Breakfast, day.Lunch, day.Dinner} {
+		if recipe == nil {
+			continue
+		}
+		handsOn := recipe.CookingTime
+		if recipe.ActiveTime > 0 {
+			handsOn = recipe.ActiveTime
+		}
+		estimate.add(recipe.CookingTime, handsOn)
+	}
+	return estimate.minutes()
+}
+
+type cookingTimeEstimate struct {
+	handsOn int
+	longest int
+}
+
+func (e *cookingTimeEstimate) add(total, handsOn int) {
+	e.handsOn += handsOn
+	e.longest = max(e.longest, total)
+}
+
+func (e cookingTimeEstimate) minutes() int {
+	return max(e.handsOn, e.longest)
+}
+
+// fitRecipesToTime проверяет время приготовления блюд дня и при превышении maxTime
+// пробует заменить одно из них (начиная с самого трудоемкого) кандидатом того же
+// приема пищи; accept дополнительно проверяет новый набор блюд. Если уложиться не
+// удалось, возвращает исходные блюда.
+func fitRecipesToTime(
+	recipes []*models.Recipe,
+	candidates [][]ScoredRecipe,
+	maxTime int,
+	excludedIDs map[int]bool,
+	accept func(day []*models.Recipe) bool,
+) []*models.Recipe {
+	if maxTime <= 0 || dayCookingTime(recipes...) <= maxTime {
+		return recipes
+	}
+
+	order := make([]int, 0, len(recipes))
+	for i := range recipes {
+		if i < len(candidates) {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return recipes[order[a]].HandsOnTime() > recipes[order[b]].HandsOnTime()
+	})
+
+	for _, i := range order {
+		for j := range candidates[i] {
+			candidate := &candidates[i][j].Recipe
+			if candidate.ID == recipes[i].ID || excludedIDs[candidate.ID] {
+				continue
+			}
+			day := append([]*models.Recipe{}, recipes...)
+			day[i] = candidate
+			if dayCookingTime(day...) <= maxTime && (accept == nil || accept(day)) {
+				return day
+			}
+		}
+	}
+	return recipes
+}
+
+// dayConstraintViolations описывает нарушенные ограничения дня меню: время
+// приготовления и недоступное в этот день оборудование
+func dayConstraintViolations(day *models.WeeklyDayMenu, maxTotalTime int, unavailable []string) []string {
+	var violations []string
+	if maxTotalTime > 0 && day.TotalTime > maxTotalTime {
+		violations = append(violations, fmt.Sprintf("время приготовления %d мин. больше лимита %d мин.", day.TotalTime, maxTotalTime))
+	}
+	for _, meal := range []struct {
+		recipe *models.RecipeDTO
+		name   string
+	}{{day.Breakfast, "завтрак"}, {day.Lunch, "обед"}, {day.Dinner, "ужин"}} {
+		if meal.recipe == nil {
+			continue
+		}
+		for _, item := range meal.recipe.Equipment {
+			if slices.Contains(unavailable, item) {
+				violations = append(violations, fmt.Sprintf("%s: оборудование %s недоступно в этот день", meal.name, item))
+			}
+		}
+	}
+	return violations
+}
+
+// withoutEquipment оставляет рецепты, которым не нужно перечисленное оборудование
+func withoutEquipment(recipes []ScoredRecipe, equipment []string) []ScoredRecipe {
+	if len(equipment) == 0 {
+		return recipes
+	}
+	filtered := make([]ScoredRecipe, 0, len(recipes))
+	for _, recipe := range recipes {
+		if !usesEquipment(&recipe.Recipe, equipment) {
+			filtered = append(filtered, recipe)
+		}
+	}
+	return filtered
+}
+
+// unavailableEquipment возвращает оборудование, недоступное в этот день: ограничение
+// weekday_unavailable_equipment действует с понедельника по пятницу
+func unavailableEquipment(date time.Time, weekdayUnavailable []string) []string {
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return nil
+	}
+	return weekdayUnavailable
+}
+
+// usesEquipment проверяет, нужно ли рецепту что-то из перечисленного оборудования
+func usesEquipment(recipe *models.Recipe, equipment []string) bool {
+	for _, required := range recipe.Equipment {
+		if slices.Contains(equipment, required) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestRecipeSteps_DtoToRecipe(t *testing.T) {
+	service := &AdminRecipeService{}
+	dto := &models.RecipeImportDTO{
+		Title:       "Запеканка",
+		Tags:        []string{"dinner"},
+		Ingredients: []models.IngredientImport{{Name: "Творог", Amount: 500, Unit: "г"}, {Name: "Яйца", Amount: 2, Unit: "шт"}},
+		Calories:    900,
+		Steps: []models.RecipeStep{
+			{Text: " Смешать творог с яйцами ", Duration: 10, Ingredients: []string{"творог", "ЯЙЦА"}, Equipment: []string{"Blender"}},
+			{Text: "Запекать", Duration: 40, Passive: true, Equipment: []string{"oven", "OVEN"}},
+		},
+	}
+	if err := service.validateRecipeDTO(dto, testTaxonomy()); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+
+	recipe := service.dtoToRecipe(dto, testTaxonomy())
+	if recipe.CookingTime != 50 || recipe.ActiveTime != 10 || recipe.HandsOnTime() != 10 {
+		t.Errorf("Ожидалось 50 мин. всего и 10 активных, получено %d и %d", recipe.CookingTime, recipe.ActiveTime)
+	}
+	if len(recipe.Instructions) != 2 || recipe.Instructions[0] != "Смешать творог с яйцами" {
+		t.Errorf("Instructions должны содержать тексты шагов: %v", recipe.Instructions)
+	}
+	if strings.Join(recipe.Steps[0].Ingredients, ",") != "Творог,Яйца" || strings.Join(recipe.Equipment, ",") != "blender,oven" {
+		t.Errorf("Неверные ингредиенты или оборудование: %+v, %v", recipe.Steps, recipe.Equipment)
+	}
+
+	for _, steps := range [][]models.RecipeStep{
+		{{Text: ""}},
+		{{Text: "Жарить", Duration: -5}},
+		{{Text: "Остудить", Passive: true}},
+		{{Text: "Добавить сахар", Ingredients: []string{"Сахар"}}},
+	} {
+		invalid := *dto
+		invalid.Steps = steps
+		if err := service.validateRecipeDTO(&invalid, testTaxonomy()); err == nil {
+			t.Errorf("Ожидалась ошибка для шагов %+v", steps)
+		}
+	}
+}
+
+func TestDayCookingTime(t *testing.T) {
+	casserole := &models.Recipe{ID: 1, CookingTime: 60, ActiveTime: 15}
+	salad := &models.Recipe{ID: 2, CookingTime: 20}
+	omelet := &models.Recipe{ID: 3, CookingTime: 15}
+
+	// Пока запеканка в духовке, готовятся салат и омлет
+	if got := dayCookingTime(casserole, salad, omelet); got != 60 {
+		t.Errorf("Ожидалось 60 мин., получено %d", got)
+	}
+	// Без пассивных шагов - сумма
+	if got := dayCookingTime(salad, omelet); got != 35 {
+		t.Errorf("Ожидалось 35 мин., получено %d", got)
+	}
+}
+
+func TestFitRecipesToTime(t *testing.T) {
+	breakfast := &models.Recipe{ID: 1, CookingTime: 30}
+	lunch := &models.Recipe{ID: 2, CookingTime: 60}
+	candidates := [][]ScoredRecipe{
+		{{Recipe: *breakfast}},
+		{{Recipe: models.Recipe{ID: 3, CookingTime: 50}}, {Recipe: models.Recipe{ID: 4, CookingTime: 70, ActiveTime: 10}}},
+	}
+
+	// Более долгий, но в основном пассивный обед укладывается в лимит, а быстрый активный - нет
+	day := fitRecipesToTime([]*models.Recipe{breakfast, lunch}, candidates, 70, nil, nil)
+	if day[1].ID != 4 || dayCookingTime(day...) != 70 {
+		t.Errorf("Ожидалась замена обеда на рецепт 4, получено %d", day[1].ID)
+	}
+	day = fitRecipesToTime([]*models.Recipe{breakfast, lunch}, candidates, 70, map[int]bool{4: true}, nil)
+	if day[1] != lunch {
+		t.Errorf("Исключенный рецепт не должен подставляться, получено %d", day[1].ID)
+	}
+	day = fitRecipesToTime([]*models.Recipe{breakfast, lunch}, candidates, 20, nil, nil)
+	if day[0] != breakfast || day[1] != lunch {
+		t.Error("Если уложиться нельзя, блюда не меняются")
+	}
+}
+
+func TestDayConstraintViolations(t *testing.T) {
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	if len(unavailableEquipment(monday, []string{"oven"})) != 1 || unavailableEquipment(monday.AddDate(0, 0, 5), []string{"oven"}) != nil {
+		t.Error("Оборудование недоступно только в будни")
+	}
+
+	day := &models.WeeklyDayMenu{
+		Dinner:    &models.RecipeDTO{Name: "Запеканка", CookingTime: 60, ActiveTime: 15, Equipment: []string{"oven"}},
+		TotalTime: 60,
+	}
+	violations := dayConstraintViolations(day, 45, []string{"oven"})
+	if len(violations) != 2 || !strings.Contains(violations[1], "oven") {
+		t.Errorf("Ожидались нарушения времени и оборудования, получено %v", violations)
+	}
+	if dayMenuCookingTime(day) != 60 {
+		t.Errorf("Ожидалось 60 мин., получено %d", dayMenuCookingTime(day))
+	}
+}
@@ -0,0 +1,462 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+// Импорт и экспорт рецептов в таблицах (CSV, XLSX): одна строка - один рецепт.
+// Ингредиенты - в столбце ingredients ("Мука: 200 г; Яйцо: 2 шт") или в отдельной
+// таблице (лист ingredients книги XLSX или второй файл CSV) со столбцами
+// recipe, name, amount, unit.
+
+// Форматы таблиц
+const (
+	TableFormatCSV  = "csv"
+	TableFormatXLSX = "xlsx"
+)
+
+// Столбцы таблицы рецептов (кроме микронутриентов - их столбцы называются по ключам
+// models.MicronutrientUnits, для импорта также salt)
+var recipeTableColumns = []string{
+	"title", "description", "tags", "ingredients", "calories", "proteins", "fats", "carbs",
+	"cooking_time", "servings", "instructions",
+}
+
+var ingredientTableColumns = []string{"recipe", "name", "amount", "unit"}
+
+// ingredientsSheetName - лист книги XLSX с ингредиентами
+const ingredientsSheetName = "ingredients"
+
+// RecipeTable - рецепты, прочитанные из таблицы
+type RecipeTable struct {
+	Recipes []models.RecipeImportDTO
+	Lines   []int    // номер строки каждого рецепта
+	Errors  []string // строки, которые не удалось разобрать
+	Failed  int      // рецепты, пропущенные из-за ошибок разбора
+}
+
+// ParseRecipeTable читает рецепты из CSV или XLSX. ingredients - необязательная
+// отдельная таблица ингредиентов (для XLSX можно использовать лист ingredients).
+// Ошибки отдельных строк попадают в RecipeTable.Errors, ошибка возвращается,
+// если не удалось прочитать файл или в таблице нет столбца title.
+func ParseRecipeTable(format string, recipes, ingredients []byte) (*RecipeTable, error) {
+	var recipeRows, ingredientRows []tableRow
+	switch format {
+	case TableFormatCSV:
+		rows, err := readCSVTable(recipes)
+		if err != nil {
+			return nil, err
+		}
+		recipeRows = rows
+		if len(ingredients) > 0 {
+			if ingredientRows, err = readCSVTable(ingredients); err != nil {
+				return nil, fmt.Errorf("таблица ингредиентов: %w", err)
+			}
+		}
+	case TableFormatXLSX:
+		sheets, err := readXLSX(recipes)
+		if err != nil {
+			return nil, err
+		}
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("в книге нет листов")
+		}
+		recipeRows = sheets[0].Rows
+		for _, sheet := range sheets[1:] {
+			if strings.EqualFold(sheet.Name, ingredientsSheetName) {
+				ingredientRows = sheet.Rows
+			}
+		}
+		if len(ingredients) > 0 {
+			sheets, err := readXLSX(ingredients)
+			if err != nil {
+				return nil, fmt.Errorf("таблица ингредиентов: %w", err)
+			}
+			if len(sheets) > 0 {
+				ingredientRows = sheets[0].Rows
+			}
+		}
+	default:
+		return nil, fmt.Errorf("неподдерживаемый формат таблицы: %s", format)
+	}
+	return parseRecipeRows(recipeRows, ingredientRows)
+}
+
+// readCSVTable читает CSV; разделитель (запятая, точка с запятой или табуляция)
+// определяется по строке заголовков
+func readCSVTable(data []byte) ([]tableRow, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	header := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		header = data[:i]
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.Comma = ','
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	if bytes.Count(header, []byte("\t")) > bytes.Count(header, []byte(string(reader.Comma))) {
+		reader.Comma = '\t'
+	}
+
+	var rows []tableRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("неверный CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, tableRow{Line: line, Cells: record})
+	}
+	return rows, nil
+}
+
+// tableHeader возвращает номера столбцов по названиям заголовков (без учета регистра)
+func tableHeader(row tableRow) map[string]int {
+	columns := make(map[string]int, len(row.Cells))
+	for i, cell := range row.Cells {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		if _, ok := columns[name]; !ok && name != "" {
+			columns[name] = i
+		}
+	}
+	return columns
+}
+
+func tableCell(row tableRow, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(row.Cells) {
+		return ""
+	}
+	return strings.TrimSpace(row.Cells[i])
+}
+
+func isEmptyRow(row tableRow) bool {
+	for _, cell := range row.Cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTableNumber разбирает число из ячейки (допускается десятичная запятая)
+func parseTableNumber(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.ParseFloat(strings.Replace(strings.ReplaceAll(value, " ", ""), ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("неверное число '%s'", value)
+	}
+	return number, nil
+}
+
+func parseRecipeRows(recipeRows, ingredientRows []tableRow) (*RecipeTable, error) {
+	table := &RecipeTable{Errors: []string{}}
+	if len(recipeRows) == 0 {
+		return table, nil
+	}
+	columns := tableHeader(recipeRows[0])
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("в таблице рецептов нет столбца title")
+	}
+
+	byTitle := make(map[string]int)
+	for _, row := range recipeRows[1:] {
+		if isEmptyRow(row) {
+			continue
+		}
+		dto, err := parseRecipeRow(row, columns)
+		if err != nil {
+			table.Errors = append(table.Errors, fmt.Sprintf("Строка %d (%s): %v", row.Line, dto.Title, err))
+			table.Failed++
+			continue
+		}
+		byTitle[strings.ToLower(dto.Title)] = len(table.Recipes)
+		table.Recipes = append(table.Recipes, dto)
+		table.Lines = append(table.Lines, row.Line)
+	}
+
+	if len(ingredientRows) == 0 {
+		return table, nil
+	}
+	columns = tableHeader(ingredientRows[0])
+	for _, name := range []string{"recipe", "name"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("в таблице ингредиентов нет столбца %s", name)
+		}
+	}
+	// Рецепт с неверной строкой ингредиента не импортируется
+	invalid := make(map[int]bool)
+	for _, row := range ingredientRows[1:] {
+		if isEmptyRow(row) {
+			continue
+		}
+		title := tableCell(row, columns, "recipe")
+		i, ok := byTitle[strings.ToLower(title)]
+		if !ok {
+			table.Errors = append(table.Errors, fmt.Sprintf("Ингредиенты, строка %d: рецепт '%s' не найден в таблице рецептов", row.Line, title))
+			continue
+		}
+		amount, err := parseTableNumber(tableCell(row, columns, "amount"))
+		if err != nil {
+			table.Errors = append(table.Errors, fmt.Sprintf("Ингредиенты, строка %d (%s): amount: %v", row.Line, title, err))
+			invalid[i] = true
+			continue
+		}
+		table.Recipes[i].Ingredients = append(table.Recipes[i].Ingredients, models.IngredientImport{
+			Name:   tableCell(row, columns, "name"),
+			Amount: amount,
+			Unit:   tableCell(row, columns, "unit"),
+		})
+	}
+
+	if len(invalid) > 0 {
+		recipes, lines := table.Recipes[:0], table.Lines[:0]
+		for i := range table.Recipes {
+			if invalid[i] {
+				table.Failed++
+				continue
+			}
+			recipes = append(recipes, table.Recipes[i])
+			lines = append(lines, table.Lines[i])
+		}
+		table.Recipes, table.Lines = recipes, lines
+	}
+	return table, nil
+}
+
+// parseRecipeRow разбирает строку рецепта
+func parseRecipeRow(row tableRow, columns map[string]int) (models.RecipeImportDTO, error) {
+	dto := models.RecipeImportDTO{
+		Title:       tableCell(row, columns, "title"),
+		Description: tableCell(row, columns, "description"),
+	}
+	if dto.Title == "" {
+		return dto, fmt.Errorf("не указано название")
+	}
+	if tags := tableCell(row, columns, "tags"); tags != "" {
+		dto.Tags = splitTags(tags)
+	}
+	for _, line := range strings.Split(tableCell(row, columns, "instructions"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			dto.Instructions = append(dto.Instructions, line)
+		}
+	}
+
+	numbers := make(map[string]float64)
+	for _, name := range []string{"calories", "proteins", "fats", "carbs", "cooking_time", "servings"} {
+		value, err := parseTableNumber(tableCell(row, columns, name))
+		if err != nil {
+			return dto, fmt.Errorf("%s: %v", name, err)
+		}
+		numbers[name] = value
+	}
+	dto.Calories = int(numbers["calories"] + 0.5)
+	dto.Proteins = numbers["proteins"]
+	dto.Fats = numbers["fats"]
+	dto.Carbs = numbers["carbs"]
+	dto.CookingTime = int(numbers["cooking_time"])
+	dto.Servings = int(numbers["servings"])
+
+	for name := range columns {
+		if _, ok := models.MicronutrientUnits[name]; !ok && name != "salt" {
+			continue
+		}
+		value := tableCell(row, columns, name)
+		if value == "" {
+			continue
+		}
+		amount, err := parseTableNumber(value)
+		if err != nil {
+			return dto, fmt.Errorf("%s: %v", name, err)
+		}
+		if dto.Micronutrients == nil {
+			dto.Micronutrients = models.Micronutrients{}
+		}
+		dto.Micronutrients[name] = amount
+	}
+
+	ingredients, err := parseIngredientsCell(tableCell(row, columns, "ingredients"))
+	if err != nil {
+		return dto, fmt.Errorf("ingredients: %v", err)
+	}
+	dto.Ingredients = ingredients
+	return dto, nil
+}
+
+// parseIngredientsCell разбирает столбец ingredients: ингредиенты через ";",
+// каждый - "Мука: 200 г" или строкой рецепта ("200 г муки", "2 tbsp olive oil")
+func parseIngredientsCell(value string) ([]models.IngredientImport, error) {
+	var ingredients []models.IngredientImport
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		colon := strings.LastIndex(item, ":")
+		if colon < 0 {
+			ing, ok := parseIngredientLine(item)
+			if !ok {
+				return nil, fmt.Errorf("не удалось разобрать '%s'", item)
+			}
+			ingredients = append(ingredients, ing)
+			continue
+		}
+		name := strings.TrimSpace(item[:colon])
+		fields := strings.Fields(item[colon+1:])
+		if name == "" || len(fields) == 0 {
+			return nil, fmt.Errorf("не удалось разобрать '%s'", item)
+		}
+		amount, err := parseTableNumber(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("'%s': %v", item, err)
+		}
+		ingredients = append(ingredients, models.IngredientImport{
+			Name:   name,
+			Amount: amount,
+			Unit:   strings.Join(fields[1:], " "),
+		})
+	}
+	return ingredients, nil
+}
+
+// formatTableNumber записывает число без лишних нулей
+func formatTableNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// recipeTableRows формирует таблицу рецептов и, если separateIngredients,
+// отдельную таблицу ингредиентов (иначе ингредиенты - в столбце ingredients)
+func recipeTableRows(recipes []models.RecipeExportDTO, separateIngredients bool) ([][]string, [][]string) {
+	// Столбцы микронутриентов, которые есть хотя бы у одного рецепта
+	present := make(map[string]bool)
+	for _, recipe := range recipes {
+		for name := range recipe.Micronutrients {
+			present[name] = true
+		}
+	}
+	var nutrients []string
+	for name := range present {
+		nutrients = append(nutrients, name)
+	}
+	sort.Strings(nutrients)
+
+	columns := recipeTableColumns
+	if separateIngredients {
+		columns = make([]string, 0, len(recipeTableColumns)-1)
+		for _, column := range recipeTableColumns {
+			if column != "ingredients" {
+				columns = append(columns, column)
+			}
+		}
+	}
+	header := append(append([]string{}, columns...), nutrients...)
+	recipeRows := [][]string{header}
+	ingredientRows := [][]string{ingredientTableColumns}
+
+	for _, recipe := range recipes {
+		var ingredients []string
+		for _, ing := range recipe.Ingredients {
+			if separateIngredients {
+				ingredientRows = append(ingredientRows, []string{recipe.Title, ing.Name, formatTableNumber(ing.Amount), ing.Unit})
+			} else {
+				ingredients = append(ingredients, strings.TrimSpace(fmt.Sprintf("%s: %s %s", ing.Name, formatTableNumber(ing.Amount), ing.Unit)))
+			}
+		}
+		values := map[string]string{
+			"title":        recipe.Title,
+			"description":  recipe.Description,
+			"tags":         strings.Join(recipe.Tags, ", "),
+			"ingredients":  strings.Join(ingredients, "; "),
+			"calories":     strconv.Itoa(recipe.Calories),
+			"proteins":     formatTableNumber(recipe.Proteins),
+			"fats":         formatTableNumber(recipe.Fats),
+			"carbs":        formatTableNumber(recipe.Carbs),
+			"cooking_time": strconv.Itoa(recipe.CookingTime),
+			"servings":     strconv.Itoa(recipe.Servings),
+			"instructions": strings.Join(recipe.Instructions, "\n"),
+		}
+		row := make([]string, 0, len(header))
+		for _, column := range columns {
+			row = append(row, values[column])
+		}
+		for _, name := range nutrients {
+			if value, ok := recipe.Micronutrients[name]; ok {
+				row = append(row, formatTableNumber(value))
+			} else {
+				row = append(row, "")
+			}
+		}
+		recipeRows = append(recipeRows, row)
+	}
+	return recipeRows, ingredientRows
+}
+
+// ImportRecipeTable импортирует рецепты, прочитанные из таблицы. Ошибки
+// указываются с номерами строк.
+func (s *AdminRecipeService) ImportRecipeTable(ctx context.Context, table *RecipeTable, opts ImportOptions) (*ImportResult, error) {
+	labels := make([]string, len(table.Lines))
+	for i, line := range table.Lines {
+		labels[i] = fmt.Sprintf("Строка %d", line)
+	}
+	result, err := s.importRecipes(ctx, table.Recipes, labels, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.Failed += table.Failed
+	result.Errors = append(append([]string{}, table.Errors...), result.Errors...)
+	return result, nil
+}
+
+// ExportRecipeTable экспортирует рецепты в CSV (ингредиенты - в столбце ingredients)
+// или XLSX (листы recipes и ingredients)
+func (s *AdminRecipeService) ExportRecipeTable(ctx context.Context, format string, filter *models.RecipeFilter) ([]byte, error) {
+	export, err := s.ExportRecipes(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case TableFormatCSV:
+		recipeRows, _ := recipeTableRows(export.Recipes, false)
+		var buf bytes.Buffer
+		// BOM - чтобы Excel открыл UTF-8 без перекодировки
+		buf.WriteString("\xef\xbb\xbf")
+		writer := csv.NewWriter(&buf)
+		if err := writer.WriteAll(recipeRows); err != nil {
+			return nil, fmt.Errorf("ошибка при записи CSV: %w", err)
+		}
+		return buf.Bytes(), nil
+	case TableFormatXLSX:
+		recipeRows, ingredientRows := recipeTableRows(export.Recipes, true)
+		return writeXLSX([]xlsxSheet{
+			{Name: "recipes", Rows: toTableRows(recipeRows)},
+			{Name: ingredientsSheetName, Rows: toTableRows(ingredientRows)},
+		})
+	default:
+		return nil, fmt.Errorf("неподдерживаемый формат таблицы: %s", format)
+	}
+}
+
+func toTableRows(rows [][]string) []tableRow {
+	result := make([]tableRow, len(rows))
+	for i, cells := range rows {
+		result[i] = tableRow{Line: i + 1, Cells: cells}
+	}
+	return result
+}
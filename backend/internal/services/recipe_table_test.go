@@ -0,0 +1,138 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestParseRecipeTable_CSV(t *testing.T) {
+	csvData := "\xef\xbb\xbftitle;tags;ingredients;calories;proteins;servings;instructions;sodium\n" +
+		"Омлет;breakfast, vegetarian;\"Яйцо: 3 шт; Молоко: 50 мл\";300;18,5;1;\"Взбить яйца\nЖарить 5 минут\";400\n" +
+		";lunch;;100;;;;\n" +
+		"\n" +
+		"Суп;lunch;200 г картофеля;abc;;;;\n" +
+		"Салат;lunch;Огурец: 2 шт;120;;2;;\n"
+
+	table, err := ParseRecipeTable(TableFormatCSV, []byte(csvData), nil)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if len(table.Recipes) != 2 || table.Failed != 2 {
+		t.Fatalf("Ожидалось 2 рецепта и 2 ошибки, получено %d и %d: %v", len(table.Recipes), table.Failed, table.Errors)
+	}
+
+	omelet := table.Recipes[0]
+	if table.Lines[0] != 2 || omelet.Title != "Омлет" || omelet.Proteins != 18.5 || omelet.Servings != 1 {
+		t.Errorf("Неверно разобран рецепт: %+v (строка %d)", omelet, table.Lines[0])
+	}
+	if len(omelet.Ingredients) != 2 || omelet.Ingredients[1] != (models.IngredientImport{Name: "Молоко", Amount: 50, Unit: "мл"}) {
+		t.Errorf("Неверные ингредиенты: %+v", omelet.Ingredients)
+	}
+	if len(omelet.Tags) != 2 || len(omelet.Instructions) != 2 || omelet.Micronutrients["sodium"] != 400 {
+		t.Errorf("Неверные теги, шаги или микронутриенты: %+v", omelet)
+	}
+	// Многострочная ячейка сдвигает номера строк
+	if table.Lines[1] != 7 {
+		t.Errorf("Ожидалась строка 7 для салата, получено %d", table.Lines[1])
+	}
+
+	errors := strings.Join(table.Errors, "\n")
+	if !strings.Contains(errors, "Строка 4 (): не указано название") || !strings.Contains(errors, "Строка 6 (Суп): calories") {
+		t.Errorf("Ожидались ошибки строк 4 и 6, получено %v", table.Errors)
+	}
+
+	if _, err := ParseRecipeTable(TableFormatCSV, []byte("name,calories\nОмлет,300\n"), nil); err == nil {
+		t.Error("Ожидалась ошибка для таблицы без столбца title")
+	}
+}
+
+func TestParseRecipeTable_SeparateIngredients(t *testing.T) {
+	recipes := "title,calories\nОмлет,300\nСалат,120\n"
+	ingredients := "recipe,name,amount,unit\nОмлет,Яйцо,3,шт\nсалат,Огурец,много,шт\nБорщ,Свекла,1,шт\n"
+
+	table, err := ParseRecipeTable(TableFormatCSV, []byte(recipes), []byte(ingredients))
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	// Салат с неверным количеством не импортируется, неизвестный рецепт - только ошибка
+	if len(table.Recipes) != 1 || table.Failed != 1 || len(table.Errors) != 2 {
+		t.Fatalf("Ожидался 1 рецепт, 1 пропущенный и 2 ошибки, получено %+v", table)
+	}
+	if len(table.Recipes[0].Ingredients) != 1 || table.Recipes[0].Ingredients[0].Amount != 3 {
+		t.Errorf("Неверные ингредиенты омлета: %+v", table.Recipes[0].Ingredients)
+	}
+}
+
+func TestRecipeTable_XLSXRoundTrip(t *testing.T) {
+	exported := []models.RecipeExportDTO{{
+		Title:          "Омлет & сыр",
+		Tags:           []string{"breakfast", "dairy"},
+		Ingredients:    []models.IngredientImport{{Name: "Яйцо", Amount: 3, Unit: "шт"}, {Name: "Сыр", Amount: 30.5, Unit: "г"}},
+		Calories:       350,
+		Proteins:       22.4,
+		Micronutrients: models.Micronutrients{"sodium": 500},
+		Servings:       1,
+		Instructions:   []string{"Взбить", "Жарить <5 минут>"},
+	}}
+	recipeRows, ingredientRows := recipeTableRows(exported, true)
+	data, err := writeXLSX([]xlsxSheet{
+		{Name: "recipes", Rows: toTableRows(recipeRows)},
+		{Name: ingredientsSheetName, Rows: toTableRows(ingredientRows)},
+	})
+	if err != nil {
+		t.Fatalf("Ошибка записи XLSX: %v", err)
+	}
+
+	table, err := ParseRecipeTable(TableFormatXLSX, data, nil)
+	if err != nil {
+		t.Fatalf("Ошибка чтения XLSX: %v", err)
+	}
+	if len(table.Recipes) != 1 || len(table.Errors) != 0 {
+		t.Fatalf("Ожидался 1 рецепт без ошибок, получено %+v", table)
+	}
+	recipe := table.Recipes[0]
+	if recipe.Title != "Омлет & сыр" || recipe.Calories != 350 || recipe.Proteins != 22.4 || recipe.Micronutrients["sodium"] != 500 {
+		t.Errorf("Неверно прочитан рецепт: %+v", recipe)
+	}
+	if len(recipe.Ingredients) != 2 || recipe.Ingredients[1].Amount != 30.5 {
+		t.Errorf("Неверные ингредиенты: %+v", recipe.Ingredients)
+	}
+	if len(recipe.Instructions) != 2 || recipe.Instructions[1] != "Жарить <5 минут>" {
+		t.Errorf("Неверные шаги: %v", recipe.Instructions)
+	}
+}
+
+func TestReadXLSX_RejectsZipBomb(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zeros := make([]byte, 1<<20)
+	for i := 0; i <= xlsxMaxUncompressedSize>>20; i++ {
+		file.Write(zeros)
+	}
+	archive.Close()
+
+	// Несколько сотен килобайт архива распаковываются больше чем в 200 МБ
+	if _, err := readXLSX(buf.Bytes()); !errors.Is(err, errXLSXTooLarge) {
+		t.Errorf("Ожидалась ошибка размера книги, получено %v", err)
+	}
+}
+
+func TestXLSXColumns(t *testing.T) {
+	for index, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumnName(index); got != name {
+			t.Errorf("%d: ожидалось %s, получено %s", index, name, got)
+		}
+		if got := xlsxColumnIndex(name + "12"); got != index {
+			t.Errorf("%s: ожидалось %d, получено %d", name, index, got)
+		}
+	}
+}
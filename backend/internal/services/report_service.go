@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/repositories"
+)
+
+// maxReportDays - максимальная длина периода отчета
+const maxReportDays = 366
+
+// topRecipesLimit - сколько самых частых рецептов включать в отчет
+const topRecipesLimit = 10
+
+type ReportService struct {
+	menuRepo    *repositories.MenuRepository
+	foodLogRepo *repositories.FoodLogRepository
+	goalsRepo   *repositories.GoalsRepository
+}
+
+func NewReportService(
+	menuRepo *repositories.MenuRepository,
+	foodLogRepo *repositories.FoodLogRepository,
+	goalsRepo *repositories.GoalsRepository,
+) *ReportService {
+	return &ReportService{
+		menuRepo:    menuRepo,
+		foodLogRepo: foodLogRepo,
+		goalsRepo:   goalsRepo,
+	}
+}
+
+// NutritionReport строит отчет о питании за период [from, to]
+func (s *ReportService) NutritionReport(ctx context.Context, userID int, from, to time.Time) (*models.NutritionReport, error) {
+	from = truncateToDate(from)
+	to = truncateToDate(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("дата 'from' должна быть не позже 'to'")
+	}
+	if int(to.Sub(from).Hours()/24)+1 > maxReportDays {
+		return nil, fmt.Errorf("период отчета не может превышать %d дней", maxReportDays)
+	}
+
+	planned, err := s.menuRepo.GetPlannedMealsInRange(ctx, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении запланированных приемов пищи: %w", err)
+	}
+	entries, err := s.foodLogRepo.GetByUserIDAndDateRange(ctx, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении дневника питания: %w", err)
+	}
+	pantryUsed, err := s.menuRepo.GetPantryUsageInRange(ctx, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении использования кладовой: %w", err)
+	}
+	goals, err := s.goalsRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении целей пользователя: %w", err)
+	}
+
+	return buildNutritionReport(from, to, planned, entries, pantryUsed, goals), nil
+}
+
+// buildNutritionReport собирает отчет из запланированных приемов пищи, дневника и целей
+func buildNutritionReport(
+	from, to time.Time,
+	planned []models.PlannedMealDetail,
+	entries []models.FoodLogEntry,
+	pantryUsed models.Ingredients,
+	goals *models.UserGoals,
+) *models.NutritionReport {
+	report := &models.NutritionReport{
+		From:       from.Format(models.DateLayout),
+		To:         to.Format(models.DateLayout),
+		Days:       []models.NutritionReportDay{},
+		TopRecipes: []models.RecipeFrequency{},
+	}
+	if goals != nil {
+		report.DailyGoal = &models.NutritionTotals{
+			Calories: goals.DailyCalories,
+			Proteins: goals.TargetProteins,
+			Fats:     goals.TargetFats,
+			Carbs:    goals.TargetCarbs,
+		}
+	}
+
+	days := make(map[string]*models.NutritionReportDay)
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		key := d.Format(models.DateLayout)
+		report.Days = append(report.Days, models.NutritionReportDay{Date: key})
+	}
+	for i := range report.Days {
+		days[report.Days[i].Date] = &report.Days[i]
+	}
+
+	// План: КБЖУ на одну порцию - план рассчитан на одного человека, как и дневник
+	plannedByID := make(map[int]models.PlannedMealDetail)
+	plannedByKey := make(map[string]models.PlannedMealDetail) // дата + прием пищи
+	frequency := make(map[int]*models.RecipeFrequency)
+	totalCookingTime := 0
+	for _, meal := range planned {
+		plannedByID[meal.MenuMealID] = meal
+		plannedByKey[meal.Date+"/"+meal.MealType] = meal
+		totalCookingTime += meal.CookingTime
+
+		if day, ok := days[meal.Date]; ok {
+			day.PlannedMeals++
+			// Одна порция на человека
+			addNutrition(&day.Planned, recipeScale(1).nutrition(meal.Calories, meal.Proteins, meal.Fats, meal.Carbs, meal.Servings))
+		}
+
+		if f, ok := frequency[meal.RecipeID]; ok {
+			f.Count++
+		} else {
+			frequency[meal.RecipeID] = &models.RecipeFrequency{RecipeID: meal.RecipeID, Name: meal.RecipeName, Count: 1}
+		}
+	}
+
+	// Факт: запись соответствует плану, если отмечена "по плану"
+	// или это тот же рецепт в тот же прием пищи
+	adherent := make(map[int]bool) // menu_meal_id
+	for _, entry := range entries {
+		key := entry.Date.Format(models.DateLayout)
+		day, ok := days[key]
+		if !ok {
+			continue
+		}
+		day.Eaten.Calories += entry.Calories
+		day.Eaten.Proteins += entry.Proteins
+		day.Eaten.Fats += entry.Fats
+		day.Eaten.Carbs += entry.Carbs
+		report.Adherence.LoggedMeals++
+
+		var meal models.PlannedMealDetail
+		found := false
+		if entry.MenuMealID != nil {
+			meal, found = plannedByID[*entry.MenuMealID]
+		}
+		if !found && entry.RecipeID != nil {
+			candidate, ok := plannedByKey[key+"/"+entry.MealType]
+			found = ok && candidate.RecipeID == *entry.RecipeID
+			meal = candidate
+		}
+		if found && !adherent[meal.MenuMealID] {
+			adherent[meal.MenuMealID] = true
+			day.EatenAsPlanned++
+		}
+	}
+
+	for i := range report.Days {
+		day := &report.Days[i]
+		if goals != nil && goals.DailyCalories > 0 {
+			day.CaloriesPercent = math.Round(float64(day.Eaten.Calories)/float64(goals.DailyCalories)*1000) / 10
+		}
+		report.Totals.Calories += day.Eaten.Calories
+		report.Totals.Proteins += day.Eaten.Proteins
+		report.Totals.Fats += day.Eaten.Fats
+		report.Totals.Carbs += day.Eaten.Carbs
+		report.Adherence.PlannedMeals += day.PlannedMeals
+		report.Adherence.EatenAsPlanned += day.EatenAsPlanned
+		report.Adherence.PlannedCalories += day.Planned.Calories
+	}
+	report.Adherence.EatenCalories = report.Totals.Calories
+	if report.Adherence.PlannedMeals > 0 {
+		report.Adherence.AdherencePercent = math.Round(
+			float64(report.Adherence.EatenAsPlanned)/float64(report.Adherence.PlannedMeals)*1000) / 10
+	}
+
+	if len(planned) > 0 {
+		report.AverageCookingTime = math.Round(float64(totalCookingTime)/float64(len(planned))*10) / 10
+	}
+
+	for _, f := range frequency {
+		report.TopRecipes = append(report.TopRecipes, *f)
+	}
+	sort.Slice(report.TopRecipes, func(i, j int) bool {
+		if report.TopRecipes[i].Count != report.TopRecipes[j].Count {
+			return report.TopRecipes[i].Count > report.TopRecipes[j].Count
+		}
+		return report.TopRecipes[i].Name < report.TopRecipes[j].Name
+	})
+	if len(report.TopRecipes) > topRecipesLimit {
+		report.TopRecipes = report.TopRecipes[:topRecipesLimit]
+	}
+
+	report.PantryUsage = summarizePantryUsage(pantryUsed)
+	return report
+}
+
+// summarizePantryUsage суммирует использованные продукты кладовой по названию и единице
+func summarizePantryUsage(used models.Ingredients) models.PantryUsage {
+	type key struct{ name, unit string }
+	totals := make(map[key]*models.Ingredient)
+	var order []key
+
+	for _, ing := range used {
+		if ing.Quantity <= 0 {
+			continue
+		}
+		k := key{normalizeIngredientName(ing.Name), ing.Unit}
+		if item, ok := totals[k]; ok {
+			item.Quantity += ing.Quantity
+			continue
+		}
+		totals[k] = &models.Ingredient{Name: ing.Name, Quantity: ing.Quantity, Unit: ing.Unit}
+		order = append(order, k)
+	}
+
+	usage := models.PantryUsage{Ingredients: models.Ingredients{}}
+	for _, k := range order {
+		usage.Ingredients = append(usage.Ingredients, *totals[k])
+	}
+	usage.ItemsUsed = len(usage.Ingredients)
+	return usage
+}
+
+// WriteNutritionReportCSV выводит отчет в CSV: строка на день и итоговая строка
+func WriteNutritionReportCSV(w io.Writer, report *models.NutritionReport) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{
+		"date", "calories", "proteins", "fats", "carbs",
+		"planned_calories", "goal_calories", "calories_percent",
+		"planned_meals", "eaten_as_planned",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	goalCalories := ""
+	if report.DailyGoal != nil {
+		goalCalories = strconv.Itoa(report.DailyGoal.Calories)
+	}
+
+	formatFloat := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	}
+
+	for _, day := range report.Days {
+		record := []string{
+			day.Date,
+			strconv.Itoa(day.Eaten.Calories),
+			formatFloat(day.Eaten.Proteins),
+			formatFloat(day.Eaten.Fats),
+			formatFloat(day.Eaten.Carbs),
+			strconv.Itoa(day.Planned.Calories),
+			goalCalories,
+			formatFloat(day.CaloriesPercent),
+			strconv.Itoa(day.PlannedMeals),
+			strconv.Itoa(day.EatenAsPlanned),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	total := []string{
+		"total",
+		strconv.Itoa(report.Totals.Calories),
+		formatFloat(report.Totals.Proteins),
+		formatFloat(report.Totals.Fats),
+		formatFloat(report.Totals.Carbs),
+		strconv.Itoa(report.Adherence.PlannedCalories),
+		"",
+		"",
+		strconv.Itoa(report.Adherence.PlannedMeals),
+		strconv.Itoa(report.Adherence.EatenAsPlanned),
+	}
+	if err := writer.Write(total); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestBuildNutritionReport(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	
+	planned := []models.PlannedMealDetail{
+		{Date: "2024-01-01", MenuMealID: 1, MealType: "breakfast", RecipeID: 10, RecipeName: "Овсянка", Calories: 800, Servings: 2, CookingTime: 10},
+		{Date: "2024-01-01", MenuMealID: 2, MealType: "dinner", RecipeID: 20, RecipeName: "Суп", Calories: 1200, Servings: 4, CookingTime: 50},
+		{Date: "2024-01-02", MenuMealID: 3, MealType: "breakfast", RecipeID: 10, RecipeName: "Овсянка", Calories: 800, Servings: 2, CookingTime: 10},
+	}
+	
+	menuMealID := 1
+	recipeID := 20
+	entries := []models.FoodLogEntry{
+		// Отмечено "по плану"
+		{Date: from, MealType: "breakfast", MenuMealID: &menuMealID, Calories: 400},
+		// Тот же рецепт в тот же прием пищи
+		{Date: from, MealType: "dinner", RecipeID: &recipeID, Calories: 300},
+		// Вне плана
+		{Date: to, MealType: "snack", Name: "Яблоко", Calories: 80},
+	}
+	
+	goals := &models.UserGoals{DailyCalories: 2000}
+	pantry := models.Ingredients{
+		{Name: "Молоко", Quantity: 200, Unit: "мл"},
+		{Name: " молоко", Quantity: 300, Unit: "мл"},
+	}
+	
+	report := buildNutritionReport(from, to, planned, entries, pantry, goals)
+	
+	if len(report.Days) != 2 {
+		t.Fatalf("Ожидалось 2 дня, получено %d", len(report.Days))
+	}
+	// План на порцию: 800/2 + 1200/4 = 700
+	if report.Days[0].Planned.Calories != 700 {
+		t.Errorf("Ожидалось 700 ккал по плану, получено %d", report.Days[0].Planned.Calories)
+	}
+	if report.Totals.Calories != 780 {
+		t.Errorf("Ожидалось 780 ккал всего, получено %d", report.Totals.Calories)
+	}
+	if report.Adherence.PlannedMeals != 3 || report.Adherence.EatenAsPlanned != 2 {
+		t.Errorf("Ожидалось 2 из 3 приемов по плану, получено %d из %d",
+			report.Adherence.EatenAsPlanned, report.Adherence.PlannedMeals)
+	}
+	if report.Adherence.AdherencePercent != 66.7 {
+		t.Errorf("Ожидалось 66.7%% соблюдения плана, получено %.1f", report.Adherence.AdherencePercent)
+	}
+	if len(report.TopRecipes) != 2 || report.TopRecipes[0].RecipeID != 10 || report.TopRecipes[0].Count != 2 {
+		t.Errorf("Ожидалось, что самый частый рецепт - Овсянка (2 раза), получено %+v", report.TopRecipes)
+	}
+	if report.AverageCookingTime != 23.3 {
+		t.Errorf("Ожидалось среднее время 23.3 мин, получено %.1f", report.AverageCookingTime)
+	}
+	if report.PantryUsage.ItemsUsed != 1 || report.PantryUsage.Ingredients[0].Quantity != 500 {
+		t.Errorf("Ожидалось 500 мл молока из кладовой, получено %+v", report.PantryUsage)
+	}
+}
+
+func TestWriteNutritionReportCSV(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := buildNutritionReport(from, from.AddDate(0, 0, 2), nil, nil, nil, nil)
+	
+	var buf bytes.Buffer
+	if err := WriteNutritionReportCSV(&buf, report); err != nil {
+		t.Fatalf("Ошибка записи CSV: %v", err)
+	}
+	
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	// Заголовок + 3 дня + итог
+	if len(lines) != 5 {
+		t.Fatalf("Ожидалось 5 строк, получено %d", len(lines))
+	}
+	if !strings.HasPrefix(lines[4], "total,") {
+		t.Errorf("Последняя строка должна быть итоговой, получено %s", lines[4])
+	}
+}
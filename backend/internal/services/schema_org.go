@@ -0,0 +1,546 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+// Импорт рецептов schema.org Recipe (JSON-LD) из HTML-страниц или JSON
+
+// SchemaOrgSource - загруженный файл (HTML или JSON-LD)
+type SchemaOrgSource struct {
+	Name string
+	Data []byte
+}
+
+// RecipeParseReport - поля рецепта, которые не удалось разобрать
+type RecipeParseReport struct {
+	Recipe   int      `json:"recipe"` // номер рецепта в импорте (как в errors)
+	Source   string   `json:"source"`
+	Title    string   `json:"title"`
+	Unparsed []string `json:"unparsed"`
+}
+
+var jsonLDScriptRe = regexp.MustCompile(`(?is)<script[^>]+type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+
+// extractJSONLD возвращает содержимое блоков <script type="application/ld+json">
+func extractJSONLD(html []byte) [][]byte {
+	var blocks [][]byte
+	for _, match := range jsonLDScriptRe.FindAllSubmatch(html, -1) {
+		blocks = append(blocks, bytes.TrimSpace(match[1]))
+	}
+	return blocks
+}
+
+// parseSchemaOrgSource находит узлы Recipe в HTML или JSON-LD
+func parseSchemaOrgSource(data []byte) ([]map[string]interface{}, error) {
+	trimmed := bytes.TrimSpace(data)
+	blocks := [][]byte{trimmed}
+	if len(trimmed) > 0 && trimmed[0] != '{' && trimmed[0] != '[' {
+		blocks = extractJSONLD(trimmed)
+		if len(blocks) == 0 {
+			return nil, fmt.Errorf("блоки JSON-LD не найдены")
+		}
+	}
+
+	var recipes []map[string]interface{}
+	for _, block := range blocks {
+		var value interface{}
+		if err := json.Unmarshal(block, &value); err != nil {
+			return nil, fmt.Errorf("неверный JSON-LD: %w", err)
+		}
+		recipes = append(recipes, findSchemaRecipes(value)...)
+	}
+	return recipes, nil
+}
+
+// findSchemaRecipes рекурсивно ищет объекты с @type Recipe (в том числе внутри @graph)
+func findSchemaRecipes(value interface{}) []map[string]interface{} {
+	var recipes []map[string]interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			recipes = append(recipes, findSchemaRecipes(item)...)
+		}
+	case map[string]interface{}:
+		if schemaHasType(v, "Recipe") {
+			return append(recipes, v)
+		}
+		for _, key := range []string{"@graph", "mainEntity", "itemListElement", "item"} {
+			if nested, ok := v[key]; ok {
+				recipes = append(recipes, findSchemaRecipes(nested)...)
+			}
+		}
+	}
+	return recipes
+}
+
+func schemaHasType(node map[string]interface{}, typeName string) bool {
+	for _, t := range schemaStrings(node["@type"]) {
+		if t == typeName || strings.HasSuffix(t, "/"+typeName) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaStrings приводит строку, число или массив к списку строк
+func schemaStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if s := strings.TrimSpace(v); s != "" {
+			return []string{s}
+		}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case []interface{}:
+		var result []string
+		for _, item := range v {
+			result = append(result, schemaStrings(item)...)
+		}
+		return result
+	case map[string]interface{}:
+		// Ссылки вида {"@id": "..."} и объекты с текстом
+		for _, key := range []string{"text", "name", "@id"} {
+			if s, ok := v[key].(string); ok && strings.TrimSpace(s) != "" {
+				return []string{strings.TrimSpace(s)}
+			}
+		}
+	}
+	return nil
+}
+
+// schemaRecipeToDTO преобразует schema.org Recipe в RecipeImportDTO и возвращает
+// описание полей, которые не удалось разобрать
+func schemaRecipeToDTO(node map[string]interface{}) (models.RecipeImportDTO, []string) {
+	var dto models.RecipeImportDTO
+	var unparsed []string
+
+	if names := schemaStrings(node["name"]); len(names) > 0 {
+		dto.Title = names[0]
+	} else {
+		unparsed = append(unparsed, "name: не указано название")
+	}
+	if descriptions := schemaStrings(node["description"]); len(descriptions) > 0 {
+		dto.Description = descriptions[0]
+	}
+
+	// Порции
+	if yield, ok := node["recipeYield"]; ok {
+		if servings, ok := parseSchemaYield(yield); ok {
+			dto.Servings = servings
+		} else {
+			unparsed = append(unparsed, fmt.Sprintf("recipeYield: %v", yield))
+		}
+	}
+
+	// Время: totalTime или prepTime + cookTime
+	if total, ok := node["totalTime"]; ok {
+		minutes, err := parseISODuration(fmt.Sprint(total))
+		if err != nil {
+			unparsed = append(unparsed, fmt.Sprintf("totalTime: %v", total))
+		}
+		dto.CookingTime = minutes
+	}
+	if prep, ok := node["prepTime"]; ok {
+		dto.PrepTime, _ = parseISODuration(fmt.Sprint(prep))
+	}
+	if dto.CookingTime == 0 {
+		for _, key := range []string{"prepTime", "cookTime"} {
+			value, ok := node[key]
+			if !ok {
+				continue
+			}
+			minutes, err := parseISODuration(fmt.Sprint(value))
+			if err != nil {
+				unparsed = append(unparsed, fmt.Sprintf("%s: %v", key, value))
+				continue
+			}
+			dto.CookingTime += minutes
+		}
+	}
+
+	// Ингредиенты
+	for _, line := range schemaStrings(node["recipeIngredient"]) {
+		ing, ok := parseIngredientLine(line)
+		if !ok {
+			unparsed = append(unparsed, fmt.Sprintf("recipeIngredient: %s", line))
+		}
+		dto.Ingredients = append(dto.Ingredients, ing)
+	}
+	if len(dto.Ingredients) == 0 {
+		unparsed = append(unparsed, "recipeIngredient: не указаны ингредиенты")
+	}
+
+	dto.Instructions = schemaInstructions(node["recipeInstructions"])
+
+	// Теги: прием пищи из recipeCategory, кухня из recipeCuisine и диеты из suitableForDiet
+	for _, category := range schemaStrings(node["recipeCategory"]) {
+		if mealType, ok := schemaMealTypes[strings.ToLower(category)]; ok {
+			dto.Tags = append(dto.Tags, mealType)
+		}
+	}
+	if cuisines := schemaStrings(node["recipeCuisine"]); len(cuisines) > 0 {
+		dto.Tags = append(dto.Tags, cuisineTagPrefix+cuisines[0])
+	}
+	for _, diet := range schemaStrings(node["suitableForDiet"]) {
+		name := diet[strings.LastIndex(diet, "/")+1:]
+		if tag, ok := schemaDiets[name]; ok {
+			dto.Tags = append(dto.Tags, tag)
+		} else {
+			unparsed = append(unparsed, fmt.Sprintf("suitableForDiet: %s", diet))
+		}
+	}
+
+	// Пищевая ценность (schema.org указывает ее на порцию, у нас - на весь рецепт)
+	if nutrition, ok := node["nutrition"].(map[string]interface{}); ok {
+		unparsed = append(unparsed, applySchemaNutrition(&dto, nutrition)...)
+	}
+
+	return dto, unparsed
+}
+
+// schemaMealTypes - значения recipeCategory, соответствующие приемам пищи
+var schemaMealTypes = map[string]string{
+	"breakfast": "breakfast",
+	"brunch":    "breakfast",
+	"завтрак":   "breakfast",
+	"lunch":     "lunch",
+	"обед":      "lunch",
+	"dinner":    "dinner",
+	"main dish": "dinner",
+	"ужин":      "dinner",
+	"snack":     "snack",
+	"перекус":   "snack",
+}
+
+// schemaDiets - значения RestrictedDiet и коды справочника диет
+var schemaDiets = map[string]string{
+	"VeganDiet":      "vegan",
+	"VegetarianDiet": "vegetarian",
+	"GlutenFreeDiet": "gluten-free",
+	"LowLactoseDiet": "lactose-free",
+	"HalalDiet":      "halal",
+	"KosherDiet":     "kosher",
+	"DiabeticDiet":   "diabetic-friendly",
+	"KetogenicDiet":  "keto",
+	"LowFODMAPDiet":  "low-fodmap",
+	"LowFodmapDiet":  "low-fodmap",
+}
+
+// applySchemaNutrition заполняет КБЖУ и микронутриенты из NutritionInformation
+func applySchemaNutrition(dto *models.RecipeImportDTO, nutrition map[string]interface{}) []string {
+	var unparsed []string
+	servings := float64(dto.Servings)
+	if servings == 0 {
+		servings = 1
+	}
+
+	value := func(key string) (float64, string, bool) {
+		raw, ok := nutrition[key]
+		if !ok {
+			return 0, "", false
+		}
+		amount, unit, err := parseSchemaQuantity(fmt.Sprint(raw))
+		if err != nil {
+			unparsed = append(unparsed, fmt.Sprintf("nutrition.%s: %v", key, raw))
+			return 0, "", false
+		}
+		return amount * servings, unit, true
+	}
+
+	if calories, unit, ok := value("calories"); ok {
+		if unit == "kj" || unit == "кдж" {
+			calories /= 4.184
+		}
+		dto.Calories = int(math.Round(calories))
+	}
+	if proteins, _, ok := value("proteinContent"); ok {
+		dto.Proteins = proteins
+	}
+	if fats, _, ok := value("fatContent"); ok {
+		dto.Fats = fats
+	}
+	if carbs, _, ok := value("carbohydrateContent"); ok {
+		dto.Carbs = carbs
+	}
+
+	micronutrients := models.Micronutrients{}
+	for _, field := range []struct{ key, nutrient string }{
+		{"fiberContent", "fiber"},
+		{"sugarContent", "sugar"},
+		{"saturatedFatContent", "saturated_fat"},
+		{"sodiumContent", "sodium"},
+	} {
+		nutrient := field.nutrient
+		amount, unit, ok := value(field.key)
+		if !ok {
+			continue
+		}
+		// Натрий хранится в мг, остальное - в г
+		if nutrient == "sodium" && (unit == "g" || unit == "г") {
+			amount *= 1000
+		}
+		if nutrient != "sodium" && (unit == "mg" || unit == "мг") {
+			amount /= 1000
+		}
+		micronutrients[nutrient] = amount
+	}
+	if len(micronutrients) > 0 {
+		dto.Micronutrients = micronutrients
+	}
+	return unparsed
+}
+
+var schemaQuantityRe = regexp.MustCompile(`^\s*([0-9]+(?:[.,][0-9]+)?)\s*([^\s0-9.,]*)`)
+
+// parseSchemaQuantity разбирает значение вида "250 kcal", "12 g", "12"
+func parseSchemaQuantity(value string) (float64, string, error) {
+	match := schemaQuantityRe.FindStringSubmatch(value)
+	if match == nil {
+		return 0, "", fmt.Errorf("не число: %s", value)
+	}
+	amount, err := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+	if err != nil {
+		return 0, "", err
+	}
+	return amount, strings.ToLower(match[2]), nil
+}
+
+// parseSchemaYield разбирает recipeYield: 4, "4", "4 servings", ["4", "4 порции"]
+func parseSchemaYield(value interface{}) (int, bool) {
+	for _, s := range schemaStrings(value) {
+		if amount, _, err := parseSchemaQuantity(s); err == nil && amount >= 1 {
+			return int(math.Round(amount)), true
+		}
+	}
+	return 0, false
+}
+
+var isoDurationRe = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseISODuration переводит длительность ISO 8601 (PT1H30M) в минуты (с округлением вверх)
+func parseISODuration(value string) (int, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	match := isoDurationRe.FindStringSubmatch(value)
+	if match == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("неверная длительность ISO 8601: %s", value)
+	}
+	factors := []float64{24 * 60, 60, 1, 1.0 / 60}
+	minutes := 0.0
+	for i, factor := range factors {
+		if match[i+1] == "" {
+			continue
+		}
+		amount, err := strconv.ParseFloat(match[i+1], 64)
+		if err != nil {
+			return 0, err
+		}
+		minutes += amount * factor
+	}
+	return int(math.Ceil(minutes)), nil
+}
+
+// schemaInstructions разбирает recipeInstructions: строку, список строк,
+// HowToStep и HowToSection
+func schemaInstructions(value interface{}) []string {
+	var steps []string
+	switch v := value.(type) {
+	case string:
+		for _, line := range strings.Split(v, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				steps = append(steps, line)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			steps = append(steps, schemaInstructions(item)...)
+		}
+	case map[string]interface{}:
+		if items, ok := v["itemListElement"]; ok {
+			return schemaInstructions(items)
+		}
+		if text, ok := v["text"].(string); ok {
+			return schemaInstructions(text)
+		}
+	}
+	return steps
+}
+
+// ingredientUnits - единицы измерения в строках ингредиентов: название -> единица
+// рецепта и множитель количества
+var ingredientUnits = map[string]struct {
+	unit   string
+	factor float64
+}{
+	"g": {"г", 1}, "gram": {"г", 1}, "grams": {"г", 1}, "г": {"г", 1}, "гр": {"г", 1}, "грамм": {"г", 1},
+	"kg": {"г", 1000}, "кг": {"г", 1000},
+	"ml": {"мл", 1}, "мл": {"мл", 1}, "l": {"мл", 1000}, "л": {"мл", 1000},
+	"cup": {"мл", 240}, "cups": {"мл", 240}, "стакан": {"мл", 250}, "стакана": {"мл", 250}, "стаканов": {"мл", 250},
+	"oz": {"г", 28.35}, "ounce": {"г", 28.35}, "ounces": {"г", 28.35},
+	"lb": {"г", 453.6}, "lbs": {"г", 453.6}, "pound": {"г", 453.6}, "pounds": {"г", 453.6},
+	"tbsp": {"ст.л.", 1}, "tablespoon": {"ст.л.", 1}, "tablespoons": {"ст.л.", 1}, "ст.л.": {"ст.л.", 1},
+	"tsp": {"ч.л.", 1}, "teaspoon": {"ч.л.", 1}, "teaspoons": {"ч.л.", 1}, "ч.л.": {"ч.л.", 1},
+	"pinch": {"щепотка", 1}, "щепотка": {"щепотка", 1}, "щепотки": {"щепотка", 1},
+	"clove": {"зубчика", 1}, "cloves": {"зубчика", 1}, "зубчик": {"зубчика", 1}, "зубчика": {"зубчика", 1}, "зубчиков": {"зубчика", 1},
+	"piece": {"шт", 1}, "pieces": {"шт", 1}, "pcs": {"шт", 1}, "шт": {"шт", 1},
+}
+
+var unicodeFractions = map[rune]float64{
+	'½': 0.5, '⅓': 1.0 / 3, '⅔': 2.0 / 3, '¼': 0.25, '¾': 0.75, '⅛': 0.125,
+}
+
+// parseIngredientLine разбирает строку ингредиента ("2 tbsp olive oil", "1 1/2 cups flour",
+// "200 г муки", "3 яйца"). Возвращает false, если количество не удалось определить
+// (ингредиент сохраняется с названием из строки и нулевым количеством).
+func parseIngredientLine(line string) (models.IngredientImport, bool) {
+	line = strings.Join(strings.Fields(line), " ")
+	line = strings.NewReplacer("ст. л.", "ст.л.", "ч. л.", "ч.л.").Replace(line)
+
+	quantity, rest, ok := parseLeadingQuantity(line)
+	if !ok {
+		return models.IngredientImport{Name: line}, false
+	}
+
+	// Без единицы измерения количество - в штуках ("3 яйца")
+	unit := "шт"
+	fields := strings.Fields(rest)
+	if len(fields) > 0 {
+		key := strings.ToLower(strings.TrimRight(fields[0], ","))
+		u, found := ingredientUnits[key]
+		if !found {
+			u, found = ingredientUnits[strings.TrimSuffix(key, ".")]
+		}
+		if found {
+			unit, quantity = u.unit, quantity*u.factor
+			fields = fields[1:]
+		}
+	}
+	if len(fields) > 0 && strings.EqualFold(fields[0], "of") {
+		fields = fields[1:]
+	}
+
+	name := strings.Join(fields, " ")
+	if name == "" {
+		return models.IngredientImport{Name: line}, false
+	}
+	return models.IngredientImport{
+		Name:   name,
+		Amount: math.Round(quantity*100) / 100,
+		Unit:   unit,
+	}, true
+}
+
+// parseLeadingQuantity разбирает количество в начале строки: 2, 1.5, 1,5, 1/2,
+// 1 1/2, ½, 1½, диапазон 2-3 (берется среднее)
+func parseLeadingQuantity(line string) (float64, string, bool) {
+	runes := []rune(line)
+	i := 0
+	readNumber := func() (float64, bool) {
+		start := i
+		for i < len(runes) && (unicode.IsDigit(runes[i]) || ((runes[i] == '.' || runes[i] == ',') && i+1 < len(runes) && unicode.IsDigit(runes[i+1]))) {
+			i++
+		}
+		value := 0.0
+		found := false
+		if i > start {
+			value, _ = strconv.ParseFloat(strings.Replace(string(runes[start:i]), ",", ".", 1), 64)
+			found = true
+			// Простая дробь 1/2
+			if i+1 < len(runes) && runes[i] == '/' && unicode.IsDigit(runes[i+1]) {
+				j := i + 1
+				for j < len(runes) && unicode.IsDigit(runes[j]) {
+					j++
+				}
+				denominator, _ := strconv.ParseFloat(string(runes[i+1:j]), 64)
+				if denominator > 0 {
+					value /= denominator
+				}
+				i = j
+			}
+		}
+		if i < len(runes) {
+			if fraction, ok := unicodeFractions[runes[i]]; ok {
+				value += fraction
+				found = true
+				i++
+			}
+		}
+		return value, found
+	}
+
+	quantity, ok := readNumber()
+	if !ok {
+		return 0, line, false
+	}
+	// Смешанная дробь "1 1/2"
+	if i+1 < len(runes) && runes[i] == ' ' {
+		save := i
+		i++
+		if fraction, ok := readNumber(); ok && fraction < 1 {
+			quantity += fraction
+		} else {
+			i = save
+		}
+	}
+	// Диапазон "2-3" или "2–3"
+	if i+1 < len(runes) && (runes[i] == '-' || runes[i] == '–') {
+		save := i
+		i++
+		if upper, ok := readNumber(); ok {
+			quantity = (quantity + upper) / 2
+		} else {
+			i = save
+		}
+	}
+	return quantity, strings.TrimSpace(string(runes[i:])), true
+}
+
+// ImportSchemaOrg импортирует рецепты schema.org из HTML-страниц или JSON-LD.
+// Рецепты сохраняются так же, как при обычном импорте; в отчете parse_report
+// перечислены поля каждого рецепта, которые не удалось разобрать.
+func (s *AdminRecipeService) ImportSchemaOrg(ctx context.Context, sources []SchemaOrgSource, opts ImportOptions) (*ImportResult, error) {
+	var dtos []models.RecipeImportDTO
+	var reports []RecipeParseReport
+	var sourceErrors []string
+	for _, source := range sources {
+		nodes, err := parseSchemaOrgSource(source.Data)
+		if err == nil && len(nodes) == 0 {
+			err = fmt.Errorf("рецепты schema.org не найдены")
+		}
+		if err != nil {
+			sourceErrors = append(sourceErrors, fmt.Sprintf("Файл %s: %v", source.Name, err))
+			continue
+		}
+		for _, node := range nodes {
+			dto, unparsed := schemaRecipeToDTO(node)
+			dtos = append(dtos, dto)
+			if len(unparsed) > 0 {
+				reports = append(reports, RecipeParseReport{
+					Recipe:   len(dtos),
+					Source:   source.Name,
+					Title:    dto.Title,
+					Unparsed: unparsed,
+				})
+			}
+		}
+	}
+
+	result, err := s.ImportRecipes(ctx, dtos, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.Failed += len(sourceErrors)
+	result.Errors = append(sourceErrors, result.Errors...)
+	result.ParseReport = reports
+	return result, nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseISODuration(t *testing.T) {
+	cases := map[string]int{
+		"PT30M":    30,
+		"PT1H30M":  90,
+		"P1DT2H":   1560,
+		"PT45S":    1,
+		"pt0.5h":   30,
+		"PT1H5M0S": 65,
+	}
+	for value, expected := range cases {
+		minutes, err := parseISODuration(value)
+		if err != nil || minutes != expected {
+			t.Errorf("%s: ожидалось %d минут, получено %d (%v)", value, expected, minutes, err)
+		}
+	}
+	for _, value := range []string{"", "PT", "30 минут", "P1H"} {
+		if _, err := parseISODuration(value); err == nil {
+			t.Errorf("%q: ожидалась ошибка", value)
+		}
+	}
+}
+
+func TestParseIngredientLine(t *testing.T) {
+	cases := []struct {
+		line   string
+		name   string
+		amount float64
+		unit   string
+	}{
+		{"2 tbsp olive oil", "olive oil", 2, "ст.л."},
+		{"1 1/2 cups of flour", "flour", 360, "мл"},
+		{"½ tsp salt", "salt", 0.5, "ч.л."},
+		{"200 г муки", "муки", 200, "г"},
+		{"1,5 кг картофеля", "картофеля", 1500, "г"},
+		{"3 яйца", "яйца", 3, "шт"},
+		{"2-3 зубчика чеснока", "чеснока", 2.5, "зубчика"},
+		{"1 ст. л. сахара", "сахара", 1, "ст.л."},
+	}
+	for _, c := range cases {
+		ing, ok := parseIngredientLine(c.line)
+		if !ok || ing.Name != c.name || ing.Amount != c.amount || ing.Unit != c.unit {
+			t.Errorf("%q: ожидалось %v %s %s, получено %+v (ok=%v)", c.line, c.amount, c.unit, c.name, ing, ok)
+		}
+	}
+
+	ing, ok := parseIngredientLine("Соль по вкусу")
+	if ok || ing.Name != "Соль по вкусу" {
+		t.Errorf("Строка без количества должна попасть в отчет, получено %+v (ok=%v)", ing, ok)
+	}
+}
+
+func TestSchemaRecipeToDTO_FromHTML(t *testing.T) {
+	html := `<html><head>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+  {"@type": "WebPage", "name": "Блог"},
+  {"@type": "Recipe",
+   "name": "Овсянка с бананом",
+   "recipeYield": ["2", "2 порции"],
+   "prepTime": "PT5M", "cookTime": "PT10M",
+   "recipeCategory": "Breakfast",
+   "suitableForDiet": ["https://schema.org/VegetarianDiet", "https://schema.org/LowSaltDiet"],
+   "recipeIngredient": ["100 g oats", "1 banana", "молоко по вкусу"],
+   "recipeInstructions": [
+     {"@type": "HowToSection", "itemListElement": [
+       {"@type": "HowToStep", "text": "Сварить овсянку"},
+       {"@type": "HowToStep", "text": "Добавить банан"}]}],
+   "nutrition": {"@type": "NutritionInformation", "calories": "250 kcal",
+     "proteinContent": "8 g", "fatContent": "5,5 g", "carbohydrateContent": "40 g",
+     "sodiumContent": "0.1 g", "fiberContent": "много"}}
+]}
+</script></head><body></body></html>`
+
+	nodes, err := parseSchemaOrgSource([]byte(html))
+	if err != nil || len(nodes) != 1 {
+		t.Fatalf("Ожидался один рецепт, получено %d (%v)", len(nodes), err)
+	}
+	dto, unparsed := schemaRecipeToDTO(nodes[0])
+
+	if dto.Title != "Овсянка с бананом" || dto.Servings != 2 || dto.CookingTime != 15 {
+		t.Errorf("Неверные основные поля: %+v", dto)
+	}
+	if len(dto.Ingredients) != 3 || dto.Ingredients[0].Amount != 100 || dto.Ingredients[1].Unit != "шт" {
+		t.Errorf("Неверные ингредиенты: %+v", dto.Ingredients)
+	}
+	if len(dto.Instructions) != 2 || dto.Instructions[1] != "Добавить банан" {
+		t.Errorf("Неверные шаги: %v", dto.Instructions)
+	}
+	if strings.Join(dto.Tags, ",") != "breakfast,vegetarian" {
+		t.Errorf("Ожидались теги breakfast,vegetarian, получено %v", dto.Tags)
+	}
+	// Пищевая ценность указана на порцию - сохраняется на весь рецепт
+	if dto.Calories != 500 || dto.Proteins != 16 || dto.Fats != 11 || dto.Carbs != 80 {
+		t.Errorf("Неверные КБЖУ: %d/%v/%v/%v", dto.Calories, dto.Proteins, dto.Fats, dto.Carbs)
+	}
+	if dto.Micronutrients["sodium"] != 200 {
+		t.Errorf("Ожидалось 200 мг натрия, получено %v", dto.Micronutrients["sodium"])
+	}
+
+	report := strings.Join(unparsed, "\n")
+	for _, expected := range []string{"suitableForDiet: https://schema.org/LowSaltDiet", "recipeIngredient: молоко по вкусу", "nutrition.fiberContent: много"} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("В отчете нет %q: %v", expected, unparsed)
+		}
+	}
+}
+
+func TestParseSchemaOrgSource_Errors(t *testing.T) {
+	if _, err := parseSchemaOrgSource([]byte("<html><body>Нет разметки</body></html>")); err == nil {
+		t.Error("Ожидалась ошибка для HTML без JSON-LD")
+	}
+	if _, err := parseSchemaOrgSource([]byte(`{"@type": "Recipe", `)); err == nil {
+		t.Error("Ожидалась ошибка для неверного JSON")
+	}
+	nodes, err := parseSchemaOrgSource([]byte(`[{"@type": ["Recipe", "NewsArticle"], "name": "Суп"}, {"@type": "Person"}]`))
+	if err != nil || len(nodes) != 1 {
+		t.Errorf("Ожидался один рецепт из массива JSON-LD, получено %d (%v)", len(nodes), err)
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/internal/repositories"
 )
@@ -26,8 +27,8 @@ func NewShoppingListService(
 	}
 }
 
-func (s *ShoppingListService) GetByMenuID(menuID int) (*models.ShoppingList, error) {
-	return s.shoppingRepo.GetByMenuID(menuID)
+func (s *ShoppingListService) GetByMenuID(ctx context.Context, menuID int) (*models.ShoppingList, error) {
+	return s.shoppingRepo.GetByMenuID(ctx, menuID)
 }
 
 
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/repositories"
+)
+
+// SubstitutionIndex - таблица замен по каноническому названию ингредиента и синонимам.
+// Аллергены продуктов определяются по категориям (ComplianceChecker).
+type SubstitutionIndex struct {
+	byIngredient map[string][]models.IngredientSubstitution
+	checker      *ComplianceChecker
+}
+
+func NewSubstitutionIndex(substitutions []models.IngredientSubstitution, checker *ComplianceChecker) *SubstitutionIndex {
+	index := &SubstitutionIndex{
+		byIngredient: make(map[string][]models.IngredientSubstitution),
+		checker:      checker,
+	}
+	for _, substitution := range substitutions {
+		for _, name := range append([]string{substitution.Ingredient}, substitution.Aliases...) {
+			key := normalizeIngredientName(name)
+			index.byIngredient[key] = append(index.byIngredient[key], substitution)
+		}
+	}
+	return index
+}
+
+// loadSubstitutionIndex загружает таблицу замен и правила аллергенов
+func loadSubstitutionIndex(ctx context.Context, recipeRepo *repositories.RecipeRepository, tagRepo *repositories.DietaryTagRepository) (*SubstitutionIndex, error) {
+	checker, err := loadComplianceChecker(ctx, tagRepo)
+	if err != nil {
+		return nil, err
+	}
+	substitutions, err := recipeRepo.GetSubstitutions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении таблицы замен: %w", err)
+	}
+	return NewSubstitutionIndex(substitutions, checker), nil
+}
+
+// Options возвращает замены ингредиента, пересчитанные на его количество.
+// Замены, содержащие аллергены из avoid, пропускаются; замены, которые
+// целиком есть в кладовой (pantry: нормализованное название -> количество), идут первыми.
+func (x *SubstitutionIndex) Options(ing models.Ingredient, avoid []string, pantry map[string]float64) []models.SubstituteOption {
+	if x == nil {
+		return nil
+	}
+	var inPantry, others []models.SubstituteOption
+	for _, substitution := range x.byIngredient[normalizeIngredientName(ing.Name)] {
+		option := models.SubstituteOption{
+			Ingredients: make(models.Ingredients, 0, len(substitution.Components)),
+			Context:     substitution.Context,
+			Note:        substitution.Note,
+			InPantry:    len(pantry) > 0,
+		}
+		safe := true
+		for _, component := range substitution.Components {
+			if len(intersectTags(x.checker.ingredientAllergens(component.Name), avoid)) > 0 {
+				safe = false
+				break
+			}
+			unit := component.Unit
+			if unit == "" {
+				unit = ing.Unit
+			}
+			quantity := ing.Quantity * component.Ratio
+			option.Ingredients = append(option.Ingredients, models.Ingredient{
+				Name:     component.Name,
+				Quantity: quantity,
+				Unit:     unit,
+			})
+			if pantry[normalizeIngredientName(component.Name)] < quantity {
+				option.InPantry = false
+			}
+		}
+		if !safe || len(option.Ingredients) == 0 {
+			continue
+		}
+		if option.InPantry {
+			inPantry = append(inPantry, option)
+		} else {
+			others = append(others, option)
+		}
+	}
+	return append(inPantry, others...)
+}
+
+// AdaptRecipe заменяет ингредиенты рецепта, содержащие аллергены из allergies.
+// Применяются только универсальные замены (без Context): уместность замены вроде
+// "только для выпечки" по рецепту не определить. Возвращает false, если какой-то
+// ингредиент заменить нечем или аллерген указан в тегах рецепта, но не найден ни
+// в одном ингредиенте (заменять нечего - рецепт небезопасен). КБЖУ не пересчитываются:
+// у адаптированного рецепта они помечаются приблизительными (NutritionApproximate).
+func (x *SubstitutionIndex) AdaptRecipe(recipe models.Recipe, allergies []string) (models.Recipe, bool) {
+	if len(allergies) == 0 {
+		return recipe, true
+	}
+
+	adapted := recipe
+	adapted.Ingredients = make(models.Ingredients, 0, len(recipe.Ingredients))
+	adapted.Substitutions = nil
+	removed := make(map[string]bool)
+	for _, ing := range recipe.Ingredients {
+		carried := intersectTags(x.checker.ingredientAllergens(ing.Name), allergies)
+		if len(carried) == 0 {
+			adapted.Ingredients = append(adapted.Ingredients, ing)
+			continue
+		}
+		option, ok := universalOption(x.Options(ing, allergies, nil))
+		if !ok {
+			return recipe, false
+		}
+		adapted.Ingredients = append(adapted.Ingredients, option.Ingredients...)
+		adapted.Substitutions = append(adapted.Substitutions, models.AppliedSubstitution{
+			Original:   ing,
+			Substitute: option,
+			Allergens:  carried,
+		})
+		adapted.NutritionApproximate = true
+		for _, allergen := range carried {
+			removed[allergen] = true
+		}
+	}
+
+	adapted.Allergens = []string{}
+	for _, allergen := range recipe.Allergens {
+		if removed[allergen] {
+			continue
+		}
+		if len(intersectTags([]string{allergen}, allergies)) > 0 {
+			return recipe, false
+		}
+		adapted.Allergens = append(adapted.Allergens, allergen)
+	}
+	return adapted, true
+}
+
+// universalOption возвращает первую замену, уместную в любом блюде (без Context)
+func universalOption(options []models.SubstituteOption) (models.SubstituteOption, bool) {
+	for _, option := range options {
+		if option.Context == "" {
+			return option, true
+		}
+	}
+	return models.SubstituteOption{}, false
+}
+
+// FilterByAllergies оставляет рецепты без аллергенов из allergies, а рецепты
+// с аллергенами - в адаптированном виде, если все такие ингредиенты можно заменить
+func (x *SubstitutionIndex) FilterByAllergies(recipes []models.Recipe, allergies []string) []models.Recipe {
+	result := make([]models.Recipe, 0, len(recipes))
+	for _, recipe := range recipes {
+		if adapted, ok := x.AdaptRecipe(recipe, allergies); ok {
+			result = append(result, adapted)
+		}
+	}
+	return result
+}
+
+// suggestForMissing добавляет варианты замен к недостающим ингредиентам
+func (x *SubstitutionIndex) suggestForMissing(missing models.Ingredients, pantryItems []models.PantryItem, allergies []string) {
+	pantry := pantryQuantities(pantryItems)
+	for i := range missing {
+		missing[i].Substitutes = x.Options(missing[i], allergies, pantry)
+	}
+}
+
+// suggestForShopping добавляет варианты замен к позициям списка покупок
+func (x *SubstitutionIndex) suggestForShopping(list *models.ShoppingList, pantryItems []models.PantryItem, allergies []string) {
+	pantry := pantryQuantities(pantryItems)
+	for i := range list.Items {
+		item := &list.Items[i]
+		ing := models.Ingredient{Name: item.Name, Quantity: item.Quantity, Unit: item.Unit}
+		item.Substitutes = x.Options(ing, allergies, pantry)
+	}
+}
+
+// pantryQuantities возвращает количество продуктов кладовой по нормализованному названию
+func pantryQuantities(pantryItems []models.PantryItem) map[string]float64 {
+	pantry := make(map[string]float64, len(pantryItems))
+	for _, item := range pantryItems {
+		pantry[normalizeIngredientName(item.Name)] += item.Quantity
+	}
+	return pantry
+}
+
+// intersectTags возвращает теги a, которые есть в b (без учета регистра)
+func intersectTags(a, b []string) []string {
+	var result []string
+	for _, x := range a {
+		for _, y := range b {
+			if normalizeTag(x) == normalizeTag(y) {
+				result = append(result, x)
+				break
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/myplate/backend/internal/models"
+)
+
+func testSubstitutionIndex() *SubstitutionIndex {
+	checker := NewComplianceChecker(
+		[]models.DietaryTag{
+			{Code: "dairy", Kind: "allergen", Categories: []string{"dairy"}},
+			{Code: "soy", Kind: "allergen", Categories: []string{"soy"}},
+		},
+		[]models.IngredientCategory{
+			{Keyword: "молок", Category: "dairy", Exceptions: []string{"овсян", "соев"}},
+			{Keyword: "пахт", Category: "dairy"},
+			{Keyword: "соев", Category: "soy"},
+		},
+	)
+	return NewSubstitutionIndex([]models.IngredientSubstitution{
+		{Ingredient: "пахта", Components: models.SubstituteComponents{{Name: "Молоко", Ratio: 0.95}, {Name: "Лимонный сок", Ratio: 0.05}}},
+		{Ingredient: "молоко", Aliases: []string{"молоко коровье"}, Components: models.SubstituteComponents{{Name: "Соевое молоко", Ratio: 1}}},
+		{Ingredient: "молоко", Aliases: []string{"молоко коровье"}, Components: models.SubstituteComponents{{Name: "Овсяное молоко", Ratio: 1}}},
+	}, checker)
+}
+
+func TestSubstitutionIndex_Options(t *testing.T) {
+	index := testSubstitutionIndex()
+
+	options := index.Options(models.Ingredient{Name: "Пахта", Quantity: 200, Unit: "мл"}, nil, nil)
+	if len(options) != 1 || len(options[0].Ingredients) != 2 {
+		t.Fatalf("Ожидалась одна замена из двух составляющих, получено %+v", options)
+	}
+	if options[0].Ingredients[0].Quantity != 190 || options[0].Ingredients[0].Unit != "мл" {
+		t.Errorf("Ожидалось 190 мл молока, получено %+v", options[0].Ingredients[0])
+	}
+
+	// Замена с молоком не подходит при аллергии на молочные продукты;
+	// для молока по синониму при аллергии на сою остается только овсяное
+	if options := index.Options(models.Ingredient{Name: "Пахта", Quantity: 200, Unit: "мл"}, []string{"dairy"}, nil); len(options) != 0 {
+		t.Errorf("Не ожидалось замен без молочных продуктов, получено %+v", options)
+	}
+	options = index.Options(models.Ingredient{Name: "Молоко коровье", Quantity: 1, Unit: "л"}, []string{"soy"}, nil)
+	if len(options) != 1 || options[0].Ingredients[0].Name != "Овсяное молоко" {
+		t.Errorf("Ожидалась замена на овсяное молоко, получено %+v", options)
+	}
+
+	// Замена, которая есть в кладовой, идет первой
+	pantry := pantryQuantities([]models.PantryItem{{Name: "овсяное молоко", Quantity: 2}})
+	options = index.Options(models.Ingredient{Name: "молоко", Quantity: 1, Unit: "л"}, nil, pantry)
+	if len(options) != 2 || !options[0].InPantry || options[0].Ingredients[0].Name != "Овсяное молоко" {
+		t.Errorf("Ожидалась первой замена из кладовой, получено %+v", options)
+	}
+}
+
+func TestSubstitutionIndex_AdaptRecipe(t *testing.T) {
+	index := testSubstitutionIndex()
+	recipe := models.Recipe{
+		Name:      "Блины",
+		Allergens: []string{"dairy", "eggs"},
+		Ingredients: models.Ingredients{
+			{Name: "Молоко", Quantity: 500, Unit: "мл"},
+			{Name: "Мука", Quantity: 200, Unit: "г"},
+		},
+	}
+
+	adapted, ok := index.AdaptRecipe(recipe, []string{"dairy", "soy"})
+	if !ok {
+		t.Fatal("Ожидалось, что рецепт можно адаптировать")
+	}
+	if adapted.Ingredients[0].Name != "Овсяное молоко" || len(adapted.Substitutions) != 1 {
+		t.Errorf("Ожидалась замена молока на овсяное, получено %+v", adapted.Ingredients)
+	}
+	if !adapted.NutritionApproximate || recipe.NutritionApproximate {
+		t.Error("КБЖУ адаптированного рецепта должны быть помечены приблизительными")
+	}
+	if len(adapted.Allergens) != 1 || adapted.Allergens[0] != "eggs" {
+		t.Errorf("Ожидались аллергены [eggs], получено %v", adapted.Allergens)
+	}
+	if recipe.Ingredients[0].Name != "Молоко" {
+		t.Error("Исходный рецепт не должен изменяться")
+	}
+
+	// Аллерген указан в тегах, но ни один ингредиент его не содержит - заменять нечего
+	if _, ok := index.AdaptRecipe(recipe, []string{"eggs"}); ok {
+		t.Error("Рецепт с неустранимым аллергеном не должен предлагаться")
+	}
+}
+
+func TestSubstitutionIndex_AdaptRecipeSkipsContextualOptions(t *testing.T) {
+	checker := NewComplianceChecker(
+		[]models.DietaryTag{{Code: "eggs", Kind: "allergen", Categories: []string{"eggs"}}},
+		[]models.IngredientCategory{{Keyword: "яйц", Category: "eggs"}},
+	)
+	index := NewSubstitutionIndex([]models.IngredientSubstitution{
+		{Ingredient: "яйцо", Aliases: []string{"яйца"}, Components: models.SubstituteComponents{{Name: "Льняная мука", Ratio: 7, Unit: "г"}}, Context: "выпечка"},
+		{Ingredient: "яйцо", Aliases: []string{"яйца"}, Components: models.SubstituteComponents{{Name: "Банановое пюре", Ratio: 60, Unit: "г"}}, Context: "сладкая выпечка"},
+	}, checker)
+	omelette := models.Recipe{
+		Name:        "Омлет",
+		Allergens:   []string{"eggs"},
+		Ingredients: models.Ingredients{{Name: "Яйца", Quantity: 3, Unit: "шт"}},
+	}
+
+	// Замены только для выпечки в омлет не подставляются - рецепт не предлагается
+	if adapted, ok := index.AdaptRecipe(omelette, []string{"eggs"}); ok {
+		t.Errorf("Рецепт не должен адаптироваться заменой для выпечки, получено %+v", adapted.Ingredients)
+	}
+	// При этом как подсказки такие замены по-прежнему показываются
+	if options := index.Options(omelette.Ingredients[0], []string{"eggs"}, nil); len(options) != 2 {
+		t.Errorf("Ожидались 2 замены с контекстом, получено %+v", options)
+	}
+}
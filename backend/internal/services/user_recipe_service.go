@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/myplate/backend/internal/models"
+	"github.com/myplate/backend/internal/repositories"
+	"github.com/myplate/backend/pkg/database"
+)
+
+// ErrRecipeInUse - личный рецепт нельзя удалить, пока он есть в сохраненных меню
+var ErrRecipeInUse = errors.New("рецепт используется в сохраненных меню, сначала удалите эти меню")
+
+// UserRecipeService - личные рецепты пользователей и модерация рецептов,
+// предложенных в общий каталог. Проверка и создание рецептов - через AdminRecipeService.
+type UserRecipeService struct {
+	adminRecipeService *AdminRecipeService
+	recipeRepo         *repositories.RecipeRepository
+	submissionRepo     *repositories.RecipeSubmissionRepository
+}
+
+func NewUserRecipeService(
+	adminRecipeService *AdminRecipeService,
+	recipeRepo *repositories.RecipeRepository,
+	submissionRepo *repositories.RecipeSubmissionRepository,
+) *UserRecipeService {
+	return &UserRecipeService{
+		adminRecipeService: adminRecipeService,
+		recipeRepo:         recipeRepo,
+		submissionRepo:     submissionRepo,
+	}
+}
+
+// recipeVisibleTo проверяет, доступен ли рецепт пользователю: рецепт общего каталога
+// или личный рецепт этого пользователя
+func recipeVisibleTo(recipe *models.Recipe, userID int) bool {
+	return recipe.OwnerID == nil || *recipe.OwnerID == userID
+}
+
+// CreateRecipe создает личный рецепт, доступный генератору меню только владельцу
+func (s *UserRecipeService) CreateRecipe(ctx context.Context, userID int, dto *models.RecipeImportDTO) (*models.Recipe, error) {
+	return s.adminRecipeService.createRecipe(ctx, dto, &userID, nil)
+}
+
+// GetRecipes возвращает личные рецепты пользователя
+func (s *UserRecipeService) GetRecipes(ctx context.Context, userID int) ([]models.Recipe, error) {
+	recipes, err := s.recipeRepo.GetByOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if recipes == nil {
+		recipes = []models.Recipe{}
+	}
+	return recipes, nil
+}
+
+// DeleteRecipe удаляет личный рецепт пользователя
+func (s *UserRecipeService) DeleteRecipe(ctx context.Context, userID, recipeID int) error {
+	return recipeDeleteError(s.recipeRepo.DeleteOwned(ctx, recipeID, userID))
+}
+
+// recipeDeleteError заменяет нарушение внешнего ключа (рецепт остался в menu_meals)
+// на ErrRecipeInUse, остальные ошибки возвращает как есть
+func recipeDeleteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrRecipeInUse
+	}
+	return err
+}
+
+// Submit предлагает рецепт в общий каталог: рецепт проверяется сразу,
+// а создается после одобрения модератором
+func (s *UserRecipeService) Submit(ctx context.Context, userID int, dto *models.RecipeImportDTO) (*models.RecipeSubmission, error) {
+	taxonomy, err := s.adminRecipeService.loadTaxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.adminRecipeService.validateRecipeDTO(dto, taxonomy); err != nil {
+		return nil, err
+	}
+
+	submission := &models.RecipeSubmission{
+		UserID: userID,
+		Recipe: *dto,
+	}
+	if err := s.submissionRepo.Create(ctx, submission); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении заявки: %w", err)
+	}
+	return submission, nil
+}
+
+// GetUserSubmissions возвращает заявки пользователя
+func (s *UserRecipeService) GetUserSubmissions(ctx context.Context, userID int) ([]models.RecipeSubmission, error) {
+	return s.submissionRepo.GetByUserID(ctx, userID)
+}
+
+// GetSubmissions возвращает заявки с указанным статусом (по умолчанию - ожидающие модерации)
+func (s *UserRecipeService) GetSubmissions(ctx context.Context, status string) ([]models.RecipeSubmission, error) {
+	if status == "" {
+		status = "pending"
+	}
+	if status != "pending" && status != "approved" && status != "rejected" {
+		return nil, fmt.Errorf("статус должен быть pending, approved или rejected")
+	}
+	return s.submissionRepo.GetByStatus(ctx, status)
+}
+
+// GetSubmission возвращает заявку по ID
+func (s *UserRecipeService) GetSubmission(ctx context.Context, id int) (*models.RecipeSubmission, error) {
+	submission, err := s.submissionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if submission == nil {
+		return nil, sql.ErrNoRows
+	}
+	return submission, nil
+}
+
+// ApproveSubmission одобряет заявку: рецепт создается в общем каталоге
+// с той же проверкой, что и при создании администратором
+func (s *UserRecipeService) ApproveSubmission(ctx context.Context, id, adminID int) (*models.RecipeSubmission, error) {
+	submission, err := s.pendingSubmission(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.adminRecipeService.createRecipe(ctx, &submission.Recipe, nil, func(ctx context.Context, tx *sql.Tx, recipe *models.Recipe) error {
+		submission.Status = "approved"
+		submission.RecipeID = &recipe.ID
+		submission.ReviewedBy = &adminID
+		return s.resolve(ctx, tx, submission)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return submission, nil
+}
+
+// RejectSubmission отклоняет заявку с указанием причины
+func (s *UserRecipeService) RejectSubmission(ctx context.Context, id, adminID int, reason string) (*models.RecipeSubmission, error) {
+	if reason == "" {
+		return nil, fmt.Errorf("укажите причину отклонения")
+	}
+	submission, err := s.pendingSubmission(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	submission.Status = "rejected"
+	submission.Reason = reason
+	submission.ReviewedBy = &adminID
+	if err := s.resolve(ctx, tx, submission); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при коммите транзакции: %w", err)
+	}
+	return submission, nil
+}
+
+// pendingSubmission возвращает заявку, ожидающую модерации
+func (s *UserRecipeService) pendingSubmission(ctx context.Context, id int) (*models.RecipeSubmission, error) {
+	submission, err := s.GetSubmission(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if submission.Status != "pending" {
+		return nil, fmt.Errorf("заявка уже рассмотрена (статус %s)", submission.Status)
+	}
+	return submission, nil
+}
+
+// resolve сохраняет решение по заявке (заявку могли рассмотреть параллельно)
+func (s *UserRecipeService) resolve(ctx context.Context, tx *sql.Tx, submission *models.RecipeSubmission) error {
+	err := s.submissionRepo.ResolveInTx(ctx, tx, submission)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("заявка уже рассмотрена")
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении решения по заявке: %w", err)
+	}
+	return nil
+}
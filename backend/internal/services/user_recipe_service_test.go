@@ -0,0 +1,78 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/myplate/backend/internal/models"
+)
+
+func TestValidateRecipeDTO(t *testing.T) {
+	service := &AdminRecipeService{}
+	valid := models.RecipeImportDTO{
+		Title:       "Омлет",
+		Tags:        []string{"breakfast", "vegetarian", "egg"},
+		Ingredients: []models.IngredientImport{{Name: "Яйца", Amount: 3, Unit: "шт"}},
+		Calories:    250,
+	}
+	if err := service.validateRecipeDTO(&valid, testTaxonomy()); err != nil {
+		t.Errorf("Не ожидалась ошибка: %v", err)
+	}
+
+	cases := map[string]func(dto *models.RecipeImportDTO){
+		"без названия":          func(dto *models.RecipeImportDTO) { dto.Title = " " },
+		"без ингредиентов":      func(dto *models.RecipeImportDTO) { dto.Ingredients = nil },
+		"отрицательные калории": func(dto *models.RecipeImportDTO) { dto.Calories = -1 },
+		"неизвестный тег":       func(dto *models.RecipeImportDTO) { dto.Tags = append(dto.Tags, "spicy") },
+	}
+	for name, modify := range cases {
+		dto := valid
+		dto.Tags = append([]string{}, valid.Tags...)
+		modify(&dto)
+		if err := service.validateRecipeDTO(&dto, testTaxonomy()); err == nil {
+			t.Errorf("%s: ожидалась ошибка валидации", name)
+		}
+	}
+}
+
+func TestRecipeVisibleTo(t *testing.T) {
+	owner := 7
+	public := &models.Recipe{ID: 1}
+	private := &models.Recipe{ID: 2, OwnerID: &owner}
+
+	if !recipeVisibleTo(public, 3) {
+		t.Error("Рецепт каталога должен быть доступен всем")
+	}
+	if !recipeVisibleTo(private, owner) {
+		t.Error("Личный рецепт должен быть доступен владельцу")
+	}
+	if recipeVisibleTo(private, 3) {
+		t.Error("Личный рецепт не должен быть доступен другим пользователям")
+	}
+}
+
+func TestRecipeDeleteError(t *testing.T) {
+	// Так PostgreSQL отвечает на удаление рецепта, который есть в сохраненном меню
+	usedInMenu := fmt.Errorf("delete: %w", &pq.Error{
+		Code:       "23503",
+		Table:      "menu_meals",
+		Constraint: "menu_meals_recipe_id_fkey",
+	})
+	if err := recipeDeleteError(usedInMenu); !errors.Is(err, ErrRecipeInUse) {
+		t.Errorf("Ожидалась ErrRecipeInUse, получено %v", err)
+	}
+
+	if err := recipeDeleteError(sql.ErrNoRows); err != sql.ErrNoRows {
+		t.Errorf("Ожидалась sql.ErrNoRows, получено %v", err)
+	}
+	if err := recipeDeleteError(nil); err != nil {
+		t.Errorf("Не ожидалась ошибка: %v", err)
+	}
+	other := &pq.Error{Code: "23505"}
+	if err := recipeDeleteError(other); err != other {
+		t.Errorf("Другие ошибки базы не должны заменяться, получено %v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package services
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/myplate/backend/internal/models"
 	"github.com/myplate/backend/internal/repositories"
 )
@@ -15,13 +18,18 @@ func NewUserService(goalsRepo *repositories.GoalsRepository) *UserService {
 	}
 }
 
-func (s *UserService) SetGoals(userID int, goals *models.UserGoals) error {
+func (s *UserService) SetGoals(ctx context.Context, userID int, goals *models.UserGoals) error {
 	goals.UserID = userID
-	return s.goalsRepo.CreateOrUpdate(goals)
+	limits, err := normalizeMicronutrients(goals.NutrientLimits)
+	if err != nil {
+		return fmt.Errorf("неверные лимиты: %w", err)
+	}
+	goals.NutrientLimits = limits
+	return s.goalsRepo.CreateOrUpdate(ctx, goals)
 }
 
-func (s *UserService) GetGoals(userID int) (*models.UserGoals, error) {
-	return s.goalsRepo.GetByUserID(userID)
+func (s *UserService) GetGoals(ctx context.Context, userID int) (*models.UserGoals, error) {
+	return s.goalsRepo.GetByUserID(ctx, userID)
 }
 
 
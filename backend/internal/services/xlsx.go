@@ -0,0 +1,308 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Минимальная поддержка XLSX (Office Open XML) без внешних зависимостей:
+// чтение значений ячеек всех листов и запись листов со строками и числами
+
+// tableRow - строка таблицы с номером строки в исходном файле (для сообщений об ошибках)
+type tableRow struct {
+	Line  int
+	Cells []string
+}
+
+// xlsxSheet - лист книги
+type xlsxSheet struct {
+	Name string
+	Rows []tableRow
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string   `xml:"r,attr"`
+			T  string   `xml:"t,attr"`
+			V  string   `xml:"v"`
+			Is xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// xlsxMaxUncompressedSize - наибольший размер распакованного содержимого книги.
+// Защищает от архивов, которые при распаковке разрастаются до гигабайт
+const xlsxMaxUncompressedSize = 200 << 20
+
+var errXLSXTooLarge = fmt.Errorf("книга XLSX больше %d МБ после распаковки", xlsxMaxUncompressedSize>>20)
+
+// xlsxLimitReader возвращает ошибку, когда из книги прочитано больше limit байт.
+// Размеры в заголовках архива не проверяются при чтении, поэтому считаются
+// фактически распакованные байты всех частей книги
+type xlsxLimitReader struct {
+	r     io.Reader
+	read  *int64
+	limit int64
+}
+
+func (l xlsxLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	*l.read += int64(n)
+	if *l.read > l.limit {
+		return n, errXLSXTooLarge
+	}
+	return n, err
+}
+
+// readXLSX читает значения ячеек всех листов книги
+func readXLSX(data []byte) ([]xlsxSheet, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("файл не является книгой XLSX: %w", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	var total uint64
+	for _, file := range archive.File {
+		files[file.Name] = file
+		total += file.UncompressedSize64
+		if total > xlsxMaxUncompressedSize {
+			return nil, errXLSXTooLarge
+		}
+	}
+	var read int64
+	readXML := func(name string, v interface{}) error {
+		file, ok := files[name]
+		if !ok {
+			return fmt.Errorf("в книге нет %s", name)
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		limited := xlsxLimitReader{r: reader, read: &read, limit: xlsxMaxUncompressedSize}
+		if err := xml.NewDecoder(limited).Decode(v); err != nil && err != io.EOF {
+			if errors.Is(err, errXLSXTooLarge) {
+				return errXLSXTooLarge
+			}
+			return fmt.Errorf("ошибка чтения %s: %w", name, err)
+		}
+		return nil
+	}
+
+	var workbook xlsxWorkbook
+	if err := readXML("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := readXML("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := readXML("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	sheets := make([]xlsxSheet, 0, len(workbook.Sheets))
+	for _, ref := range workbook.Sheets {
+		var worksheet xlsxWorksheet
+		if err := readXML(targets[ref.RID], &worksheet); err != nil {
+			return nil, err
+		}
+		sheet := xlsxSheet{Name: ref.Name}
+		for i, row := range worksheet.Rows {
+			line := row.R
+			if line == 0 {
+				line = i + 1
+			}
+			var cells []string
+			for j, cell := range row.Cells {
+				column := j
+				if cell.R != "" {
+					column = xlsxColumnIndex(cell.R)
+				}
+				for len(cells) <= column {
+					cells = append(cells, "")
+				}
+				switch cell.T {
+				case "s":
+					index, err := strconv.Atoi(cell.V)
+					if err != nil || index < 0 || index >= len(shared.Items) {
+						return nil, fmt.Errorf("лист %s, ячейка %s: неверная ссылка на строку", ref.Name, cell.R)
+					}
+					cells[column] = shared.Items[index].String()
+				case "inlineStr":
+					cells[column] = cell.Is.String()
+				default:
+					cells[column] = cell.V
+				}
+			}
+			sheet.Rows = append(sheet.Rows, tableRow{Line: line, Cells: cells})
+		}
+		sheets = append(sheets, sheet)
+	}
+	return sheets, nil
+}
+
+// xlsxColumnIndex возвращает номер столбца (с нуля) по адресу ячейки: "A1" -> 0, "AB12" -> 27
+func xlsxColumnIndex(ref string) int {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A') + 1
+	}
+	return index - 1
+}
+
+// xlsxColumnName - обратное преобразование: 0 -> "A", 27 -> "AB"
+func xlsxColumnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+%s</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+// writeXLSX записывает листы в книгу XLSX. Значения, которые являются числами,
+// записываются числовыми ячейками, остальные - строками
+func writeXLSX(sheets []xlsxSheet) ([]byte, error) {
+	var overrides, workbookSheets, workbookRels strings.Builder
+	for i, sheet := range sheets {
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`+"\n", i+1)
+		fmt.Fprintf(&workbookSheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheet.Name), i+1, i+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", fmt.Sprintf(xlsxContentTypes, overrides.String())},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` + workbookSheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + workbookRels.String() + `</Relationships>`},
+	}
+	for i, sheet := range sheets {
+		parts = append(parts, struct{ name, content string }{
+			fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1),
+			xlsxWorksheetXML(sheet),
+		})
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, part := range parts {
+		writer, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(writer, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var xlsxNumberRe = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+func xlsxWorksheetXML(sheet xlsxSheet) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range sheet.Rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, value := range row.Cells {
+			if value == "" {
+				continue
+			}
+			ref := xlsxColumnName(j) + strconv.Itoa(i+1)
+			if xlsxNumberRe.MatchString(value) {
+				fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, value)
+			} else {
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(value))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+func xmlEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
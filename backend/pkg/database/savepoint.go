@@ -0,0 +1,26 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// WithSavepoint выполняет fn внутри точки сохранения транзакции. Если fn вернула
+// ошибку, изменения fn откатываются, а транзакция остается рабочей - в PostgreSQL
+// ошибка запроса иначе прерывает всю транзакцию.
+func WithSavepoint(ctx context.Context, tx *sql.Tx, name string, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("ошибка при создании точки сохранения: %w", err)
+	}
+	if err := fn(); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rollbackErr != nil {
+			return fmt.Errorf("%v (ошибка отката к точке сохранения: %v)", err, rollbackErr)
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("ошибка при освобождении точки сохранения: %w", err)
+	}
+	return nil
+}
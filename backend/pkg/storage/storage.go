@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound - объекта с таким ключом нет
+	ErrNotFound = errors.New("объект не найден")
+	// ErrInvalidKey - ключ пуст или выходит за пределы хранилища
+	ErrInvalidKey = errors.New("недопустимый ключ")
+)
+
+// Object - сохраненный файл
+type Object struct {
+	Data        []byte
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage - хранилище файлов (изображений рецептов). Ключи - пути через "/",
+// например recipes/12/ab34-small.jpg.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewFromEnv создает хранилище по переменным окружения: STORAGE_DRIVER=local
+// (по умолчанию, каталог STORAGE_DIR) или s3 (S3_ENDPOINT, S3_BUCKET, S3_REGION,
+// S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY)
+func NewFromEnv() (Storage, error) {
+	switch driver := strings.ToLower(os.Getenv("STORAGE_DRIVER")); driver {
+	case "", "local":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		return NewLocal(dir)
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Region:          os.Getenv("S3_REGION"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("неизвестный STORAGE_DRIVER: %s (ожидается local или s3)", driver)
+	}
+}
+
+// validKey проверяет, что ключ не выходит за пределы хранилища
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
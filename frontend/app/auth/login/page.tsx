@@ -3,7 +3,7 @@
 import { useState } from "react"
 import { useRouter } from "next/navigation"
 import Link from "next/link"
-import api from "@/lib/api"
+import api, { saveTokens } from "@/lib/api"
 import { Button } from "@/components/ui/button"
 import { Input } from "@/components/ui/input"
 import { Label } from "@/components/ui/label"
@@ -26,7 +26,7 @@ export default function LoginPage() {
     try {
       const response = await api.post("/auth/login", formData)
       if (response.data.token) {
-        localStorage.setItem("token", response.data.token)
+        saveTokens(response.data)
         router.push("/")
       }
     } catch (err: any) {
@@ -28,6 +28,7 @@ interface RecipeDTO {
 
 interface WeeklyDayMenu {
   day: number
+  date?: string
   breakfast: RecipeDTO
   lunch: RecipeDTO
   dinner: RecipeDTO
@@ -41,6 +42,7 @@ interface WeeklyDayMenu {
 }
 
 interface WeeklyMenu {
+  start_date?: string
   week: WeeklyDayMenu[]
 }
 
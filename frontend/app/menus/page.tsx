@@ -17,6 +17,7 @@ interface WeeklyMenu {
   id?: number
   week: Array<{
     day: number
+    date?: string
     breakfast: { id: number; name: string; calories: number; cooking_time: number }
     lunch: { id: number; name: string; calories: number; cooking_time: number }
     dinner: { id: number; name: string; calories: number; cooking_time: number }
@@ -50,12 +51,13 @@ export default function MenusPage() {
         if (response.data && response.data.length > 0) {
           // Преобразуем все недельные меню
           const weeklyMenusData = response.data.map((menu: any) => {
-            if (menu.meals && Array.isArray(menu.meals)) {
+            if (menu.week && Array.isArray(menu.week)) {
               return {
                 id: menu.id,
                 date: menu.date,
-                week: menu.meals.map((day: any) => ({
+                week: menu.week.map((day: any) => ({
                   day: day.day || 0,
+                  date: day.date,
                   breakfast: day.breakfast || null,
                   lunch: day.lunch || null,
                   dinner: day.dinner || null,
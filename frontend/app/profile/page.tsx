@@ -2,7 +2,7 @@
 
 import { useState, useEffect } from "react"
 import { useRouter } from "next/navigation"
-import api from "@/lib/api"
+import api, { clearTokens, logout } from "@/lib/api"
 import { Button } from "@/components/ui/button"
 import { Input } from "@/components/ui/input"
 import { Label } from "@/components/ui/label"
@@ -49,7 +49,7 @@ export default function ProfilePage() {
       })
     } catch (err: any) {
       if (err.response?.status === 401 || err.response?.status === 500) {
-        localStorage.removeItem("token")
+        clearTokens()
         router.push("/auth/login")
       } else {
         setError(err.response?.data?.error || "Ошибка при загрузке профиля")
@@ -109,7 +109,7 @@ export default function ProfilePage() {
   }
 
   const handleLogout = () => {
-    localStorage.removeItem("token")
+    logout()
     router.push("/auth/login")
   }
 
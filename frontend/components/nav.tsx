@@ -13,6 +13,7 @@ import {
 } from "@/components/ui/sheet"
 import { Menu } from "lucide-react"
 import { ThemeToggle } from "@/components/theme-toggle"
+import { logout } from "@/lib/api"
 
 export function Nav() {
   const pathname = usePathname()
@@ -26,7 +27,7 @@ export function Nav() {
   }, [pathname])
 
   const handleLogout = () => {
-    localStorage.removeItem("token")
+    logout()
     setIsAuthenticated(false)
     router.push("/auth/login")
   }
@@ -1,7 +1,7 @@
 "use client"
 
 import { createContext, useContext, useEffect, useState } from "react"
-import api from "@/lib/api"
+import api, { clearTokens, saveTokens } from "@/lib/api"
 
 interface TelegramContextType {
   isTelegram: boolean
@@ -36,7 +36,7 @@ export function TelegramProvider({ children }: { children: React.ReactNode }) {
         // Authenticate with backend
         api.post("/auth/telegram", { init_data: initDataStr })
           .then((response) => {
-            localStorage.setItem("token", response.data.token)
+            saveTokens(response.data)
             setUser(response.data.user)
           })
           .catch((error) => {
@@ -55,7 +55,7 @@ export function TelegramProvider({ children }: { children: React.ReactNode }) {
           .catch((error) => {
             // Если токен невалидный или ошибка сервера, удаляем его
             if (error.response?.status === 401 || error.response?.status === 500) {
-              localStorage.removeItem("token")
+              clearTokens()
             }
             console.error("Ошибка при проверке токена:", error)
           })
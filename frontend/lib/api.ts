@@ -9,6 +9,34 @@ const api = axios.create({
   },
 })
 
+// Сохраняет токены из ответа входа или обновления
+export function saveTokens(data: { token?: string; refresh_token?: string }) {
+  if (data.token) {
+    localStorage.setItem("token", data.token)
+  }
+  if (data.refresh_token) {
+    localStorage.setItem("refresh_token", data.refresh_token)
+  }
+}
+
+export function clearTokens() {
+  localStorage.removeItem("token")
+  localStorage.removeItem("refresh_token")
+}
+
+// Выход: токены отзываются на сервере и удаляются локально
+export async function logout() {
+  const refreshToken = localStorage.getItem("refresh_token")
+  clearTokens()
+  if (refreshToken) {
+    try {
+      await axios.post(`${API_URL}/auth/logout`, { refresh_token: refreshToken })
+    } catch (error) {
+      console.error("Ошибка при выходе:", error)
+    }
+  }
+}
+
 // Add auth token to requests
 api.interceptors.request.use((config) => {
   const token = localStorage.getItem("token")
@@ -18,6 +46,44 @@ api.interceptors.request.use((config) => {
   return config
 })
 
-export default api
+// Access-токен живет 15 минут: при 401 получаем новую пару по refresh-токену и
+// повторяем запрос. Одновременные запросы ждут одного обновления - refresh-токен
+// одноразовый, повторное использование завершает вход
+let refreshing: Promise<string | null> | null = null
 
+function refreshTokens(): Promise<string | null> {
+  if (!refreshing) {
+    const refreshToken = localStorage.getItem("refresh_token")
+    refreshing = (refreshToken
+      ? axios.post(`${API_URL}/auth/refresh`, { refresh_token: refreshToken })
+          .then((response) => {
+            saveTokens(response.data)
+            return response.data.token as string
+          })
+          .catch(() => {
+            clearTokens()
+            return null
+          })
+      : Promise.resolve(null)
+    ).finally(() => {
+      refreshing = null
+    })
+  }
+  return refreshing
+}
+
+api.interceptors.response.use(undefined, async (error) => {
+  const config = error.config
+  if (error.response?.status !== 401 || !config || config._retried) {
+    return Promise.reject(error)
+  }
+  const token = await refreshTokens()
+  if (!token) {
+    return Promise.reject(error)
+  }
+  config._retried = true
+  config.headers.Authorization = `Bearer ${token}`
+  return api(config)
+})
 
+export default api
@@ -0,0 +1,90 @@
+-- Нормализация хранения меню: дни и приемы пищи в отдельных таблицах
+-- вместо JSON в menus.meals (и для дневных, и для недельных меню)
+
+CREATE TABLE menu_days (
+    id SERIAL PRIMARY KEY,
+    menu_id INT NOT NULL REFERENCES menus(id) ON DELETE CASCADE,
+    day_number INT NOT NULL CHECK (day_number BETWEEN 1 AND 7),
+    date DATE NOT NULL,
+    total_calories INT NOT NULL DEFAULT 0,
+    total_proteins NUMERIC(10,2) NOT NULL DEFAULT 0,
+    total_fats NUMERIC(10,2) NOT NULL DEFAULT 0,
+    total_carbs NUMERIC(10,2) NOT NULL DEFAULT 0,
+    total_time INT NOT NULL DEFAULT 0,
+    ingredients_used JSONB NOT NULL DEFAULT '[]',
+    missing_ingredients JSONB NOT NULL DEFAULT '[]',
+    UNIQUE(menu_id, day_number)
+);
+
+CREATE TABLE menu_meals (
+    id SERIAL PRIMARY KEY,
+    menu_day_id INT NOT NULL REFERENCES menu_days(id) ON DELETE CASCADE,
+    recipe_id INT NOT NULL REFERENCES recipes(id),
+    meal_type TEXT NOT NULL CHECK (meal_type IN ('breakfast', 'lunch', 'dinner', 'snack')),
+    calories INT NOT NULL DEFAULT 0,
+    cooking_time INT NOT NULL DEFAULT 0,
+    position INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_menu_days_menu_id ON menu_days(menu_id);
+CREATE INDEX idx_menu_days_date ON menu_days(date);
+CREATE INDEX idx_menu_meals_menu_day_id ON menu_meals(menu_day_id);
+CREATE INDEX idx_menu_meals_recipe_id ON menu_meals(recipe_id);
+
+-- Перенос недельных меню: meals содержит массив дней
+-- {day, date, breakfast, lunch, dinner, totalCalories, ...}
+INSERT INTO menu_days (menu_id, day_number, date, total_calories, total_proteins, total_fats, total_carbs,
+                       total_time, ingredients_used, missing_ingredients)
+SELECT m.id,
+       (d->>'day')::int,
+       COALESCE(NULLIF(d->>'date', '')::date, m.date + ((d->>'day')::int - 1)),
+       COALESCE((d->>'totalCalories')::int, 0),
+       COALESCE((d->>'totalProteins')::numeric, 0),
+       COALESCE((d->>'totalFats')::numeric, 0),
+       COALESCE((d->>'totalCarbs')::numeric, 0),
+       COALESCE((d->>'totalTime')::int, 0),
+       COALESCE(NULLIF(d->'ingredients_used', 'null'::jsonb), '[]'),
+       COALESCE(NULLIF(d->'missing_ingredients', 'null'::jsonb), '[]')
+FROM menus m
+CROSS JOIN LATERAL jsonb_array_elements(m.meals) AS d
+WHERE m.menu_type = 'weekly' AND jsonb_typeof(m.meals) = 'array';
+
+INSERT INTO menu_meals (menu_day_id, recipe_id, meal_type, calories, cooking_time, position)
+SELECT md.id,
+       r.id,
+       mt.meal_type,
+       r.calories,
+       r.cooking_time,
+       mt.position
+FROM menus m
+CROSS JOIN LATERAL jsonb_array_elements(m.meals) AS d
+JOIN menu_days md ON md.menu_id = m.id AND md.day_number = (d->>'day')::int
+CROSS JOIN (VALUES ('breakfast', 1), ('lunch', 2), ('dinner', 3)) AS mt(meal_type, position)
+JOIN recipes r ON r.id = (d->mt.meal_type->>'id')::int
+WHERE m.menu_type = 'weekly'
+  AND jsonb_typeof(m.meals) = 'array'
+  AND jsonb_typeof(d->mt.meal_type) = 'object';
+
+-- Перенос дневных меню: meals содержит массив {recipe_id, meal_type, calories, time}
+INSERT INTO menu_days (menu_id, day_number, date, total_calories, total_time, ingredients_used, missing_ingredients)
+SELECT id, 1, date, COALESCE(total_calories, 0), COALESCE(total_time, 0),
+       COALESCE(ingredients_used, '[]'), COALESCE(missing_ingredients, '[]')
+FROM menus
+WHERE menu_type = 'daily';
+
+INSERT INTO menu_meals (menu_day_id, recipe_id, meal_type, calories, cooking_time, position)
+SELECT md.id,
+       r.id,
+       e->>'meal_type',
+       COALESCE((e->>'calories')::int, r.calories),
+       COALESCE((e->>'time')::int, r.cooking_time),
+       x.ord
+FROM menus m
+JOIN menu_days md ON md.menu_id = m.id
+CROSS JOIN LATERAL jsonb_array_elements(m.meals) WITH ORDINALITY AS x(e, ord)
+JOIN recipes r ON r.id = (e->>'recipe_id')::int
+WHERE m.menu_type = 'daily' AND jsonb_typeof(m.meals) = 'array';
+
+-- JSON больше не используется
+DROP INDEX IF EXISTS idx_menus_meals;
+ALTER TABLE menus DROP COLUMN meals;
@@ -0,0 +1,22 @@
+-- Дневник питания: что пользователь фактически съел
+CREATE TABLE food_log (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    meal_type TEXT NOT NULL CHECK (meal_type IN ('breakfast', 'lunch', 'dinner', 'snack')),
+    recipe_id INT REFERENCES recipes(id) ON DELETE SET NULL, -- рецепт (если запись по рецепту или по плану)
+    menu_meal_id INT REFERENCES menu_meals(id) ON DELETE SET NULL, -- запланированный прием пищи ("съел по плану")
+    portion NUMERIC(6,2) NOT NULL DEFAULT 1, -- количество порций
+    name TEXT NOT NULL,
+    calories INT NOT NULL DEFAULT 0,
+    proteins NUMERIC(10,2) NOT NULL DEFAULT 0,
+    fats NUMERIC(10,2) NOT NULL DEFAULT 0,
+    carbs NUMERIC(10,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_food_log_user_id_date ON food_log(user_id, date);
+CREATE INDEX idx_food_log_menu_meal_id ON food_log(menu_meal_id);
+
+COMMENT ON COLUMN food_log.calories IS 'Калории записи с учетом порции (снимок на момент записи)';
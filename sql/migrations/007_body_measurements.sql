@@ -0,0 +1,39 @@
+-- Измерения тела (вес, талия) и автоматическая корректировка калорийности
+
+CREATE TABLE body_measurements (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    weight NUMERIC(5,2) NOT NULL CHECK (weight > 0), -- кг
+    waist NUMERIC(5,1) CHECK (waist > 0), -- см
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, date)
+);
+
+CREATE INDEX idx_body_measurements_user_id_date ON body_measurements(user_id, date);
+
+-- Цель по изменению веса: кг в неделю (отрицательное значение - похудение)
+ALTER TABLE user_goals ADD COLUMN weekly_weight_change NUMERIC(4,2);
+-- Применять предложенную калорийность автоматически, без подтверждения
+ALTER TABLE user_goals ADD COLUMN auto_adjust_calories BOOLEAN NOT NULL DEFAULT false;
+
+-- Журнал корректировок калорийности: что предложено и почему
+CREATE TABLE goal_adjustments (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    measurements_count INT NOT NULL,
+    target_weekly_change NUMERIC(4,2) NOT NULL,
+    actual_weekly_change NUMERIC(5,2) NOT NULL,
+    previous_calories INT NOT NULL,
+    proposed_calories INT NOT NULL,
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'proposed' CHECK (status IN ('proposed', 'applied', 'rejected')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    UNIQUE(user_id, period_end)
+);
+
+CREATE INDEX idx_goal_adjustments_user_id ON goal_adjustments(user_id);
@@ -0,0 +1,45 @@
+-- Микронутриенты рецептов, справочник продуктов и лимиты пользователя
+
+-- Значения на весь рецепт (как и КБЖУ): {"fiber": 12.5, "sodium": 1800, ...}
+ALTER TABLE recipes ADD COLUMN micronutrients JSONB NOT NULL DEFAULT '{}';
+-- declared - указаны в рецепте, computed - рассчитаны по ингредиентам
+ALTER TABLE recipes ADD COLUMN micronutrients_source TEXT
+    CHECK (micronutrients_source IN ('declared', 'computed'));
+
+-- Справочник продуктов для расчета микронутриентов по ингредиентам
+CREATE TABLE ingredient_nutrients (
+    name TEXT PRIMARY KEY, -- нормализованное название (нижний регистр)
+    per_100g JSONB NOT NULL DEFAULT '{}', -- на 100 г или 100 мл
+    piece_weight NUMERIC(8,2), -- вес одной штуки, г (для единиц "шт")
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO ingredient_nutrients (name, per_100g, piece_weight) VALUES
+    ('соль', '{"sodium": 38758}', NULL),
+    ('сахар', '{"sugar": 99.8}', NULL),
+    ('сливочное масло', '{"saturated_fat": 51.4, "sodium": 11, "vitamin_a": 684}', NULL),
+    ('молоко', '{"sugar": 4.8, "saturated_fat": 1.9, "sodium": 44, "calcium": 113, "potassium": 150, "vitamin_d": 1.3, "vitamin_b12": 0.45}', NULL),
+    ('яйцо', '{"saturated_fat": 3.1, "sodium": 124, "iron": 1.8, "vitamin_a": 160, "vitamin_d": 2, "vitamin_b12": 0.9}', 55),
+    ('овсяные хлопья', '{"fiber": 10.1, "sugar": 1, "iron": 4.3, "magnesium": 138, "potassium": 362}', NULL),
+    ('гречка', '{"fiber": 10, "magnesium": 231, "iron": 2.2, "potassium": 460}', NULL),
+    ('рис', '{"fiber": 1.3, "magnesium": 25, "potassium": 115}', NULL),
+    ('куриная грудка', '{"saturated_fat": 1, "sodium": 74, "potassium": 256, "vitamin_b12": 0.3}', NULL),
+    ('говядина', '{"saturated_fat": 6, "sodium": 65, "iron": 2.6, "potassium": 318, "vitamin_b12": 2.6}', NULL),
+    ('лосось', '{"saturated_fat": 3.1, "sodium": 59, "potassium": 363, "vitamin_d": 11, "vitamin_b12": 3.2}', NULL),
+    ('картофель', '{"fiber": 2.2, "sugar": 0.8, "potassium": 425, "vitamin_c": 19.7}', 150),
+    ('морковь', '{"fiber": 2.8, "sugar": 4.7, "potassium": 320, "vitamin_a": 835, "vitamin_c": 5.9}', 80),
+    ('помидор', '{"fiber": 1.2, "sugar": 2.6, "potassium": 237, "vitamin_c": 13.7}', 120),
+    ('огурец', '{"fiber": 0.5, "sugar": 1.7, "potassium": 147, "vitamin_c": 2.8}', 100),
+    ('лук', '{"fiber": 1.7, "sugar": 4.2, "potassium": 146, "vitamin_c": 7.4}', 100),
+    ('яблоко', '{"fiber": 2.4, "sugar": 10.4, "potassium": 107, "vitamin_c": 4.6}', 180),
+    ('банан', '{"fiber": 2.6, "sugar": 12.2, "potassium": 358, "magnesium": 27, "vitamin_c": 8.7}', 120),
+    ('шпинат', '{"fiber": 2.2, "iron": 2.7, "calcium": 99, "magnesium": 79, "potassium": 558, "vitamin_a": 469, "vitamin_c": 28}', NULL),
+    ('творог', '{"saturated_fat": 3, "sodium": 41, "calcium": 164, "vitamin_b12": 1}', NULL),
+    ('сыр', '{"saturated_fat": 19, "sodium": 620, "calcium": 720, "vitamin_a": 265, "vitamin_b12": 1.5}', NULL),
+    ('хлеб', '{"fiber": 2.7, "sugar": 5, "sodium": 490, "iron": 3.6}', NULL);
+
+-- Верхние лимиты пользователя на человека в день: {"sodium": 2000, "sugar": 50}
+ALTER TABLE user_goals ADD COLUMN nutrient_limits JSONB NOT NULL DEFAULT '{}';
+
+-- Итоги дня меню по микронутриентам
+ALTER TABLE menu_days ADD COLUMN total_micronutrients JSONB NOT NULL DEFAULT '{}';
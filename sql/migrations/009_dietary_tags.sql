@@ -0,0 +1,36 @@
+-- Справочник диет и аллергенов: теги рецептов проверяются по нему
+
+CREATE TABLE dietary_tags (
+    code TEXT PRIMARY KEY, -- значение в recipes.diet_type / recipes.allergens
+    kind TEXT NOT NULL CHECK (kind IN ('diet', 'allergen')),
+    name TEXT NOT NULL, -- название для отображения
+    aliases TEXT[] NOT NULL DEFAULT '{}' -- альтернативные написания тега при импорте
+);
+
+INSERT INTO dietary_tags (code, kind, name, aliases) VALUES
+    -- Диеты
+    ('vegetarian', 'diet', 'Вегетарианская', '{}'),
+    ('vegan', 'diet', 'Веганская', '{}'),
+    ('gluten-free', 'diet', 'Без глютена', '{"gluten free", "glutenfree"}'),
+    ('lactose-free', 'diet', 'Без лактозы', '{"lactose free", "dairy-free", "dairy free"}'),
+    ('halal', 'diet', 'Халяль', '{}'),
+    ('kosher', 'diet', 'Кошерная', '{}'),
+    ('keto', 'diet', 'Кето', '{"ketogenic"}'),
+    ('low-fodmap', 'diet', 'Low-FODMAP', '{"low fodmap", "fodmap"}'),
+    ('diabetic-friendly', 'diet', 'Для диабетиков', '{"diabetic", "diabetic friendly"}'),
+    -- 14 аллергенов ЕС (Регламент 1169/2011)
+    ('gluten', 'allergen', 'Злаки с глютеном', '{"cereals"}'),
+    ('crustaceans', 'allergen', 'Ракообразные', '{"shellfish", "crustacean"}'),
+    ('eggs', 'allergen', 'Яйца', '{"egg"}'),
+    ('fish', 'allergen', 'Рыба', '{}'),
+    ('peanuts', 'allergen', 'Арахис', '{"peanut"}'),
+    ('soy', 'allergen', 'Соя', '{"soya", "soybeans"}'),
+    ('dairy', 'allergen', 'Молоко и молочные продукты', '{"milk", "lactose"}'),
+    ('nuts', 'allergen', 'Орехи', '{"tree nuts", "tree-nuts"}'),
+    ('celery', 'allergen', 'Сельдерей', '{}'),
+    ('mustard', 'allergen', 'Горчица', '{}'),
+    ('sesame', 'allergen', 'Кунжут', '{"sesame seeds"}'),
+    ('sulphites', 'allergen', 'Диоксид серы и сульфиты', '{"sulfites", "sulphur dioxide"}'),
+    ('lupin', 'allergen', 'Люпин', '{}'),
+    ('molluscs', 'allergen', 'Моллюски', '{"mollusks", "mollusc"}');
+
@@ -0,0 +1,73 @@
+-- Проверка соответствия рецептов диетам и аллергенам по категориям ингредиентов
+
+-- Ключевые слова (начала слов в названии ингредиента) и категории продуктов
+CREATE TABLE ingredient_categories (
+    keyword TEXT NOT NULL, -- нижний регистр; совпадает с началом любого слова названия
+    category TEXT NOT NULL,
+    PRIMARY KEY (keyword, category)
+);
+
+INSERT INTO ingredient_categories (keyword, category)
+SELECT keyword, category
+FROM (VALUES
+    ('meat', ARRAY['говядин', 'телятин', 'баранин', 'ягнятин', 'мясо', 'мясн', 'фарш', 'колбас', 'сосиск', 'сардельк', 'печень']),
+    ('pork', ARRAY['свинин', 'свин', 'бекон', 'ветчин', 'сало', 'прошутто', 'хамон']),
+    ('poultry', ARRAY['куриц', 'курин', 'цыпл', 'индейк', 'индюш', 'утк', 'утин', 'гус']),
+    ('fish', ARRAY['рыб', 'лосос', 'семг', 'сёмг', 'форел', 'треск', 'тунец', 'тунц', 'скумбри', 'сельд', 'минта', 'хек', 'судак', 'анчоус', 'горбуш']),
+    ('crustaceans', ARRAY['креветк', 'краб', 'лангуст', 'омар', 'лобстер']),
+    ('molluscs', ARRAY['миди', 'кальмар', 'осьминог', 'устриц', 'гребешк', 'гребешок']),
+    ('gelatin', ARRAY['желатин']),
+    ('dairy', ARRAY['молок', 'молоч', 'сливк', 'сливоч', 'сметан', 'творог', 'творож', 'кефир', 'йогурт', 'сыр', 'ряженк', 'пармезан', 'моцарелл', 'брынз', 'маскарпоне', 'рикотт', 'мороженое']),
+    ('lactose', ARRAY['молок', 'молоч', 'сливк', 'сметан', 'творог', 'творож', 'кефир', 'йогурт', 'ряженк', 'мороженое']),
+    ('eggs', ARRAY['яйц', 'яйцо', 'яичн', 'желтк', 'майонез']),
+    ('honey', ARRAY['мед', 'мёд', 'медов']),
+    ('gluten', ARRAY['пшениц', 'пшеничн', 'мука', 'макарон', 'спагетти', 'лапш', 'хлеб', 'батон', 'лаваш', 'булгур', 'манк', 'кускус', 'ячмен', 'перлов', 'ржан', 'сухар', 'тесто', 'панировк']),
+    ('peanuts', ARRAY['арахис']),
+    ('nuts', ARRAY['орех', 'миндал', 'фундук', 'кешью', 'фисташк', 'пекан']),
+    ('soy', ARRAY['соев', 'соя', 'тофу', 'эдамаме']),
+    ('sesame', ARRAY['кунжут', 'тахини']),
+    ('celery', ARRAY['сельдере']),
+    ('mustard', ARRAY['горчиц', 'горчичн']),
+    ('sulphites', ARRAY['вино', 'сухофрукт']),
+    ('lupin', ARRAY['люпин']),
+    ('alcohol', ARRAY['вино', 'пиво', 'водк', 'коньяк', 'ликер', 'ликёр']),
+    ('sugar', ARRAY['сахар', 'мед', 'мёд', 'сироп', 'варень', 'джем', 'сгущ']),
+    ('high_carb', ARRAY['картоф', 'рис', 'макарон', 'спагетти', 'хлеб', 'мука', 'круп', 'гречк', 'пшен', 'овсян', 'кукуруз', 'сахар']),
+    ('high_fodmap', ARRAY['лук', 'чеснок', 'пшениц', 'пшеничн', 'фасол', 'нут', 'чечевиц', 'яблок', 'груш', 'мед', 'мёд', 'молок'])
+) AS c(category, keywords)
+CROSS JOIN LATERAL unnest(c.keywords) AS keyword;
+
+-- Для диеты - запрещенные категории, для аллергена - категории, в которых он содержится
+ALTER TABLE dietary_tags ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE dietary_tags SET categories = c.categories
+FROM (VALUES
+    ('vegetarian', ARRAY['meat', 'pork', 'poultry', 'fish', 'crustaceans', 'molluscs', 'gelatin']),
+    ('vegan', ARRAY['meat', 'pork', 'poultry', 'fish', 'crustaceans', 'molluscs', 'gelatin', 'dairy', 'eggs', 'honey']),
+    ('gluten-free', ARRAY['gluten']),
+    ('lactose-free', ARRAY['lactose']),
+    ('halal', ARRAY['pork', 'alcohol', 'gelatin']),
+    ('kosher', ARRAY['pork', 'crustaceans', 'molluscs']),
+    ('keto', ARRAY['sugar', 'high_carb']),
+    ('low-fodmap', ARRAY['high_fodmap']),
+    ('diabetic-friendly', ARRAY['sugar']),
+    ('gluten', ARRAY['gluten']),
+    ('crustaceans', ARRAY['crustaceans']),
+    ('eggs', ARRAY['eggs']),
+    ('fish', ARRAY['fish']),
+    ('peanuts', ARRAY['peanuts']),
+    ('soy', ARRAY['soy']),
+    ('dairy', ARRAY['dairy']),
+    ('nuts', ARRAY['nuts']),
+    ('celery', ARRAY['celery']),
+    ('mustard', ARRAY['mustard']),
+    ('sesame', ARRAY['sesame']),
+    ('sulphites', ARRAY['sulphites']),
+    ('lupin', ARRAY['lupin']),
+    ('molluscs', ARRAY['molluscs'])
+) AS c(code, categories)
+WHERE dietary_tags.code = c.code;
+
+-- Найденные противоречия рецепта (результат последней проверки)
+ALTER TABLE recipes ADD COLUMN compliance_issues JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE recipes ADD COLUMN compliance_checked_at TIMESTAMP;
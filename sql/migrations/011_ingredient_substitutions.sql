@@ -0,0 +1,55 @@
+-- Замены ингредиентов
+
+-- Исключения для ключевых слов категорий: "овсяное молоко" - не молочный продукт
+ALTER TABLE ingredient_categories ADD COLUMN exceptions TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE ingredient_categories SET exceptions = ARRAY['овсян', 'соев', 'кокос', 'миндал', 'рисов']
+WHERE keyword IN ('молок', 'молоч', 'сливк') AND category IN ('dairy', 'lactose');
+
+UPDATE ingredient_categories SET exceptions = ARRAY['рисов', 'кукуруз', 'миндал', 'гречн', 'кокос', 'нутов', 'льнян', 'безглютен']
+WHERE keyword = 'мука' AND category = 'gluten';
+
+UPDATE ingredient_categories SET exceptions = ARRAY['соев']
+WHERE keyword = 'йогурт' AND category IN ('dairy', 'lactose');
+
+-- Таблица замен: ключ - каноническое название ингредиента (нижний регистр) и синонимы.
+-- components - состав замены: количество (ratio) на 1 единицу исходного ингредиента,
+-- unit - единица измерения (по умолчанию - как у исходного).
+CREATE TABLE ingredient_substitutions (
+    id SERIAL PRIMARY KEY,
+    ingredient TEXT NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    components JSONB NOT NULL,
+    context TEXT NOT NULL DEFAULT '', -- где замена уместна (выпечка, соусы...); пусто - везде
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_ingredient_substitutions_ingredient ON ingredient_substitutions(ingredient);
+CREATE INDEX idx_ingredient_substitutions_aliases ON ingredient_substitutions USING GIN(aliases);
+
+INSERT INTO ingredient_substitutions (ingredient, aliases, components, context, note) VALUES
+    ('пахта', '{}', '[{"name": "Молоко", "ratio": 0.95}, {"name": "Лимонный сок", "ratio": 0.05}]', 'выпечка', 'Смешать и оставить на 10 минут'),
+    ('кефир', '{}', '[{"name": "Йогурт натуральный", "ratio": 0.75}, {"name": "Молоко", "ratio": 0.25}]', '', NULL),
+    ('яйцо', '{"яйца", "яйцо куриное", "яйца куриные"}', '[{"name": "Льняная мука", "ratio": 7, "unit": "г"}, {"name": "Вода", "ratio": 45, "unit": "мл"}]', 'выпечка', 'Льняное яйцо: смешать и оставить на 5 минут'),
+    ('яйцо', '{"яйца", "яйцо куриное", "яйца куриные"}', '[{"name": "Банановое пюре", "ratio": 60, "unit": "г"}]', 'сладкая выпечка', NULL),
+    ('молоко', '{"молоко коровье"}', '[{"name": "Овсяное молоко", "ratio": 1}]', '', NULL),
+    ('молоко', '{"молоко коровье"}', '[{"name": "Соевое молоко", "ratio": 1}]', '', NULL),
+    ('масло сливочное', '{"сливочное масло"}', '[{"name": "Масло растительное", "ratio": 0.8}]', 'выпечка, жарка', NULL),
+    ('масло сливочное', '{"сливочное масло"}', '[{"name": "Кокосовое масло", "ratio": 1}]', 'выпечка', NULL),
+    ('сметана', '{}', '[{"name": "Йогурт греческий", "ratio": 1}]', '', NULL),
+    ('сметана', '{}', '[{"name": "Соевый йогурт", "ratio": 1}]', 'соусы', NULL),
+    ('сливки', '{"сливки 20%", "сливки 33%"}', '[{"name": "Кокосовые сливки", "ratio": 1}]', '', NULL),
+    ('творог', '{}', '[{"name": "Тофу", "ratio": 1}]', 'запеканки, начинки', NULL),
+    ('пармезан', '{"сыр пармезан"}', '[{"name": "Пищевые дрожжи", "ratio": 0.5}]', 'посыпка', NULL),
+    ('мука пшеничная', '{"мука"}', '[{"name": "Мука рисовая", "ratio": 1}]', 'загущение соусов', NULL),
+    ('мука пшеничная', '{"мука"}', '[{"name": "Безглютеновая мучная смесь", "ratio": 1}]', 'выпечка', NULL),
+    ('панировочные сухари', '{"сухари панировочные"}', '[{"name": "Кукурузная крупа", "ratio": 1}]', 'панировка', NULL),
+    ('соевый соус', '{}', '[{"name": "Кокосовый аминос", "ratio": 1}]', 'соусы, маринады', NULL),
+    ('арахисовая паста', '{"арахисовое масло"}', '[{"name": "Паста из семечек подсолнечника", "ratio": 1}]', '', NULL),
+    ('грецкий орех', '{"грецкие орехи", "орехи"}', '[{"name": "Тыквенные семечки", "ratio": 1}]', 'салаты, выпечка', NULL),
+    ('мед', '{"мёд"}', '[{"name": "Кленовый сироп", "ratio": 1}]', '', NULL),
+    ('горчица', '{}', '[{"name": "Хрен", "ratio": 0.5}]', 'соусы', NULL),
+    ('кунжутное масло', '{}', '[{"name": "Масло оливковое", "ratio": 1}]', '', NULL),
+    ('вино белое', '{"белое вино"}', '[{"name": "Бульон овощной", "ratio": 0.95}, {"name": "Лимонный сок", "ratio": 0.05}]', 'соусы, тушение', NULL);
@@ -0,0 +1,13 @@
+-- Оценки, избранное и запрещенные рецепты пользователя
+CREATE TABLE recipe_feedback (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipe_id INT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    rating SMALLINT CHECK (rating BETWEEN 1 AND 5),
+    favorite BOOLEAN NOT NULL DEFAULT false,
+    banned BOOLEAN NOT NULL DEFAULT false, -- "больше не показывать"
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, recipe_id)
+);
+
+CREATE INDEX idx_recipe_feedback_recipe_id ON recipe_feedback(recipe_id);
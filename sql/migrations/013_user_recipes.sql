@@ -0,0 +1,21 @@
+-- Личные рецепты пользователей и модерация рецептов, предложенных в общий каталог
+
+-- Владелец личного рецепта; NULL - рецепт общего каталога
+ALTER TABLE recipes ADD COLUMN owner_id INT REFERENCES users(id) ON DELETE CASCADE;
+CREATE INDEX idx_recipes_owner_id ON recipes(owner_id);
+
+-- Очередь модерации: рецепт хранится в формате импорта до одобрения
+CREATE TABLE recipe_submissions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payload JSONB NOT NULL, -- RecipeImportDTO
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reason TEXT, -- причина отклонения
+    recipe_id INT REFERENCES recipes(id) ON DELETE SET NULL, -- созданный рецепт каталога
+    reviewed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP
+);
+
+CREATE INDEX idx_recipe_submissions_status ON recipe_submissions(status, created_at);
+CREATE INDEX idx_recipe_submissions_user_id ON recipe_submissions(user_id);
@@ -0,0 +1,23 @@
+-- Фоновые задания импорта рецептов: файлы хранятся в задании до его выполнения,
+-- поэтому задание переживает перезапуск сервера
+
+CREATE TABLE import_jobs (
+    id SERIAL PRIMARY KEY,
+    format TEXT NOT NULL CHECK (format IN ('json', 'csv', 'xlsx', 'schema-org')),
+    mode TEXT NOT NULL DEFAULT 'fail' CHECK (mode IN ('fail', 'skip', 'update')),
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    files JSONB NOT NULL, -- [{"name": "recipes", "data": "<base64>"}]
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    result JSONB, -- ImportResult
+    error TEXT,
+    attempts INT NOT NULL DEFAULT 0,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    heartbeat_at TIMESTAMP, -- обновляется, пока задание выполняется
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_import_jobs_status ON import_jobs(status, created_at);
@@ -0,0 +1,3 @@
+-- Инкрементальный экспорт каталога выбирает рецепты по updated_at
+
+CREATE INDEX IF NOT EXISTS idx_recipes_updated_at ON recipes (updated_at);
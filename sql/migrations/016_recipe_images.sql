@@ -0,0 +1,4 @@
+-- Загруженные изображения рецептов: image_url - оригинал в хранилище,
+-- image_thumbnails - URL уменьшенных копий по размерам ({"small": "...", ...})
+
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS image_thumbnails JSONB NOT NULL DEFAULT '{}';
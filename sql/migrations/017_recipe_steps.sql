@@ -0,0 +1,6 @@
+-- Структурированные шаги рецептов: длительность, активное/пассивное время,
+-- ингредиенты и оборудование шага. cooking_time - общее время рецепта.
+
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS steps JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS prep_time INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS active_time INTEGER NOT NULL DEFAULT 0;
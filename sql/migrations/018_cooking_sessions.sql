@@ -0,0 +1,18 @@
+-- Сессии пошагового приготовления: план шагов и выбранные приемы пищи хранятся
+-- в сессии, поэтому ее можно продолжить с другого устройства
+
+CREATE TABLE cooking_sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'finished', 'cancelled')),
+    servings NUMERIC(6,2) NOT NULL DEFAULT 1,
+    meals JSONB NOT NULL DEFAULT '[]', -- [{menu_meal_id, recipe_id, recipe_name, meal_type, date}]
+    steps JSONB NOT NULL DEFAULT '[]', -- общий план шагов всех блюд
+    current_step INT NOT NULL DEFAULT 0,
+    pantry_used JSONB NOT NULL DEFAULT '[]', -- списано из кладовой при завершении
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_cooking_sessions_user_id ON cooking_sessions(user_id, status);
@@ -0,0 +1,9 @@
+-- Кухня, сезонность и сложность рецептов. seasons - сезоны, в которые блюдо
+-- особенно уместно (пустой список - круглый год).
+
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS cuisine TEXT;
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS seasons TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS difficulty TEXT
+    CHECK (difficulty IN ('easy', 'medium', 'hard'));
+
+CREATE INDEX IF NOT EXISTS idx_recipes_cuisine ON recipes (cuisine);
@@ -0,0 +1,28 @@
+-- Refresh-токены хранятся только как SHA-256 хеш. Токены, полученные ротацией из
+-- одного входа, образуют семейство: повторное предъявление уже использованного
+-- токена отзывает все семейство вместе с выданными по нему access-токенами.
+-- Сроки задает сервер приложения, поэтому они хранятся с часовым поясом
+
+CREATE TABLE refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    access_jti TEXT NOT NULL, -- access-токен, выданный вместе с refresh-токеном
+    access_expires_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ, -- токен обменян на новую пару
+    revoked_at TIMESTAMPTZ, -- выход или обнаружено повторное использование
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
+
+-- Отозванные access-токены (jti) нужны только до истечения срока их действия
+CREATE TABLE revoked_tokens (
+    jti TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
@@ -0,0 +1,24 @@
+-- Исключения для ключевых слов, которые совпадают с началом обычных слов:
+-- "печень" - "печенье", "сыр" - "сырой", "гус" - "густой", "круп" - "крупная соль",
+-- "вино" - "виноград", "мед" - "медальоны". Исключение, которое начинается с
+-- ключевого слова, отменяет только совпавшее слово названия.
+
+UPDATE ingredient_categories SET exceptions = exceptions || ARRAY['печенье', 'печенья', 'печеньк']
+WHERE keyword = 'печень';
+
+UPDATE ingredient_categories
+SET exceptions = exceptions || ARRAY['сырой', 'сырая', 'сырое', 'сырые', 'сырых', 'сырым', 'сырого', 'сырую',
+                                     'сыроед', 'сыровялен', 'сырокопч']
+WHERE keyword = 'сыр';
+
+UPDATE ingredient_categories SET exceptions = exceptions || ARRAY['густ']
+WHERE keyword = 'гус';
+
+UPDATE ingredient_categories SET exceptions = exceptions || ARRAY['крупн']
+WHERE keyword = 'круп';
+
+UPDATE ingredient_categories SET exceptions = exceptions || ARRAY['виноград']
+WHERE keyword = 'вино';
+
+UPDATE ingredient_categories SET exceptions = exceptions || ARRAY['медальон', 'медвеж']
+WHERE keyword = 'мед';